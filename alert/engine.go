@@ -0,0 +1,354 @@
+package alert
+
+import (
+    "fmt"
+    log "github.com/sirupsen/logrus"
+    "github.com/sobitada/go-cardano"
+    "github.com/sobitada/thor/clock"
+    "github.com/sobitada/thor/leader"
+    "github.com/sobitada/thor/monitor"
+    "github.com/sobitada/thor/storage"
+    "math/big"
+    "sort"
+    "sync"
+    "time"
+)
+
+type State string
+
+const (
+    // the expression of the rule holds, but not yet for the
+    // required duration.
+    Pending State = "pending"
+    Firing  State = "firing"
+    // the expression of a firing rule does not hold anymore.
+    Resolved State = "resolved"
+)
+
+// a rule, which fires an alert once its expression holds for the
+// specified duration.
+type Rule struct {
+    Name       string
+    Expression Expression
+    For        time.Duration
+    Severity   string
+    Summary    string
+    // names of the notifiers to which the alerts are routed, all
+    // notifiers are used, if it is empty.
+    Notifiers []string
+    // interval in which a firing alert is sent again, it is only
+    // sent once if it is zero.
+    RepeatInterval time.Duration
+}
+
+// an alert of a rule for a node, or for the whole swarm if no node
+// is specified.
+type Alert struct {
+    Rule        string     `json:"rule"`
+    Node        string     `json:"node,omitempty"`
+    Expression  string     `json:"expression"`
+    Severity    string     `json:"severity,omitempty"`
+    Summary     string     `json:"summary,omitempty"`
+    State       State      `json:"state"`
+    Value       float64    `json:"value"`
+    ActiveSince time.Time  `json:"activeSince"`
+    FiredAt     *time.Time `json:"firedAt,omitempty"`
+    ResolvedAt  *time.Time `json:"resolvedAt,omitempty"`
+    Silenced    bool       `json:"silenced"`
+    // time at which the alert has last been sent.
+    lastSent time.Time
+}
+
+// sources of the state, over which the rules are evaluated. all of them
+// except the monitor are optional and can be nil, the corresponding
+// variables are then unknown.
+type Sources struct {
+    Monitor      *monitor.NodeMonitor
+    WatchDog     *monitor.ScheduleWatchDog
+    Jury         *leader.Jury
+    TimeSettings *cardano.TimeSettings
+}
+
+// settings of the alert engine.
+type Settings struct {
+    Rules []Rule
+    // notifiers by their name.
+    Notifiers map[string]Notifier
+    // clock used for timing, the clock of the system is used if
+    // it is nil.
+    Clock clock.Clock
+}
+
+// a notification of an alert for a notifier.
+type notification struct {
+    notifier string
+    alert    Alert
+}
+
+// engine evaluating the rules after each check of the monitor. the alerts
+// are only sent, when they start firing or are resolved.
+type Engine struct {
+    store         storage.Store
+    sources       Sources
+    settings      Settings
+    checkChannel  chan monitor.Check
+    notifications chan notification
+    alerts        map[string]*Alert
+    mutex         *sync.RWMutex
+}
+
+// creates a new alert engine, whose silences are persisted in the given
+// store. the notifiers of all rules must be specified in the settings.
+func NewEngine(store storage.Store, sources Sources, settings Settings) (*Engine, error) {
+    engine, err := newEngine(store, sources, settings)
+    if err != nil {
+        return nil, err
+    }
+    engine.checkChannel = make(chan monitor.Check)
+    sources.Monitor.ListenerManager.RegisterCheckListener(engine.checkChannel)
+    return engine, nil
+}
+
+func newEngine(store storage.Store, sources Sources, settings Settings) (*Engine, error) {
+    for _, rule := range settings.Rules {
+        for _, name := range rule.Notifiers {
+            if _, found := settings.Notifiers[name]; !found {
+                return nil, fmt.Errorf("the notifier '%v' of rule '%v' is unknown", name, rule.Name)
+            }
+        }
+    }
+    err := store.Update(func(tx storage.Tx) error {
+        return tx.CreateBucket(storage.AlertSilencesBucket)
+    })
+    if err != nil {
+        return nil, err
+    }
+    settings.Clock = clock.OrReal(settings.Clock)
+    return &Engine{
+        store:         store,
+        sources:       sources,
+        settings:      settings,
+        notifications: make(chan notification, 64),
+        alerts:        map[string]*Alert{},
+        mutex:         &sync.RWMutex{},
+    }, nil
+}
+
+// gets the values of the variables of each node, as well as of the swarm
+// for the given check. unknown values are missing.
+func (engine *Engine) getValues(check monitor.Check) (map[string]map[string]float64, map[string]float64) {
+    nodeValues := map[string]map[string]float64{}
+    global := map[string]float64{}
+    reachable := 0
+    for _, nodeCheck := range check.Nodes {
+        values := map[string]float64{Latency: float64(nodeCheck.Latency) / float64(time.Millisecond)}
+        if nodeCheck.Error != nil {
+            values[Unreachable] = 1
+        } else {
+            values[Unreachable] = 0
+            reachable++
+        }
+        if nodeCheck.Bootstrapping {
+            values[Bootstrapping] = 1
+        } else {
+            values[Bootstrapping] = 0
+        }
+        if stats := nodeCheck.Statistic; stats != nil {
+            values[UpTime] = stats.UpTime.Seconds()
+            if stats.LastBlockHeight != nil {
+                values[Height], _ = new(big.Float).SetInt(stats.LastBlockHeight).Float64()
+                if check.MaximumBlockHeight != nil {
+                    values[Lag], _ = new(big.Float).SetInt(new(big.Int).Sub(check.MaximumBlockHeight,
+                        stats.LastBlockHeight)).Float64()
+                }
+            }
+            if stats.PeerAvailableCount != nil {
+                values[PeerAvailableCount] = float64(*stats.PeerAvailableCount)
+            }
+            if stats.PeerQuarantinedCount != nil {
+                values[PeerQuarantinedCount] = float64(*stats.PeerQuarantinedCount)
+            }
+            if stats.PeerUnreachableCnt != nil {
+                values[PeerUnreachableCount] = float64(*stats.PeerUnreachableCnt)
+            }
+            if engine.sources.TimeSettings != nil && stats.LastBlockDate != nil {
+                lastBlock := cardano.MakeFullSlotDate(stats.LastBlockDate, *engine.sources.TimeSettings).GetEndDateTime()
+                values[TimeSinceLastBlock] = check.Time.Sub(lastBlock).Seconds()
+            } else if !stats.LastBlockTime.IsZero() {
+                values[TimeSinceLastBlock] = check.Time.Sub(stats.LastBlockTime).Seconds()
+            }
+        }
+        nodeValues[nodeCheck.Name] = values
+    }
+    global[ReachableNodes] = float64(reachable)
+    if engine.sources.Jury != nil {
+        if _, found := engine.sources.Jury.GetLeader(); found {
+            global[Leaders] = 1
+        } else {
+            global[Leaders] = 0
+        }
+    }
+    if engine.sources.WatchDog != nil {
+        global[ViableCandidates] = float64(len(engine.sources.WatchDog.GetViableLeaderNodes()))
+    }
+    return nodeValues, global
+}
+
+// gets the key identifying the alert of the given rule and node.
+func getKey(rule string, node string) string {
+    return rule + "/" + node
+}
+
+// evaluates all rules for the given check of the monitor.
+func (engine *Engine) evaluate(check monitor.Check) {
+    nodeValues, global := engine.getValues(check)
+    silences, err := engine.GetSilences()
+    if err != nil {
+        log.Warnf("[ALERT] Could not load the silences. %v", err.Error())
+    }
+    engine.mutex.Lock()
+    defer engine.mutex.Unlock()
+    for _, rule := range engine.settings.Rules {
+        if rule.Expression.IsPerNode() {
+            for node, values := range nodeValues {
+                // the alert remains unchanged, while its variable is unknown
+                // (e.g. the lag of an unreachable node).
+                if value, known := values[rule.Expression.Variable]; known {
+                    engine.transition(rule, node, value, rule.Expression.Matches(value), check.Time, silences)
+                }
+            }
+        } else if value, known := global[rule.Expression.Variable]; known {
+            engine.transition(rule, "", value, rule.Expression.Matches(value), check.Time, silences)
+        }
+    }
+    // resolve the alerts of nodes, which are no longer monitored. the alerts of
+    // nodes skipped in this check (e.g. in a quiet period) remain unchanged.
+    if engine.sources.Monitor != nil {
+        monitored := map[string]bool{}
+        for _, name := range engine.sources.Monitor.GetNodeNames() {
+            monitored[name] = true
+        }
+        for key, alert := range engine.alerts {
+            if alert.Node != "" && !monitored[alert.Node] {
+                engine.resolve(key, alert, check.Time)
+            }
+        }
+    }
+}
+
+// moves the alert of the given rule and node to its next state, depending on
+// whether the expression holds for the given value.
+func (engine *Engine) transition(rule Rule, node string, value float64, holds bool, now time.Time,
+    silences []Silence) {
+    key := getKey(rule.Name, node)
+    alert, active := engine.alerts[key]
+    if !holds {
+        if active {
+            engine.resolve(key, alert, now)
+        }
+        return
+    }
+    if !active {
+        alert = &Alert{
+            Rule:        rule.Name,
+            Node:        node,
+            Expression:  rule.Expression.String(),
+            Severity:    rule.Severity,
+            Summary:     rule.Summary,
+            State:       Pending,
+            ActiveSince: now,
+        }
+        engine.alerts[key] = alert
+    }
+    alert.Value = value
+    alert.Silenced = isSilenced(silences, rule.Name, node, now)
+    switch alert.State {
+    case Pending:
+        if now.Sub(alert.ActiveSince) >= rule.For {
+            firedAt := now
+            alert.State = Firing
+            alert.FiredAt = &firedAt
+            log.Warnf("[ALERT] The alert %v is firing (%v = %v).", key, rule.Expression.Variable, value)
+            engine.send(rule, alert, now)
+        }
+    case Firing:
+        if rule.RepeatInterval > 0 && now.Sub(alert.lastSent) >= rule.RepeatInterval {
+            engine.send(rule, alert, now)
+        }
+    }
+}
+
+// resolves the given alert, pending alerts are discarded silently.
+func (engine *Engine) resolve(key string, alert *Alert, now time.Time) {
+    delete(engine.alerts, key)
+    if alert.State != Firing {
+        return
+    }
+    resolvedAt := now
+    alert.State = Resolved
+    alert.ResolvedAt = &resolvedAt
+    log.Infof("[ALERT] The alert %v has been resolved.", key)
+    for _, rule := range engine.settings.Rules {
+        if rule.Name == alert.Rule {
+            engine.send(rule, alert, now)
+        }
+    }
+}
+
+// queues the given alert for the notifiers of the given rule, unless it
+// is silenced.
+func (engine *Engine) send(rule Rule, alert *Alert, now time.Time) {
+    alert.lastSent = now
+    if alert.Silenced {
+        log.Infof("[ALERT] The alert %v is silenced.", getKey(alert.Rule, alert.Node))
+        return
+    }
+    names := rule.Notifiers
+    if len(names) == 0 {
+        for name := range engine.settings.Notifiers {
+            names = append(names, name)
+        }
+        sort.Strings(names)
+    }
+    for _, name := range names {
+        select {
+        case engine.notifications <- notification{notifier: name, alert: *alert}:
+        default:
+            log.Errorf("[ALERT] Dropping the alert %v for notifier %v, too many pending notifications.",
+                getKey(alert.Rule, alert.Node), name)
+        }
+    }
+}
+
+// gets the currently pending and firing alerts sorted by rule and node.
+func (engine *Engine) GetAlerts() []Alert {
+    engine.mutex.RLock()
+    defer engine.mutex.RUnlock()
+    alerts := make([]Alert, 0, len(engine.alerts))
+    for _, alert := range engine.alerts {
+        alerts = append(alerts, *alert)
+    }
+    sort.Slice(alerts, func(i, j int) bool {
+        return getKey(alerts[i].Rule, alerts[i].Node) < getKey(alerts[j].Rule, alerts[j].Node)
+    })
+    return alerts
+}
+
+func (engine *Engine) notify() {
+    for n := range engine.notifications {
+        err := engine.settings.Notifiers[n.notifier].Notify(n.alert)
+        if err != nil {
+            log.Errorf("[ALERT] Could not send the alert %v to notifier %v. %v", getKey(n.alert.Rule, n.alert.Node),
+                n.notifier, err.Error())
+        }
+    }
+}
+
+// a blocking call, which evaluates the rules after each check of the monitor.
+func (engine *Engine) Run() {
+    log.Infof("[ALERT] Evaluating %v alerting rules.", len(engine.settings.Rules))
+    go engine.notify()
+    for check := range engine.checkChannel {
+        engine.evaluate(check)
+    }
+}
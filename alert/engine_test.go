@@ -0,0 +1,187 @@
+package alert
+
+import (
+    "errors"
+    jor "github.com/sobitada/go-jormungandr/api"
+    "github.com/sobitada/thor/jortest"
+    "github.com/sobitada/thor/monitor"
+    "github.com/sobitada/thor/storage"
+    "github.com/stretchr/testify/assert"
+    "math/big"
+    "testing"
+    "time"
+)
+
+var start = time.Date(2020, 6, 1, 12, 0, 0, 0, time.UTC)
+
+type nopNotifier struct{}
+
+func (nopNotifier) Notify(alert Alert) error {
+    return nil
+}
+
+// gets a check, in which the nodes have the given heights. a node with a
+// negative height could not be reached.
+func getCheck(now time.Time, heights map[string]int64) monitor.Check {
+    check := monitor.Check{Time: now, MaximumBlockHeight: big.NewInt(0)}
+    for name, height := range heights {
+        nodeCheck := monitor.NodeCheck{Name: name}
+        if height < 0 {
+            nodeCheck.Error = errors.New("timeout")
+        } else {
+            nodeCheck.Statistic = &jor.NodeStatistic{LastBlockHeight: big.NewInt(height)}
+            if big.NewInt(height).Cmp(check.MaximumBlockHeight) > 0 {
+                check.MaximumBlockHeight = big.NewInt(height)
+            }
+        }
+        check.Nodes = append(check.Nodes, nodeCheck)
+    }
+    return check
+}
+
+func getEngine(t *testing.T, testClock *jortest.Clock, exprs ...string) *Engine {
+    rules := make([]Rule, len(exprs))
+    for i, text := range exprs {
+        expr, duration, err := ParseExpression(text)
+        if !assert.Nil(t, err) {
+            t.FailNow()
+        }
+        rules[i] = Rule{Name: expr.Variable, Expression: expr, For: duration}
+    }
+    engine, err := newEngine(storage.NewMemory(), Sources{}, Settings{Rules: rules,
+        Notifiers: map[string]Notifier{"nop": nopNotifier{}}, Clock: testClock})
+    if !assert.Nil(t, err) {
+        t.FailNow()
+    }
+    return engine
+}
+
+// gets the notifications queued so far.
+func drain(engine *Engine) []Alert {
+    alerts := make([]Alert, 0)
+    for ; ; {
+        select {
+        case n := <-engine.notifications:
+            alerts = append(alerts, n.alert)
+        default:
+            return alerts
+        }
+    }
+}
+
+func TestParseExpression(t *testing.T) {
+    expr, duration, err := ParseExpression("lag > 5 for 2m")
+    if assert.Nil(t, err) {
+        assert.Equal(t, Expression{Variable: Lag, Operator: ">", Threshold: 5}, expr)
+        assert.Equal(t, 2*time.Minute, duration)
+        assert.True(t, expr.IsPerNode())
+    }
+    expr, duration, err = ParseExpression("no leader for 30s")
+    if assert.Nil(t, err) {
+        assert.Equal(t, Expression{Variable: Leaders, Operator: "==", Threshold: 0}, expr)
+        assert.Equal(t, 30*time.Second, duration)
+        assert.False(t, expr.IsPerNode())
+    }
+    _, _, err = ParseExpression("lag >> 5")
+    assert.NotNil(t, err)
+    _, _, err = ParseExpression("temperature > 5")
+    assert.NotNil(t, err)
+}
+
+func TestEngine_Lag_mustBePendingThenFiringThenResolved(t *testing.T) {
+    engine := getEngine(t, jortest.NewClock(start), "lag > 5 for 2m")
+    engine.evaluate(getCheck(start, map[string]int64{"a": 100, "b": 90}))
+    alerts := engine.GetAlerts()
+    if assert.Len(t, alerts, 1) {
+        assert.Equal(t, "b", alerts[0].Node)
+        assert.Equal(t, Pending, alerts[0].State)
+    }
+    assert.Empty(t, drain(engine))
+    engine.evaluate(getCheck(start.Add(2*time.Minute), map[string]int64{"a": 100, "b": 90}))
+    engine.evaluate(getCheck(start.Add(3*time.Minute), map[string]int64{"a": 100, "b": 91}))
+    sent := drain(engine)
+    if assert.Len(t, sent, 1) {
+        assert.Equal(t, Firing, sent[0].State)
+    }
+    engine.evaluate(getCheck(start.Add(4*time.Minute), map[string]int64{"a": 100, "b": 100}))
+    sent = drain(engine)
+    if assert.Len(t, sent, 1) {
+        assert.Equal(t, Resolved, sent[0].State)
+        assert.Equal(t, start.Add(4*time.Minute), *sent[0].ResolvedAt)
+    }
+    assert.Empty(t, engine.GetAlerts())
+}
+
+func TestEngine_PendingAlert_mustBeDiscardedSilently(t *testing.T) {
+    engine := getEngine(t, jortest.NewClock(start), "unreachable == 1 for 1m")
+    engine.evaluate(getCheck(start, map[string]int64{"a": -1}))
+    engine.evaluate(getCheck(start.Add(30*time.Second), map[string]int64{"a": 100}))
+    assert.Empty(t, engine.GetAlerts())
+    assert.Empty(t, drain(engine))
+}
+
+func TestEngine_Silence_mustSuppressNotifications(t *testing.T) {
+    testClock := jortest.NewClock(start)
+    engine := getEngine(t, testClock, "reachableNodes < 2")
+    _, err := engine.AddSilence(Silence{Rule: ReachableNodes, EndsAt: start.Add(time.Hour)})
+    assert.Nil(t, err)
+    engine.evaluate(getCheck(start, map[string]int64{"a": 100, "b": -1}))
+    alerts := engine.GetAlerts()
+    if assert.Len(t, alerts, 1) {
+        assert.Equal(t, Firing, alerts[0].State)
+        assert.True(t, alerts[0].Silenced)
+    }
+    assert.Empty(t, drain(engine))
+    testClock.Advance(2 * time.Hour)
+    silences, err := engine.GetSilences()
+    assert.Nil(t, err)
+    assert.Empty(t, silences)
+}
+
+func TestEngine_UnknownNotifier_mustBeRejected(t *testing.T) {
+    expr, _, _ := ParseExpression("lag > 5")
+    _, err := newEngine(storage.NewMemory(), Sources{}, Settings{
+        Rules: []Rule{{Name: "lag", Expression: expr, Notifiers: []string{"pager"}}}})
+    assert.NotNil(t, err)
+}
+
+func TestEngine_NodeSkippedInCheck_mustKeepAlert(t *testing.T) {
+    engine := getEngine(t, jortest.NewClock(start), "lag > 5")
+    engine.sources.Monitor = monitor.GetNodeMonitor([]monitor.Node{{Name: "a"}, {Name: "b"}},
+        monitor.NodeMonitorBehaviour{}, nil, nil, nil, nil)
+    engine.evaluate(getCheck(start, map[string]int64{"a": 100, "b": 90}))
+    if assert.Len(t, drain(engine), 1) {
+        assert.Equal(t, Firing, engine.GetAlerts()[0].State)
+    }
+    // node b is skipped, e.g. in the quiet period around a scheduled block.
+    engine.evaluate(getCheck(start.Add(time.Minute), map[string]int64{"a": 101}))
+    alerts := engine.GetAlerts()
+    if assert.Len(t, alerts, 1) {
+        assert.Equal(t, "b", alerts[0].Node)
+        assert.Equal(t, Firing, alerts[0].State)
+    }
+    assert.Empty(t, drain(engine))
+    // node c is not monitored anymore.
+    engine.alerts[getKey("lag", "c")] = &Alert{Rule: "lag", Node: "c", State: Firing}
+    engine.evaluate(getCheck(start.Add(2*time.Minute), map[string]int64{"a": 101}))
+    sent := drain(engine)
+    if assert.Len(t, sent, 1) {
+        assert.Equal(t, "c", sent[0].Node)
+        assert.Equal(t, Resolved, sent[0].State)
+    }
+    assert.Len(t, engine.GetAlerts(), 1)
+}
+
+func TestEngine_UnknownVariable_mustKeepAlert(t *testing.T) {
+    engine := getEngine(t, jortest.NewClock(start), "lag > 5")
+    engine.evaluate(getCheck(start, map[string]int64{"a": 100, "b": 90}))
+    assert.Len(t, drain(engine), 1)
+    // the lag of node b is unknown, while it is unreachable.
+    engine.evaluate(getCheck(start.Add(time.Minute), map[string]int64{"a": 101, "b": -1}))
+    alerts := engine.GetAlerts()
+    if assert.Len(t, alerts, 1) {
+        assert.Equal(t, "b", alerts[0].Node)
+        assert.Equal(t, Firing, alerts[0].State)
+    }
+    assert.Empty(t, drain(engine))
+}
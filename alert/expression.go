@@ -0,0 +1,117 @@
+package alert
+
+import (
+    "fmt"
+    "strconv"
+    "strings"
+    "time"
+)
+
+// variables, whose value is determined per node.
+const (
+    // number of blocks the node lags behind the maximum block height.
+    Lag = "lag"
+    // seconds since the last block received by the node.
+    TimeSinceLastBlock = "timeSinceLastBlock"
+    // block height of the node.
+    Height = "height"
+    // uptime of the node in seconds.
+    UpTime = "uptime"
+    // peer counts reported by the node.
+    PeerAvailableCount   = "peerAvailableCount"
+    PeerQuarantinedCount = "peerQuarantinedCount"
+    PeerUnreachableCount = "peerUnreachableCount"
+    // milliseconds it took to fetch the statistics of the node.
+    Latency = "latency"
+    // 1, if the statistics of the node could not be fetched, otherwise 0.
+    Unreachable = "unreachable"
+    // 1, if the node is bootstrapping, otherwise 0.
+    Bootstrapping = "bootstrapping"
+)
+
+// variables, whose value is determined for the whole swarm.
+const (
+    // number of leaders elected by the leader jury, i.e. 0 or 1.
+    Leaders = "leaders"
+    // number of leader candidates reporting the expected schedule.
+    ViableCandidates = "viableCandidates"
+    // number of nodes, whose statistics could be fetched.
+    ReachableNodes = "reachableNodes"
+)
+
+var nodeVariables = map[string]bool{Lag: true, TimeSinceLastBlock: true, Height: true, UpTime: true,
+    PeerAvailableCount: true, PeerQuarantinedCount: true, PeerUnreachableCount: true, Latency: true,
+    Unreachable: true, Bootstrapping: true}
+
+var globalVariables = map[string]bool{Leaders: true, ViableCandidates: true, ReachableNodes: true}
+
+// shorthands, which can be used instead of an expression.
+var shorthands = map[string]string{
+    "no leader": Leaders + " == 0",
+}
+
+var operators = map[string]func(a float64, b float64) bool{
+    "<":  func(a float64, b float64) bool { return a < b },
+    "<=": func(a float64, b float64) bool { return a <= b },
+    ">":  func(a float64, b float64) bool { return a > b },
+    ">=": func(a float64, b float64) bool { return a >= b },
+    "==": func(a float64, b float64) bool { return a == b },
+    "!=": func(a float64, b float64) bool { return a != b },
+}
+
+// an expression comparing a variable with a threshold, e.g. 'lag > 5'.
+type Expression struct {
+    Variable  string
+    Operator  string
+    Threshold float64
+}
+
+// checks whether the variable of this expression is determined per node.
+func (expr Expression) IsPerNode() bool {
+    return nodeVariables[expr.Variable]
+}
+
+// checks whether the given value satisfies this expression.
+func (expr Expression) Matches(value float64) bool {
+    return operators[expr.Operator](value, expr.Threshold)
+}
+
+func (expr Expression) String() string {
+    return fmt.Sprintf("%v %v %v", expr.Variable, expr.Operator, strconv.FormatFloat(expr.Threshold, 'f', -1, 64))
+}
+
+// parses the given expression of the form '<variable> <operator> <threshold>',
+// which can be followed by 'for <duration>'. the duration is zero, if it has
+// not been specified.
+func ParseExpression(text string) (Expression, time.Duration, error) {
+    var duration time.Duration = 0
+    fields := strings.Fields(text)
+    if len(fields) >= 2 && fields[len(fields)-2] == "for" {
+        d, err := time.ParseDuration(fields[len(fields)-1])
+        if err != nil {
+            return Expression{}, 0, fmt.Errorf("the duration in '%v' is invalid. %v", text, err.Error())
+        }
+        duration = d
+        fields = fields[:len(fields)-2]
+    }
+    if expanded, found := shorthands[strings.Join(fields, " ")]; found {
+        fields = strings.Fields(expanded)
+    }
+    if len(fields) != 3 {
+        return Expression{}, 0, fmt.Errorf("the expression '%v' must have the form "+
+            "'<variable> <operator> <threshold> [for <duration>]'", text)
+    }
+    expr := Expression{Variable: fields[0], Operator: fields[1]}
+    if !nodeVariables[expr.Variable] && !globalVariables[expr.Variable] {
+        return expr, 0, fmt.Errorf("the variable '%v' is unknown", expr.Variable)
+    }
+    if _, found := operators[expr.Operator]; !found {
+        return expr, 0, fmt.Errorf("the operator '%v' is unknown", expr.Operator)
+    }
+    threshold, err := strconv.ParseFloat(fields[2], 64)
+    if err != nil {
+        return expr, 0, fmt.Errorf("the threshold '%v' is not a number", fields[2])
+    }
+    expr.Threshold = threshold
+    return expr, duration, nil
+}
@@ -0,0 +1,62 @@
+package alert
+
+import (
+    "encoding/json"
+    "net/http"
+    "strconv"
+)
+
+// serves the currently pending and firing alerts as JSON.
+func (engine *Engine) ServeAlerts(writer http.ResponseWriter, request *http.Request) {
+    writer.Header().Set("Content-Type", "application/json")
+    _ = json.NewEncoder(writer).Encode(engine.GetAlerts())
+}
+
+// serves the silences, which have not yet ended, as JSON for GET requests.
+// a silence given as JSON is added with a POST request, and the silence with
+// the ID given in the query is removed with a DELETE request.
+func (engine *Engine) ServeSilences(writer http.ResponseWriter, request *http.Request) {
+    switch request.Method {
+    case http.MethodGet:
+        silences, err := engine.GetSilences()
+        if err != nil {
+            http.Error(writer, err.Error(), http.StatusInternalServerError)
+            return
+        }
+        writer.Header().Set("Content-Type", "application/json")
+        _ = json.NewEncoder(writer).Encode(silences)
+    case http.MethodPost:
+        var silence Silence
+        err := json.NewDecoder(request.Body).Decode(&silence)
+        if err != nil {
+            http.Error(writer, err.Error(), http.StatusBadRequest)
+            return
+        }
+        silence, err = engine.AddSilence(silence)
+        if err != nil {
+            http.Error(writer, err.Error(), http.StatusBadRequest)
+            return
+        }
+        writer.Header().Set("Content-Type", "application/json")
+        writer.WriteHeader(http.StatusCreated)
+        _ = json.NewEncoder(writer).Encode(silence)
+    case http.MethodDelete:
+        id, err := strconv.ParseUint(request.URL.Query().Get("id"), 10, 64)
+        if err != nil {
+            http.Error(writer, "the parameter 'id' must be the ID of a silence.", http.StatusBadRequest)
+            return
+        }
+        found, err := engine.RemoveSilence(id)
+        if err != nil {
+            http.Error(writer, err.Error(), http.StatusInternalServerError)
+            return
+        }
+        if !found {
+            http.Error(writer, "there is no silence with this ID.", http.StatusNotFound)
+            return
+        }
+        writer.WriteHeader(http.StatusNoContent)
+    default:
+        http.Error(writer, "Method not allowed", http.StatusMethodNotAllowed)
+    }
+}
@@ -0,0 +1,52 @@
+package alert
+
+import (
+    "bytes"
+    "encoding/json"
+    "fmt"
+    "github.com/sobitada/thor/notify"
+    "strings"
+)
+
+// receiver of the alerts, when they start firing or are resolved.
+type Notifier interface {
+    // sends the given alert, and returns an error, if it could
+    // not be sent.
+    Notify(alert Alert) error
+}
+
+// notifier posting the alert as JSON to a webhook.
+type WebhookNotifier notify.Webhook
+
+func (notifier WebhookNotifier) Notify(alert Alert) error {
+    data, err := json.Marshal(alert)
+    if err != nil {
+        return err
+    }
+    return notify.Webhook(notifier).Post("application/json", bytes.NewReader(data))
+}
+
+// notifier sending the alert in a mail over SMTP.
+type MailNotifier notify.Mail
+
+// gets the subject of a message about the given alert.
+func getSubject(alert Alert) string {
+    subject := fmt.Sprintf("[%v] %v", strings.ToUpper(string(alert.State)), alert.Rule)
+    if alert.Node != "" {
+        subject += fmt.Sprintf(" (%v)", alert.Node)
+    }
+    return subject
+}
+
+func (notifier MailNotifier) Notify(alert Alert) error {
+    var body bytes.Buffer
+    if alert.Summary != "" {
+        _, _ = fmt.Fprintf(&body, "%v\r\n\r\n", alert.Summary)
+    }
+    _, _ = fmt.Fprintf(&body, "Expression: %v\r\nValue: %v\r\nSeverity: %v\r\nActive since: %v\r\n",
+        alert.Expression, alert.Value, alert.Severity, alert.ActiveSince.Format("2006-01-02 15:04:05 MST"))
+    if alert.ResolvedAt != nil {
+        _, _ = fmt.Fprintf(&body, "Resolved at: %v\r\n", alert.ResolvedAt.Format("2006-01-02 15:04:05 MST"))
+    }
+    return notify.Mail(notifier).Send(getSubject(alert), "text/plain", body.Bytes())
+}
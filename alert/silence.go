@@ -0,0 +1,102 @@
+package alert
+
+import (
+    "encoding/json"
+    "fmt"
+    "github.com/sobitada/thor/storage"
+    "time"
+)
+
+// a silence suppressing the notifications of the matching alerts in its
+// period. an empty rule or node matches all rules or nodes.
+type Silence struct {
+    ID       uint64    `json:"id"`
+    Rule     string    `json:"rule,omitempty"`
+    Node     string    `json:"node,omitempty"`
+    StartsAt time.Time `json:"startsAt"`
+    EndsAt   time.Time `json:"endsAt"`
+    Comment  string    `json:"comment,omitempty"`
+}
+
+// checks whether this silence is active at the given time, and matches the
+// alert of the given rule and node.
+func (silence Silence) matches(rule string, node string, now time.Time) bool {
+    if now.Before(silence.StartsAt) || !now.Before(silence.EndsAt) {
+        return false
+    }
+    return (silence.Rule == "" || silence.Rule == rule) && (silence.Node == "" || silence.Node == node)
+}
+
+// checks whether one of the given silences matches the alert of the given
+// rule and node at the given time.
+func isSilenced(silences []Silence, rule string, node string, now time.Time) bool {
+    for _, silence := range silences {
+        if silence.matches(rule, node, now) {
+            return true
+        }
+    }
+    return false
+}
+
+func silenceKey(id uint64) string {
+    return fmt.Sprintf("%020d", id)
+}
+
+// adds the given silence, which starts now if no start is specified. the
+// silence with its assigned ID is returned.
+func (engine *Engine) AddSilence(silence Silence) (Silence, error) {
+    if silence.StartsAt.IsZero() {
+        silence.StartsAt = engine.settings.Clock.Now()
+    }
+    if !silence.EndsAt.After(silence.StartsAt) {
+        return silence, fmt.Errorf("the end of the silence must be after its start")
+    }
+    err := engine.store.Update(func(tx storage.Tx) error {
+        id, err := tx.NextSequence(storage.AlertSilencesBucket)
+        if err != nil {
+            return err
+        }
+        silence.ID = id
+        data, err := json.Marshal(silence)
+        if err != nil {
+            return err
+        }
+        return tx.Put(storage.AlertSilencesBucket, silenceKey(id), data)
+    })
+    return silence, err
+}
+
+// removes the silence with the given ID, and returns false, if there is
+// no such silence.
+func (engine *Engine) RemoveSilence(id uint64) (bool, error) {
+    found := false
+    err := engine.store.Update(func(tx storage.Tx) error {
+        data, err := tx.Get(storage.AlertSilencesBucket, silenceKey(id))
+        if err != nil || data == nil {
+            return err
+        }
+        found = true
+        return tx.Delete(storage.AlertSilencesBucket, silenceKey(id))
+    })
+    return found, err
+}
+
+// gets all the silences, which have not yet ended.
+func (engine *Engine) GetSilences() ([]Silence, error) {
+    now := engine.settings.Clock.Now()
+    silences := make([]Silence, 0)
+    err := engine.store.View(func(tx storage.Tx) error {
+        return tx.ForEach(storage.AlertSilencesBucket, func(key string, value []byte) error {
+            var silence Silence
+            err := json.Unmarshal(value, &silence)
+            if err != nil {
+                return err
+            }
+            if now.Before(silence.EndsAt) {
+                silences = append(silences, silence)
+            }
+            return nil
+        })
+    })
+    return silences, err
+}
@@ -0,0 +1,231 @@
+package audit
+
+import (
+    "encoding/binary"
+    "encoding/json"
+    log "github.com/sirupsen/logrus"
+    "github.com/sobitada/go-cardano"
+    "github.com/sobitada/thor/clock"
+    "github.com/sobitada/thor/storage"
+    "strings"
+    "sync"
+    "time"
+)
+
+type Action string
+
+const (
+    Promotion Action = "promotion"
+    Demotion  Action = "demotion"
+    Shutdown  Action = "shutdown"
+    // a restart of a node observed by the monitor, i.e. its uptime
+    // has been reset. it has not necessarily been issued by this tool.
+    Restart Action = "restart"
+)
+
+// outcomes of an audited action.
+const (
+    Success    string = "success"
+    Failure    string = "failure"
+    RolledBack string = "rolled-back"
+    // the action has only been decided by a jury in
+    // shadow mode, but not executed.
+    Shadow string = "shadow"
+)
+
+// an entry of the audit log recording a leader promotion,
+// demotion or shutdown of a node.
+type Entry struct {
+    Time     time.Time `json:"time"`
+    Epoch    *uint64   `json:"epoch,omitempty"`
+    Slot     *uint64   `json:"slot,omitempty"`
+    Node     string    `json:"node"`
+    Action   Action    `json:"action"`
+    Reason   string    `json:"reason"`
+    // short cause of a shutdown, which allows to group them.
+    Cause    string    `json:"cause,omitempty"`
+    Outcome  string    `json:"outcome"`
+    LeaderID *uint64   `json:"leaderID,omitempty"`
+}
+
+// checks whether this entry records a successful leader promotion, i.e. a
+// change of the leader.
+func (entry Entry) IsLeaderChange() bool {
+    return entry.Action == Promotion && entry.Outcome == Success
+}
+
+// gets the cause and the outcome (success or failure) of the shutdown recorded
+// by this entry, which label the counted shutdowns. false is returned, if this
+// entry does not record an executed shutdown.
+func (entry Entry) GetShutdownLabels() (string, string, bool) {
+    if entry.Action != Shutdown || entry.Outcome == Shadow {
+        return "", "", false
+    }
+    outcome := Success
+    if strings.HasPrefix(entry.Outcome, Failure) {
+        outcome = Failure
+    }
+    cause := entry.Cause
+    if cause == "" {
+        cause = "unknown"
+    }
+    return cause, outcome, true
+}
+
+// filter for querying the audit log. zero values
+// of the fields are ignored.
+type Filter struct {
+    From   time.Time
+    To     time.Time
+    Node   string
+    Action Action
+}
+
+// number of entries a listener should be able to buffer, such that
+// short delays in consuming them do not lead to dropped entries.
+const ListenerCapacity = 64
+
+// append-only audit log of actions taken on the nodes, which
+// is persisted in the store.
+type Log struct {
+    store        storage.Store
+    timeSettings *cardano.TimeSettings
+    clock        clock.Clock
+    listeners    []chan Entry
+    // number of entries that have not been passed to a listener,
+    // because its buffer was full.
+    dropped      uint64
+    mutex        *sync.Mutex
+}
+
+// creates a new audit log persisted in the given store. the time
+// settings are optional, and used to record the slot date. the entries
+// are recorded at the time of the given clock, the clock of the system
+// is used if it is nil.
+func NewLog(store storage.Store, timeSettings *cardano.TimeSettings, auditClock clock.Clock) (*Log, error) {
+    err := store.Update(func(tx storage.Tx) error {
+        return tx.CreateBucket(storage.AuditBucket)
+    })
+    if err != nil {
+        return nil, err
+    }
+    return &Log{store: store, timeSettings: timeSettings, clock: clock.OrReal(auditClock), mutex: &sync.Mutex{}}, nil
+}
+
+// registers a listener, which is informed about each recorded entry. the
+// recording is not blocked by the listener, an entry is dropped for the
+// listener if its buffer is full. hence, the listener should be buffered
+// with ListenerCapacity.
+func (auditLog *Log) RegisterListener(listener chan Entry) {
+    auditLog.mutex.Lock()
+    defer auditLog.mutex.Unlock()
+    auditLog.listeners = append(auditLog.listeners, listener)
+}
+
+// records the given action for the given node. the leader ID is
+// optional and can be nil. a nil audit log is ignoring all records.
+func (auditLog *Log) Record(node string, action Action, reason string, outcome string, leaderID *uint64) {
+    if auditLog == nil {
+        return
+    }
+    auditLog.record(Entry{Node: node, Action: action, Reason: reason, Outcome: outcome, LeaderID: leaderID})
+}
+
+// records the shutdown of the given node with the given short cause. a nil
+// audit log is ignoring all records.
+func (auditLog *Log) RecordShutdown(node string, cause string, reason string, outcome string) {
+    if auditLog == nil {
+        return
+    }
+    auditLog.record(Entry{Node: node, Action: Shutdown, Cause: cause, Reason: reason, Outcome: outcome})
+}
+
+func (auditLog *Log) record(entry Entry) {
+    entry.Time = auditLog.clock.Now()
+    if auditLog.timeSettings != nil {
+        slotDate, err := auditLog.timeSettings.GetSlotDateFor(entry.Time)
+        if err == nil {
+            epoch, slot := slotDate.GetEpoch().Uint64(), slotDate.GetSlot().Uint64()
+            entry.Epoch = &epoch
+            entry.Slot = &slot
+        }
+    }
+    err := auditLog.store.Update(func(tx storage.Tx) error {
+        seq, err := tx.NextSequence(storage.AuditBucket)
+        if err != nil {
+            return err
+        }
+        data, err := json.Marshal(entry)
+        if err != nil {
+            return err
+        }
+        return tx.Put(storage.AuditBucket, sequenceKey(seq), data)
+    })
+    if err != nil {
+        log.Errorf("[AUDIT] Could not record the %v of node %v. %v", entry.Action, entry.Node, err.Error())
+    }
+    auditLog.mutex.Lock()
+    defer auditLog.mutex.Unlock()
+    for _, listener := range auditLog.listeners {
+        select {
+        case listener <- entry:
+        default:
+            auditLog.dropped++
+            log.Warnf("[AUDIT] A listener could not take the %v of node %v, %v entries dropped so far.",
+                entry.Action, entry.Node, auditLog.dropped)
+        }
+    }
+}
+
+// gets the number of entries that have been dropped for listeners, because
+// their buffer was full.
+func (auditLog *Log) GetDroppedEntries() uint64 {
+    auditLog.mutex.Lock()
+    defer auditLog.mutex.Unlock()
+    return auditLog.dropped
+}
+
+// queries the entries of the audit log that match the given filter in
+// the order in which they have been recorded.
+func (auditLog *Log) Query(filter Filter) ([]Entry, error) {
+    entries := make([]Entry, 0)
+    err := auditLog.store.View(func(tx storage.Tx) error {
+        return tx.ForEach(storage.AuditBucket, func(k string, v []byte) error {
+            var entry Entry
+            err := json.Unmarshal(v, &entry)
+            if err != nil {
+                return err
+            }
+            if filter.matches(entry) {
+                entries = append(entries, entry)
+            }
+            return nil
+        })
+    })
+    return entries, err
+}
+
+// checks whether the given entry matches this filter.
+func (filter Filter) matches(entry Entry) bool {
+    if !filter.From.IsZero() && entry.Time.Before(filter.From) {
+        return false
+    }
+    if !filter.To.IsZero() && entry.Time.After(filter.To) {
+        return false
+    }
+    if filter.Node != "" && filter.Node != entry.Node {
+        return false
+    }
+    if filter.Action != "" && filter.Action != entry.Action {
+        return false
+    }
+    return true
+}
+
+// encodes the given sequence number as big endian such that
+// the keys are sorted in the order of recording.
+func sequenceKey(seq uint64) string {
+    key := make([]byte, 8)
+    binary.BigEndian.PutUint64(key, seq)
+    return string(key)
+}
@@ -0,0 +1,118 @@
+package audit
+
+import (
+    "github.com/sobitada/thor/jortest"
+    "github.com/sobitada/thor/storage"
+    "github.com/stretchr/testify/assert"
+    "testing"
+    "time"
+)
+
+func openTestLog(t *testing.T) (*Log, func()) {
+    db := storage.NewMemory()
+    auditLog, err := NewLog(db, nil, nil)
+    if err != nil {
+        t.Fatal(err)
+    }
+    return auditLog, func() {
+        _ = db.Close()
+    }
+}
+
+func TestLog_QueryByNodeAndAction_mustReturnMatchingEntriesInOrder(t *testing.T) {
+    auditLog, cleanUp := openTestLog(t)
+    defer cleanUp()
+    leaderID := uint64(1)
+    auditLog.Record("a", Promotion, "healthiest node", Success, &leaderID)
+    auditLog.Record("b", Demotion, "other node promoted", Success, &leaderID)
+    auditLog.Record("a", Shutdown, "lagging 12 blocks", Success, nil)
+    entries, err := auditLog.Query(Filter{Node: "a"})
+    if assert.Nil(t, err) && assert.Len(t, entries, 2) {
+        assert.Equal(t, Promotion, entries[0].Action)
+        assert.Equal(t, Shutdown, entries[1].Action)
+    }
+    entries, err = auditLog.Query(Filter{Action: Demotion})
+    if assert.Nil(t, err) && assert.Len(t, entries, 1) {
+        assert.Equal(t, "b", entries[0].Node)
+    }
+}
+
+func TestLog_QueryByTimeRange_mustIgnoreEntriesOutsideOfRange(t *testing.T) {
+    auditLog, cleanUp := openTestLog(t)
+    defer cleanUp()
+    auditLog.Record("a", Promotion, "", Success, nil)
+    entries, err := auditLog.Query(Filter{From: time.Now().Add(time.Hour)})
+    if assert.Nil(t, err) {
+        assert.Empty(t, entries)
+    }
+    entries, err = auditLog.Query(Filter{From: time.Now().Add(-time.Hour), To: time.Now().Add(time.Hour)})
+    if assert.Nil(t, err) {
+        assert.Len(t, entries, 1)
+    }
+}
+
+func TestLog_RecordOnNilLog_mustBeIgnored(t *testing.T) {
+    var auditLog *Log
+    auditLog.Record("a", Shutdown, "", Success, nil)
+}
+
+func TestLog_RecordShutdown_mustInformListenersWithCause(t *testing.T) {
+    auditLog, cleanUp := openTestLog(t)
+    defer cleanUp()
+    listener := make(chan Entry, 1)
+    auditLog.RegisterListener(listener)
+    auditLog.RecordShutdown("a", "lag", "lagging 12 blocks", Success)
+    entry := <-listener
+    assert.Equal(t, Shutdown, entry.Action)
+    assert.Equal(t, "lag", entry.Cause)
+    entries, err := auditLog.Query(Filter{Node: "a"})
+    if assert.Nil(t, err) && assert.Len(t, entries, 1) {
+        assert.Equal(t, "lag", entries[0].Cause)
+    }
+}
+
+func TestLog_FullListener_mustNotBlockRecordingAndCountDroppedEntries(t *testing.T) {
+    auditLog, cleanUp := openTestLog(t)
+    defer cleanUp()
+    listener := make(chan Entry, 1)
+    auditLog.RegisterListener(listener)
+    auditLog.Record("a", Promotion, "", Success, nil)
+    auditLog.Record("b", Promotion, "", Success, nil)
+    assert.Equal(t, uint64(1), auditLog.GetDroppedEntries())
+    assert.Equal(t, "a", (<-listener).Node)
+    entries, err := auditLog.Query(Filter{})
+    if assert.Nil(t, err) {
+        assert.Len(t, entries, 2)
+    }
+}
+
+func TestEntry_GetShutdownLabels_mustIgnoreShadowAndGroupFailures(t *testing.T) {
+    cause, outcome, ok := Entry{Action: Shutdown, Outcome: Failure + ": connection refused"}.GetShutdownLabels()
+    if assert.True(t, ok) {
+        assert.Equal(t, "unknown", cause)
+        assert.Equal(t, Failure, outcome)
+    }
+    _, _, ok = Entry{Action: Shutdown, Cause: "lag", Outcome: Shadow}.GetShutdownLabels()
+    assert.False(t, ok)
+    _, _, ok = Entry{Action: Promotion, Outcome: Success}.GetShutdownLabels()
+    assert.False(t, ok)
+    assert.True(t, Entry{Action: Promotion, Outcome: Success}.IsLeaderChange())
+    assert.False(t, Entry{Action: Promotion, Outcome: RolledBack}.IsLeaderChange())
+}
+
+func TestLog_Record_mustUseTimeOfGivenClock(t *testing.T) {
+    testClock := jortest.NewClock(time.Date(2020, 6, 1, 12, 0, 0, 0, time.UTC))
+    settings := jortest.TimeSettingsAt(testClock.Now(), 5, 10, time.Second, 1000)
+    auditLog, err := NewLog(storage.NewMemory(), settings, testClock)
+    if !assert.Nil(t, err) {
+        t.FailNow()
+    }
+    testClock.AdvanceToSlot(*settings, 6, 20)
+    auditLog.Record("a", Promotion, "", Success, nil)
+    entries, err := auditLog.Query(Filter{})
+    if assert.Nil(t, err) && assert.Len(t, entries, 1) {
+        assert.Equal(t, testClock.Now(), entries[0].Time)
+        assert.Equal(t, uint64(6), *entries[0].Epoch)
+        assert.Equal(t, uint64(20), *entries[0].Slot)
+    }
+}
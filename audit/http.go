@@ -0,0 +1,51 @@
+package audit
+
+import (
+    "encoding/json"
+    "fmt"
+    "net/http"
+    "net/url"
+    "time"
+)
+
+// parses the filter from the given URL query. the parameters "from" and "to"
+// are expected in RFC3339 format, "node" and "action" are matched exactly.
+func ParseFilter(query url.Values) (Filter, error) {
+    filter := Filter{Node: query.Get("node"), Action: Action(query.Get("action"))}
+    for _, param := range []struct {
+        name   string
+        target *time.Time
+    }{{name: "from", target: &filter.From}, {name: "to", target: &filter.To}} {
+        value := query.Get(param.name)
+        if value != "" {
+            t, err := time.Parse(time.RFC3339, value)
+            if err != nil {
+                return filter, fmt.Errorf("the parameter '%v' must be in RFC3339 format. %v", param.name, err.Error())
+            }
+            *param.target = t
+        }
+    }
+    switch filter.Action {
+    case "", Promotion, Demotion, Shutdown, Restart:
+        return filter, nil
+    default:
+        return filter, fmt.Errorf("the action '%v' is unknown", filter.Action)
+    }
+}
+
+// serves the entries of the audit log matching the filter specified
+// in the query of the request as JSON.
+func (auditLog *Log) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+    filter, err := ParseFilter(request.URL.Query())
+    if err != nil {
+        http.Error(writer, err.Error(), http.StatusBadRequest)
+        return
+    }
+    entries, err := auditLog.Query(filter)
+    if err != nil {
+        http.Error(writer, err.Error(), http.StatusInternalServerError)
+        return
+    }
+    writer.Header().Set("Content-Type", "application/json")
+    _ = json.NewEncoder(writer).Encode(entries)
+}
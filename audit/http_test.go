@@ -0,0 +1,20 @@
+package audit
+
+import (
+    "github.com/stretchr/testify/assert"
+    "net/url"
+    "testing"
+)
+
+func TestParseFilter_restartAction_mustBeAccepted(t *testing.T) {
+    filter, err := ParseFilter(url.Values{"action": {"restart"}, "node": {"a"}})
+    if assert.Nil(t, err) {
+        assert.Equal(t, Restart, filter.Action)
+        assert.Equal(t, "a", filter.Node)
+    }
+}
+
+func TestParseFilter_unknownAction_mustFail(t *testing.T) {
+    _, err := ParseFilter(url.Values{"action": {"reboot"}})
+    assert.NotNil(t, err)
+}
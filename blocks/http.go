@@ -0,0 +1,30 @@
+package blocks
+
+import (
+    "encoding/json"
+    "net/http"
+    "strconv"
+)
+
+// serves the outcomes of the leader assignments in the epoch specified by the
+// optional query parameter 'epoch' as JSON. the current epoch is used, if the
+// parameter is missing.
+func (tracker *Tracker) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+    epoch := tracker.CurrentEpoch()
+    epochParam := request.URL.Query().Get("epoch")
+    if epochParam != "" {
+        var err error
+        epoch, err = strconv.ParseUint(epochParam, 10, 64)
+        if err != nil {
+            http.Error(writer, "The epoch must be a non-negative number.", http.StatusBadRequest)
+            return
+        }
+    }
+    epochOutcomes, err := tracker.GetEpochOutcomes(epoch)
+    if err != nil {
+        http.Error(writer, err.Error(), http.StatusInternalServerError)
+        return
+    }
+    writer.Header().Set("Content-Type", "application/json")
+    _ = json.NewEncoder(writer).Encode(epochOutcomes)
+}
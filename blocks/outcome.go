@@ -0,0 +1,97 @@
+package blocks
+
+import "time"
+
+// outcome of a leader assignment.
+type Outcome string
+
+const (
+    // a block has been produced, but it is not yet known
+    // whether it will be adopted by the main chain.
+    Minted Outcome = "minted"
+    // the produced block is part of the main chain.
+    Adopted Outcome = "adopted"
+    // the produced block lost the height battle against
+    // another block at the same height.
+    Lost Outcome = "lost"
+    // no block has been produced for the assignment.
+    Missed Outcome = "missed"
+)
+
+// checks whether the outcome is final, i.e. it will not change anymore.
+func (outcome Outcome) IsFinal() bool {
+    return outcome != Minted
+}
+
+// outcome of a single leader assignment.
+type BlockOutcome struct {
+    Epoch        uint64    `json:"epoch"`
+    Slot         uint64    `json:"slot"`
+    ScheduleTime time.Time `json:"scheduleTime"`
+    Outcome      Outcome   `json:"outcome"`
+    // node that produced the block, or was rejected.
+    Node string `json:"node,omitempty"`
+    // hash and chain length of the produced block.
+    BlockHash   string `json:"blockHash,omitempty"`
+    ChainLength uint64 `json:"chainLength,omitempty"`
+    // time at which the node woke up for the assignment
+    // and finished it.
+    WakeTime      *time.Time `json:"wakeTime,omitempty"`
+    FinishingTime *time.Time `json:"finishingTime,omitempty"`
+    // reason for a lost or missed block.
+    Reason string `json:"reason,omitempty"`
+    // whether a missed block may still turn out to be produced,
+    // because the leader logs of some candidates are unknown.
+    Tentative bool `json:"tentative,omitempty"`
+    // time at which the outcome has been determined.
+    CheckTime time.Time `json:"checkTime"`
+}
+
+// checks whether the outcome of the assignment is final, i.e. it will not
+// change anymore. a tentative miss is not final.
+func (outcome BlockOutcome) IsFinal() bool {
+    return outcome.Outcome.IsFinal() && !outcome.Tentative
+}
+
+// summary of the outcomes of all leader assignments in an epoch.
+type EpochSummary struct {
+    Epoch uint64 `json:"epoch"`
+    // number of scheduled blocks.
+    Scheduled int `json:"scheduled"`
+    // number of assignments, whose outcome has not yet
+    // been determined.
+    Pending int `json:"pending"`
+    Minted  int `json:"minted"`
+    Adopted int `json:"adopted"`
+    Lost    int `json:"lost"`
+    Missed  int `json:"missed"`
+}
+
+// outcomes of all the leader assignments in an epoch.
+type EpochOutcomes struct {
+    Summary  EpochSummary   `json:"summary"`
+    Outcomes []BlockOutcome `json:"outcomes"`
+}
+
+// summarizes the given outcomes of the epoch with the given number of
+// scheduled blocks.
+func summarize(epoch uint64, scheduled int, outcomes []BlockOutcome) EpochSummary {
+    summary := EpochSummary{Epoch: epoch, Scheduled: scheduled}
+    for _, outcome := range outcomes {
+        switch outcome.Outcome {
+        case Minted:
+            summary.Minted++
+        case Adopted:
+            summary.Adopted++
+        case Lost:
+            summary.Lost++
+        case Missed:
+            summary.Missed++
+        }
+    }
+    if summary.Scheduled < len(outcomes) {
+        summary.Scheduled = len(outcomes)
+    }
+    summary.Pending = summary.Scheduled - len(outcomes)
+    return summary
+}
@@ -0,0 +1,48 @@
+package blocks
+
+import (
+    "encoding/json"
+    "github.com/sobitada/thor/storage"
+    "sort"
+    "strconv"
+)
+
+// creates the bucket for the block outcomes, if it does not exist yet.
+func createBucket(store storage.Store) error {
+    return store.Update(func(tx storage.Tx) error {
+        return tx.CreateBucket(storage.BlocksBucket)
+    })
+}
+
+// gets the path of the bucket with the outcomes of the given epoch.
+func getEpochBucket(epoch uint64) string {
+    return storage.SubBucket(storage.BlocksBucket, strconv.FormatUint(epoch, 10))
+}
+
+// stores the given outcome in the bucket of its epoch.
+func storeOutcome(store storage.Store, outcome BlockOutcome) error {
+    return store.Update(func(tx storage.Tx) error {
+        data, err := json.Marshal(outcome)
+        if err != nil {
+            return err
+        }
+        return tx.Put(getEpochBucket(outcome.Epoch), strconv.FormatUint(outcome.Slot, 10), data)
+    })
+}
+
+// gets the stored outcomes of the given epoch sorted by their slot.
+func getOutcomes(store storage.Store, epoch uint64) ([]BlockOutcome, error) {
+    outcomes := make([]BlockOutcome, 0)
+    err := store.View(func(tx storage.Tx) error {
+        return tx.ForEach(getEpochBucket(epoch), func(key string, value []byte) error {
+            var outcome BlockOutcome
+            err := json.Unmarshal(value, &outcome)
+            if err == nil {
+                outcomes = append(outcomes, outcome)
+            }
+            return err
+        })
+    })
+    sort.Slice(outcomes, func(i, j int) bool { return outcomes[i].Slot < outcomes[j].Slot })
+    return outcomes, err
+}
@@ -0,0 +1,306 @@
+package blocks
+
+import (
+    "fmt"
+    log "github.com/sirupsen/logrus"
+    "github.com/sobitada/go-cardano"
+    "github.com/sobitada/go-jormungandr/api"
+    "github.com/sobitada/thor/clock"
+    "github.com/sobitada/thor/jormungandr"
+    "github.com/sobitada/thor/monitor"
+    "github.com/sobitada/thor/storage"
+    "math/big"
+    "sort"
+    "sync"
+    "time"
+)
+
+// settings for the block outcome tracker.
+type TrackerSettings struct {
+    // interval in which the outcomes of past assignments
+    // are checked.
+    Interval time.Duration
+    // number of slots after the scheduled slot, after which
+    // the outcome of an assignment is determined.
+    SettleSlots uint64
+    // number of blocks on top of a produced block, after which
+    // it is decided whether the block has been adopted.
+    ConfirmationDepth uint64
+    // time after the scheduled time of an assignment, after which
+    // it is considered missed, even if the leader logs of some
+    // candidates could not be fetched.
+    ConfirmationWindow time.Duration
+    // clock used for waiting between checks, the clock
+    // of the system is used if it is nil.
+    Clock clock.Clock
+}
+
+// tracker that determines the outcome of each leader assignment after its
+// scheduled time has passed, i.e. whether the block has been minted, adopted,
+// lost in a height battle or missed.
+type Tracker struct {
+    nodes        []monitor.Node
+    watchDog     *monitor.ScheduleWatchDog
+    store        storage.Store
+    timeSettings *cardano.TimeSettings
+    settings     TrackerSettings
+    listeners    []chan BlockOutcome
+    mutex        *sync.Mutex
+}
+
+// creates a new block outcome tracker for the leader candidates among the
+// given nodes, which tracks the schedules fetched by the given watchdog.
+func NewTracker(nodes []monitor.Node, watchDog *monitor.ScheduleWatchDog, store storage.Store,
+    timeSettings *cardano.TimeSettings, settings TrackerSettings) (*Tracker, error) {
+    err := createBucket(store)
+    if err != nil {
+        return nil, err
+    }
+    candidates := make([]monitor.Node, 0)
+    for _, node := range nodes {
+        if node.Type == monitor.LeaderCandidate && node.BlockAPI != nil {
+            candidates = append(candidates, node)
+        }
+    }
+    settings.Clock = clock.OrReal(settings.Clock)
+    return &Tracker{
+        nodes:        candidates,
+        watchDog:     watchDog,
+        store:        store,
+        timeSettings: timeSettings,
+        settings:     settings,
+        listeners:    make([]chan BlockOutcome, 0),
+        mutex:        &sync.Mutex{},
+    }, nil
+}
+
+// registers a listener that will be informed about each determined outcome
+// and change of an outcome. the listener must continuously consume them.
+func (tracker *Tracker) RegisterListener(listener chan BlockOutcome) {
+    tracker.mutex.Lock()
+    defer tracker.mutex.Unlock()
+    tracker.listeners = append(tracker.listeners, listener)
+}
+
+func (tracker *Tracker) informListeners(outcome BlockOutcome) {
+    tracker.mutex.Lock()
+    defer tracker.mutex.Unlock()
+    for i := range tracker.listeners {
+        tracker.listeners[i] <- outcome
+    }
+}
+
+// gets the current epoch.
+func (tracker *Tracker) CurrentEpoch() uint64 {
+    currentSlotDate, _ := tracker.timeSettings.GetSlotDateFor(tracker.settings.Clock.Now())
+    return currentSlotDate.GetEpoch().Uint64()
+}
+
+// gets the determined outcomes of the leader assignments in the given epoch.
+func (tracker *Tracker) GetOutcomes(epoch uint64) ([]BlockOutcome, error) {
+    return getOutcomes(tracker.store, epoch)
+}
+
+// gets the outcomes and their summary for the given epoch.
+func (tracker *Tracker) GetEpochOutcomes(epoch uint64) (EpochOutcomes, error) {
+    outcomes, err := tracker.GetOutcomes(epoch)
+    if err != nil {
+        return EpochOutcomes{}, err
+    }
+    scheduled := 0
+    schedule, found := tracker.watchDog.GetStoredScheduleFor(new(big.Int).SetUint64(epoch))
+    if found {
+        scheduled = len(schedule)
+    }
+    return EpochOutcomes{Summary: summarize(epoch, scheduled, outcomes), Outcomes: outcomes}, nil
+}
+
+// a blocking call, which continuously tracks the outcomes of the
+// leader assignments.
+func (tracker *Tracker) Track() {
+    log.Info("[BLOCKS] Starting to track the outcome of leader assignments.")
+    for ; ; {
+        tracker.track()
+        tracker.settings.Clock.Sleep(tracker.settings.Interval)
+    }
+}
+
+// determines the outcome of all the leader assignments of the current
+// and previous epoch, which have passed and have no final outcome yet.
+func (tracker *Tracker) track() {
+    currentEpoch := tracker.CurrentEpoch()
+    for _, epoch := range []uint64{currentEpoch - 1, currentEpoch} {
+        if epoch > currentEpoch {
+            continue
+        }
+        schedule, found := tracker.watchDog.GetScheduleFor(new(big.Int).SetUint64(epoch))
+        if !found || len(schedule) == 0 {
+            continue
+        }
+        stored, err := tracker.GetOutcomes(epoch)
+        if err != nil {
+            log.Errorf("[BLOCKS] Could not read the outcomes of epoch %v. %v", epoch, err.Error())
+            continue
+        }
+        storedMap := make(map[uint64]BlockOutcome)
+        for _, outcome := range stored {
+            storedMap[outcome.Slot] = outcome
+        }
+        pending := tracker.getPendingAssignments(schedule, storedMap)
+        if len(pending) == 0 {
+            continue
+        }
+        leaderLogs := tracker.fetchLeaderLogs()
+        if len(leaderLogs) == 0 {
+            log.Warnf("[BLOCKS] The leader logs could not be fetched from any leader candidate.")
+            return
+        }
+        maxHeight := tracker.fetchMaxHeight()
+        for _, assignment := range pending {
+            outcome := tracker.classify(assignment, leaderLogs, maxHeight)
+            previous, found := storedMap[outcome.Slot]
+            if found && previous.Outcome == outcome.Outcome && previous.Tentative == outcome.Tentative {
+                continue
+            }
+            err := storeOutcome(tracker.store, outcome)
+            if err != nil {
+                log.Errorf("[BLOCKS] Could not store the outcome of %v.%v. %v", outcome.Epoch, outcome.Slot, err.Error())
+                continue
+            }
+            log.Infof("[BLOCKS] Assignment at %v.%v has been %v. %v", outcome.Epoch, outcome.Slot, outcome.Outcome,
+                outcome.Reason)
+            tracker.informListeners(outcome)
+        }
+    }
+}
+
+// gets the assignments of the given schedule, whose scheduled time has passed
+// long enough and which have no final outcome yet.
+func (tracker *Tracker) getPendingAssignments(schedule []api.LeaderAssignment,
+    stored map[uint64]BlockOutcome) []api.LeaderAssignment {
+    settleTime := time.Duration(tracker.settings.SettleSlots) * tracker.timeSettings.SlotDuration
+    now := tracker.settings.Clock.Now()
+    pending := make([]api.LeaderAssignment, 0)
+    for _, assignment := range schedule {
+        if assignment.ScheduleBlockDate == nil || assignment.ScheduleTime.Add(settleTime).After(now) {
+            continue
+        }
+        outcome, found := stored[assignment.ScheduleBlockDate.GetSlot().Uint64()]
+        if !found || !outcome.IsFinal() {
+            pending = append(pending, assignment)
+        }
+    }
+    return pending
+}
+
+// fetches the leader logs of all leader candidates, the logs of candidates
+// that could not be reached are missing in the returned map.
+func (tracker *Tracker) fetchLeaderLogs() map[string][]jormungandr.LeaderLog {
+    leaderLogs := make(map[string][]jormungandr.LeaderLog)
+    for _, node := range tracker.nodes {
+        logs, err := node.BlockAPI.GetLeaderLogs()
+        if err == nil {
+            leaderLogs[node.Name] = logs
+        } else {
+            log.Warnf("[BLOCKS] Could not fetch the leader logs of %v. %v", node.Name, err.Error())
+        }
+    }
+    return leaderLogs
+}
+
+// fetches the maximum block height reported by the leader candidates.
+func (tracker *Tracker) fetchMaxHeight() uint64 {
+    var maxHeight uint64 = 0
+    for _, node := range tracker.nodes {
+        stats, _, err := node.API.GetNodeStatistics()
+        if err == nil && stats != nil && stats.LastBlockHeight != nil && stats.LastBlockHeight.Uint64() > maxHeight {
+            maxHeight = stats.LastBlockHeight.Uint64()
+        }
+    }
+    return maxHeight
+}
+
+// classifies the outcome of the given assignment based on the given leader
+// logs of the candidates and the maximum block height of the chain.
+func (tracker *Tracker) classify(assignment api.LeaderAssignment, leaderLogs map[string][]jormungandr.LeaderLog,
+    maxHeight uint64) BlockOutcome {
+    outcome := BlockOutcome{
+        Epoch:        assignment.ScheduleBlockDate.GetEpoch().Uint64(),
+        Slot:         assignment.ScheduleBlockDate.GetSlot().Uint64(),
+        ScheduleTime: assignment.ScheduleTime,
+        Outcome:      Missed,
+        Reason:       "No leader candidate woke up for the assignment.",
+        CheckTime:    tracker.settings.Clock.Now(),
+    }
+    names := make([]string, 0, len(leaderLogs))
+    for name := range leaderLogs {
+        names = append(names, name)
+    }
+    sort.Strings(names)
+    var minted *jormungandr.LeaderLog
+    for _, name := range names {
+        for _, leaderLog := range leaderLogs[name] {
+            if leaderLog.ScheduleBlockDate == nil || !leaderLog.ScheduleBlockDate.SameAs(assignment.ScheduleBlockDate) {
+                continue
+            }
+            if leaderLog.Status == jormungandr.Block && minted == nil {
+                current := leaderLog
+                minted = &current
+                outcome.Node = name
+                outcome.WakeTime = leaderLog.WakeTime
+                outcome.FinishingTime = leaderLog.FinishingTime
+            } else if leaderLog.Status == jormungandr.Rejected && minted == nil {
+                outcome.Node = name
+                outcome.WakeTime = leaderLog.WakeTime
+                outcome.FinishingTime = leaderLog.FinishingTime
+                outcome.Reason = fmt.Sprintf("The block has been rejected: %v", leaderLog.Reason)
+            } else if leaderLog.WakeTime != nil && outcome.WakeTime == nil && minted == nil {
+                outcome.Node = name
+                outcome.WakeTime = leaderLog.WakeTime
+                outcome.Reason = "The leader candidate woke up, but did not produce a block."
+            }
+        }
+    }
+    if minted == nil {
+        // the block might have been produced by an unreachable candidate.
+        if len(leaderLogs) < len(tracker.nodes) &&
+            assignment.ScheduleTime.Add(tracker.settings.ConfirmationWindow).After(outcome.CheckTime) {
+            outcome.Tentative = true
+        }
+        return outcome
+    }
+    outcome.BlockHash = minted.BlockHash
+    outcome.ChainLength = minted.ChainLength
+    outcome.Reason = ""
+    if maxHeight < minted.ChainLength+tracker.settings.ConfirmationDepth {
+        outcome.Outcome = Minted
+        return outcome
+    }
+    inMainChain, reachable := tracker.isInMainChain(minted.BlockHash)
+    if !reachable {
+        outcome.Outcome = Minted
+    } else if inMainChain {
+        outcome.Outcome = Adopted
+    } else {
+        outcome.Outcome = Lost
+        outcome.Reason = fmt.Sprintf("Another block has been adopted at height %v.", minted.ChainLength)
+    }
+    return outcome
+}
+
+// checks whether the block with the given hash is in the main chain of any
+// leader candidate. the second return value is false, if no candidate could
+// be asked.
+func (tracker *Tracker) isInMainChain(blockHash string) (bool, bool) {
+    reachable := false
+    for _, node := range tracker.nodes {
+        inMainChain, err := node.BlockAPI.IsInMainChain(blockHash)
+        if err == nil {
+            reachable = true
+            if inMainChain {
+                return true, true
+            }
+        }
+    }
+    return false, reachable
+}
@@ -0,0 +1,211 @@
+package blocks
+
+import (
+    "github.com/sobitada/go-cardano"
+    jor "github.com/sobitada/go-jormungandr/api"
+    "github.com/sobitada/thor/jortest"
+    "github.com/sobitada/thor/monitor"
+    "github.com/sobitada/thor/storage"
+    "github.com/stretchr/testify/assert"
+    "math/big"
+    "net/http"
+    "testing"
+    "time"
+)
+
+// creates a tracker for the given fake leader candidates, whose schedule
+// watchdog has fetched the given schedule.
+func newTestTracker(t *testing.T, fakes map[string]*jortest.Node, schedule []jor.LeaderAssignment,
+    timeSettings *cardano.TimeSettings, clock *jortest.Clock) (*Tracker, func()) {
+    nodes := make([]monitor.Node, 0)
+    for name, fake := range fakes {
+        fake.SetSchedule(schedule)
+        nodes = append(nodes, monitor.Node{Name: name, Type: monitor.LeaderCandidate, API: fake.API(time.Second),
+            BlockAPI: fake.BlockAPI(time.Second)})
+    }
+    db := storage.NewMemory()
+    cleanUp := func() {
+        _ = db.Close()
+    }
+    scheduleSettings := monitor.DefaultScheduleSettings()
+    scheduleSettings.Clock = clock
+    watchDog := monitor.NewScheduleWatchDog(nodes, timeSettings, db, scheduleSettings)
+    go watchDog.Watch()
+    assert.Eventually(t, func() bool {
+        _, found := watchDog.GetScheduleFor(big.NewInt(5))
+        return found
+    }, 5*time.Second, 10*time.Millisecond)
+    tracker, err := NewTracker(nodes, watchDog, db, timeSettings, TrackerSettings{
+        Interval:           30 * time.Second,
+        SettleSlots:        5,
+        ConfirmationDepth:  10,
+        ConfirmationWindow: time.Minute,
+        Clock:              clock,
+    })
+    if err != nil {
+        cleanUp()
+        t.Fatal(err)
+    }
+    return tracker, cleanUp
+}
+
+func getOutcomeMap(t *testing.T, tracker *Tracker, epoch uint64) map[uint64]BlockOutcome {
+    outcomes, err := tracker.GetOutcomes(epoch)
+    if err != nil {
+        t.Fatal(err)
+    }
+    outcomeMap := make(map[uint64]BlockOutcome)
+    for _, outcome := range outcomes {
+        outcomeMap[outcome.Slot] = outcome
+    }
+    return outcomeMap
+}
+
+func TestTracker_PassedAssignments_mustBeClassified(t *testing.T) {
+    now := time.Now()
+    clock := jortest.NewClock(now)
+    settings := jortest.TimeSettingsAt(now, 5, 100, time.Second, 1000)
+    schedule := []jor.LeaderAssignment{
+        jortest.Assignment(5, 10, *settings),
+        jortest.Assignment(5, 20, *settings),
+        jortest.Assignment(5, 30, *settings),
+        jortest.Assignment(5, 40, *settings),
+        jortest.Assignment(5, 90, *settings),
+        jortest.Assignment(5, 98, *settings),
+        jortest.Assignment(5, 500, *settings),
+    }
+    a, b := jortest.NewNodeWithClock(clock), jortest.NewNodeWithClock(clock)
+    defer a.Close()
+    defer b.Close()
+    a.SetTip(100, jortest.Hash(100), cardano.PlainSlotDateFromInt(5, 99))
+    b.SetTip(100, jortest.Hash(100), cardano.PlainSlotDateFromInt(5, 99))
+    a.SetBlockMinted(cardano.PlainSlotDateFromInt(5, 10), jortest.Hash(50), 50)
+    b.AddToMainChain(jortest.Hash(50))
+    b.SetBlockMinted(cardano.PlainSlotDateFromInt(5, 20), jortest.Hash(60), 60)
+    a.SetBlockRejected(cardano.PlainSlotDateFromInt(5, 30), "invalid parent")
+    a.SetBlockMinted(cardano.PlainSlotDateFromInt(5, 90), jortest.Hash(95), 95)
+    tracker, cleanUp := newTestTracker(t, map[string]*jortest.Node{"a": a, "b": b}, schedule, settings, clock)
+    defer cleanUp()
+    listener := make(chan BlockOutcome, 10)
+    tracker.RegisterListener(listener)
+
+    tracker.track()
+
+    outcomes := getOutcomeMap(t, tracker, 5)
+    assert.Len(t, outcomes, 5)
+    assert.Equal(t, Adopted, outcomes[10].Outcome)
+    assert.Equal(t, "a", outcomes[10].Node)
+    assert.Equal(t, uint64(50), outcomes[10].ChainLength)
+    assert.Equal(t, Lost, outcomes[20].Outcome)
+    assert.Equal(t, "b", outcomes[20].Node)
+    assert.Equal(t, Missed, outcomes[30].Outcome)
+    assert.Contains(t, outcomes[30].Reason, "invalid parent")
+    assert.Equal(t, Missed, outcomes[40].Outcome)
+    assert.Empty(t, outcomes[40].Node)
+    assert.Equal(t, Minted, outcomes[90].Outcome)
+    assert.Len(t, listener, 5)
+
+    epochOutcomes, err := tracker.GetEpochOutcomes(5)
+    if assert.NoError(t, err) {
+        assert.Equal(t, EpochSummary{Epoch: 5, Scheduled: 7, Pending: 2, Minted: 1, Adopted: 1, Lost: 1, Missed: 2},
+            epochOutcomes.Summary)
+    }
+}
+
+func TestTracker_MintedBlock_mustBeAdoptedOnceConfirmed(t *testing.T) {
+    now := time.Now()
+    clock := jortest.NewClock(now)
+    settings := jortest.TimeSettingsAt(now, 5, 100, time.Second, 1000)
+    schedule := []jor.LeaderAssignment{jortest.Assignment(5, 90, *settings)}
+    a := jortest.NewNodeWithClock(clock)
+    defer a.Close()
+    a.SetTip(95, jortest.Hash(95), cardano.PlainSlotDateFromInt(5, 99))
+    a.SetBlockMinted(cardano.PlainSlotDateFromInt(5, 90), jortest.Hash(95), 95)
+    tracker, cleanUp := newTestTracker(t, map[string]*jortest.Node{"a": a}, schedule, settings, clock)
+    defer cleanUp()
+    listener := make(chan BlockOutcome, 10)
+    tracker.RegisterListener(listener)
+
+    tracker.track()
+    assert.Equal(t, Minted, getOutcomeMap(t, tracker, 5)[90].Outcome)
+    // nothing changed, the listener must not be informed again.
+    tracker.track()
+    assert.Len(t, listener, 1)
+
+    clock.Advance(20 * time.Second)
+    a.SetTip(110, jortest.Hash(110), cardano.PlainSlotDateFromInt(5, 119))
+    a.AddToMainChain(jortest.Hash(95))
+    tracker.track()
+    assert.Equal(t, Adopted, getOutcomeMap(t, tracker, 5)[90].Outcome)
+    assert.Len(t, listener, 2)
+}
+
+func TestTracker_NoCandidateReachable_mustNotStoreOutcomes(t *testing.T) {
+    now := time.Now()
+    clock := jortest.NewClock(now)
+    settings := jortest.TimeSettingsAt(now, 5, 100, time.Second, 1000)
+    schedule := []jor.LeaderAssignment{jortest.Assignment(5, 10, *settings)}
+    a := jortest.NewNodeWithClock(clock)
+    defer a.Close()
+    tracker, cleanUp := newTestTracker(t, map[string]*jortest.Node{"a": a}, schedule, settings, clock)
+    defer cleanUp()
+    a.Fail(jortest.LeaderLogsEndpoint, 500)
+
+    tracker.track()
+
+    assert.Empty(t, getOutcomeMap(t, tracker, 5))
+}
+
+func TestTracker_CandidateUnreachable_mustNotMissBlockForGood(t *testing.T) {
+    now := time.Now()
+    clock := jortest.NewClock(now)
+    settings := jortest.TimeSettingsAt(now, 5, 100, time.Second, 1000)
+    schedule := []jor.LeaderAssignment{jortest.Assignment(5, 90, *settings)}
+    a, b := jortest.NewNodeWithClock(clock), jortest.NewNodeWithClock(clock)
+    defer a.Close()
+    defer b.Close()
+    a.SetTip(110, jortest.Hash(110), cardano.PlainSlotDateFromInt(5, 99))
+    b.SetTip(110, jortest.Hash(110), cardano.PlainSlotDateFromInt(5, 99))
+    b.SetBlockMinted(cardano.PlainSlotDateFromInt(5, 90), jortest.Hash(95), 95)
+    b.AddToMainChain(jortest.Hash(95))
+    tracker, cleanUp := newTestTracker(t, map[string]*jortest.Node{"a": a, "b": b}, schedule, settings, clock)
+    defer cleanUp()
+    listener := make(chan BlockOutcome, 10)
+    tracker.RegisterListener(listener)
+
+    b.Fail(jortest.LeaderLogsEndpoint, http.StatusServiceUnavailable)
+    tracker.track()
+    outcome := getOutcomeMap(t, tracker, 5)[90]
+    assert.Equal(t, Missed, outcome.Outcome)
+    assert.True(t, outcome.Tentative)
+    assert.False(t, outcome.IsFinal())
+
+    b.Fail(jortest.LeaderLogsEndpoint, 0)
+    tracker.track()
+    outcome = getOutcomeMap(t, tracker, 5)[90]
+    assert.Equal(t, Adopted, outcome.Outcome)
+    assert.Equal(t, "b", outcome.Node)
+    assert.Len(t, listener, 2)
+}
+
+func TestTracker_CandidateUnreachableBeyondWindow_mustMissBlock(t *testing.T) {
+    now := time.Now()
+    clock := jortest.NewClock(now)
+    settings := jortest.TimeSettingsAt(now, 5, 100, time.Second, 1000)
+    schedule := []jor.LeaderAssignment{jortest.Assignment(5, 90, *settings)}
+    a, b := jortest.NewNodeWithClock(clock), jortest.NewNodeWithClock(clock)
+    defer a.Close()
+    defer b.Close()
+    tracker, cleanUp := newTestTracker(t, map[string]*jortest.Node{"a": a, "b": b}, schedule, settings, clock)
+    defer cleanUp()
+
+    b.Fail(jortest.LeaderLogsEndpoint, http.StatusServiceUnavailable)
+    tracker.track()
+    assert.True(t, getOutcomeMap(t, tracker, 5)[90].Tentative)
+
+    clock.Advance(2 * time.Minute)
+    tracker.track()
+    outcome := getOutcomeMap(t, tracker, 5)[90]
+    assert.Equal(t, Missed, outcome.Outcome)
+    assert.True(t, outcome.IsFinal())
+}
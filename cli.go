@@ -0,0 +1,122 @@
+package main
+
+import (
+    "flag"
+    "fmt"
+    "github.com/sobitada/thor/config"
+    "gopkg.in/yaml.v2"
+    "io/ioutil"
+    "os"
+    "sort"
+)
+
+// a command of this application, which can be
+// run instead of the monitor.
+type command struct {
+    // short description of the command.
+    description string
+    // runs the command with the given arguments.
+    run func(args []string) error
+}
+
+// gets all the commands of this application.
+func getCommands() map[string]command {
+    return map[string]command{
+        "audit": {
+            description: "lists the recorded promotions, demotions and shutdowns.",
+            run:         runAuditCommand,
+        },
+        "blocks": {
+            description: "lists the outcomes of the leader assignments in an epoch.",
+            run:         runBlocksCommand,
+        },
+        "dashboards": {
+            description: "prints the Grafana dashboard or the Prometheus alerting rules (dashboards <grafana|rules>).",
+            run:         runDashboardsCommand,
+        },
+        "db": {
+            description: "exports, imports or compacts the db, while thor is not running (db <export|import|compact>).",
+            run:         runDBCommand,
+        },
+        "history": {
+            description: "lists the downsampled history of the height, lag, uptime, peers and latency of the nodes.",
+            run:         runHistoryCommand,
+        },
+        "maintenance": {
+            description: "lists the safe maintenance windows, or checks whether a node can be taken down now.",
+            run:         runMaintenanceCommand,
+        },
+        "report": {
+            description: "prints the report of an epoch as JSON, CSV or Markdown (report epoch <n>).",
+            run:         runReportCommand,
+        },
+        "schedule": {
+            description: "exports the leader schedule as iCalendar or CSV.",
+            run:         runScheduleCommand,
+        },
+        "shadow": {
+            description: "compares the decisions of a leader jury in shadow mode with the actual leader.",
+            run:         runShadowCommand,
+        },
+        "viability": {
+            description: "shows the viable leader candidates and differences of their schedules.",
+            run:         runViabilityCommand,
+        },
+    }
+}
+
+// checks whether there is a command with the given name.
+func isCommand(name string) bool {
+    _, found := getCommands()[name]
+    return found
+}
+
+// runs the command with the given name and arguments, and exits
+// with an error code, if the command failed.
+func runCommand(name string, args []string) {
+    err := getCommands()[name].run(args)
+    if err != nil {
+        fmt.Printf("%v\n", err.Error())
+        os.Exit(1)
+    }
+}
+
+// prints the name and description of all commands.
+func printCommands() {
+    commands := getCommands()
+    names := make([]string, 0, len(commands))
+    for name := range commands {
+        names = append(names, name)
+    }
+    sort.Strings(names)
+    for _, name := range names {
+        fmt.Printf("  %-12v %v\n", name, commands[name].description)
+    }
+}
+
+// reads the YAML configuration at the given path.
+func readConfig(configPath string) (config.General, error) {
+    var conf config.General
+    data, err := ioutil.ReadFile(configPath)
+    if err == nil {
+        err = yaml.UnmarshalStrict(data, &conf)
+    }
+    return conf, err
+}
+
+// parses the given arguments of a command with the given flag set, and
+// reads the configuration passed as the last argument.
+func parseCommandArgs(flags *flag.FlagSet, args []string) (config.General, error) {
+    err := flags.Parse(args)
+    if err != nil {
+        return config.General{}, err
+    }
+    if flags.NArg() != 1 {
+        return config.General{}, fmt.Errorf("Usage: %v %v [options] <config>", ApplicationName, flags.Name())
+    }
+    conf, err := readConfig(flags.Arg(0))
+    if err != nil {
+        return conf, fmt.Errorf("Could not parse the config file. %v", err.Error())
+    }
+    return conf, nil
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+    "flag"
+    "fmt"
+    "github.com/sobitada/thor/audit"
+    "github.com/sobitada/thor/config"
+    "net/url"
+    "os"
+    "text/tabwriter"
+    "time"
+)
+
+// lists the entries of the audit log of the running instance, which
+// match the filter given in the arguments.
+func runAuditCommand(args []string) error {
+    flags := flag.NewFlagSet("audit", flag.ContinueOnError)
+    from := flags.String("from", "", "only entries after this time (RFC3339).")
+    to := flags.String("to", "", "only entries before this time (RFC3339).")
+    node := flags.String("node", "", "only entries of the node with this name.")
+    action := flags.String("action", "", "only entries of this action (promotion, demotion, shutdown or restart).")
+    conf, err := parseCommandArgs(flags, args)
+    if err != nil {
+        return err
+    }
+    query := url.Values{}
+    for name, value := range map[string]string{"from": *from, "to": *to, "node": *node, "action": *action} {
+        if value != "" {
+            query.Set(name, value)
+        }
+    }
+    _, err = audit.ParseFilter(query)
+    if err != nil {
+        return err
+    }
+    client, err := config.GetStatusClient(conf)
+    if err != nil {
+        return err
+    }
+    var entries []audit.Entry
+    err = client.Get("audit", query, &entries)
+    if err != nil {
+        return err
+    }
+    writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+    _, _ = fmt.Fprintln(writer, "TIME\tSLOT\tNODE\tACTION\tOUTCOME\tLEADER\tREASON")
+    for _, entry := range entries {
+        slotDate, leaderID := "-", "-"
+        if entry.Epoch != nil && entry.Slot != nil {
+            slotDate = fmt.Sprintf("%v.%v", *entry.Epoch, *entry.Slot)
+        }
+        if entry.LeaderID != nil {
+            leaderID = fmt.Sprintf("%v", *entry.LeaderID)
+        }
+        _, _ = fmt.Fprintf(writer, "%v\t%v\t%v\t%v\t%v\t%v\t%v\n", entry.Time.Format(time.RFC3339), slotDate,
+            entry.Node, entry.Action, entry.Outcome, leaderID, entry.Reason)
+    }
+    return writer.Flush()
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+    "flag"
+    "fmt"
+    "github.com/sobitada/thor/blocks"
+    "github.com/sobitada/thor/config"
+    "net/url"
+    "os"
+    "strconv"
+    "text/tabwriter"
+    "time"
+)
+
+// prints the outcomes of the leader assignments in an epoch, i.e. whether
+// the blocks have been minted, adopted, lost or missed.
+func runBlocksCommand(args []string) error {
+    flags := flag.NewFlagSet("blocks", flag.ContinueOnError)
+    epoch := flags.Int64("epoch", -1, "epoch for which the outcomes shall be listed, the current epoch by default.")
+    conf, err := parseCommandArgs(flags, args)
+    if err != nil {
+        return err
+    }
+    client, err := config.GetStatusClient(conf)
+    if err != nil {
+        return err
+    }
+    params := url.Values{}
+    if *epoch >= 0 {
+        params.Set("epoch", strconv.FormatInt(*epoch, 10))
+    }
+    var epochOutcomes blocks.EpochOutcomes
+    err = client.Get("blocks", params, &epochOutcomes)
+    if err != nil {
+        return err
+    }
+    summary := epochOutcomes.Summary
+    fmt.Printf("Epoch:      %v\n", summary.Epoch)
+    fmt.Printf("Scheduled:  %v\n", summary.Scheduled)
+    fmt.Printf("Pending:    %v\n", summary.Pending)
+    fmt.Printf("Minted:     %v\n", summary.Minted)
+    fmt.Printf("Adopted:    %v\n", summary.Adopted)
+    fmt.Printf("Lost:       %v\n", summary.Lost)
+    fmt.Printf("Missed:     %v\n\n", summary.Missed)
+    writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+    _, _ = fmt.Fprintln(writer, "SLOT\tTIME\tOUTCOME\tNODE\tHEIGHT\tBLOCK\tREASON")
+    for _, outcome := range epochOutcomes.Outcomes {
+        height := ""
+        if outcome.ChainLength > 0 {
+            height = strconv.FormatUint(outcome.ChainLength, 10)
+        }
+        _, _ = fmt.Fprintf(writer, "%v.%v\t%v\t%v\t%v\t%v\t%v\t%v\n", outcome.Epoch, outcome.Slot,
+            outcome.ScheduleTime.Format(time.RFC3339), outcome.Outcome, outcome.Node, height, outcome.BlockHash,
+            outcome.Reason)
+    }
+    return writer.Flush()
+}
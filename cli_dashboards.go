@@ -0,0 +1,53 @@
+package main
+
+import (
+    "encoding/json"
+    "flag"
+    "fmt"
+    "github.com/sobitada/thor/prometheus"
+    "gopkg.in/yaml.v2"
+    "io/ioutil"
+    "os"
+)
+
+// prints the Grafana dashboard or the Prometheus alerting rules for the
+// exposed metrics. the arguments are expected as '<grafana|rules> [options]'.
+func runDashboardsCommand(args []string) error {
+    usage := fmt.Errorf("Usage: %v dashboards <grafana|rules> [options]", ApplicationName)
+    if len(args) < 1 || (args[0] != "grafana" && args[0] != "rules") {
+        return usage
+    }
+    defaults := prometheus.DefaultAlertSettings()
+    flags := flag.NewFlagSet("dashboards "+args[0], flag.ContinueOnError)
+    maxBlockLag := flags.Uint64("maxBlockLag", defaults.MaxBlockLag, "number of blocks a node can lag behind.")
+    maxTimeSinceLastBlock := flags.Duration("maxTimeSinceLastBlock", defaults.MaxTimeSinceLastBlock,
+        "time since the last block of a node, before it is considered stuck.")
+    maxLeaderChanges := flags.Int("maxLeaderChanges", defaults.MaxLeaderChanges, "number of leader changes per hour.")
+    output := flags.String("output", "", "path of the file to which the output shall be written, stdout by default.")
+    err := flags.Parse(args[1:])
+    if err != nil {
+        return err
+    }
+    if flags.NArg() != 0 {
+        return usage
+    }
+    settings := prometheus.AlertSettings{
+        MaxBlockLag:           *maxBlockLag,
+        MaxTimeSinceLastBlock: *maxTimeSinceLastBlock,
+        MaxLeaderChanges:      *maxLeaderChanges,
+    }
+    var data []byte
+    if args[0] == "grafana" {
+        data, err = json.MarshalIndent(prometheus.GetDashboard(settings), "", "  ")
+    } else {
+        data, err = yaml.Marshal(prometheus.GetAlertRules(settings))
+    }
+    if err != nil {
+        return err
+    }
+    if *output != "" {
+        return ioutil.WriteFile(*output, data, 0644)
+    }
+    _, err = os.Stdout.Write(data)
+    return err
+}
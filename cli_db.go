@@ -0,0 +1,116 @@
+package main
+
+import (
+    "flag"
+    "fmt"
+    "github.com/sobitada/thor/config"
+    "github.com/sobitada/thor/storage"
+    "os"
+)
+
+// exports, imports or compacts the store. the arguments are expected as
+// '<export|import|compact> [options] <config>'. the store is accessed
+// directly, and hence the thor instance must not be running.
+func runDBCommand(args []string) error {
+    usage := fmt.Errorf("Usage: %v db <export|import|compact> [options] <config>", ApplicationName)
+    if len(args) < 1 {
+        return usage
+    }
+    switch args[0] {
+    case "export":
+        return runDBExportCommand(args[1:])
+    case "import":
+        return runDBImportCommand(args[1:])
+    case "compact":
+        return runDBCompactCommand(args[1:])
+    }
+    return usage
+}
+
+// opens the store specified in the given configuration.
+func openStore(conf config.General) (storage.Store, storage.Settings, error) {
+    settings, err := config.GetStoreSettings(conf)
+    if err != nil {
+        return nil, settings, err
+    }
+    store, err := storage.Open(settings)
+    return store, settings, err
+}
+
+// writes the content of the store as JSON.
+func runDBExportCommand(args []string) error {
+    flags := flag.NewFlagSet("db export", flag.ContinueOnError)
+    output := flags.String("output", "", "path of the file to which the export shall be written, stdout by default.")
+    conf, err := parseCommandArgs(flags, args)
+    if err != nil {
+        return err
+    }
+    store, _, err := openStore(conf)
+    if err != nil {
+        return err
+    }
+    defer store.Close()
+    if *output != "" {
+        file, err := os.OpenFile(*output, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+        if err != nil {
+            return err
+        }
+        defer file.Close()
+        return storage.WriteExport(file, store)
+    }
+    return storage.WriteExport(os.Stdout, store)
+}
+
+// replaces the content of the store with an export.
+func runDBImportCommand(args []string) error {
+    flags := flag.NewFlagSet("db import", flag.ContinueOnError)
+    input := flags.String("input", "", "path of the file with the export, which shall be imported.")
+    replace := flags.Bool("replace", false, "replaces the data in the store, if it is not empty.")
+    conf, err := parseCommandArgs(flags, args)
+    if err != nil {
+        return err
+    }
+    if *input == "" {
+        return fmt.Errorf("The file with the export must be specified with -input.")
+    }
+    file, err := os.Open(*input)
+    if err != nil {
+        return err
+    }
+    defer file.Close()
+    store, settings, err := openStore(conf)
+    if err != nil {
+        return err
+    }
+    defer store.Close()
+    empty, err := storage.IsEmpty(store)
+    if err != nil {
+        return err
+    }
+    if !empty && !*replace {
+        return fmt.Errorf("The store is not empty, use -replace to replace its data with the export.")
+    }
+    err = storage.ReadImport(file, store)
+    if err == nil {
+        fmt.Printf("Imported '%v' into '%v'.\n", *input, settings.Path)
+    }
+    return err
+}
+
+// rewrites the file of the store to free the space of removed data.
+func runDBCompactCommand(args []string) error {
+    flags := flag.NewFlagSet("db compact", flag.ContinueOnError)
+    conf, err := parseCommandArgs(flags, args)
+    if err != nil {
+        return err
+    }
+    settings, err := config.GetStoreSettings(conf)
+    if err != nil {
+        return err
+    }
+    before, after, err := storage.Compact(settings)
+    if err == nil {
+        fmt.Printf("Compacted '%v' from %v to %v bytes.\n", settings.Path, before, after)
+    }
+    return err
+}
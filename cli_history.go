@@ -0,0 +1,69 @@
+package main
+
+import (
+    "flag"
+    "fmt"
+    "github.com/sobitada/thor/config"
+    "github.com/sobitada/thor/history"
+    "net/url"
+    "os"
+    "text/tabwriter"
+    "time"
+)
+
+// lists the downsampled statistics of the nodes recorded by the running
+// instance, which match the filter given in the arguments.
+func runHistoryCommand(args []string) error {
+    flags := flag.NewFlagSet("history", flag.ContinueOnError)
+    from := flags.String("from", "", "only points after this time (RFC3339).")
+    to := flags.String("to", "", "only points before this time (RFC3339).")
+    node := flags.String("node", "", "only points of the node with this name.")
+    resolution := flags.String("resolution", "", "resolution of the points, e.g. 1m or 1h. by default the finest one covering the start.")
+    conf, err := parseCommandArgs(flags, args)
+    if err != nil {
+        return err
+    }
+    query := url.Values{}
+    for name, value := range map[string]string{"from": *from, "to": *to, "node": *node, "resolution": *resolution} {
+        if value != "" {
+            query.Set(name, value)
+        }
+    }
+    _, err = history.ParseFilter(query)
+    if err != nil {
+        return err
+    }
+    client, err := config.GetStatusClient(conf)
+    if err != nil {
+        return err
+    }
+    var points []history.Point
+    err = client.Get("history", query, &points)
+    if err != nil {
+        return err
+    }
+    writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+    _, _ = fmt.Fprintln(writer, "TIME\tNODE\tSAMPLES\tFAILURES\tHEIGHT\tLAG (AVG/MAX)\tUPTIME\tPEERS (AV/QU/UN)\tLATENCY (AVG/MAX)")
+    for _, point := range points {
+        height, lag, uptime, peers := "-", "-", "-", "-"
+        if point.Statistics() > 0 {
+            height = fmt.Sprintf("%v", point.Height)
+            lag = fmt.Sprintf("%.1f/%v", point.AvgLag, point.MaxLag)
+            uptime = (time.Duration(point.UpTimeSeconds) * time.Second).String()
+            peers = fmt.Sprintf("%v/%v/%v", formatCount(point.PeersAvailable), formatCount(point.PeersQuarantined),
+                formatCount(point.PeersUnreachable))
+        }
+        _, _ = fmt.Fprintf(writer, "%v\t%v\t%v\t%v\t%v\t%v\t%v\t%v\t%.0fms/%.0fms\n", point.Time.Format(time.RFC3339),
+            point.Node, point.Samples, point.Failures+point.Bootstrapping, height, lag, uptime, peers,
+            point.AvgLatencyMs, point.MaxLatencyMs)
+    }
+    return writer.Flush()
+}
+
+// formats the given optional count, which is printed as '-' if missing.
+func formatCount(count *uint64) string {
+    if count == nil {
+        return "-"
+    }
+    return fmt.Sprintf("%v", *count)
+}
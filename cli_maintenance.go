@@ -0,0 +1,74 @@
+package main
+
+import (
+    "flag"
+    "fmt"
+    "github.com/sobitada/thor/config"
+    "github.com/sobitada/thor/maintenance"
+    "net/url"
+    "os"
+    "text/tabwriter"
+    "time"
+)
+
+// prints the safe maintenance windows of the current epoch, or checks whether
+// a node can be taken down for maintenance now. the check can optionally wait
+// until a safe window opens, and fails if it is not safe.
+func runMaintenanceCommand(args []string) error {
+    flags := flag.NewFlagSet("maintenance", flag.ContinueOnError)
+    node := flags.String("node", "", "node that shall be taken down, the safe windows are listed if it is not given.")
+    duration := flags.Duration("duration", 5*time.Minute, "duration of the maintenance.")
+    wait := flags.Duration("wait", 0, "maximum duration to wait for a safe window.")
+    conf, err := parseCommandArgs(flags, args)
+    if err != nil {
+        return err
+    }
+    client, err := config.GetStatusClient(conf)
+    if err != nil {
+        return err
+    }
+    if *node == "" {
+        var windows maintenance.Windows
+        err = client.Get("maintenance/windows", url.Values{}, &windows)
+        if err != nil {
+            return err
+        }
+        fmt.Printf("Epoch:    %v\n", windows.Epoch)
+        fmt.Printf("Horizon:  %v\n\n", windows.Horizon.Format(time.RFC3339))
+        writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+        _, _ = fmt.Fprintln(writer, "START\tEND\tDURATION")
+        for _, window := range windows.Safe {
+            _, _ = fmt.Fprintf(writer, "%v\t%v\t%v\n", window.Start.Format(time.RFC3339),
+                window.End.Format(time.RFC3339), window.Duration().Round(time.Second))
+        }
+        return writer.Flush()
+    }
+    deadline := time.Now().Add(*wait)
+    for ; ; {
+        var decision maintenance.Decision
+        err = client.Get("maintenance/check", url.Values{
+            "node":     []string{*node},
+            "duration": []string{duration.String()},
+        }, &decision)
+        if err != nil {
+            return err
+        }
+        if decision.Safe {
+            fmt.Printf("Safe to take down %v for %v. %v\n", *node, *duration, decision.Reason)
+            return nil
+        }
+        now := time.Now()
+        if !now.Before(deadline) {
+            return fmt.Errorf("Not safe to take down %v for %v. %v", *node, *duration, decision.Reason)
+        }
+        // re-check at the start of the next window, but at least every minute.
+        next := now.Add(time.Minute)
+        if decision.NextWindow != nil && decision.NextWindow.Start.Before(next) {
+            next = decision.NextWindow.Start
+        }
+        if deadline.Before(next) {
+            next = deadline
+        }
+        time.Sleep(next.Sub(now))
+    }
+}
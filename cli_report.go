@@ -0,0 +1,47 @@
+package main
+
+import (
+    "flag"
+    "fmt"
+    "github.com/sobitada/thor/config"
+    "github.com/sobitada/thor/report"
+    "net/url"
+    "os"
+    "strconv"
+)
+
+// prints the report of an epoch in the requested format. the arguments are
+// expected as 'epoch <n> [options] <config>'.
+func runReportCommand(args []string) error {
+    usage := fmt.Errorf("Usage: %v report epoch <n> [-format json|csv|markdown] <config>", ApplicationName)
+    if len(args) < 2 || args[0] != "epoch" {
+        return usage
+    }
+    epoch, err := strconv.ParseUint(args[1], 10, 64)
+    if err != nil {
+        return usage
+    }
+    flags := flag.NewFlagSet("report", flag.ContinueOnError)
+    formatName := flags.String("format", string(report.JSON), "format of the report, i.e. json, csv or markdown.")
+    conf, err := parseCommandArgs(flags, args[2:])
+    if err != nil {
+        return err
+    }
+    format, err := report.ParseFormat(*formatName)
+    if err != nil {
+        return err
+    }
+    client, err := config.GetStatusClient(conf)
+    if err != nil {
+        return err
+    }
+    data, err := client.GetRaw("report/epoch", url.Values{
+        "epoch":  []string{strconv.FormatUint(epoch, 10)},
+        "format": []string{string(format)},
+    })
+    if err != nil {
+        return err
+    }
+    _, err = os.Stdout.Write(data)
+    return err
+}
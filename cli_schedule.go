@@ -0,0 +1,44 @@
+package main
+
+import (
+    "flag"
+    "github.com/sobitada/thor/config"
+    "github.com/sobitada/thor/monitor"
+    "io/ioutil"
+    "net/url"
+    "os"
+)
+
+// exports the leader schedule of the current, a given or all stored epochs
+// as iCalendar or CSV.
+func runScheduleCommand(args []string) error {
+    flags := flag.NewFlagSet("schedule", flag.ContinueOnError)
+    epoch := flags.String("epoch", "", "epoch of the schedule or 'all' for all stored epochs, the current epoch by default.")
+    formatName := flags.String("format", string(monitor.ICS), "format of the export, i.e. ics or csv.")
+    output := flags.String("output", "", "path of the file to which the schedule shall be written, stdout by default.")
+    conf, err := parseCommandArgs(flags, args)
+    if err != nil {
+        return err
+    }
+    format, err := monitor.ParseScheduleFormat(*formatName)
+    if err != nil {
+        return err
+    }
+    client, err := config.GetStatusClient(conf)
+    if err != nil {
+        return err
+    }
+    params := url.Values{"format": []string{string(format)}}
+    if *epoch != "" {
+        params.Set("epoch", *epoch)
+    }
+    data, err := client.GetRaw("schedule/export", params)
+    if err != nil {
+        return err
+    }
+    if *output != "" {
+        return ioutil.WriteFile(*output, data, 0644)
+    }
+    _, err = os.Stdout.Write(data)
+    return err
+}
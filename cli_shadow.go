@@ -0,0 +1,46 @@
+package main
+
+import (
+    "flag"
+    "fmt"
+    "github.com/sobitada/thor/config"
+    "github.com/sobitada/thor/leader"
+    "net/url"
+    "os"
+    "text/tabwriter"
+    "time"
+)
+
+// prints the report of the running leader jury in shadow mode, which compares
+// the decisions of the jury with the actual leader.
+func runShadowCommand(args []string) error {
+    flags := flag.NewFlagSet("shadow", flag.ContinueOnError)
+    conf, err := parseCommandArgs(flags, args)
+    if err != nil {
+        return err
+    }
+    client, err := config.GetStatusClient(conf)
+    if err != nil {
+        return err
+    }
+    var report leader.ShadowReport
+    err = client.Get("shadow", url.Values{}, &report)
+    if err != nil {
+        return err
+    }
+    var agreement float64 = 0
+    if report.Checkpoints > 0 {
+        agreement = 100 * float64(report.AgreedCheckpoints) / float64(report.Checkpoints)
+    }
+    fmt.Printf("Since:                 %v\n", report.Since.Format(time.RFC3339))
+    fmt.Printf("Checkpoints:           %v (%.2f%% agreed with actual leader)\n", report.Checkpoints, agreement)
+    fmt.Printf("Shadow leader:         %v (%v changes)\n", report.ShadowLeader, report.ShadowLeaderChanges)
+    fmt.Printf("Actual leader:         %v (%v changes)\n\n", report.ActualLeader, report.ActualLeaderChanges)
+    writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+    _, _ = fmt.Fprintln(writer, "TIME\tNODE\tACTION\tACTUAL LEADER\tREASON")
+    for _, decision := range report.Decisions {
+        _, _ = fmt.Fprintf(writer, "%v\t%v\t%v\t%v\t%v\n", decision.Time.Format(time.RFC3339), decision.Node,
+            decision.Action, decision.ActualLeader, decision.Reason)
+    }
+    return writer.Flush()
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+    "flag"
+    "fmt"
+    "github.com/sobitada/thor/config"
+    "github.com/sobitada/thor/monitor"
+    "net/url"
+    "os"
+    "sort"
+    "strings"
+    "text/tabwriter"
+    "time"
+)
+
+// prints the viability of the leader candidates in the current epoch, and the
+// differences of the schedules computed by non-viable candidates.
+func runViabilityCommand(args []string) error {
+    flags := flag.NewFlagSet("viability", flag.ContinueOnError)
+    conf, err := parseCommandArgs(flags, args)
+    if err != nil {
+        return err
+    }
+    client, err := config.GetStatusClient(conf)
+    if err != nil {
+        return err
+    }
+    var report monitor.ViabilityReport
+    err = client.Get("schedule/viability", url.Values{}, &report)
+    if err != nil {
+        return err
+    }
+    fmt.Printf("Epoch:         %v\n", report.Epoch)
+    fmt.Printf("Assignments:   %v\n", len(report.Assignments))
+    fmt.Printf("Viable nodes:  [%v]\n\n", strings.Join(report.ViableNodes, ","))
+    names := make([]string, 0, len(report.Differences))
+    for name := range report.Differences {
+        names = append(names, name)
+    }
+    sort.Strings(names)
+    writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+    _, _ = fmt.Fprintln(writer, "NODE\tDIFFERENCE\tSLOT\tTIME")
+    for _, name := range names {
+        for _, slot := range report.Differences[name].Missing {
+            _, _ = fmt.Fprintf(writer, "%v\tmissing\t%v\t%v\n", name, slot.Date, slot.Time.Format(time.RFC3339))
+        }
+        for _, slot := range report.Differences[name].Unexpected {
+            _, _ = fmt.Fprintf(writer, "%v\tunexpected\t%v\t%v\n", name, slot.Date, slot.Time.Format(time.RFC3339))
+        }
+    }
+    return writer.Flush()
+}
@@ -0,0 +1,79 @@
+package clock
+
+import "time"
+
+// clock that is used by all the time-dependent logic of this program, such
+// that the passing of time can be simulated.
+type Clock interface {
+    // gets the current time.
+    Now() time.Time
+    // pauses the current goroutine for at least the given duration.
+    Sleep(d time.Duration)
+    // waits for the given duration to elapse and then sends the
+    // current time on the returned channel.
+    After(d time.Duration) <-chan time.Time
+    // creates a new timer that sends the current time on its
+    // channel after at least the given duration.
+    NewTimer(d time.Duration) Timer
+}
+
+// timer, which sends the current time on its channel once it fires.
+type Timer interface {
+    // gets the channel on which the time is sent.
+    C() <-chan time.Time
+    // prevents the timer from firing, returns false if the timer
+    // has already expired or been stopped.
+    Stop() bool
+    // changes the timer to fire after the given duration, returns
+    // true if the timer had been active.
+    Reset(d time.Duration) bool
+}
+
+// clock of the system.
+type realClock struct{}
+
+type realTimer struct {
+    timer *time.Timer
+}
+
+// gets the clock of the system.
+func Real() Clock {
+    return realClock{}
+}
+
+// gets the given clock, or the clock of the system, if the given
+// clock is nil.
+func OrReal(clock Clock) Clock {
+    if clock == nil {
+        return Real()
+    }
+    return clock
+}
+
+func (realClock) Now() time.Time {
+    return time.Now()
+}
+
+func (realClock) Sleep(d time.Duration) {
+    time.Sleep(d)
+}
+
+func (realClock) After(d time.Duration) <-chan time.Time {
+    return time.After(d)
+}
+
+func (realClock) NewTimer(d time.Duration) Timer {
+    return realTimer{timer: time.NewTimer(d)}
+}
+
+func (timer realTimer) C() <-chan time.Time {
+    return timer.timer.C
+}
+
+func (timer realTimer) Stop() bool {
+    return timer.timer.Stop()
+}
+
+func (timer realTimer) Reset(d time.Duration) bool {
+    return timer.timer.Reset(d)
+}
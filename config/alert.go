@@ -0,0 +1,96 @@
+package config
+
+import (
+    "fmt"
+    "github.com/sobitada/thor/alert"
+    "net/http"
+    "time"
+)
+
+// configuration struct for the built-in alerting.
+type Alerts struct {
+    Rules     []AlertRule     `yaml:"rules"`
+    Notifiers []AlertNotifier `yaml:"notifiers"`
+}
+
+// configuration struct for an alerting rule.
+type AlertRule struct {
+    Name string `yaml:"name"`
+    // expression such as 'lag > 5 for 2m'.
+    Expr     string `yaml:"expr"`
+    Severity string `yaml:"severity"`
+    Summary  string `yaml:"summary"`
+    // names of the notifiers to which the alerts are routed, all
+    // notifiers are used if none is specified.
+    Notifiers []string `yaml:"notifiers"`
+    // time in milliseconds after which a firing alert is sent again.
+    RepeatIntervalInMs uint64 `yaml:"repeatInterval"`
+}
+
+// configuration struct for a notifier of alerts, which is either a
+// webhook or a mail server.
+type AlertNotifier struct {
+    Name    string `yaml:"name"`
+    Webhook string `yaml:"webhook"`
+    Mail    *Mail  `yaml:"mail"`
+}
+
+// gets the settings of the alert engine specified in the given configuration,
+// and false, if no alerting has been configured.
+func GetAlertSettings(conf General) (alert.Settings, bool, error) {
+    settings := alert.Settings{Notifiers: map[string]alert.Notifier{}}
+    if conf.Alerts == nil {
+        return settings, false, nil
+    }
+    for i, notifierConf := range conf.Alerts.Notifiers {
+        path := fmt.Sprintf("alerts/notifiers[%v]", i)
+        if notifierConf.Name == "" {
+            return settings, false, ConfigurationError{Path: path, Reason: "The name must be specified."}
+        }
+        if _, found := settings.Notifiers[notifierConf.Name]; found {
+            return settings, false, ConfigurationError{Path: path,
+                Reason: fmt.Sprintf("The name '%v' is not unique.", notifierConf.Name)}
+        }
+        if notifierConf.Webhook != "" {
+            settings.Notifiers[notifierConf.Name] = alert.WebhookNotifier{URL: notifierConf.Webhook,
+                Client: &http.Client{Timeout: 30 * time.Second}}
+        } else if notifierConf.Mail != nil {
+            mail, err := getMailTransport(*notifierConf.Mail, path + "/mail")
+            if err != nil {
+                return settings, false, err
+            }
+            settings.Notifiers[notifierConf.Name] = alert.MailNotifier(mail)
+        } else {
+            return settings, false, ConfigurationError{Path: path,
+                Reason: "Either a webhook or a mail server must be specified."}
+        }
+    }
+    names := map[string]bool{}
+    for i, ruleConf := range conf.Alerts.Rules {
+        path := fmt.Sprintf("alerts/rules[%v]", i)
+        if ruleConf.Name == "" || names[ruleConf.Name] {
+            return settings, false, ConfigurationError{Path: path, Reason: "The name must be specified and unique."}
+        }
+        names[ruleConf.Name] = true
+        expr, duration, err := alert.ParseExpression(ruleConf.Expr)
+        if err != nil {
+            return settings, false, ConfigurationError{Path: path + "/expr", Reason: err.Error()}
+        }
+        for _, name := range ruleConf.Notifiers {
+            if _, found := settings.Notifiers[name]; !found {
+                return settings, false, ConfigurationError{Path: path + "/notifiers",
+                    Reason: fmt.Sprintf("The notifier '%v' is unknown.", name)}
+            }
+        }
+        settings.Rules = append(settings.Rules, alert.Rule{
+            Name:           ruleConf.Name,
+            Expression:     expr,
+            For:            duration,
+            Severity:       ruleConf.Severity,
+            Summary:        ruleConf.Summary,
+            Notifiers:      ruleConf.Notifiers,
+            RepeatInterval: time.Duration(ruleConf.RepeatIntervalInMs) * time.Millisecond,
+        })
+    }
+    return settings, true, nil
+}
@@ -0,0 +1,48 @@
+package config
+
+import (
+    "github.com/sobitada/thor/blocks"
+    "time"
+)
+
+// configuration struct for the tracker of block outcomes.
+type Blocks struct {
+    // time in milliseconds between checks of the outcomes.
+    IntervalInMs uint32 `yaml:"interval"`
+    // number of slots after the scheduled slot, after which
+    // the outcome of an assignment is determined.
+    SettleSlots uint64 `yaml:"settleSlots"`
+    // number of blocks on top of a produced block, after which
+    // it is decided whether the block has been adopted.
+    ConfirmationDepth uint64 `yaml:"confirmationDepth"`
+    // time in milliseconds after the scheduled time, after which an
+    // assignment is missed, even if some candidates are unreachable.
+    ConfirmationWindowInMs uint32 `yaml:"confirmationWindow"`
+}
+
+// gets the settings of the block outcome tracker specified in the given
+// configuration. default values are used for unspecified settings.
+func GetBlockTrackerSettings(conf General) blocks.TrackerSettings {
+    settings := blocks.TrackerSettings{
+        Interval:           30 * time.Second,
+        SettleSlots:        5,
+        ConfirmationDepth:  10,
+        ConfirmationWindow: 10 * time.Minute,
+    }
+    if conf.Blocks != nil {
+        blocksConf := *conf.Blocks
+        if blocksConf.IntervalInMs > 0 {
+            settings.Interval = time.Duration(blocksConf.IntervalInMs) * time.Millisecond
+        }
+        if blocksConf.SettleSlots > 0 {
+            settings.SettleSlots = blocksConf.SettleSlots
+        }
+        if blocksConf.ConfirmationDepth > 0 {
+            settings.ConfirmationDepth = blocksConf.ConfirmationDepth
+        }
+        if blocksConf.ConfirmationWindowInMs > 0 {
+            settings.ConfirmationWindow = time.Duration(blocksConf.ConfirmationWindowInMs) * time.Millisecond
+        }
+    }
+    return settings
+}
@@ -4,12 +4,21 @@ import "fmt"
 
 // general config for this application.
 type General struct {
-    Logging    Logging             `yaml:"logging"`
-    Blockchain *BlockchainSettings `yaml:"blockchain"`
-    Peers      []Node              `yaml:"peers"`
-    Monitor    Monitor             `yaml:"monitor"`
-    PoolTool   *PoolTool           `yaml:"pooltool"`
-    Prometheus *Prometheus         `yaml:"prometheus"`
+    Logging     Logging             `yaml:"logging"`
+    Blockchain  *BlockchainSettings `yaml:"blockchain"`
+    Peers       []Node              `yaml:"peers"`
+    Monitor     Monitor             `yaml:"monitor"`
+    PoolTool    *PoolTool           `yaml:"pooltool"`
+    Prometheus  *Prometheus         `yaml:"prometheus"`
+    Metrics     *Metrics            `yaml:"metrics"`
+    Status      *Status             `yaml:"status"`
+    Schedule    *Schedule           `yaml:"schedule"`
+    Blocks      *Blocks             `yaml:"blocks"`
+    Report      *Report             `yaml:"report"`
+    Maintenance *Maintenance        `yaml:"maintenance"`
+    Storage     *Storage            `yaml:"storage"`
+    History     *History            `yaml:"history"`
+    Alerts      *Alerts             `yaml:"alerts"`
 }
 
 type ConfigurationError struct {
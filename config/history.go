@@ -0,0 +1,51 @@
+package config
+
+import (
+    "fmt"
+    "github.com/sobitada/thor/history"
+    "time"
+)
+
+// configuration struct for the history of the node statistics.
+type History struct {
+    // tiers in which the history is downsampled, by default one
+    // point per minute is kept for two days, and one point per
+    // hour for 90 days.
+    Tiers []HistoryTier `yaml:"tiers"`
+    // time in milliseconds between removals of old points.
+    PruneIntervalInMs uint32 `yaml:"pruneInterval"`
+}
+
+// configuration struct for a tier of the history.
+type HistoryTier struct {
+    // time in milliseconds covered by a point of this tier.
+    ResolutionInMs uint32 `yaml:"resolution"`
+    // time in milliseconds for which the points are kept, zero
+    // keeps them forever.
+    RetentionInMs uint64 `yaml:"retention"`
+}
+
+// gets the settings for the history of the node statistics specified in the
+// given configuration. default values are used for unspecified settings.
+func GetHistorySettings(conf General) (history.Settings, error) {
+    settings := history.Settings{Tiers: history.DefaultTiers(), PruneInterval: 1 * time.Hour}
+    if conf.History != nil {
+        if len(conf.History.Tiers) > 0 {
+            settings.Tiers = make([]history.Tier, len(conf.History.Tiers))
+            for i, tier := range conf.History.Tiers {
+                if tier.ResolutionInMs == 0 || tier.ResolutionInMs%1000 != 0 {
+                    return settings, ConfigurationError{Path: fmt.Sprintf("history/tiers[%v]/resolution", i),
+                        Reason: "The resolution must be a positive multiple of one second."}
+                }
+                settings.Tiers[i] = history.Tier{
+                    Resolution: time.Duration(tier.ResolutionInMs) * time.Millisecond,
+                    Retention:  time.Duration(tier.RetentionInMs) * time.Millisecond,
+                }
+            }
+        }
+        if conf.History.PruneIntervalInMs > 0 {
+            settings.PruneInterval = time.Duration(conf.History.PruneIntervalInMs) * time.Millisecond
+        }
+    }
+    return settings, nil
+}
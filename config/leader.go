@@ -3,8 +3,10 @@ package config
 import (
     "github.com/sobitada/go-cardano"
     "github.com/sobitada/go-jormungandr/api"
+    "github.com/sobitada/thor/audit"
     "github.com/sobitada/thor/leader"
     "github.com/sobitada/thor/monitor"
+    "github.com/sobitada/thor/utils"
     "io/ioutil"
     "math/big"
     "time"
@@ -15,12 +17,50 @@ type LeaderConfig struct {
     Window                      int    `yaml:"window"`
     ExclusionZoneInMs           uint32 `yaml:"exclusionZone"`
     PreTurnOverExclusionZoneInS uint32 `yaml:"preTurnoverExclusionZone"`
+    // settings for the readiness check before promotion.
+    Readiness *ReadinessConfig `yaml:"readiness"`
+    // mode of the jury, "active" or "shadow".
+    Mode leader.Mode `yaml:"mode"`
+}
+
+// configuration struct for the readiness check of leader
+// candidates before they are promoted.
+type ReadinessConfig struct {
+    // maximum number of blocks a candidate can lag behind.
+    MaxBlockLag uint64 `yaml:"maxBlockLag"`
+    // maximum clock drift of a candidate in milliseconds.
+    MaxClockDriftInMs uint32 `yaml:"maxClockDrift"`
+    // time in milliseconds to wait for the promoted candidate
+    // to report the leader assignments.
+    ConfirmationTimeoutInMs uint32 `yaml:"confirmationTimeout"`
+}
+
+// gets the readiness settings for the given configuration, the
+// confirmation timeout is bounded by half of the exclusion zone.
+func getReadinessSettings(conf *ReadinessConfig, exclusionZone time.Duration) leader.ReadinessSettings {
+    settings := leader.ReadinessSettings{
+        MaxBlockLag:                  0,
+        MaxClockDrift:                5 * time.Second,
+        ScheduleConfirmationTimeout:  10 * time.Second,
+        ScheduleConfirmationInterval: 1 * time.Second,
+    }
+    if conf != nil {
+        settings.MaxBlockLag = conf.MaxBlockLag
+        if conf.MaxClockDriftInMs > 0 {
+            settings.MaxClockDrift = time.Duration(conf.MaxClockDriftInMs) * time.Millisecond
+        }
+        if conf.ConfirmationTimeoutInMs > 0 {
+            settings.ScheduleConfirmationTimeout = time.Duration(conf.ConfirmationTimeoutInMs) * time.Millisecond
+        }
+    }
+    settings.ScheduleConfirmationTimeout = utils.MinDuration(settings.ScheduleConfirmationTimeout, exclusionZone/2)
+    return settings
 }
 
 // gets the leader jury for the given configuration. It expects also the nodes
 // for which the leader jury shall be activated.
 func GetLeaderJury(nodes []monitor.Node, mon *monitor.NodeMonitor, watchDog *monitor.ScheduleWatchDog,
-    timeSettings *cardano.TimeSettings, config General) (*leader.Jury, error) {
+    auditLog *audit.Log, timeSettings *cardano.TimeSettings, config General) (*leader.Jury, error) {
     leaderConfig := config.Monitor.LeaderConfig
     if leaderConfig != nil {
         if timeSettings != nil {
@@ -49,11 +89,20 @@ func GetLeaderJury(nodes []monitor.Node, mon *monitor.NodeMonitor, watchDog *mon
                         }
                         preTurnOverExclusionSlots = new(big.Int).Div(new(big.Int).SetInt64(int64(time.Duration(int64(preTurnOverExclusionInS))*time.Second)),
                             new(big.Int).SetInt64(int64(timeSettings.SlotDuration)))
-                        return leader.GetLeaderJuryFor(nodes, mon, watchDog, leaderCert, leader.JurySettings{
+                        // mode of the jury.
+                        mode := leaderConfig.Mode
+                        if mode == "" {
+                            mode = leader.Active
+                        } else if mode != leader.Active && mode != leader.Shadow {
+                            return nil, ConfigurationError{Path: "monitor/leader_jury/mode", Reason: "The mode must be 'active' or 'shadow'."}
+                        }
+                        return leader.GetLeaderJuryFor(nodes, mon, watchDog, auditLog, leaderCert, leader.JurySettings{
                             Window:                         window,
                             ExclusionZone:                  exclusionZone,
                             PreEpochTurnOverExclusionSlots: preTurnOverExclusionSlots,
                             TimeSettings:                   timeSettings,
+                            Readiness:                      getReadinessSettings(leaderConfig.Readiness, exclusionZone),
+                            Mode:                           mode,
                         })
                     } else {
                         return nil, ConfigurationError{Path: "monitor/leader_jury/cert", Reason: err.Error()}
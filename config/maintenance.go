@@ -0,0 +1,60 @@
+package config
+
+import (
+    "github.com/sobitada/thor/maintenance"
+    "time"
+)
+
+// configuration struct for the safe maintenance windows.
+type Maintenance struct {
+    // time in milliseconds in front of a scheduled block
+    // that is not safe.
+    BeforeBlockInMs uint32 `yaml:"beforeBlock"`
+    // time in milliseconds after the slot of a scheduled
+    // block that is not safe.
+    AfterBlockInMs uint32 `yaml:"afterBlock"`
+    // time in milliseconds in front of an epoch turn over
+    // that is not safe.
+    BeforeTurnOverInMs uint32 `yaml:"beforeTurnOver"`
+    // time in milliseconds after an epoch turn over that
+    // is not safe.
+    AfterTurnOverInMs uint32 `yaml:"afterTurnOver"`
+}
+
+// gets the settings for the safe maintenance windows specified in the given
+// configuration. per default, the exclusion zones of the leader jury are
+// respected.
+func GetMaintenanceSettings(conf General) maintenance.Settings {
+    settings := maintenance.Settings{
+        BeforeBlock:    60 * time.Second,
+        AfterBlock:     20 * time.Second,
+        BeforeTurnOver: 60 * time.Second,
+        AfterTurnOver:  10 * time.Minute,
+    }
+    if leaderConfig := conf.Monitor.LeaderConfig; leaderConfig != nil {
+        exclusionZone := time.Duration(leaderConfig.ExclusionZoneInMs) * time.Millisecond
+        if exclusionZone > settings.BeforeBlock {
+            settings.BeforeBlock = exclusionZone
+        }
+        preTurnOverExclusionZone := time.Duration(leaderConfig.PreTurnOverExclusionZoneInS) * time.Second
+        if preTurnOverExclusionZone > settings.BeforeTurnOver {
+            settings.BeforeTurnOver = preTurnOverExclusionZone
+        }
+    }
+    if conf.Maintenance != nil {
+        maintenanceConf := *conf.Maintenance
+        if maintenanceConf.BeforeBlockInMs > 0 {
+            settings.BeforeBlock = time.Duration(maintenanceConf.BeforeBlockInMs) * time.Millisecond
+        }
+        if maintenanceConf.AfterBlockInMs > 0 {
+            settings.AfterBlock = time.Duration(maintenanceConf.AfterBlockInMs) * time.Millisecond
+        }
+        if maintenanceConf.BeforeTurnOverInMs > 0 {
+            settings.BeforeTurnOver = time.Duration(maintenanceConf.BeforeTurnOverInMs) * time.Millisecond
+        }
+        if maintenanceConf.AfterTurnOverInMs > 0 {
+            settings.AfterTurnOver = time.Duration(maintenanceConf.AfterTurnOverInMs) * time.Millisecond
+        }
+    }
+    return settings
+}
@@ -0,0 +1,73 @@
+package config
+
+import (
+    "fmt"
+    "github.com/sobitada/thor/metrics"
+    "net/http"
+    "time"
+)
+
+// configuration struct for sending the metrics over StatsD or the
+// InfluxDB line protocol.
+type Metrics struct {
+    Protocol string `yaml:"protocol"`
+    // address of the StatsD server as host:port.
+    Address string `yaml:"address"`
+    // prefix of the StatsD metric names.
+    Prefix *string `yaml:"prefix"`
+    // URL of the write endpoint of the InfluxDB.
+    URL string `yaml:"url"`
+    // token or credentials required by the InfluxDB.
+    Token     string     `yaml:"token"`
+    BasicAuth *BasicAuth `yaml:"basicAuth"`
+    // tags added to all InfluxDB points.
+    Tags map[string]string `yaml:"tags"`
+    // time in milliseconds to wait for an answer of the InfluxDB.
+    TimeoutInMs uint32 `yaml:"timeout"`
+}
+
+// gets the exporter of the metrics specified in the given configuration, or
+// nil, if no such exporter has been configured.
+func ParseMetricsConfig(sources metrics.Sources, conf General) (*metrics.Exporter, error) {
+    if conf.Metrics == nil {
+        return nil, nil
+    }
+    metricsConf := *conf.Metrics
+    settings := metrics.Settings{Protocol: metrics.Protocol(metricsConf.Protocol), Prefix: "thor"}
+    switch settings.Protocol {
+    case metrics.StatsD:
+        if metricsConf.Address == "" {
+            return nil, ConfigurationError{Path: "metrics/address",
+                Reason: "The address of the StatsD server must be specified."}
+        }
+        settings.Address = metricsConf.Address
+        if metricsConf.Prefix != nil {
+            settings.Prefix = *metricsConf.Prefix
+        }
+    case metrics.Influx:
+        if metricsConf.URL == "" {
+            return nil, ConfigurationError{Path: "metrics/url",
+                Reason: "The URL of the write endpoint of the InfluxDB must be specified."}
+        }
+        settings.URL = metricsConf.URL
+        settings.Token = metricsConf.Token
+        if metricsConf.BasicAuth != nil {
+            if metricsConf.BasicAuth.Username == "" || metricsConf.BasicAuth.Password == "" {
+                return nil, ConfigurationError{Path: "metrics/basicAuth",
+                    Reason: "The username and the password must be specified."}
+            }
+            settings.Username = metricsConf.BasicAuth.Username
+            settings.Password = metricsConf.BasicAuth.Password
+        }
+        settings.Tags = metricsConf.Tags
+        timeout := 10 * time.Second
+        if metricsConf.TimeoutInMs > 0 {
+            timeout = time.Duration(metricsConf.TimeoutInMs) * time.Millisecond
+        }
+        settings.Client = &http.Client{Timeout: timeout}
+    default:
+        return nil, ConfigurationError{Path: "metrics/protocol",
+            Reason: fmt.Sprintf("The protocol must be '%v' or '%v'.", metrics.StatsD, metrics.Influx)}
+    }
+    return metrics.NewExporter(settings, sources)
+}
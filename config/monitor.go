@@ -12,15 +12,68 @@ type Monitor struct {
     IntervalInMs uint32 `yaml:"interval"`
     // needed for enabling the leader election jury.
     LeaderConfig *LeaderConfig `yaml:"leaderJury"`
+    // quiet periods around scheduled blocks.
+    QuietPeriod *QuietPeriodConfig `yaml:"quietPeriod"`
+}
+
+// configuration struct for the quiet periods of leader candidates
+// and passive nodes around scheduled blocks.
+type QuietPeriodConfig struct {
+    Candidates *QuietPolicyConfig `yaml:"candidates"`
+    Passive    *QuietPolicyConfig `yaml:"passive"`
+}
+
+// configuration struct for a quiet period around scheduled blocks.
+type QuietPolicyConfig struct {
+    // number of slots in front of the scheduled slot.
+    BeforeSlots *uint64 `yaml:"beforeSlots"`
+    // number of slots after the scheduled slot.
+    AfterSlots *uint64 `yaml:"afterSlots"`
+    // what is suppressed, i.e. none, polling, actions or both.
+    Suppress monitor.Suppression `yaml:"suppress"`
+}
+
+// gets the quiet period for the given configuration, default values are
+// used for unspecified settings.
+func getQuietPeriod(conf *QuietPolicyConfig, path string) (monitor.QuietPeriod, error) {
+    period := monitor.DefaultQuietPeriod()
+    if conf != nil {
+        if conf.BeforeSlots != nil {
+            period.BeforeSlots = *conf.BeforeSlots
+        }
+        if conf.AfterSlots != nil {
+            period.AfterSlots = *conf.AfterSlots
+        }
+        switch conf.Suppress {
+        case "":
+        case monitor.SuppressNone, monitor.SuppressPolling, monitor.SuppressActions, monitor.SuppressBoth:
+            period.Suppress = conf.Suppress
+        default:
+            return period, ConfigurationError{Path: path + "/suppress",
+                Reason: "The suppression must be 'none', 'polling', 'actions' or 'both'."}
+        }
+    }
+    return period, nil
 }
 
 // gets the behaviour of the monitor specified in the given configuration.
-func GetNodeMonitorBehaviour(config General) monitor.NodeMonitorBehaviour {
+func GetNodeMonitorBehaviour(config General) (monitor.NodeMonitorBehaviour, error) {
     var interval time.Duration
     if config.Monitor.IntervalInMs == 0 {
         interval = 60 * time.Second
     } else {
         interval = time.Duration(config.Monitor.IntervalInMs) * time.Millisecond
     }
-    return monitor.NodeMonitorBehaviour{Interval: interval}
+    behaviour := monitor.NodeMonitorBehaviour{Interval: interval}
+    quietPeriodConf := config.Monitor.QuietPeriod
+    if quietPeriodConf == nil {
+        quietPeriodConf = &QuietPeriodConfig{}
+    }
+    var err error
+    behaviour.CandidateQuietPeriod, err = getQuietPeriod(quietPeriodConf.Candidates, "monitor/quietPeriod/candidates")
+    if err != nil {
+        return behaviour, err
+    }
+    behaviour.PassiveQuietPeriod, err = getQuietPeriod(quietPeriodConf.Passive, "monitor/quietPeriod/passive")
+    return behaviour, err
 }
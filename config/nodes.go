@@ -3,6 +3,7 @@ package config
 import (
     log "github.com/sirupsen/logrus"
     jor "github.com/sobitada/go-jormungandr/api"
+    "github.com/sobitada/thor/jormungandr"
     "github.com/sobitada/thor/monitor"
     "time"
 )
@@ -49,6 +50,10 @@ func GetNodesFromConfig(config General) ([]monitor.Node, error) {
             apiTimeout = time.Duration(peerConfig.Timeout) * time.Millisecond
         }
         api, err := jor.GetAPIFromHost(peerConfig.APIUrl, apiTimeout)
+        var blockAPI *jormungandr.API
+        if err == nil {
+            blockAPI, err = jormungandr.GetAPIFromHost(peerConfig.APIUrl, apiTimeout)
+        }
         if err == nil {
             var maxTimeSinceLastBlock time.Duration
             // maximum block lag.
@@ -69,12 +74,13 @@ func GetNodesFromConfig(config General) ([]monitor.Node, error) {
                 Type:                  t,
                 Name:                  peerConfig.Name,
                 API:                   api,
+                BlockAPI:              blockAPI,
                 MaxBlockLag:           peerConfig.MaxBlockLag,
                 MaxTimeSinceLastBlock: maxTimeSinceLastBlock,
                 WarmUpTime:            time.Duration(peerConfig.WarmUpTime) * time.Millisecond,
             })
         } else {
-            log.Warn("[%s] Could not build an API for this peer from the specified configuration. %s", err.Error())
+            log.Warnf("[%s] Could not build an API for this peer from the specified configuration. %s", peerConfig.Name, err.Error())
         }
     }
     return nodeList, nil
@@ -1,10 +1,11 @@
 package config
 
 import (
-    "github.com/boltdb/bolt"
-    "github.com/sobitada/go-cardano"
-    "github.com/sobitada/thor/monitor"
+    "fmt"
     "github.com/sobitada/thor/pooltool"
+    "github.com/sobitada/thor/storage"
+    "net/url"
+    "time"
 )
 
 // configuration struct for PoolTool
@@ -14,24 +15,124 @@ type PoolTool struct {
     // the ID of the pool for which information
     // shall be requested/pushed from PoolTool.
     PoolID string `yaml:"poolID"`
+    // endpoints to which the tip and the schedule are
+    // sent, the official endpoints are used by default.
+    TipURL      string `yaml:"tipURL"`
+    ScheduleURL string `yaml:"scheduleURL"`
+    // interval in milliseconds in which the tip is sent.
+    TipIntervalInMs uint32 `yaml:"tipInterval"`
+    // which tip is reported, i.e. 'max' or 'leader'.
+    TipSource string `yaml:"tipSource"`
+    // time in milliseconds after which a tip that has not
+    // advanced is stale, and whether it is 'flag'ged or
+    // 'suppress'ed.
+    StaleTipAfterInMs uint32 `yaml:"staleTipAfter"`
+    StaleTipAction    string `yaml:"staleTipAction"`
+    // whether the tip of the network shall be fetched, and
+    // the endpoint from which it is fetched.
+    NetworkTip    bool   `yaml:"networkTip"`
+    NetworkTipURL string `yaml:"networkTipURL"`
+    // interval in milliseconds in which failed schedule
+    // updates are retried.
+    RetryIntervalInMs uint32 `yaml:"retryInterval"`
+    // minimum interval in milliseconds between two updates
+    // of the schedule with changed outcomes.
+    OutcomeIntervalInMs uint32 `yaml:"outcomeInterval"`
+    // time in milliseconds to wait for an answer of PoolTool.
+    TimeoutInMs uint32 `yaml:"timeout"`
+    // URL of the proxy through which PoolTool is accessed.
+    Proxy string `yaml:"proxy"`
 }
 
-// gets the pool tool client for given configuration
-func ParsePoolToolConfig(mon *monitor.NodeMonitor, watchDog *monitor.ScheduleWatchDog, timeSettings *cardano.TimeSettings,
-    db *bolt.DB, conf General) (*pooltool.PoolTool, error) {
+// gets the settings of the PoolTool client specified in the given
+// configuration. default values are used for unspecified settings.
+func GetPoolToolSettings(conf General) (pooltool.Settings, error) {
+    settings := pooltool.Settings{
+        TipInterval:     pooltool.DefaultTipInterval,
+        RetryInterval:   pooltool.DefaultRetryInterval,
+        OutcomeInterval: pooltool.DefaultOutcomeInterval,
+        Timeout:         30 * time.Second,
+    }
+    if conf.PoolTool == nil {
+        return settings, nil
+    }
+    poolToolConf := *conf.PoolTool
+    if poolToolConf.UserID == "" || poolToolConf.PoolID == "" {
+        return settings, ConfigurationError{Path: "pooltool", Reason: "Personal pool ID, pool tool user ID  must be specified."}
+    }
+    if conf.Blockchain == nil || conf.Blockchain.GenesisBlockHash == "" {
+        return settings, ConfigurationError{Path: "blockchain/genesisBlockHash", Reason: "The hash of the genesis block must be specified for Pool Tool actions."}
+    }
+    settings.PoolID = poolToolConf.PoolID
+    settings.UserID = poolToolConf.UserID
+    settings.GenesisHash = conf.Blockchain.GenesisBlockHash
+    for _, endpoint := range []struct {
+        path   string
+        value  string
+        target *string
+    }{
+        {path: "pooltool/tipURL", value: poolToolConf.TipURL, target: &settings.TipURL},
+        {path: "pooltool/scheduleURL", value: poolToolConf.ScheduleURL, target: &settings.ScheduleURL},
+        {path: "pooltool/networkTipURL", value: poolToolConf.NetworkTipURL, target: &settings.NetworkTipURL},
+        {path: "pooltool/proxy", value: poolToolConf.Proxy},
+    } {
+        if endpoint.value == "" {
+            continue
+        }
+        u, err := url.Parse(endpoint.value)
+        if err != nil || u.Scheme == "" || u.Host == "" {
+            return settings, ConfigurationError{Path: endpoint.path,
+                Reason: fmt.Sprintf("'%v' is not a valid URL.", endpoint.value)}
+        }
+        if endpoint.target != nil {
+            *endpoint.target = endpoint.value
+        } else {
+            settings.Proxy = u
+        }
+    }
+    if poolToolConf.TipIntervalInMs > 0 {
+        settings.TipInterval = time.Duration(poolToolConf.TipIntervalInMs) * time.Millisecond
+    }
+    if poolToolConf.NetworkTip && settings.NetworkTipURL == "" {
+        settings.NetworkTipURL = pooltool.DefaultNetworkTipURL
+    } else if !poolToolConf.NetworkTip {
+        settings.NetworkTipURL = ""
+    }
+    switch pooltool.TipSource(poolToolConf.TipSource) {
+    case "", pooltool.MaxTip, pooltool.LeaderTip:
+        settings.TipSource = pooltool.TipSource(poolToolConf.TipSource)
+    default:
+        return settings, ConfigurationError{Path: "pooltool/tipSource",
+            Reason: fmt.Sprintf("'%v' is unknown, it must be 'max' or 'leader'.", poolToolConf.TipSource)}
+    }
+    switch pooltool.StaleTipAction(poolToolConf.StaleTipAction) {
+    case "", pooltool.FlagStaleTip, pooltool.SuppressStaleTip:
+        settings.StaleTipAction = pooltool.StaleTipAction(poolToolConf.StaleTipAction)
+    default:
+        return settings, ConfigurationError{Path: "pooltool/staleTipAction",
+            Reason: fmt.Sprintf("'%v' is unknown, it must be 'flag' or 'suppress'.", poolToolConf.StaleTipAction)}
+    }
+    settings.StaleTipAfter = time.Duration(poolToolConf.StaleTipAfterInMs) * time.Millisecond
+    if poolToolConf.RetryIntervalInMs > 0 {
+        settings.RetryInterval = time.Duration(poolToolConf.RetryIntervalInMs) * time.Millisecond
+    }
+    if poolToolConf.OutcomeIntervalInMs > 0 {
+        settings.OutcomeInterval = time.Duration(poolToolConf.OutcomeIntervalInMs) * time.Millisecond
+    }
+    if poolToolConf.TimeoutInMs > 0 {
+        settings.Timeout = time.Duration(poolToolConf.TimeoutInMs) * time.Millisecond
+    }
+    return settings, nil
+}
 
+// gets the pool tool client for given configuration
+func ParsePoolToolConfig(sources pooltool.Sources, store storage.Store, conf General) (*pooltool.PoolTool, error) {
     if conf.PoolTool != nil {
-        poolToolConf := *conf.PoolTool
-        if poolToolConf.UserID != "" && poolToolConf.PoolID != "" {
-            if conf.Blockchain != nil && conf.Blockchain.GenesisBlockHash != "" {
-                return pooltool.GetPoolTool(mon, watchDog, timeSettings, db,
-                    poolToolConf.PoolID, poolToolConf.UserID, conf.Blockchain.GenesisBlockHash), nil
-            } else {
-                return nil, ConfigurationError{Path: "blockchain/genesisBlockHash", Reason: "The hash of the genesis block must be specified for Pool Tool actions."}
-            }
-        } else {
-            return nil, ConfigurationError{Path: "pooltool", Reason: "Personal pool ID, pool tool user ID  must be specified."}
+        settings, err := GetPoolToolSettings(conf)
+        if err != nil {
+            return nil, err
         }
+        return pooltool.GetPoolTool(sources, store, settings), nil
     }
     return nil, nil
 }
@@ -1,23 +1,145 @@
 package config
 
 import (
-    "github.com/sobitada/thor/monitor"
+    "fmt"
     "github.com/sobitada/thor/prometheus"
+    "os"
+    "time"
 )
 
 type Prometheus struct {
     Hostname string `yaml:"hostname"`
     Port     string `yaml:"port"`
+    // certificate and key for serving the metrics over TLS.
+    TLS *TLS `yaml:"tls"`
+    // credentials required for fetching the metrics.
+    BasicAuth *BasicAuth `yaml:"basicAuth"`
+    // time in milliseconds after which the series of a node
+    // are removed, if no statistics have been fetched for it.
+    StaleAfterInMs uint32 `yaml:"staleAfter"`
+    // settings for pushing the metrics periodically.
+    Push *Push `yaml:"push"`
 }
 
-func ParsePrometheusConfig(mon *monitor.NodeMonitor, conf General) (*prometheus.Client, error) {
+// configuration struct for pushing the metrics to a Pushgateway
+// or a remote-write endpoint.
+type Push struct {
+    Mode     string `yaml:"mode"`
+    URL      string `yaml:"url"`
+    Job      string `yaml:"job"`
+    Instance string `yaml:"instance"`
+    // credentials required by the endpoint.
+    BasicAuth *BasicAuth `yaml:"basicAuth"`
+    // interval in milliseconds in which the metrics are pushed.
+    IntervalInMs uint32 `yaml:"interval"`
+    // number of retries of a failed push.
+    Retries *int `yaml:"retries"`
+    // milliseconds to wait before the first retry.
+    RetryIntervalInMs uint32 `yaml:"retryInterval"`
+}
+
+// configuration struct for serving over TLS.
+type TLS struct {
+    CertFile string `yaml:"certFile"`
+    KeyFile  string `yaml:"keyFile"`
+}
+
+// configuration struct for basic auth.
+type BasicAuth struct {
+    Username string `yaml:"username"`
+    Password string `yaml:"password"`
+}
+
+func ParsePrometheusConfig(sources prometheus.Sources, conf General) (*prometheus.Client, error) {
     if conf.Prometheus != nil {
         prometheusConf := *conf.Prometheus
-        if prometheusConf.Hostname != "" && prometheusConf.Port != "" {
-            return prometheus.GetClient(prometheusConf.Hostname, prometheusConf.Port, mon), nil
-        } else {
+        if (prometheusConf.Hostname == "" || prometheusConf.Port == "") && prometheusConf.Push == nil {
             return nil, ConfigurationError{Path: "prometheus", Reason: "Hostname and port must be specified for Prometheus."}
         }
+        settings := prometheus.Settings{
+            Hostname:   prometheusConf.Hostname,
+            Port:       prometheusConf.Port,
+            StaleAfter: 5 * time.Minute,
+        }
+        if prometheusConf.TLS != nil {
+            if prometheusConf.TLS.CertFile == "" || prometheusConf.TLS.KeyFile == "" {
+                return nil, ConfigurationError{Path: "prometheus/tls",
+                    Reason: "The certificate and the key file must be specified."}
+            }
+            settings.CertFile = prometheusConf.TLS.CertFile
+            settings.KeyFile = prometheusConf.TLS.KeyFile
+        }
+        if prometheusConf.BasicAuth != nil {
+            if prometheusConf.BasicAuth.Username == "" || prometheusConf.BasicAuth.Password == "" {
+                return nil, ConfigurationError{Path: "prometheus/basicAuth",
+                    Reason: "The username and the password must be specified."}
+            }
+            settings.Username = prometheusConf.BasicAuth.Username
+            settings.Password = prometheusConf.BasicAuth.Password
+        }
+        if prometheusConf.StaleAfterInMs > 0 {
+            settings.StaleAfter = time.Duration(prometheusConf.StaleAfterInMs) * time.Millisecond
+        }
+        if prometheusConf.Push != nil {
+            pushSettings, err := getPushSettings(*prometheusConf.Push)
+            if err != nil {
+                return nil, err
+            }
+            settings.Push = pushSettings
+        }
+        return prometheus.GetClient(settings, sources), nil
     }
     return nil, nil
 }
+
+// gets the settings for pushing the metrics from the given configuration.
+func getPushSettings(conf Push) (*prometheus.PushSettings, error) {
+    mode := prometheus.PushMode(conf.Mode)
+    if mode != prometheus.Pushgateway && mode != prometheus.RemoteWrite {
+        return nil, ConfigurationError{Path: "prometheus/push/mode",
+            Reason: fmt.Sprintf("The mode must be '%v' or '%v'.", prometheus.Pushgateway, prometheus.RemoteWrite)}
+    }
+    if conf.URL == "" {
+        return nil, ConfigurationError{Path: "prometheus/push/url", Reason: "The URL of the endpoint must be specified."}
+    }
+    settings := &prometheus.PushSettings{
+        Mode:          mode,
+        URL:           conf.URL,
+        Job:           "thor",
+        Instance:      conf.Instance,
+        Interval:      15 * time.Second,
+        Retries:       3,
+        RetryInterval: 1 * time.Second,
+    }
+    if conf.Job != "" {
+        settings.Job = conf.Job
+    }
+    if settings.Instance == "" {
+        hostname, err := os.Hostname()
+        if err == nil {
+            settings.Instance = hostname
+        }
+    }
+    if conf.BasicAuth != nil {
+        if conf.BasicAuth.Username == "" || conf.BasicAuth.Password == "" {
+            return nil, ConfigurationError{Path: "prometheus/push/basicAuth",
+                Reason: "The username and the password must be specified."}
+        }
+        settings.Username = conf.BasicAuth.Username
+        settings.Password = conf.BasicAuth.Password
+    }
+    if conf.IntervalInMs > 0 {
+        settings.Interval = time.Duration(conf.IntervalInMs) * time.Millisecond
+    }
+    if conf.Retries != nil {
+        if *conf.Retries < 0 {
+            return nil, ConfigurationError{Path: "prometheus/push/retries",
+                Reason: "The number of retries must not be negative."}
+        }
+        settings.Retries = *conf.Retries
+    }
+    if conf.RetryIntervalInMs > 0 {
+        settings.RetryInterval = time.Duration(conf.RetryIntervalInMs) * time.Millisecond
+    }
+    return settings, nil
+}
@@ -0,0 +1,79 @@
+package config
+
+import (
+    "github.com/sobitada/thor/notify"
+    "github.com/sobitada/thor/report"
+    "net/http"
+    "time"
+)
+
+// configuration struct for the epoch reports.
+type Report struct {
+    // number of slots after the turn over, after which the
+    // report of the previous epoch is sent.
+    DelaySlots uint64 `yaml:"delaySlots"`
+    // URL of a webhook to which the report is posted as JSON.
+    Webhook string `yaml:"webhook"`
+    // mail server and addresses to which the report is sent.
+    Mail *Mail `yaml:"mail"`
+}
+
+// configuration struct for sending mails over SMTP.
+type Mail struct {
+    Hostname string   `yaml:"hostname"`
+    Port     string   `yaml:"port"`
+    Username string   `yaml:"username"`
+    Password string   `yaml:"password"`
+    From     string   `yaml:"from"`
+    To       []string `yaml:"to"`
+}
+
+// gets the transport for sending mails over the given mail server. the
+// given path of the configuration is used in the returned error, if the
+// server, sender or recipients are missing.
+func getMailTransport(mailConf Mail, path string) (notify.Mail, error) {
+    if mailConf.Hostname == "" || mailConf.Port == "" {
+        return notify.Mail{}, ConfigurationError{Path: path,
+            Reason: "Hostname and port of the mail server must be specified."}
+    }
+    if mailConf.From == "" || len(mailConf.To) == 0 {
+        return notify.Mail{}, ConfigurationError{Path: path,
+            Reason: "The sender and at least one recipient must be specified."}
+    }
+    return notify.Mail{
+        Host:     mailConf.Hostname,
+        Port:     mailConf.Port,
+        Username: mailConf.Username,
+        Password: mailConf.Password,
+        From:     mailConf.From,
+        To:       mailConf.To,
+    }, nil
+}
+
+// gets the settings for the epoch reports specified in the given
+// configuration. default values are used for unspecified settings.
+func GetReportSettings(conf General) (report.Settings, error) {
+    settings := report.Settings{
+        DelaySlots:        100,
+        HeartbeatInterval: time.Minute,
+        Notifiers:         []report.Notifier{},
+    }
+    if conf.Report != nil {
+        reportConf := *conf.Report
+        if reportConf.DelaySlots > 0 {
+            settings.DelaySlots = reportConf.DelaySlots
+        }
+        if reportConf.Webhook != "" {
+            settings.Notifiers = append(settings.Notifiers, report.WebhookNotifier{URL: reportConf.Webhook,
+                Client: &http.Client{Timeout: 30 * time.Second}})
+        }
+        if reportConf.Mail != nil {
+            mail, err := getMailTransport(*reportConf.Mail, "report/mail")
+            if err != nil {
+                return settings, err
+            }
+            settings.Notifiers = append(settings.Notifiers, report.MailNotifier(mail))
+        }
+    }
+    return settings, nil
+}
@@ -0,0 +1,65 @@
+package config
+
+import (
+    "github.com/sobitada/thor/monitor"
+    "time"
+)
+
+// configuration struct for the cadence of the schedule watchdog.
+type Schedule struct {
+    // number of slots after the epoch turn over in which the
+    // fetching of a missing schedule is retried often.
+    EarlyEpochSlots uint64 `yaml:"earlyEpochSlots"`
+    // number of slots between attempts early in the epoch.
+    EarlyRetrySlots uint64 `yaml:"earlyRetrySlots"`
+    // time in milliseconds between attempts later in the epoch.
+    RetryIntervalInMs uint32 `yaml:"retryInterval"`
+    // time in milliseconds before the first re-check of the
+    // viability of a candidate.
+    ViabilityMinRetryIntervalInMs uint32 `yaml:"viabilityMinRetryInterval"`
+    // maximum time in milliseconds between re-checks of the
+    // viability of a candidate.
+    ViabilityMaxRetryIntervalInMs uint32 `yaml:"viabilityMaxRetryInterval"`
+    // name of the time zone (e.g. Europe/Vienna) in which
+    // the schedule is exported.
+    TimeZone string `yaml:"timeZone"`
+}
+
+// gets the settings of the schedule watchdog specified in the given
+// configuration. default values are used for unspecified settings.
+func GetScheduleSettings(conf General) (monitor.ScheduleSettings, error) {
+    settings := monitor.DefaultScheduleSettings()
+    if conf.Schedule != nil {
+        scheduleConf := *conf.Schedule
+        if scheduleConf.EarlyEpochSlots > 0 {
+            settings.EarlyEpochSlots = scheduleConf.EarlyEpochSlots
+        }
+        if scheduleConf.EarlyRetrySlots > 0 {
+            settings.EarlyRetrySlots = scheduleConf.EarlyRetrySlots
+        }
+        if scheduleConf.RetryIntervalInMs > 0 {
+            settings.RetryInterval = time.Duration(scheduleConf.RetryIntervalInMs) * time.Millisecond
+        }
+        if scheduleConf.ViabilityMinRetryIntervalInMs > 0 {
+            settings.ViabilityMinRetryInterval = time.Duration(scheduleConf.ViabilityMinRetryIntervalInMs) * time.Millisecond
+        }
+        if scheduleConf.ViabilityMaxRetryIntervalInMs > 0 {
+            settings.ViabilityMaxRetryInterval = time.Duration(scheduleConf.ViabilityMaxRetryIntervalInMs) * time.Millisecond
+        }
+        if scheduleConf.TimeZone == "Local" {
+            return settings, ConfigurationError{Path: "schedule/timeZone",
+                Reason: "The time zone must be an IANA name (e.g. Europe/Vienna) or UTC."}
+        } else if scheduleConf.TimeZone != "" {
+            location, err := time.LoadLocation(scheduleConf.TimeZone)
+            if err != nil {
+                return settings, ConfigurationError{Path: "schedule/timeZone", Reason: err.Error()}
+            }
+            settings.Location = location
+        }
+        if settings.ViabilityMinRetryInterval > settings.ViabilityMaxRetryInterval {
+            return settings, ConfigurationError{Path: "schedule/viabilityMinRetryInterval",
+                Reason: "The minimum retry interval must not be greater than the maximum retry interval."}
+        }
+    }
+    return settings, nil
+}
@@ -0,0 +1,34 @@
+package config
+
+import (
+    "github.com/sobitada/thor/status"
+)
+
+// configuration struct for the status API.
+type Status struct {
+    Hostname string `yaml:"hostname"`
+    Port     string `yaml:"port"`
+}
+
+// gets the status API server for the given configuration, or nil
+// if no status API has been configured.
+func ParseStatusConfig(conf General) (*status.Server, error) {
+    if conf.Status != nil {
+        statusConf := *conf.Status
+        if statusConf.Hostname != "" && statusConf.Port != "" {
+            return status.GetServer(statusConf.Hostname, statusConf.Port), nil
+        } else {
+            return nil, ConfigurationError{Path: "status", Reason: "Hostname and port must be specified for the status API."}
+        }
+    }
+    return nil, nil
+}
+
+// gets a client for the status API of the running instance
+// specified in the given configuration.
+func GetStatusClient(conf General) (*status.Client, error) {
+    if conf.Status != nil && conf.Status.Port != "" {
+        return status.GetClient(conf.Status.Hostname, conf.Status.Port)
+    }
+    return nil, ConfigurationError{Path: "status", Reason: "The status API must be configured for this command."}
+}
@@ -0,0 +1,56 @@
+package config
+
+import (
+    "github.com/sobitada/thor/storage"
+    "time"
+)
+
+// configuration struct for the storage of the state of this tool.
+type Storage struct {
+    // backend in which the state is stored, i.e. bolt, sqlite
+    // or memory. by default bolt is used.
+    Backend storage.Backend `yaml:"backend"`
+    // path of the file in which the state is stored. by default
+    // the file is located in the directory THOR_DATA_DIR.
+    Path string `yaml:"path"`
+    // number of past epochs for which the data is kept, the
+    // data of older epochs is removed. by default all the
+    // data is kept.
+    RetentionEpochs uint64 `yaml:"retentionEpochs"`
+    // time in milliseconds between removals of old data.
+    PruneIntervalInMs uint32 `yaml:"pruneInterval"`
+}
+
+// gets the settings of the store specified in the given configuration.
+// default values are used for unspecified settings.
+func GetStoreSettings(conf General) (storage.Settings, error) {
+    settings := storage.Settings{Backend: storage.Bolt}
+    if conf.Storage != nil {
+        switch conf.Storage.Backend {
+        case "":
+        case storage.Bolt, storage.SQLite, storage.Memory:
+            settings.Backend = conf.Storage.Backend
+        default:
+            return settings, ConfigurationError{Path: "storage/backend",
+                Reason: "The backend must be 'bolt', 'sqlite' or 'memory'."}
+        }
+        settings.Path = conf.Storage.Path
+    }
+    if settings.Path == "" {
+        settings.Path = storage.GetDefaultPath(settings.Backend)
+    }
+    return settings, nil
+}
+
+// gets the settings for the retention of data specified in the given
+// configuration. default values are used for unspecified settings.
+func GetRetentionSettings(conf General) storage.RetentionSettings {
+    settings := storage.RetentionSettings{Interval: 1 * time.Hour}
+    if conf.Storage != nil {
+        settings.Epochs = conf.Storage.RetentionEpochs
+        if conf.Storage.PruneIntervalInMs > 0 {
+            settings.Interval = time.Duration(conf.Storage.PruneIntervalInMs) * time.Millisecond
+        }
+    }
+    return settings
+}
@@ -4,12 +4,15 @@ go 1.13
 
 require (
 	github.com/boltdb/bolt v1.3.1
+	github.com/golang/snappy v0.0.1
 	github.com/hako/durafmt v0.0.0-20191009132224-3f39dc1ed9f4
+	github.com/mattn/go-sqlite3 v1.14.16
 	github.com/prometheus/client_golang v1.4.0
+	github.com/prometheus/client_model v0.2.0
 	github.com/sirupsen/logrus v1.4.2
 	github.com/sobitada/go-cardano v0.0.1
 	github.com/sobitada/go-jormungandr v0.0.1
 	github.com/stretchr/testify v1.5.1
-	golang.org/x/crypto v0.0.0-20200406173513-056763e48d71 // indirect
+	golang.org/x/crypto v0.0.0-20200406173513-056763e48d71
 	gopkg.in/yaml.v2 v2.2.8
 )
@@ -0,0 +1,342 @@
+package history
+
+import (
+    "encoding/json"
+    "fmt"
+    log "github.com/sirupsen/logrus"
+    "github.com/sobitada/thor/clock"
+    "github.com/sobitada/thor/monitor"
+    "github.com/sobitada/thor/storage"
+    "math/big"
+    "net/url"
+    "sort"
+    "strconv"
+    "time"
+)
+
+// format of the keys of the points, which is sortable.
+const keyFormat = "2006-01-02T15:04:05Z"
+
+// resolution in which the statistics of the nodes are downsampled, and
+// for how long the downsampled points are kept.
+type Tier struct {
+    Resolution time.Duration
+    Retention  time.Duration
+}
+
+// settings for the history of the node statistics.
+type Settings struct {
+    // tiers in which the history is kept.
+    Tiers []Tier
+    // interval in which points older than the retention of their
+    // tier are removed.
+    PruneInterval time.Duration
+    // clock used for timing, the clock of the system is used if
+    // it is nil.
+    Clock clock.Clock
+}
+
+// gets the default tiers, which keep one point per minute for two days, and
+// one point per hour for 90 days.
+func DefaultTiers() []Tier {
+    return []Tier{
+        {Resolution: time.Minute, Retention: 48 * time.Hour},
+        {Resolution: time.Hour, Retention: 90 * 24 * time.Hour},
+    }
+}
+
+// statistics of a node downsampled to a window of the resolution of a tier.
+// the height, uptime and peer counts are the last ones fetched in the window.
+type Point struct {
+    Node string    `json:"node"`
+    Time time.Time `json:"time"`
+    // number of checks in which the node has been polled, and how many
+    // of them failed or found the node bootstrapping.
+    Samples       int     `json:"samples"`
+    Failures      int     `json:"failures"`
+    Bootstrapping int     `json:"bootstrapping"`
+    Height        uint64  `json:"height"`
+    MaxLag        uint64  `json:"maxLag"`
+    AvgLag        float64 `json:"avgLag"`
+    UpTimeSeconds int64   `json:"upTimeSeconds"`
+    // peer counts, which are only reported by some versions.
+    PeersAvailable   *uint64 `json:"peersAvailable,omitempty"`
+    PeersQuarantined *uint64 `json:"peersQuarantined,omitempty"`
+    PeersUnreachable *uint64 `json:"peersUnreachable,omitempty"`
+    // latency of the API of the node in milliseconds.
+    AvgLatencyMs float64 `json:"avgLatencyMs"`
+    MaxLatencyMs float64 `json:"maxLatencyMs"`
+}
+
+// number of checks in the window of this point, in which the statistics
+// of the node have been fetched.
+func (point Point) Statistics() int {
+    return point.Samples - point.Failures - point.Bootstrapping
+}
+
+// adds the outcome of the given check of the node to this point.
+func (point *Point) add(nodeCheck monitor.NodeCheck, maxHeight *big.Int) {
+    latency := float64(nodeCheck.Latency) / float64(time.Millisecond)
+    point.AvgLatencyMs = (point.AvgLatencyMs*float64(point.Samples) + latency) / float64(point.Samples+1)
+    if latency > point.MaxLatencyMs {
+        point.MaxLatencyMs = latency
+    }
+    point.Samples++
+    if nodeCheck.Error != nil {
+        point.Failures++
+        return
+    }
+    if nodeCheck.Statistic == nil {
+        point.Bootstrapping++
+        return
+    }
+    stats := nodeCheck.Statistic
+    var lag uint64 = 0
+    if stats.LastBlockHeight != nil {
+        point.Height = stats.LastBlockHeight.Uint64()
+        if maxHeight != nil && maxHeight.Cmp(stats.LastBlockHeight) > 0 {
+            lag = new(big.Int).Sub(maxHeight, stats.LastBlockHeight).Uint64()
+        }
+    }
+    n := float64(point.Statistics() - 1)
+    point.AvgLag = (point.AvgLag*n + float64(lag)) / (n + 1)
+    if lag > point.MaxLag {
+        point.MaxLag = lag
+    }
+    point.UpTimeSeconds = int64(stats.UpTime.Seconds())
+    point.PeersAvailable = stats.PeerAvailableCount
+    point.PeersQuarantined = stats.PeerQuarantinedCount
+    point.PeersUnreachable = stats.PeerUnreachableCnt
+}
+
+// filter for querying the history.
+type Filter struct {
+    // only points of the node with this name, all nodes if empty.
+    Node string
+    // only points in the window [From, To], unbounded if zero.
+    From time.Time
+    To   time.Time
+    // resolution of the tier to query. if it is zero, the finest tier
+    // is taken, whose retention covers the start of the window.
+    Resolution time.Duration
+}
+
+// history of the statistics of the monitored nodes, which is downsampled
+// to the configured tiers and stored in the local store.
+type History struct {
+    store     storage.Store
+    settings  Settings
+    checks    chan monitor.Check
+    lastPrune time.Time
+}
+
+// creates a new history, which records the checks of the given monitor. the
+// tiers must have distinct resolutions of at least one second.
+func NewHistory(store storage.Store, mon *monitor.NodeMonitor, settings Settings) (*History, error) {
+    if len(settings.Tiers) == 0 {
+        settings.Tiers = DefaultTiers()
+    }
+    tiers := append([]Tier{}, settings.Tiers...)
+    sort.Slice(tiers, func(i, j int) bool {
+        return tiers[i].Resolution < tiers[j].Resolution
+    })
+    for i, tier := range tiers {
+        if tier.Resolution < time.Second || tier.Resolution%time.Second != 0 {
+            return nil, fmt.Errorf("the resolution '%v' must be a multiple of one second", tier.Resolution)
+        }
+        if i > 0 && tiers[i-1].Resolution == tier.Resolution {
+            return nil, fmt.Errorf("the resolution '%v' is specified twice", tier.Resolution)
+        }
+    }
+    settings.Tiers = tiers
+    settings.Clock = clock.OrReal(settings.Clock)
+    err := store.Update(func(tx storage.Tx) error {
+        return tx.CreateBucket(storage.HistoryBucket)
+    })
+    if err != nil {
+        return nil, err
+    }
+    history := &History{store: store, settings: settings}
+    if mon != nil {
+        history.checks = make(chan monitor.Check)
+        mon.ListenerManager.RegisterCheckListener(history.checks)
+    }
+    return history, nil
+}
+
+// gets the bucket of the given tier and node.
+func getBucket(tier Tier, node string) string {
+    return storage.SubBucket(getTierBucket(tier), url.PathEscape(node))
+}
+
+func getTierBucket(tier Tier) string {
+    return storage.SubBucket(storage.HistoryBucket, strconv.FormatInt(int64(tier.Resolution/time.Second), 10))
+}
+
+// records the outcome of the given check in all tiers.
+func (history *History) Record(check monitor.Check) error {
+    return history.store.Update(func(tx storage.Tx) error {
+        for _, tier := range history.settings.Tiers {
+            window := check.Time.UTC().Truncate(tier.Resolution)
+            key := window.Format(keyFormat)
+            for _, nodeCheck := range check.Nodes {
+                bucket := getBucket(tier, nodeCheck.Name)
+                point := Point{Node: nodeCheck.Name, Time: window}
+                data, err := tx.Get(bucket, key)
+                if err != nil {
+                    return err
+                }
+                if data != nil {
+                    err = json.Unmarshal(data, &point)
+                    if err != nil {
+                        return err
+                    }
+                }
+                point.add(nodeCheck, check.MaximumBlockHeight)
+                data, err = json.Marshal(point)
+                if err != nil {
+                    return err
+                }
+                err = tx.Put(bucket, key, data)
+                if err != nil {
+                    return err
+                }
+            }
+        }
+        return nil
+    })
+}
+
+// removes the points, which are older than the retention of their tier, and
+// returns the number of removed points.
+func (history *History) Prune() (int, error) {
+    now := history.settings.Clock.Now()
+    pruned := 0
+    err := history.store.Update(func(tx storage.Tx) error {
+        for _, tier := range history.settings.Tiers {
+            if tier.Retention <= 0 {
+                continue
+            }
+            cutoff := now.Add(-tier.Retention).UTC().Format(keyFormat)
+            nodes, err := tx.Buckets(getTierBucket(tier))
+            if err != nil {
+                return err
+            }
+            for _, node := range nodes {
+                bucket := storage.SubBucket(getTierBucket(tier), node)
+                keys := make([]string, 0)
+                err := tx.ForEach(bucket, func(key string, value []byte) error {
+                    if key < cutoff {
+                        keys = append(keys, key)
+                    }
+                    return nil
+                })
+                if err != nil {
+                    return err
+                }
+                for _, key := range keys {
+                    err = tx.Delete(bucket, key)
+                    if err != nil {
+                        return err
+                    }
+                }
+                pruned += len(keys)
+            }
+        }
+        return nil
+    })
+    return pruned, err
+}
+
+// gets the tier with the given resolution, or if the resolution is zero the
+// finest tier, whose retention covers the given start.
+func (history *History) getTier(resolution time.Duration, from time.Time) (Tier, error) {
+    tiers := history.settings.Tiers
+    if resolution != 0 {
+        for _, tier := range tiers {
+            if tier.Resolution == resolution {
+                return tier, nil
+            }
+        }
+        return Tier{}, fmt.Errorf("no history is kept with the resolution '%v'", resolution)
+    }
+    if !from.IsZero() {
+        now := history.settings.Clock.Now()
+        for _, tier := range tiers {
+            if tier.Retention <= 0 || !from.Before(now.Add(-tier.Retention)) {
+                return tier, nil
+            }
+        }
+        return tiers[len(tiers)-1], nil
+    }
+    return tiers[0], nil
+}
+
+// gets the points matching the given filter ordered by node and time.
+func (history *History) Query(filter Filter) ([]Point, error) {
+    tier, err := history.getTier(filter.Resolution, filter.From)
+    if err != nil {
+        return nil, err
+    }
+    from, to := "", ""
+    if !filter.From.IsZero() {
+        from = filter.From.UTC().Truncate(tier.Resolution).Format(keyFormat)
+    }
+    if !filter.To.IsZero() {
+        to = filter.To.UTC().Format(keyFormat)
+    }
+    points := make([]Point, 0)
+    err = history.store.View(func(tx storage.Tx) error {
+        nodes, err := tx.Buckets(getTierBucket(tier))
+        if err != nil {
+            return err
+        }
+        for _, node := range nodes {
+            name, err := url.PathUnescape(node)
+            if err != nil || (filter.Node != "" && name != filter.Node) {
+                continue
+            }
+            err = tx.ForEach(storage.SubBucket(getTierBucket(tier), node), func(key string, value []byte) error {
+                if (from != "" && key < from) || (to != "" && key > to) {
+                    return nil
+                }
+                var point Point
+                err := json.Unmarshal(value, &point)
+                if err == nil {
+                    points = append(points, point)
+                }
+                return err
+            })
+            if err != nil {
+                return err
+            }
+        }
+        return nil
+    })
+    return points, err
+}
+
+// a blocking call, which records the checks of the monitor and removes old
+// points in the configured interval.
+func (history *History) Run() {
+    if history.checks == nil {
+        return
+    }
+    for ; ; {
+        check := <-history.checks
+        err := history.Record(check)
+        if err != nil {
+            log.Errorf("[HISTORY] Could not record the node statistics. %v", err.Error())
+        }
+        now := history.settings.Clock.Now()
+        if now.Sub(history.lastPrune) >= history.settings.PruneInterval {
+            history.lastPrune = now
+            pruned, err := history.Prune()
+            if err != nil {
+                log.Errorf("[HISTORY] Could not remove old points. %v", err.Error())
+            } else if pruned > 0 {
+                log.Infof("[HISTORY] Removed %v old points from the history.", pruned)
+            }
+        }
+    }
+}
@@ -0,0 +1,142 @@
+package history
+
+import (
+    "errors"
+    jor "github.com/sobitada/go-jormungandr/api"
+    "github.com/sobitada/thor/jortest"
+    "github.com/sobitada/thor/monitor"
+    "github.com/sobitada/thor/storage"
+    "github.com/stretchr/testify/assert"
+    "math/big"
+    "net/url"
+    "testing"
+    "time"
+)
+
+var start = time.Date(2020, 6, 1, 12, 0, 0, 0, time.UTC)
+
+func openTestHistory(t *testing.T, tiers []Tier) (*History, *jortest.Clock, func()) {
+    store := storage.NewMemory()
+    testClock := jortest.NewClock(start)
+    history, err := NewHistory(store, nil, Settings{Tiers: tiers, Clock: testClock})
+    if err != nil {
+        t.Fatal(err)
+    }
+    return history, testClock, func() {
+        _ = store.Close()
+    }
+}
+
+func getNodeCheck(name string, height int64, latency time.Duration) monitor.NodeCheck {
+    peers := uint64(8)
+    return monitor.NodeCheck{Name: name, Latency: latency, Statistic: &jor.NodeStatistic{
+        LastBlockHeight:    big.NewInt(height),
+        UpTime:             time.Hour,
+        PeerAvailableCount: &peers,
+    }}
+}
+
+func TestHistory_Record_mustDownsampleChecksOfWindow(t *testing.T) {
+    history, _, cleanUp := openTestHistory(t, []Tier{{Resolution: time.Minute}})
+    defer cleanUp()
+    checks := []monitor.Check{
+        {Time: start, Nodes: []monitor.NodeCheck{getNodeCheck("a", 100, 10*time.Millisecond),
+            getNodeCheck("b", 98, 30*time.Millisecond)}, MaximumBlockHeight: big.NewInt(100)},
+        {Time: start.Add(20 * time.Second), Nodes: []monitor.NodeCheck{getNodeCheck("a", 101, 20*time.Millisecond),
+            getNodeCheck("b", 101, 50*time.Millisecond)}, MaximumBlockHeight: big.NewInt(101)},
+        {Time: start.Add(40 * time.Second), Nodes: []monitor.NodeCheck{getNodeCheck("a", 102, 30*time.Millisecond),
+            {Name: "b", Error: errors.New("timeout"), Latency: 2 * time.Second}}, MaximumBlockHeight: big.NewInt(102)},
+        {Time: start.Add(time.Minute), Nodes: []monitor.NodeCheck{getNodeCheck("a", 103, 10*time.Millisecond)},
+            MaximumBlockHeight: big.NewInt(103)},
+    }
+    for _, check := range checks {
+        assert.Nil(t, history.Record(check))
+    }
+    points, err := history.Query(Filter{Node: "b"})
+    if assert.Nil(t, err) && assert.Len(t, points, 1) {
+        assert.Equal(t, start, points[0].Time.UTC())
+        assert.Equal(t, 3, points[0].Samples)
+        assert.Equal(t, 1, points[0].Failures)
+        assert.Equal(t, 2, points[0].Statistics())
+        assert.Equal(t, uint64(101), points[0].Height)
+        assert.Equal(t, uint64(2), points[0].MaxLag)
+        assert.Equal(t, 1.0, points[0].AvgLag)
+        assert.Equal(t, 2000.0, points[0].MaxLatencyMs)
+        assert.Equal(t, int64(3600), points[0].UpTimeSeconds)
+        if assert.NotNil(t, points[0].PeersAvailable) {
+            assert.Equal(t, uint64(8), *points[0].PeersAvailable)
+        }
+    }
+    points, err = history.Query(Filter{Node: "a"})
+    if assert.Nil(t, err) && assert.Len(t, points, 2) {
+        assert.Equal(t, 3, points[0].Samples)
+        assert.Equal(t, 20.0, points[0].AvgLatencyMs)
+        assert.Equal(t, uint64(0), points[0].MaxLag)
+        assert.Equal(t, start.Add(time.Minute), points[1].Time.UTC())
+    }
+}
+
+func TestHistory_Query_mustPickFinestTierCoveringStart(t *testing.T) {
+    history, testClock, cleanUp := openTestHistory(t, []Tier{
+        {Resolution: time.Hour, Retention: 30 * 24 * time.Hour},
+        {Resolution: time.Minute, Retention: 2 * time.Hour},
+    })
+    defer cleanUp()
+    for i := 0; i < 3; i++ {
+        assert.Nil(t, history.Record(monitor.Check{Time: start.Add(time.Duration(i) * time.Hour),
+            Nodes: []monitor.NodeCheck{getNodeCheck("a node/1", 100, time.Millisecond)}}))
+    }
+    testClock.AdvanceTo(start.Add(3 * time.Hour))
+    points, err := history.Query(Filter{From: start.Add(90 * time.Minute)})
+    if assert.Nil(t, err) && assert.Len(t, points, 1) {
+        assert.Equal(t, "a node/1", points[0].Node)
+        assert.Equal(t, start.Add(2*time.Hour), points[0].Time.UTC())
+    }
+    points, err = history.Query(Filter{From: start})
+    if assert.Nil(t, err) {
+        assert.Len(t, points, 3)
+    }
+    _, err = history.Query(Filter{Resolution: time.Second})
+    assert.NotNil(t, err)
+}
+
+func TestHistory_Prune_mustRemovePointsOlderThanRetentionOfTier(t *testing.T) {
+    history, testClock, cleanUp := openTestHistory(t, []Tier{
+        {Resolution: time.Minute, Retention: time.Hour},
+        {Resolution: time.Hour},
+    })
+    defer cleanUp()
+    for i := 0; i < 180; i += 30 {
+        assert.Nil(t, history.Record(monitor.Check{Time: start.Add(time.Duration(i) * time.Minute),
+            Nodes: []monitor.NodeCheck{getNodeCheck("a", 100, time.Millisecond)}}))
+    }
+    testClock.AdvanceTo(start.Add(3 * time.Hour))
+    pruned, err := history.Prune()
+    if assert.Nil(t, err) {
+        assert.Equal(t, 4, pruned)
+    }
+    points, err := history.Query(Filter{Resolution: time.Minute})
+    if assert.Nil(t, err) {
+        assert.Len(t, points, 2)
+    }
+    points, err = history.Query(Filter{Resolution: time.Hour})
+    if assert.Nil(t, err) {
+        assert.Len(t, points, 3)
+    }
+}
+
+func TestNewHistory_duplicateResolution_mustFail(t *testing.T) {
+    _, err := NewHistory(storage.NewMemory(), nil, Settings{Tiers: []Tier{{Resolution: time.Minute},
+        {Resolution: time.Minute, Retention: time.Hour}}})
+    assert.NotNil(t, err)
+}
+
+func TestParseFilter_invalidResolution_mustFail(t *testing.T) {
+    _, err := ParseFilter(url.Values{"resolution": []string{"often"}})
+    assert.NotNil(t, err)
+    filter, err := ParseFilter(url.Values{"resolution": []string{"1h"}, "node": []string{"a"}})
+    if assert.Nil(t, err) {
+        assert.Equal(t, time.Hour, filter.Resolution)
+        assert.Equal(t, "a", filter.Node)
+    }
+}
@@ -0,0 +1,57 @@
+package history
+
+import (
+    "encoding/json"
+    "fmt"
+    "net/http"
+    "net/url"
+    "time"
+)
+
+// parses the filter from the given URL query. the parameters "from" and "to"
+// are expected in RFC3339 format, "node" is matched exactly and "resolution"
+// is a duration such as "1m" or "1h".
+func ParseFilter(query url.Values) (Filter, error) {
+    filter := Filter{Node: query.Get("node")}
+    for _, param := range []struct {
+        name   string
+        target *time.Time
+    }{{name: "from", target: &filter.From}, {name: "to", target: &filter.To}} {
+        value := query.Get(param.name)
+        if value != "" {
+            t, err := time.Parse(time.RFC3339, value)
+            if err != nil {
+                return filter, fmt.Errorf("the parameter '%v' must be in RFC3339 format. %v", param.name, err.Error())
+            }
+            *param.target = t
+        }
+    }
+    if value := query.Get("resolution"); value != "" {
+        resolution, err := time.ParseDuration(value)
+        if err != nil {
+            return filter, fmt.Errorf("the parameter 'resolution' must be a duration such as '1m'. %v", err.Error())
+        }
+        filter.Resolution = resolution
+    }
+    return filter, nil
+}
+
+// serves the points of the history matching the filter specified in the
+// query of the request as JSON.
+func (history *History) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+    filter, err := ParseFilter(request.URL.Query())
+    if err == nil {
+        _, err = history.getTier(filter.Resolution, filter.From)
+    }
+    if err != nil {
+        http.Error(writer, err.Error(), http.StatusBadRequest)
+        return
+    }
+    points, err := history.Query(filter)
+    if err != nil {
+        http.Error(writer, err.Error(), http.StatusInternalServerError)
+        return
+    }
+    writer.Header().Set("Content-Type", "application/json")
+    _ = json.NewEncoder(writer).Encode(points)
+}
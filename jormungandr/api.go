@@ -0,0 +1,94 @@
+package jormungandr
+
+import (
+    "encoding/json"
+    "fmt"
+    "io/ioutil"
+    "net/http"
+    "net/url"
+    "time"
+)
+
+// client for the parts of the Jormungandr node API, which are not
+// covered by the go-jormungandr library such as the status of the
+// leader logs and the main chain.
+type API struct {
+    url    *url.URL
+    client *http.Client
+}
+
+// gets an API instance for the node at the given host URL, which must not
+// include the API path (e.g. "http://127.0.0.1:3101").
+func GetAPIFromHost(host string, timeout time.Duration) (*API, error) {
+    hostURL, err := url.Parse(host)
+    if err != nil || hostURL == nil {
+        return nil, fmt.Errorf("you must enter a valid host URL, but it was '%v'", host)
+    }
+    apiURL, err := hostURL.Parse("/api/v0/")
+    if err != nil {
+        return nil, err
+    }
+    client := &http.Client{}
+    if timeout > 0 {
+        client.Timeout = timeout
+    }
+    return &API{url: apiURL, client: client}, nil
+}
+
+// gets the logs of the leader assignments of the node including
+// their status.
+func (api *API) GetLeaderLogs() ([]LeaderLog, error) {
+    data, found, err := api.get("leaders/logs")
+    if err != nil {
+        return nil, err
+    }
+    if !found {
+        return nil, fmt.Errorf("the leader logs could not be found")
+    }
+    var logs []leaderLogJSON
+    err = json.Unmarshal(data, &logs)
+    if err != nil {
+        return nil, err
+    }
+    leaderLogs := make([]LeaderLog, 0, len(logs))
+    for i := range logs {
+        leaderLog, err := logs[i].transform()
+        if err != nil {
+            return nil, err
+        }
+        leaderLogs = append(leaderLogs, leaderLog)
+    }
+    return leaderLogs, nil
+}
+
+// checks whether the block with the given hash is in the main chain
+// of the node, i.e. it is the tip or an ancestor of the tip.
+func (api *API) IsInMainChain(blockHash string) (bool, error) {
+    _, found, err := api.get(fmt.Sprintf("block/%v/next_id?count=1", url.PathEscape(blockHash)))
+    if err != nil {
+        return false, err
+    }
+    return found, nil
+}
+
+// requests the given path of the API. the second return value is false,
+// if the resource could not be found.
+func (api *API) get(path string) ([]byte, bool, error) {
+    apiURL, err := api.url.Parse(path)
+    if err != nil {
+        return nil, false, err
+    }
+    response, err := api.client.Get(apiURL.String())
+    if err != nil {
+        return nil, false, err
+    }
+    defer response.Body.Close()
+    if response.StatusCode == http.StatusNotFound {
+        return nil, false, nil
+    }
+    if response.StatusCode != http.StatusOK {
+        return nil, false, fmt.Errorf("request to '%v' failed with status code %v", apiURL.String(), response.StatusCode)
+    }
+    data, err := ioutil.ReadAll(response.Body)
+    return data, true, err
+}
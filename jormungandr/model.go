@@ -0,0 +1,119 @@
+package jormungandr
+
+import (
+    "encoding/json"
+    "fmt"
+    "github.com/sobitada/go-cardano"
+    "time"
+)
+
+// status of a leader assignment.
+type LeaderLogStatus string
+
+const (
+    // the slot of the assignment has not been reached yet.
+    Pending LeaderLogStatus = "pending"
+    // the node has produced a block for the assignment.
+    Block LeaderLogStatus = "block"
+    // the node could not produce a block for the assignment.
+    Rejected LeaderLogStatus = "rejected"
+)
+
+// log of a leader assignment including its status.
+type LeaderLog struct {
+    LeaderID          uint64
+    CreationTime      time.Time
+    ScheduleTime      time.Time
+    ScheduleBlockDate *cardano.PlainSlotDate
+    // time at which the node woke up for the assignment, nil
+    // if the node has not woken up yet.
+    WakeTime *time.Time
+    // time at which the node finished the assignment, nil if
+    // the node has not finished it yet.
+    FinishingTime *time.Time
+    Status        LeaderLogStatus
+    // hash of the produced block, if the status is block.
+    BlockHash string
+    // chain length of the produced block, if the status is block.
+    ChainLength uint64
+    // reason for the rejection, if the status is rejected.
+    Reason string
+}
+
+// leader log as returned by the Jormungandr API.
+type leaderLogJSON struct {
+    CreatedAtTime   string          `json:"created_at_time"`
+    ScheduledAtTime string          `json:"scheduled_at_time"`
+    ScheduledAtDate string          `json:"scheduled_at_date"`
+    WakeAtTime      *string         `json:"wake_at_time"`
+    FinishedAtTime  *string         `json:"finished_at_time"`
+    Status          json.RawMessage `json:"status"`
+    EnclaveLeaderID uint64          `json:"enclave_leader_id"`
+}
+
+type leaderLogStatusJSON struct {
+    Block *struct {
+        Block       string `json:"block"`
+        ChainLength uint64 `json:"chain_length"`
+    } `json:"Block"`
+    Rejected *struct {
+        Reason string `json:"reason"`
+    } `json:"Rejected"`
+}
+
+func parseOptionalTime(text *string) (*time.Time, error) {
+    if text == nil || *text == "" {
+        return nil, nil
+    }
+    t, err := time.Parse(time.RFC3339, *text)
+    if err != nil {
+        return nil, err
+    }
+    return &t, nil
+}
+
+func (log leaderLogJSON) transform() (LeaderLog, error) {
+    leaderLog := LeaderLog{LeaderID: log.EnclaveLeaderID, Status: Pending}
+    var err error
+    leaderLog.CreationTime, err = time.Parse(time.RFC3339, log.CreatedAtTime)
+    if err != nil {
+        return leaderLog, err
+    }
+    leaderLog.ScheduleTime, err = time.Parse(time.RFC3339, log.ScheduledAtTime)
+    if err != nil {
+        return leaderLog, err
+    }
+    leaderLog.ScheduleBlockDate, err = cardano.ParsePlainData(log.ScheduledAtDate)
+    if err != nil {
+        return leaderLog, err
+    }
+    leaderLog.WakeTime, err = parseOptionalTime(log.WakeAtTime)
+    if err != nil {
+        return leaderLog, err
+    }
+    leaderLog.FinishingTime, err = parseOptionalTime(log.FinishedAtTime)
+    if err != nil {
+        return leaderLog, err
+    }
+    var statusText string
+    if json.Unmarshal(log.Status, &statusText) == nil {
+        if statusText != "Pending" {
+            return leaderLog, fmt.Errorf("unknown status '%v' of leader log", statusText)
+        }
+        return leaderLog, nil
+    }
+    var status leaderLogStatusJSON
+    err = json.Unmarshal(log.Status, &status)
+    if err != nil {
+        return leaderLog, err
+    }
+    if status.Block != nil {
+        leaderLog.Status = Block
+        leaderLog.BlockHash = status.Block.Block
+        leaderLog.ChainLength = status.Block.ChainLength
+    } else if status.Rejected != nil {
+        leaderLog.Status = Rejected
+        leaderLog.Reason = status.Rejected.Reason
+    }
+    return leaderLog, nil
+}
@@ -0,0 +1,45 @@
+package jormungandr
+
+import (
+    "encoding/json"
+    "github.com/stretchr/testify/assert"
+    "testing"
+)
+
+func TestLeaderLogJSON_Transform_mustParseStatus(t *testing.T) {
+    data := []byte(`[
+        {"created_at_time": "2020-05-01T10:00:00+00:00", "scheduled_at_time": "2020-05-01T10:05:00+00:00",
+         "scheduled_at_date": "5.10", "wake_at_time": null, "finished_at_time": null, "status": "Pending",
+         "enclave_leader_id": 1},
+        {"created_at_time": "2020-05-01T10:00:00+00:00", "scheduled_at_time": "2020-05-01T10:06:00+00:00",
+         "scheduled_at_date": "5.20", "wake_at_time": "2020-05-01T10:06:00+00:00",
+         "finished_at_time": "2020-05-01T10:06:01+00:00",
+         "status": {"Block": {"block": "abc", "chain_length": 42}}, "enclave_leader_id": 1},
+        {"created_at_time": "2020-05-01T10:00:00+00:00", "scheduled_at_time": "2020-05-01T10:07:00+00:00",
+         "scheduled_at_date": "5.30", "wake_at_time": "2020-05-01T10:07:00+00:00",
+         "finished_at_time": "2020-05-01T10:07:01+00:00",
+         "status": {"Rejected": {"reason": "invalid parent"}}, "enclave_leader_id": 1}
+    ]`)
+    var logs []leaderLogJSON
+    if assert.NoError(t, json.Unmarshal(data, &logs)) {
+        pending, err := logs[0].transform()
+        if assert.NoError(t, err) {
+            assert.Equal(t, Pending, pending.Status)
+            assert.Nil(t, pending.WakeTime)
+            assert.Equal(t, "5.10", pending.ScheduleBlockDate.String())
+        }
+        block, err := logs[1].transform()
+        if assert.NoError(t, err) {
+            assert.Equal(t, Block, block.Status)
+            assert.Equal(t, "abc", block.BlockHash)
+            assert.Equal(t, uint64(42), block.ChainLength)
+            assert.NotNil(t, block.WakeTime)
+            assert.NotNil(t, block.FinishingTime)
+        }
+        rejected, err := logs[2].transform()
+        if assert.NoError(t, err) {
+            assert.Equal(t, Rejected, rejected.Status)
+            assert.Equal(t, "invalid parent", rejected.Reason)
+        }
+    }
+}
@@ -0,0 +1,168 @@
+package jortest
+
+import (
+    "github.com/sobitada/go-cardano"
+    "github.com/sobitada/thor/clock"
+    "sync"
+    "time"
+)
+
+// simulated clock, which only moves forward when it is told to do so. sleeping
+// goroutines and timers are woken up in the order of their deadlines, when the
+// clock is moved forward past them.
+type Clock struct {
+    now    time.Time
+    mutex  *sync.Mutex
+    timers []*fakeTimer
+}
+
+type fakeTimer struct {
+    clock    *Clock
+    deadline time.Time
+    channel  chan time.Time
+}
+
+// creates a new simulated clock starting at the given time.
+func NewClock(start time.Time) *Clock {
+    return &Clock{now: start, mutex: &sync.Mutex{}}
+}
+
+// gets the current time of this clock.
+func (fake *Clock) Now() time.Time {
+    fake.mutex.Lock()
+    defer fake.mutex.Unlock()
+    return fake.now
+}
+
+// blocks until this clock has been moved forward by the given duration.
+func (fake *Clock) Sleep(d time.Duration) {
+    <-fake.After(d)
+}
+
+// returns a channel on which the time is sent, once this clock has been
+// moved forward by the given duration.
+func (fake *Clock) After(d time.Duration) <-chan time.Time {
+    return fake.NewTimer(d).C()
+}
+
+// creates a timer firing once this clock has been moved forward by the
+// given duration.
+func (fake *Clock) NewTimer(d time.Duration) clock.Timer {
+    timer := &fakeTimer{clock: fake, channel: make(chan time.Time, 1)}
+    fake.mutex.Lock()
+    defer fake.mutex.Unlock()
+    fake.schedule(timer, d)
+    return timer
+}
+
+// gets the number of sleeping goroutines and active timers.
+func (fake *Clock) Timers() int {
+    fake.mutex.Lock()
+    defer fake.mutex.Unlock()
+    return len(fake.timers)
+}
+
+// waits in real time until at least the given number of goroutines are sleeping
+// or timers are active, but at most for the given timeout. it returns false, if
+// the timeout has been reached.
+func (fake *Clock) WaitForTimers(n int, timeout time.Duration) bool {
+    deadline := time.Now().Add(timeout)
+    for ; fake.Timers() < n; {
+        if time.Now().After(deadline) {
+            return false
+        }
+        time.Sleep(time.Millisecond)
+    }
+    return true
+}
+
+// moves this clock forward by the given duration.
+func (fake *Clock) Advance(d time.Duration) {
+    fake.AdvanceTo(fake.Now().Add(d))
+}
+
+// moves this clock forward to the given time. all timers with a deadline up
+// to this time are fired one after another, and the woken up goroutines get a
+// moment of real time to proceed before the next timer is fired. the clock
+// never moves backwards.
+func (fake *Clock) AdvanceTo(t time.Time) {
+    for ; ; {
+        fake.mutex.Lock()
+        next := -1
+        for i, timer := range fake.timers {
+            if !timer.deadline.After(t) && (next < 0 || timer.deadline.Before(fake.timers[next].deadline)) {
+                next = i
+            }
+        }
+        if next < 0 {
+            if t.After(fake.now) {
+                fake.now = t
+            }
+            fake.mutex.Unlock()
+            return
+        }
+        timer := fake.timers[next]
+        if timer.deadline.After(fake.now) {
+            fake.now = timer.deadline
+        }
+        fake.remove(timer)
+        timer.channel <- fake.now
+        fake.mutex.Unlock()
+        time.Sleep(time.Millisecond)
+    }
+}
+
+// moves this clock forward by the given number of slots.
+func (fake *Clock) AdvanceSlots(settings cardano.TimeSettings, slots uint64) {
+    fake.Advance(time.Duration(slots) * settings.SlotDuration)
+}
+
+// moves this clock forward to the start of the given slot date.
+func (fake *Clock) AdvanceToSlot(settings cardano.TimeSettings, epoch uint64, slot uint64) {
+    fake.AdvanceTo(cardano.FullSlotDateFromInt(epoch, slot, settings).GetStartDateTime())
+}
+
+// schedules the given timer to fire after the given duration. the timer fires
+// immediately, if the duration is not positive. the mutex must be held.
+func (fake *Clock) schedule(timer *fakeTimer, d time.Duration) {
+    timer.deadline = fake.now.Add(d)
+    if d <= 0 {
+        timer.channel <- fake.now
+    } else {
+        fake.timers = append(fake.timers, timer)
+    }
+}
+
+// removes the given timer, and returns true, if it has been active. the
+// mutex must be held.
+func (fake *Clock) remove(timer *fakeTimer) bool {
+    for i := range fake.timers {
+        if fake.timers[i] == timer {
+            fake.timers = append(fake.timers[:i], fake.timers[i+1:]...)
+            return true
+        }
+    }
+    return false
+}
+
+func (timer *fakeTimer) C() <-chan time.Time {
+    return timer.channel
+}
+
+func (timer *fakeTimer) Stop() bool {
+    timer.clock.mutex.Lock()
+    defer timer.clock.mutex.Unlock()
+    return timer.clock.remove(timer)
+}
+
+func (timer *fakeTimer) Reset(d time.Duration) bool {
+    timer.clock.mutex.Lock()
+    defer timer.clock.mutex.Unlock()
+    active := timer.clock.remove(timer)
+    select {
+    case <-timer.channel:
+    default:
+    }
+    timer.clock.schedule(timer, d)
+    return active
+}
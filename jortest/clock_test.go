@@ -0,0 +1,44 @@
+package jortest
+
+import (
+    "github.com/stretchr/testify/assert"
+    "testing"
+    "time"
+)
+
+func TestClock_Advance_mustFireTimersInOrderOfDeadline(t *testing.T) {
+    start := time.Now()
+    clock := NewClock(start)
+    late := clock.After(2 * time.Hour)
+    early := clock.After(1 * time.Hour)
+    stopped := clock.NewTimer(30 * time.Minute)
+    assert.True(t, stopped.Stop())
+    assert.Equal(t, 2, clock.Timers())
+    clock.Advance(90 * time.Minute)
+    assert.Equal(t, start.Add(1*time.Hour), <-early)
+    assert.Empty(t, late)
+    assert.Empty(t, stopped.C())
+    assert.Equal(t, start.Add(90*time.Minute), clock.Now())
+    clock.Advance(1 * time.Hour)
+    assert.Equal(t, start.Add(2*time.Hour), <-late)
+    assert.Zero(t, clock.Timers())
+}
+
+func TestClock_Sleep_mustReturnAfterClockHasBeenAdvanced(t *testing.T) {
+    clock := NewClock(time.Now())
+    settings := TimeSettingsAt(clock.Now(), 5, 10, time.Second, 100)
+    woken := make(chan time.Time)
+    go func() {
+        clock.Sleep(100 * time.Second)
+        woken <- clock.Now()
+    }()
+    assert.True(t, clock.WaitForTimers(1, 2*time.Second))
+    clock.AdvanceToSlot(*settings, 6, 50)
+    select {
+    case now := <-woken:
+        slotDate, _ := settings.GetSlotDateFor(now)
+        assert.Equal(t, uint64(6), slotDate.GetEpoch().Uint64())
+    case <-time.After(2 * time.Second):
+        t.Fatal("the sleeping goroutine has not been woken up.")
+    }
+}
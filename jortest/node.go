@@ -0,0 +1,412 @@
+package jortest
+
+import (
+    "encoding/json"
+    "fmt"
+    "github.com/sobitada/go-cardano"
+    jor "github.com/sobitada/go-jormungandr/api"
+    "github.com/sobitada/thor/clock"
+    "github.com/sobitada/thor/jormungandr"
+    "math/big"
+    "net/http"
+    "net/http/httptest"
+    "strconv"
+    "strings"
+    "sync"
+    "time"
+)
+
+// endpoints of the fake node, which can be scripted to fail.
+const (
+    StatsEndpoint      string = "node/stats"
+    LeadersEndpoint    string = "leaders"
+    LeaderLogsEndpoint string = "leaders/logs"
+    ShutdownEndpoint   string = "shutdown"
+    BlockEndpoint      string = "block"
+)
+
+const apiPrefix string = "/api/v0/"
+
+// fake Jormungandr node serving the subset of the node API that is used by
+// this program. the state of the node (block height, hash, bootstrapping,
+// registered leaders, schedule) is scriptable, and failures as well as
+// latency of the API can be injected.
+type Node struct {
+    server *httptest.Server
+    clock  clock.Clock
+    mutex  *sync.Mutex
+
+    startTime     time.Time
+    version       string
+    bootstrapping bool
+    height        uint64
+    hash          string
+    blockDate     *cardano.PlainSlotDate
+    blockTime     time.Time
+    peers         uint64
+
+    leaders      []uint64
+    nextLeaderID uint64
+    schedule     []jor.LeaderAssignment
+    logs         map[string]assignmentLog
+    mainChain    map[string]bool
+
+    latency   time.Duration
+    failures  map[string]int
+    requests  map[string]int
+    shutdowns int
+}
+
+// creates and starts a new fake node using the real time.
+func NewNode() *Node {
+    return NewNodeWithClock(nil)
+}
+
+// creates and starts a new fake node using the given clock, which is usually
+// a simulated one. if the clock is nil, then the real time is used.
+func NewNodeWithClock(nodeClock clock.Clock) *Node {
+    nodeClock = clock.OrReal(nodeClock)
+    node := &Node{
+        clock:        nodeClock,
+        mutex:        &sync.Mutex{},
+        startTime:    nodeClock.Now(),
+        version:      "jormungandr 0.8.18",
+        hash:         Hash(0),
+        blockDate:    cardano.PlainSlotDateFromInt(0, 0),
+        peers:        32,
+        nextLeaderID: 1,
+        logs:         make(map[string]assignmentLog),
+        mainChain:    make(map[string]bool),
+        failures:     make(map[string]int),
+        requests:     make(map[string]int),
+    }
+    node.server = httptest.NewServer(http.HandlerFunc(node.serve))
+    return node
+}
+
+// gets the URL of this fake node.
+func (node *Node) URL() string {
+    return node.server.URL
+}
+
+// gets a Jormungandr API client for this fake node.
+func (node *Node) API(timeout time.Duration) *jor.JormungandrAPI {
+    api, err := jor.GetAPIFromHost(node.server.URL, timeout)
+    if err != nil {
+        panic(err)
+    }
+    return api
+}
+
+// gets a client for the status of leader assignments and the main chain
+// of this fake node.
+func (node *Node) BlockAPI(timeout time.Duration) *jormungandr.API {
+    api, err := jormungandr.GetAPIFromHost(node.server.URL, timeout)
+    if err != nil {
+        panic(err)
+    }
+    return api
+}
+
+// stops this fake node.
+func (node *Node) Close() {
+    node.server.Close()
+}
+
+// sets the most recent block of this node, which has been received
+// at the current time of the node.
+func (node *Node) SetTip(height uint64, hash string, date *cardano.PlainSlotDate) {
+    node.mutex.Lock()
+    defer node.mutex.Unlock()
+    node.height = height
+    node.hash = hash
+    node.blockDate = date
+    node.blockTime = node.clock.Now()
+}
+
+// sets the time at which the most recent block has been received.
+func (node *Node) SetBlockTime(t time.Time) {
+    node.mutex.Lock()
+    defer node.mutex.Unlock()
+    node.blockTime = t
+}
+
+// sets whether this node is bootstrapping.
+func (node *Node) SetBootstrapping(bootstrapping bool) {
+    node.mutex.Lock()
+    defer node.mutex.Unlock()
+    node.bootstrapping = bootstrapping
+}
+
+// sets the leader schedule reported by this node.
+func (node *Node) SetSchedule(schedule []jor.LeaderAssignment) {
+    node.mutex.Lock()
+    defer node.mutex.Unlock()
+    node.schedule = schedule
+}
+
+// lets this node report that it has produced a block with the given hash and
+// chain length for the assignment at the given slot date.
+func (node *Node) SetBlockMinted(date *cardano.PlainSlotDate, hash string, chainLength uint64) {
+    node.mutex.Lock()
+    defer node.mutex.Unlock()
+    now := node.clock.Now()
+    node.logs[date.String()] = assignmentLog{wakeTime: &now, finishingTime: &now, status: map[string]interface{}{
+        "Block": map[string]interface{}{"block": hash, "chain_length": chainLength},
+    }}
+}
+
+// lets this node report that it could not produce a block for the assignment
+// at the given slot date.
+func (node *Node) SetBlockRejected(date *cardano.PlainSlotDate, reason string) {
+    node.mutex.Lock()
+    defer node.mutex.Unlock()
+    now := node.clock.Now()
+    node.logs[date.String()] = assignmentLog{wakeTime: &now, finishingTime: &now, status: map[string]interface{}{
+        "Rejected": map[string]interface{}{"reason": reason},
+    }}
+}
+
+// adds the blocks with the given hashes to the main chain of this node. the
+// most recent block of this node is always part of the main chain.
+func (node *Node) AddToMainChain(hashes ...string) {
+    node.mutex.Lock()
+    defer node.mutex.Unlock()
+    for _, hash := range hashes {
+        node.mainChain[hash] = true
+    }
+}
+
+// sets the latency of each API request.
+func (node *Node) SetLatency(latency time.Duration) {
+    node.mutex.Lock()
+    defer node.mutex.Unlock()
+    node.latency = latency
+}
+
+// lets all requests to the given endpoint fail with the given status
+// code. a status code of 0 lets the endpoint recover.
+func (node *Node) Fail(endpoint string, statusCode int) {
+    node.mutex.Lock()
+    defer node.mutex.Unlock()
+    if statusCode == 0 {
+        delete(node.failures, endpoint)
+    } else {
+        node.failures[endpoint] = statusCode
+    }
+}
+
+// registers a leader at this node and returns its ID.
+func (node *Node) RegisterLeader() uint64 {
+    node.mutex.Lock()
+    defer node.mutex.Unlock()
+    return node.registerLeader()
+}
+
+// gets the IDs of the leaders registered at this node.
+func (node *Node) Leaders() []uint64 {
+    node.mutex.Lock()
+    defer node.mutex.Unlock()
+    return append([]uint64{}, node.leaders...)
+}
+
+// gets the number of shutdown requests this node received.
+func (node *Node) Shutdowns() int {
+    node.mutex.Lock()
+    defer node.mutex.Unlock()
+    return node.shutdowns
+}
+
+// gets the number of requests this node received for the given endpoint.
+func (node *Node) Requests(endpoint string) int {
+    node.mutex.Lock()
+    defer node.mutex.Unlock()
+    return node.requests[endpoint]
+}
+
+func (node *Node) registerLeader() uint64 {
+    id := node.nextLeaderID
+    node.nextLeaderID++
+    node.leaders = append(node.leaders, id)
+    return id
+}
+
+// gets the endpoint for the given request path.
+func getEndpoint(path string) string {
+    endpoint := strings.TrimPrefix(path, apiPrefix)
+    if strings.HasPrefix(endpoint, LeadersEndpoint+"/") && endpoint != LeaderLogsEndpoint {
+        return LeadersEndpoint
+    }
+    if strings.HasPrefix(endpoint, BlockEndpoint+"/") {
+        return BlockEndpoint
+    }
+    return endpoint
+}
+
+func (node *Node) serve(writer http.ResponseWriter, request *http.Request) {
+    endpoint := getEndpoint(request.URL.Path)
+    node.mutex.Lock()
+    node.requests[endpoint]++
+    latency := node.latency
+    statusCode, failing := node.failures[endpoint]
+    node.mutex.Unlock()
+    if latency > 0 {
+        time.Sleep(latency)
+    }
+    if failing {
+        http.Error(writer, "injected failure", statusCode)
+        return
+    }
+    node.mutex.Lock()
+    defer node.mutex.Unlock()
+    switch {
+    case endpoint == StatsEndpoint && request.Method == http.MethodGet:
+        writeJSON(writer, node.stats())
+    case endpoint == LeaderLogsEndpoint && request.Method == http.MethodGet:
+        writeJSON(writer, node.transformSchedule())
+    case request.URL.Path == apiPrefix+LeadersEndpoint && request.Method == http.MethodGet:
+        writeJSON(writer, append([]uint64{}, node.leaders...))
+    case request.URL.Path == apiPrefix+LeadersEndpoint && request.Method == http.MethodPost:
+        writeJSON(writer, node.registerLeader())
+    case endpoint == LeadersEndpoint && request.Method == http.MethodDelete:
+        id, err := strconv.ParseUint(strings.TrimPrefix(request.URL.Path, apiPrefix+LeadersEndpoint+"/"), 10, 64)
+        if err != nil {
+            http.Error(writer, err.Error(), http.StatusBadRequest)
+            return
+        }
+        for i := range node.leaders {
+            if node.leaders[i] == id {
+                node.leaders = append(node.leaders[:i], node.leaders[i+1:]...)
+                writer.WriteHeader(http.StatusOK)
+                return
+            }
+        }
+        http.NotFound(writer, request)
+    case endpoint == BlockEndpoint && request.Method == http.MethodGet:
+        parts := strings.Split(strings.TrimPrefix(request.URL.Path, apiPrefix), "/")
+        if len(parts) == 3 && parts[2] == "next_id" && (node.mainChain[parts[1]] || parts[1] == node.hash) {
+            writer.WriteHeader(http.StatusOK)
+            return
+        }
+        http.NotFound(writer, request)
+    case endpoint == ShutdownEndpoint && request.Method == http.MethodGet:
+        node.shutdowns++
+        writer.WriteHeader(http.StatusOK)
+    default:
+        http.NotFound(writer, request)
+    }
+}
+
+// node statistics as returned by the Jormungandr API.
+type nodeStatsJSON struct {
+    Version            string `json:"version"`
+    State              string `json:"state"`
+    UpTime             uint32 `json:"uptime"`
+    LastBlockDate      string `json:"lastBlockDate,omitempty"`
+    LastBlockHash      string `json:"lastBlockHash,omitempty"`
+    LastBlockHeight    string `json:"lastBlockHeight,omitempty"`
+    LastBlockTime      string `json:"lastBlockTime,omitempty"`
+    PeerAvailableCnt   uint64 `json:"peerAvailableCnt"`
+    PeerQuarantinedCnt uint64 `json:"peerQuarantinedCnt"`
+    PeerUnreachableCnt uint64 `json:"peerUnreachableCnt"`
+}
+
+func (node *Node) stats() nodeStatsJSON {
+    if node.bootstrapping {
+        return nodeStatsJSON{Version: node.version, State: "Bootstrapping"}
+    }
+    blockTime := node.blockTime
+    if blockTime.IsZero() {
+        blockTime = node.clock.Now()
+    }
+    return nodeStatsJSON{
+        Version:          node.version,
+        State:            "Running",
+        UpTime:           uint32(node.clock.Now().Sub(node.startTime).Seconds()),
+        LastBlockDate:    node.blockDate.String(),
+        LastBlockHash:    node.hash,
+        LastBlockHeight:  strconv.FormatUint(node.height, 10),
+        LastBlockTime:    blockTime.Format(time.RFC3339Nano),
+        PeerAvailableCnt: node.peers,
+    }
+}
+
+// leader assignment as returned by the Jormungandr API.
+type leaderAssignmentJSON struct {
+    CreatedAtTime   string      `json:"created_at_time"`
+    ScheduledAtTime string      `json:"scheduled_at_time"`
+    ScheduledAtDate string      `json:"scheduled_at_date"`
+    WakeAtTime      *string     `json:"wake_at_time"`
+    FinishedAtTime  *string     `json:"finished_at_time"`
+    Status          interface{} `json:"status"`
+    EnclaveLeaderID uint64      `json:"enclave_leader_id"`
+}
+
+// status of an assignment, which has been scripted.
+type assignmentLog struct {
+    wakeTime      *time.Time
+    finishingTime *time.Time
+    status        interface{}
+}
+
+func formatOptionalTime(t *time.Time) *string {
+    if t == nil {
+        return nil
+    }
+    text := t.Format(time.RFC3339Nano)
+    return &text
+}
+
+func (node *Node) transformSchedule() []leaderAssignmentJSON {
+    assignments := make([]leaderAssignmentJSON, len(node.schedule))
+    for i, assignment := range node.schedule {
+        assignments[i] = leaderAssignmentJSON{
+            CreatedAtTime:   assignment.CreationTime.Format(time.RFC3339Nano),
+            ScheduledAtTime: assignment.ScheduleTime.Format(time.RFC3339Nano),
+            ScheduledAtDate: assignment.ScheduleBlockDate.String(),
+            Status:          "Pending",
+            EnclaveLeaderID: assignment.LeaderID,
+        }
+        scriptedLog, found := node.logs[assignment.ScheduleBlockDate.String()]
+        if found {
+            assignments[i].WakeAtTime = formatOptionalTime(scriptedLog.wakeTime)
+            assignments[i].FinishedAtTime = formatOptionalTime(scriptedLog.finishingTime)
+            assignments[i].Status = scriptedLog.status
+        }
+    }
+    return assignments
+}
+
+func writeJSON(writer http.ResponseWriter, value interface{}) {
+    writer.Header().Set("Content-Type", "application/json")
+    _ = json.NewEncoder(writer).Encode(value)
+}
+
+// gets a deterministic block hash for the given number.
+func Hash(n uint64) string {
+    return fmt.Sprintf("%064x", n)
+}
+
+// creates a leader assignment of the leader with ID 1 for the given
+// slot date.
+func Assignment(epoch uint64, slot uint64, settings cardano.TimeSettings) jor.LeaderAssignment {
+    slotDate := cardano.FullSlotDateFromInt(epoch, slot, settings)
+    return jor.LeaderAssignment{
+        CreationTime:      settings.GenesisBlockDateTime,
+        ScheduleTime:      slotDate.GetStartDateTime(),
+        ScheduleBlockDate: cardano.PlainSlotDateFromInt(epoch, slot),
+        LeaderID:          1,
+    }
+}
+
+// creates time settings of a block chain with the given slot duration and number
+// of slots per epoch, in which the given time lies at the start of the given slot date.
+func TimeSettingsAt(t time.Time, epoch uint64, slot uint64, slotDuration time.Duration,
+    slotsPerEpoch uint64) *cardano.TimeSettings {
+    slots := time.Duration(epoch*slotsPerEpoch + slot)
+    return &cardano.TimeSettings{
+        GenesisBlockDateTime: t.Add(-slots * slotDuration),
+        SlotsPerEpoch:        new(big.Int).SetUint64(slotsPerEpoch),
+        SlotDuration:         slotDuration,
+    }
+}
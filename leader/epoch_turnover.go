@@ -1,9 +1,10 @@
 package leader
 
 import (
+    "fmt"
     log "github.com/sirupsen/logrus"
     "github.com/sobitada/go-cardano"
-    "github.com/sobitada/go-jormungandr/api"
+    "github.com/sobitada/thor/audit"
     "github.com/sobitada/thor/monitor"
     "github.com/sobitada/thor/utils"
     "math/big"
@@ -20,16 +21,20 @@ func nextEpochStart(slotDate *cardano.FullSlotDate, timeSettings cardano.TimeSet
 // this method promotes the given node to leader. should this attempt
 // fail, then it is retried all 5 slots until the epoch turn over has
 // been reached and another attempt would be useless.
-func promoteNode(node monitor.Node, cert api.LeaderCertificate, nextEpoch *cardano.FullSlotDate,
-    settings cardano.TimeSettings) {
-    _, err := node.API.PostLeader(cert)
+func (jury *Jury) promoteNode(node monitor.Node, nextEpoch *cardano.FullSlotDate) {
+    settings := *jury.settings.TimeSettings
+    reason := fmt.Sprintf("Epoch turn over to %v.", nextEpoch.GetEpoch().String())
+    leaderID, err := jury.postLeader(node, reason)
     if err != nil {
         log.Warnf("[TURNOVER] Could not promote node %v. %v", node.Name, err.Error())
-        if !time.Now().After(nextEpoch.GetStartDateTime().Add(-1 * settings.SlotDuration)) {
-            diff := nextEpoch.GetStartDateTime().Add(-1 * settings.SlotDuration).Sub(time.Now())
-            time.Sleep(utils.MaxDuration(diff, 5*settings.SlotDuration))
-            go promoteNode(node, cert, nextEpoch, settings)
+        jury.record(node.Name, audit.Promotion, reason, audit.Failure+": "+err.Error(), nil)
+        if !jury.settings.Clock.Now().After(nextEpoch.GetStartDateTime().Add(-1 * settings.SlotDuration)) {
+            diff := nextEpoch.GetStartDateTime().Add(-1 * settings.SlotDuration).Sub(jury.settings.Clock.Now())
+            jury.settings.Clock.Sleep(utils.MaxDuration(diff, 5*settings.SlotDuration))
+            go jury.promoteNode(node, nextEpoch)
         }
+    } else {
+        jury.record(node.Name, audit.Promotion, reason, audit.Success, &leaderID)
     }
 }
 
@@ -40,39 +45,45 @@ func promoteNode(node monitor.Node, cert api.LeaderCertificate, nextEpoch *carda
 // in which the block shall be minted.
 func (jury *Jury) turnOverHandling() {
     for ; ; {
-        currentSlotDate, _ := jury.settings.TimeSettings.GetSlotDateFor(time.Now())
-        // get time for turn over.
-        nextEpoch := nextEpochStart(currentSlotDate, *jury.settings.TimeSettings)
-        schedule, found := jury.watchDog.GetScheduleFor(currentSlotDate.GetEpoch())
-        leaderPromotionDate := nextEpoch.GetStartDateTime().Add(-time.Duration(jury.settings.PreEpochTurnOverExclusionSlots.Int64()) * jury.settings.TimeSettings.SlotDuration)
-        // get last assignment in this epoch
-        if found && schedule != nil && len(schedule) > 0 {
-            lastAssignment := schedule[len(schedule)-1]
-            afterLastAssignmentSlotDate, _ := cardano.FullSlotDateFrom(lastAssignment.ScheduleBlockDate.GetEpoch(),
-                lastAssignment.ScheduleBlockDate.GetSlot(), *jury.settings.TimeSettings)
-            afterLastAssignmentDate := afterLastAssignmentSlotDate.GetEndDateTime()
-            if afterLastAssignmentDate.After(leaderPromotionDate) {
-                leaderPromotionDate = afterLastAssignmentDate.Add(500 * time.Millisecond)
-            }
-        }
-        waitTime := leaderPromotionDate.Sub(time.Now())
-        log.Infof("[TURNOVER] Waiting %s for handling turn over.", utils.GetHumanReadableUpTime(waitTime))
-        if waitTime > 0 {
-            time.Sleep(waitTime)
-        }
-        // promote all nodes to leader
-        for _, node := range jury.nodes {
-            if jury.leader == nil || jury.leader.name != node.Name {
-                go promoteNode(node, jury.cert, nextEpoch, *jury.settings.TimeSettings)
-            }
+        jury.handleTurnOver()
+        // waiting a bit for new turn over handling check.
+        jury.settings.Clock.Sleep(10 * time.Minute)
+    }
+}
+
+// handles the next epoch turn over. it waits until the promotion date, promotes
+// all candidates and performs a sanity check shortly after the turn over.
+func (jury *Jury) handleTurnOver() {
+    currentSlotDate, _ := jury.settings.TimeSettings.GetSlotDateFor(jury.settings.Clock.Now())
+    // get time for turn over.
+    nextEpoch := nextEpochStart(currentSlotDate, *jury.settings.TimeSettings)
+    schedule, found := jury.watchDog.GetScheduleFor(currentSlotDate.GetEpoch())
+    leaderPromotionDate := nextEpoch.GetStartDateTime().Add(-time.Duration(jury.settings.PreEpochTurnOverExclusionSlots.Int64()) * jury.settings.TimeSettings.SlotDuration)
+    // get last assignment in this epoch
+    if found && schedule != nil && len(schedule) > 0 {
+        lastAssignment := schedule[len(schedule)-1]
+        afterLastAssignmentSlotDate, _ := cardano.FullSlotDateFrom(lastAssignment.ScheduleBlockDate.GetEpoch(),
+            lastAssignment.ScheduleBlockDate.GetSlot(), *jury.settings.TimeSettings)
+        afterLastAssignmentDate := afterLastAssignmentSlotDate.GetEndDateTime()
+        if afterLastAssignmentDate.After(leaderPromotionDate) {
+            leaderPromotionDate = afterLastAssignmentDate.Add(500 * time.Millisecond)
         }
-        waitTime = nextEpoch.GetEndDateTime().Add(2 * jury.settings.TimeSettings.SlotDuration).Sub(time.Now())
-        if waitTime > 0 {
-            time.Sleep(waitTime)
+    }
+    waitTime := leaderPromotionDate.Sub(jury.settings.Clock.Now())
+    log.Infof("[TURNOVER] Waiting %s for handling turn over.", utils.GetHumanReadableUpTime(waitTime))
+    if waitTime > 0 {
+        jury.settings.Clock.Sleep(waitTime)
+    }
+    // promote all nodes to leader
+    for _, node := range jury.nodes {
+        if jury.leader == nil || jury.leader.name != node.Name {
+            go jury.promoteNode(node, nextEpoch)
         }
-        // do sanity check
-        jury.sanityCheck()
-        // waiting a bit for new turn over handling check.
-        time.Sleep(10 * time.Minute)
     }
+    waitTime = nextEpoch.GetEndDateTime().Add(2 * jury.settings.TimeSettings.SlotDuration).Sub(jury.settings.Clock.Now())
+    if waitTime > 0 {
+        jury.settings.Clock.Sleep(waitTime)
+    }
+    // do sanity check
+    jury.sanityCheck()
 }
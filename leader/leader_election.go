@@ -1,9 +1,12 @@
 package leader
 
 import (
+    "fmt"
     log "github.com/sirupsen/logrus"
     "github.com/sobitada/go-cardano"
     "github.com/sobitada/go-jormungandr/api"
+    "github.com/sobitada/thor/audit"
+    "github.com/sobitada/thor/clock"
     "github.com/sobitada/thor/monitor"
     "github.com/sobitada/thor/utils"
     "math/big"
@@ -19,11 +22,22 @@ type Jury struct {
 
     watchDog        *monitor.ScheduleWatchDog
     scheduleChannel chan []api.LeaderAssignment
+    // candidates that have become viable, and the signal for
+    // judging again without waiting for the next check.
+    viabilityChannel chan monitor.ViabilityEvent
+    viabilitySignal  chan bool
 
     leader      *currentLeader
     leaderMutex *sync.Mutex
     cert        api.LeaderCertificate
 
+    // most recent health scores, and the mutex guarding them
+    // as well as the current leader.
+    health      map[string]float64
+    statusMutex *sync.RWMutex
+
+    auditLog *audit.Log
+    shadow   *shadowState
     settings JurySettings
 }
 
@@ -48,13 +62,23 @@ type JurySettings struct {
     // creation time of the genesis block, slots
     // per epoch and slot duration.
     TimeSettings *cardano.TimeSettings
+    // settings for checking the readiness of a
+    // candidate before it is promoted to leader.
+    Readiness ReadinessSettings
+    // mode in which the jury is operating, in shadow
+    // mode decisions are not executed on the nodes.
+    Mode Mode
+    // clock used for all time-dependent decisions, the
+    // clock of the system is used if it is nil.
+    Clock clock.Clock
 }
 
 // gets the leader jury judging the given nodes. it expects the certificate of the
 // leader that shall be managed and jury settings. moreover, the time
-// settings for the block chain is needed to handle epoch turn overs.
+// settings for the block chain is needed to handle epoch turn overs. the
+// audit log is optional and records all promotions, demotions and shutdowns.
 func GetLeaderJuryFor(nodes []monitor.Node, mon *monitor.NodeMonitor, watchDog *monitor.ScheduleWatchDog,
-    certificate api.LeaderCertificate, settings JurySettings) (*Jury, error) {
+    auditLog *audit.Log, certificate api.LeaderCertificate, settings JurySettings) (*Jury, error) {
     // create a node map
     nodeMap := make(map[string]monitor.Node)
     for i := range nodes {
@@ -85,15 +109,68 @@ func GetLeaderJuryFor(nodes []monitor.Node, mon *monitor.NodeMonitor, watchDog *
     }
     scheduleChannel := make(chan []api.LeaderAssignment)
     watchDog.RegisterListener(scheduleChannel)
-    return &Jury{
+    viabilityChannel := make(chan monitor.ViabilityEvent)
+    watchDog.RegisterViabilityListener(viabilityChannel)
+    settings.Clock = clock.OrReal(settings.Clock)
+    jury := &Jury{
         nodes:            nodeMap,
         nodeStatsChannel: nodeStatsChannel,
         watchDog:         watchDog,
         scheduleChannel:  scheduleChannel,
+        viabilityChannel: viabilityChannel,
+        viabilitySignal:  make(chan bool, 1),
         cert:             certificate,
+        auditLog:         auditLog,
+        shadow:           newShadowState(settings.Clock.Now()),
         settings:         settings,
         leaderMutex:      &sync.Mutex{},
-    }, nil
+        health:           map[string]float64{},
+        statusMutex:      &sync.RWMutex{},
+    }
+    // the watchdog must not wait for the jury to be started.
+    go jury.listenForViability()
+    return jury, nil
+}
+
+// sets the current leader.
+func (jury *Jury) setLeader(leader *currentLeader) {
+    jury.statusMutex.Lock()
+    defer jury.statusMutex.Unlock()
+    jury.leader = leader
+}
+
+// gets the name of the node, which is currently leader. false is returned
+// as second value, if the leader is unknown.
+func (jury *Jury) GetLeader() (string, bool) {
+    jury.statusMutex.RLock()
+    defer jury.statusMutex.RUnlock()
+    if jury.leader == nil {
+        return "", false
+    }
+    return jury.leader.name, true
+}
+
+// gets the most recent health scores of the leader candidates, which is the
+// weighted drift from the maximum block height. the lower the score, the
+// healthier the node.
+func (jury *Jury) GetHealthScores() map[string]float64 {
+    jury.statusMutex.RLock()
+    defer jury.statusMutex.RUnlock()
+    health := make(map[string]float64, len(jury.health))
+    for name, score := range jury.health {
+        health[name] = score
+    }
+    return health
+}
+
+// sets the given health scores as the most recent ones.
+func (jury *Jury) setHealthScores(health map[string]*big.Float) {
+    jury.statusMutex.Lock()
+    defer jury.statusMutex.Unlock()
+    jury.health = make(map[string]float64, len(health))
+    for name, score := range health {
+        jury.health[name], _ = score.Float64()
+    }
 }
 
 // scans for the current leader among all the nodes,
@@ -112,12 +189,12 @@ func (jury *Jury) scanForLeader() *currentLeader {
                 if len(leaderIDs) > 1 {
                     otherLeaderIDs := leaderIDs[1:]
                     for i := range otherLeaderIDs {
-                        demoteLeader(node, otherLeaderIDs[i], 3)
+                        jury.demoteLeader(node, otherLeaderIDs[i], 3, "Multiple leaders registered at start up.")
                     }
                 }
             } else if len(leaderIDs) > 0 {
                 for i := range leaderIDs {
-                    demoteLeader(node, leaderIDs[i], 3)
+                    jury.demoteLeader(node, leaderIDs[i], 3, "Another node is already leader at start up.")
                 }
             }
         }
@@ -148,59 +225,109 @@ func (jury *Jury) Judge() {
     leader := jury.scanForLeader()
     if leader != nil {
         log.Infof("[LEADER JURY] Node %v is elected and has ID=%v", leader.name, leader.leaderID)
-        jury.leader = leader
+        jury.setLeader(leader)
     }
     // start sanity management
     go jury.startSanityChecks()
     go jury.turnOverHandling()
     // turn over preparation
+    var latestBlockStats map[string]api.NodeStatistic = nil
+    for ; ; {
+        select {
+        case latestBlockStats = <-jury.nodeStatsChannel:
+            jury.judge(latestBlockStats, mem)
+        case <-jury.viabilitySignal:
+            if latestBlockStats != nil && jury.lacksViableLeader() {
+                log.Info("[LEADER JURY] A candidate has become viable, while there is no viable leader.")
+                jury.elect(latestBlockStats, mem)
+            }
+        }
+    }
+}
+
+// a blocking call, which consumes the viability events of the watchdog and
+// signals them to the jury without blocking the watchdog.
+func (jury *Jury) listenForViability() {
     for ; ; {
-        latestBlockStats := <-jury.nodeStatsChannel
-        // check the leader schedule
-        currentSlotDate, _ := jury.settings.TimeSettings.GetSlotDateFor(time.Now())
-        schedule, found := jury.watchDog.GetScheduleFor(currentSlotDate.GetEpoch())
-        if !found || schedule == nil {
-            schedule = []api.LeaderAssignment{}
+        event := <-jury.viabilityChannel
+        log.Debugf("[LEADER JURY] Node %v has become viable in epoch %v.", event.Node, event.Epoch)
+        select {
+        case jury.viabilitySignal <- true:
+        default:
         }
-        // check health
-        viableNodeNames := jury.watchDog.GetViableLeaderNodes()
-        log.Infof("[LEADER JURY] Viable Nodes are [%v].", strings.Join(viableNodeNames, ","))
-        mem.addBlockHeights(latestBlockStats)
-        if len(viableNodeNames) > 0 {
-            maxConf, maxConfNodes := utils.MinFloat(mapWithViableLeaders(viableNodeNames, mem.computeHealth()))
-            log.Infof("[LEADER JURY] Nodes [%v] have lowest drift (%v).", strings.Join(maxConfNodes, ","), maxConf)
-            //_, bestLCNodes := utils.MaxFloat(mapUpTime(maxConfNodes, latestBlockStats))
-            bestLCNodes := maxConfNodes
-            log.Infof("[LEADER JURY] Nodes [%v] considered to be healthiest.", strings.Join(bestLCNodes, ","))
-            if bestLCNodes != nil && len(bestLCNodes) > 0 {
-                if jury.leader == nil || !containsLeader(bestLCNodes, jury.leader.name) {
-                    if len(bestLCNodes) > 0 {
-                        // no leader change if in exclusion zone.
-                        if len(schedule) > 0 {
-                            futureSchedule := api.FilterLeaderLogsBefore(time.Now().Add(-2*jury.settings.TimeSettings.SlotDuration), schedule)
-                            if len(futureSchedule) > 0 {
-                                timeToNextBlock := futureSchedule[0].ScheduleTime.Sub(time.Now())
-                                if timeToNextBlock < jury.settings.ExclusionZone {
-                                    log.Warnf("[LEADER JURY] In exclusion zone before scheduled block.")
-                                    continue
-                                }
+    }
+}
+
+// checks whether there is no leader, or the leader is not viable.
+func (jury *Jury) lacksViableLeader() bool {
+    leaderName, found := jury.GetLeader()
+    return !found || !containsLeader(jury.watchDog.GetViableLeaderNodes(), leaderName)
+}
+
+// judges the health of the nodes at the checkpoint with the given node statistics
+// and changes the leader, if the leader is not among the healthiest nodes.
+func (jury *Jury) judge(latestBlockStats map[string]api.NodeStatistic, mem *blockHeightMemory) {
+    mem.addBlockHeights(latestBlockStats)
+    jury.elect(latestBlockStats, mem)
+}
+
+// elects the healthiest viable candidate according to the checkpoints in the
+// given memory, if the leader is not among the healthiest nodes.
+func (jury *Jury) elect(latestBlockStats map[string]api.NodeStatistic, mem *blockHeightMemory) {
+    // check the leader schedule
+    currentSlotDate, _ := jury.settings.TimeSettings.GetSlotDateFor(jury.settings.Clock.Now())
+    schedule, found := jury.watchDog.GetScheduleFor(currentSlotDate.GetEpoch())
+    if !found || schedule == nil {
+        schedule = []api.LeaderAssignment{}
+    }
+    // check health
+    viableNodeNames := jury.watchDog.GetViableLeaderNodes()
+    log.Infof("[LEADER JURY] Viable Nodes are [%v].", strings.Join(viableNodeNames, ","))
+    health := mem.computeHealth()
+    jury.setHealthScores(health)
+    if jury.inShadowMode() {
+        jury.compareWithActualLeader()
+    }
+    if len(viableNodeNames) > 0 {
+        maxConf, maxConfNodes := utils.MinFloat(mapWithViableLeaders(viableNodeNames, health))
+        log.Infof("[LEADER JURY] Nodes [%v] have lowest drift (%v).", strings.Join(maxConfNodes, ","), maxConf)
+        //_, bestLCNodes := utils.MaxFloat(mapUpTime(maxConfNodes, latestBlockStats))
+        bestLCNodes := maxConfNodes
+        log.Infof("[LEADER JURY] Nodes [%v] considered to be healthiest.", strings.Join(bestLCNodes, ","))
+        if bestLCNodes != nil && len(bestLCNodes) > 0 {
+            if jury.leader == nil || !containsLeader(bestLCNodes, jury.leader.name) {
+                if len(bestLCNodes) > 0 {
+                    // no leader change if in exclusion zone.
+                    if len(schedule) > 0 {
+                        futureSchedule := api.FilterLeaderLogsBefore(jury.settings.Clock.Now().Add(-2*jury.settings.TimeSettings.SlotDuration), schedule)
+                        if len(futureSchedule) > 0 {
+                            timeToNextBlock := futureSchedule[0].ScheduleTime.Sub(jury.settings.Clock.Now())
+                            if timeToNextBlock < jury.settings.ExclusionZone {
+                                log.Warnf("[LEADER JURY] In exclusion zone before scheduled block.")
+                                return
                             }
                         }
-                        // no leader change in exclusion zone before epoch turn over.
-                        if new(big.Int).Sub(jury.settings.TimeSettings.SlotsPerEpoch,
-                            currentSlotDate.GetSlot()).Cmp(jury.settings.PreEpochTurnOverExclusionSlots) <= 0 {
-                            log.Warnf("[LEADER JURY] In exclusion zone before epoch turn over, no leader change will be performed.")
-                            continue
+                    }
+                    // no leader change in exclusion zone before epoch turn over.
+                    if new(big.Int).Sub(jury.settings.TimeSettings.SlotsPerEpoch,
+                        currentSlotDate.GetSlot()).Cmp(jury.settings.PreEpochTurnOverExclusionSlots) <= 0 {
+                        log.Warnf("[LEADER JURY] In exclusion zone before epoch turn over, no leader change will be performed.")
+                        return
+                    }
+                    // change leader to the first ready candidate.
+                    reason := fmt.Sprintf("Lowest drift (%v) among viable nodes [%v].", maxConf,
+                        strings.Join(bestLCNodes, ","))
+                    for _, candidate := range randomSort(bestLCNodes) {
+                        if jury.changeLeader(candidate, latestBlockStats, reason) {
+                            break
                         }
-                        // change leader.
-                        jury.changeLeader(randomSort(bestLCNodes)[0])
                     }
                 }
             }
         }
-        if jury.leader != nil {
-            log.Infof("[LEADER JURY] Current Leader is %v.", jury.leader.name)
-        }
+    }
+    if jury.leader != nil {
+        log.Infof("[LEADER JURY] Current Leader is %v.", jury.leader.name)
     }
 }
 
@@ -221,33 +348,54 @@ func randomSort(nodes []string) []string {
     return nodes
 }
 
-// changes the leader to the given name.
-func (jury *Jury) changeLeader(leaderName string) {
+// changes the leader to the given name. the candidate has to pass the readiness
+// check before it is promoted, and has to report the leader assignments of the
+// current epoch after the promotion. only then the old leader is demoted, otherwise
+// the promotion is rolled back. true is returned, if the leader has been changed.
+func (jury *Jury) changeLeader(leaderName string, latestBlockStats map[string]api.NodeStatistic, reason string) bool {
     jury.leaderMutex.Lock()
     defer jury.leaderMutex.Unlock()
 
     newLeaderNode := jury.nodes[leaderName]
-    leaderID, err := newLeaderNode.API.PostLeader(jury.cert)
+    err := jury.checkReadiness(newLeaderNode, latestBlockStats)
+    if err != nil {
+        log.Warnf("[LEADER JURY] %v", err.Error())
+        return false
+    }
+    leaderID, err := jury.postLeader(newLeaderNode, reason)
     if err == nil {
+        if !jury.confirmSchedule(newLeaderNode) {
+            log.Errorf("[LEADER JURY] Node %v has not computed the leader assignments after promotion. Rolling back.",
+                newLeaderNode.Name)
+            jury.record(newLeaderNode.Name, audit.Promotion, reason, audit.RolledBack, &leaderID)
+            jury.demoteLeader(newLeaderNode, leaderID, 3, "Rollback of promotion, leader assignments are missing.")
+            return false
+        }
+        jury.record(newLeaderNode.Name, audit.Promotion, reason, audit.Success, &leaderID)
         if jury.leader != nil {
-            go demoteLeader(jury.nodes[jury.leader.name], jury.leader.leaderID, 3)
+            go jury.demoteLeader(jury.nodes[jury.leader.name], jury.leader.leaderID, 3,
+                fmt.Sprintf("Node %v has been promoted.", newLeaderNode.Name))
         }
-        jury.leader = &currentLeader{name: newLeaderNode.Name, leaderID: leaderID}
+        jury.setLeader(&currentLeader{name: newLeaderNode.Name, leaderID: leaderID})
         log.Infof("[LEADER JURY] Node %v is elected and has ID=%v", newLeaderNode.Name, leaderID)
+        return true
     } else {
         log.Errorf("[LEADER JURY] Could not change to leader %v. %v", newLeaderNode.Name, err.Error())
+        jury.record(newLeaderNode.Name, audit.Promotion, reason, audit.Failure+": "+err.Error(), nil)
+        return false
     }
 }
 
 // tries at first in n attempts to demote the given leader node. if this fails,
-// then the leader node is shut down as a safety measure.
-func demoteLeader(node monitor.Node, ID uint64, attempts int) {
+// then the leader node is shut down as a safety measure. the demotion is recorded
+// with the given reason in the audit log.
+func (jury *Jury) demoteLeader(node monitor.Node, ID uint64, attempts int, reason string) {
     demoted := false
     for i := 0; i < attempts; i++ {
-        found, err := node.API.RemoveRegisteredLeader(ID)
+        found, err := jury.removeLeader(node, ID, reason)
         if err != nil {
             log.Warnf("[LEADER JURY] The leader node %v could not be demoted. Attempt: %v. %v. ", node.Name, i+1, err.Error())
-            time.Sleep(1 * time.Second)
+            jury.settings.Clock.Sleep(1 * time.Second)
         } else if !found {
             log.Warnf("[LEADER JURY] The node %v was not in leader mode.", node.Name)
             demoted = true
@@ -257,9 +405,12 @@ func demoteLeader(node monitor.Node, ID uint64, attempts int) {
             break
         }
     }
-    if !demoted {
+    if demoted {
+        jury.record(node.Name, audit.Demotion, reason, audit.Success, &ID)
+    } else {
         log.Warnf("[LEADER JURY] Could not demote %v. Now a shutdown will be tried.", node.Name)
-        monitor.ShutDownNode(node)
+        jury.record(node.Name, audit.Demotion, reason, audit.Failure, &ID)
+        jury.shutDown(node, monitor.DemotionFailedCause, fmt.Sprintf("Demotion failed after %v attempts.", attempts))
     }
 }
 
@@ -0,0 +1,261 @@
+package leader
+
+import (
+    "github.com/sobitada/go-cardano"
+    jor "github.com/sobitada/go-jormungandr/api"
+    "github.com/sobitada/thor/audit"
+    "github.com/sobitada/thor/jortest"
+    "github.com/sobitada/thor/monitor"
+    "github.com/sobitada/thor/storage"
+    "github.com/stretchr/testify/assert"
+    "math/big"
+    "testing"
+    "time"
+)
+
+// swarm of fake leader candidates with a leader jury judging them.
+type testSwarm struct {
+    fakes    map[string]*jortest.Node
+    nodes    []monitor.Node
+    watchDog *monitor.ScheduleWatchDog
+    jury     *Jury
+    auditLog *audit.Log
+    cleanUp  func()
+}
+
+// creates a swarm of fake leader candidates with the given names, which have all
+// computed the given schedule. the schedule watchdog is started, and the jury is
+// created, but not started.
+func newTestSwarm(t *testing.T, names []string, settings JurySettings,
+    schedule []jor.LeaderAssignment) *testSwarm {
+    db := storage.NewMemory()
+    swarm := &testSwarm{fakes: make(map[string]*jortest.Node), nodes: make([]monitor.Node, 0)}
+    for _, name := range names {
+        fake := jortest.NewNode()
+        fake.SetSchedule(schedule)
+        swarm.fakes[name] = fake
+        swarm.nodes = append(swarm.nodes, monitor.Node{Type: monitor.LeaderCandidate, Name: name,
+            API: fake.API(time.Second)})
+    }
+    swarm.cleanUp = func() {
+        for _, fake := range swarm.fakes {
+            fake.Close()
+        }
+        _ = db.Close()
+    }
+    swarm.auditLog, _ = audit.NewLog(db, settings.TimeSettings, settings.Clock)
+    scheduleSettings := monitor.DefaultScheduleSettings()
+    scheduleSettings.Clock = settings.Clock
+    swarm.watchDog = monitor.NewScheduleWatchDog(swarm.nodes, settings.TimeSettings, db, scheduleSettings)
+    mon := monitor.GetNodeMonitor(swarm.nodes, monitor.NodeMonitorBehaviour{Interval: time.Second}, nil,
+        swarm.watchDog, settings.TimeSettings, swarm.auditLog)
+    var err error
+    swarm.jury, err = GetLeaderJuryFor(swarm.nodes, mon, swarm.watchDog, swarm.auditLog, jor.LeaderCertificate{}, settings)
+    if err != nil {
+        t.Fatal(err)
+    }
+    // the schedule listener of the jury is usually drained by the sanity checks.
+    go func() {
+        for range swarm.jury.scheduleChannel {
+        }
+    }()
+    if len(schedule) > 0 {
+        go swarm.watchDog.Watch()
+        assert.Eventually(t, func() bool {
+            return len(swarm.watchDog.GetViableLeaderNodes()) > 0
+        }, 2*time.Second, 10*time.Millisecond)
+    }
+    return swarm
+}
+
+// fetches the node statistics of all fake nodes.
+func (swarm *testSwarm) stats() map[string]jor.NodeStatistic {
+    stats := make(map[string]jor.NodeStatistic)
+    for _, node := range swarm.nodes {
+        nodeStats, _, err := node.API.GetNodeStatistics()
+        if err == nil && nodeStats != nil {
+            stats[node.Name] = *nodeStats
+        }
+    }
+    return stats
+}
+
+func testJurySettings(timeSettings *cardano.TimeSettings) JurySettings {
+    return JurySettings{
+        Window:                         3,
+        ExclusionZone:                  30 * time.Second,
+        PreEpochTurnOverExclusionSlots: big.NewInt(10),
+        TimeSettings:                   timeSettings,
+        Readiness: ReadinessSettings{
+            MaxClockDrift:                5 * time.Second,
+            ScheduleConfirmationTimeout:  300 * time.Millisecond,
+            ScheduleConfirmationInterval: 50 * time.Millisecond,
+        },
+        Mode: Active,
+    }
+}
+
+func TestJury_LeaderLaggingBehind_mustBeReplacedByHealthyCandidate(t *testing.T) {
+    timeSettings := jortest.TimeSettingsAt(time.Now(), 5, 10, time.Second, 1000)
+    schedule := []jor.LeaderAssignment{jortest.Assignment(5, 500, *timeSettings)}
+    swarm := newTestSwarm(t, []string{"a", "b"}, testJurySettings(timeSettings), schedule)
+    defer swarm.cleanUp()
+    swarm.fakes["a"].SetTip(90, jortest.Hash(90), cardano.PlainSlotDateFromInt(5, 5))
+    swarm.fakes["b"].SetTip(100, jortest.Hash(100), cardano.PlainSlotDateFromInt(5, 9))
+    swarm.fakes["a"].RegisterLeader()
+    swarm.jury.leader = swarm.jury.scanForLeader()
+    mem := createBlockHeightMemory([]string{"a", "b"}, 3)
+    swarm.jury.judge(swarm.stats(), mem)
+    if assert.NotNil(t, swarm.jury.leader) {
+        assert.Equal(t, "b", swarm.jury.leader.name)
+    }
+    assert.Len(t, swarm.fakes["b"].Leaders(), 1)
+    assert.Eventually(t, func() bool { return len(swarm.fakes["a"].Leaders()) == 0 }, 2*time.Second, 10*time.Millisecond)
+    entries, err := swarm.auditLog.Query(audit.Filter{Action: audit.Promotion})
+    if assert.Nil(t, err) && assert.Len(t, entries, 1) {
+        assert.Equal(t, "b", entries[0].Node)
+        assert.Equal(t, audit.Success, entries[0].Outcome)
+    }
+}
+
+func TestJury_CandidateOnOtherFork_mustNotBePromoted(t *testing.T) {
+    timeSettings := jortest.TimeSettingsAt(time.Now(), 5, 10, time.Second, 1000)
+    schedule := []jor.LeaderAssignment{jortest.Assignment(5, 500, *timeSettings)}
+    swarm := newTestSwarm(t, []string{"a", "b", "c"}, testJurySettings(timeSettings), schedule)
+    defer swarm.cleanUp()
+    swarm.fakes["a"].SetTip(90, jortest.Hash(90), cardano.PlainSlotDateFromInt(5, 5))
+    swarm.fakes["b"].SetTip(100, jortest.Hash(100), cardano.PlainSlotDateFromInt(5, 9))
+    swarm.fakes["c"].SetTip(100, jortest.Hash(1000), cardano.PlainSlotDateFromInt(5, 9))
+    swarm.fakes["a"].RegisterLeader()
+    swarm.jury.leader = swarm.jury.scanForLeader()
+    mem := createBlockHeightMemory([]string{"a", "b", "c"}, 3)
+    swarm.jury.judge(swarm.stats(), mem)
+    // both b and c disagree with each other, and none of them is ready.
+    if assert.NotNil(t, swarm.jury.leader) {
+        assert.Equal(t, "a", swarm.jury.leader.name)
+    }
+    assert.Empty(t, swarm.fakes["b"].Leaders())
+    assert.Empty(t, swarm.fakes["c"].Leaders())
+}
+
+func TestJury_PromotedCandidateWithoutSchedule_mustBeRolledBack(t *testing.T) {
+    timeSettings := jortest.TimeSettingsAt(time.Now(), 5, 10, time.Second, 1000)
+    schedule := []jor.LeaderAssignment{jortest.Assignment(5, 500, *timeSettings)}
+    swarm := newTestSwarm(t, []string{"a", "b"}, testJurySettings(timeSettings), schedule)
+    defer swarm.cleanUp()
+    swarm.fakes["a"].SetTip(90, jortest.Hash(90), cardano.PlainSlotDateFromInt(5, 5))
+    swarm.fakes["b"].SetTip(100, jortest.Hash(100), cardano.PlainSlotDateFromInt(5, 9))
+    swarm.fakes["b"].SetSchedule(nil)
+    swarm.fakes["a"].RegisterLeader()
+    swarm.jury.leader = swarm.jury.scanForLeader()
+    mem := createBlockHeightMemory([]string{"a", "b"}, 3)
+    swarm.jury.judge(swarm.stats(), mem)
+    if assert.NotNil(t, swarm.jury.leader) {
+        assert.Equal(t, "a", swarm.jury.leader.name)
+    }
+    assert.Len(t, swarm.fakes["a"].Leaders(), 1)
+    assert.Empty(t, swarm.fakes["b"].Leaders())
+    entries, err := swarm.auditLog.Query(audit.Filter{Node: "b", Action: audit.Promotion})
+    if assert.Nil(t, err) && assert.Len(t, entries, 1) {
+        assert.Equal(t, audit.RolledBack, entries[0].Outcome)
+    }
+}
+
+func TestJury_ShadowMode_mustNotChangeNodes(t *testing.T) {
+    timeSettings := jortest.TimeSettingsAt(time.Now(), 5, 10, time.Second, 1000)
+    schedule := []jor.LeaderAssignment{jortest.Assignment(5, 500, *timeSettings)}
+    settings := testJurySettings(timeSettings)
+    settings.Mode = Shadow
+    swarm := newTestSwarm(t, []string{"a", "b"}, settings, schedule)
+    defer swarm.cleanUp()
+    swarm.fakes["a"].SetTip(90, jortest.Hash(90), cardano.PlainSlotDateFromInt(5, 5))
+    swarm.fakes["b"].SetTip(100, jortest.Hash(100), cardano.PlainSlotDateFromInt(5, 9))
+    swarm.fakes["a"].RegisterLeader()
+    swarm.jury.leader = swarm.jury.scanForLeader()
+    mem := createBlockHeightMemory([]string{"a", "b"}, 3)
+    swarm.jury.judge(swarm.stats(), mem)
+    swarm.jury.judge(swarm.stats(), mem)
+    assert.Len(t, swarm.fakes["a"].Leaders(), 1)
+    assert.Empty(t, swarm.fakes["b"].Leaders())
+    report := swarm.jury.GetShadowReport()
+    assert.Equal(t, "b", report.ShadowLeader)
+    assert.Equal(t, "a", report.ActualLeader)
+    assert.Equal(t, uint64(2), report.Checkpoints)
+    if assert.NotEmpty(t, report.Decisions) {
+        assert.Equal(t, "b", report.Decisions[0].Node)
+        assert.Equal(t, audit.Promotion, report.Decisions[0].Action)
+    }
+}
+
+func TestJury_TurnOver_mustPromoteAllCandidatesAndDemoteAfterwards(t *testing.T) {
+    clock := jortest.NewClock(time.Now())
+    timeSettings := jortest.TimeSettingsAt(clock.Now(), 5, 20, time.Second, 1000)
+    settings := testJurySettings(timeSettings)
+    settings.Clock = clock
+    swarm := newTestSwarm(t, []string{"a", "b", "c"}, settings, nil)
+    defer swarm.cleanUp()
+    swarm.fakes["a"].RegisterLeader()
+    swarm.jury.leader = swarm.jury.scanForLeader()
+    done := make(chan bool)
+    go func() {
+        swarm.jury.handleTurnOver()
+        done <- true
+    }()
+    // fast forward to the promotion ten slots before the turn over.
+    assert.True(t, clock.WaitForTimers(1, 2*time.Second))
+    clock.AdvanceToSlot(*timeSettings, 5, 990)
+    assert.Eventually(t, func() bool {
+        return len(swarm.fakes["b"].Leaders()) == 1 && len(swarm.fakes["c"].Leaders()) == 1
+    }, 2*time.Second, 10*time.Millisecond)
+    // fast forward across the turn over to the sanity check.
+    assert.True(t, clock.WaitForTimers(1, 2*time.Second))
+    clock.AdvanceToSlot(*timeSettings, 6, 3)
+    select {
+    case <-done:
+    case <-time.After(2 * time.Second):
+        t.Fatal("the turn over has not been handled.")
+    }
+    assert.Len(t, swarm.fakes["a"].Leaders(), 1)
+    assert.Empty(t, swarm.fakes["b"].Leaders())
+    assert.Empty(t, swarm.fakes["c"].Leaders())
+    for _, name := range []string{"b", "c"} {
+        promotions, _ := swarm.auditLog.Query(audit.Filter{Node: name, Action: audit.Promotion})
+        demotions, _ := swarm.auditLog.Query(audit.Filter{Node: name, Action: audit.Demotion})
+        assert.Len(t, promotions, 1)
+        assert.Len(t, demotions, 1)
+    }
+}
+
+func TestJury_PromotedCandidateWithOtherSlots_mustBeRolledBack(t *testing.T) {
+    timeSettings := jortest.TimeSettingsAt(time.Now(), 5, 10, time.Second, 1000)
+    schedule := []jor.LeaderAssignment{jortest.Assignment(5, 500, *timeSettings)}
+    swarm := newTestSwarm(t, []string{"a", "b"}, testJurySettings(timeSettings), schedule)
+    defer swarm.cleanUp()
+    swarm.fakes["a"].SetTip(90, jortest.Hash(90), cardano.PlainSlotDateFromInt(5, 5))
+    swarm.fakes["b"].SetTip(100, jortest.Hash(100), cardano.PlainSlotDateFromInt(5, 9))
+    swarm.fakes["b"].SetSchedule([]jor.LeaderAssignment{jortest.Assignment(5, 600, *timeSettings)})
+    swarm.fakes["a"].RegisterLeader()
+    swarm.jury.leader = swarm.jury.scanForLeader()
+    mem := createBlockHeightMemory([]string{"a", "b"}, 3)
+    swarm.jury.judge(swarm.stats(), mem)
+    if assert.NotNil(t, swarm.jury.leader) {
+        assert.Equal(t, "a", swarm.jury.leader.name)
+    }
+    assert.Len(t, swarm.fakes["a"].Leaders(), 1)
+    assert.Empty(t, swarm.fakes["b"].Leaders())
+    entries, err := swarm.auditLog.Query(audit.Filter{Node: "b", Action: audit.Promotion})
+    if assert.Nil(t, err) && assert.Len(t, entries, 1) {
+        assert.Equal(t, audit.RolledBack, entries[0].Outcome)
+    }
+}
+
+func TestJury_LacksViableLeader_mustBeTrueWithoutLeader(t *testing.T) {
+    timeSettings := jortest.TimeSettingsAt(time.Now(), 5, 10, time.Second, 1000)
+    schedule := []jor.LeaderAssignment{jortest.Assignment(5, 500, *timeSettings)}
+    swarm := newTestSwarm(t, []string{"a", "b"}, testJurySettings(timeSettings), schedule)
+    defer swarm.cleanUp()
+    assert.True(t, swarm.jury.lacksViableLeader())
+    swarm.fakes["a"].RegisterLeader()
+    swarm.jury.leader = swarm.jury.scanForLeader()
+    assert.False(t, swarm.jury.lacksViableLeader())
+}
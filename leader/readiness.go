@@ -0,0 +1,141 @@
+package leader
+
+import (
+    "fmt"
+    log "github.com/sirupsen/logrus"
+    "github.com/sobitada/go-cardano"
+    "github.com/sobitada/go-jormungandr/api"
+    "github.com/sobitada/thor/monitor"
+    "github.com/sobitada/thor/utils"
+    "math/big"
+    "time"
+)
+
+// settings for the readiness check of a leader candidate, which
+// is performed before a candidate is promoted to leader.
+type ReadinessSettings struct {
+    // the number of blocks a candidate is allowed to lag
+    // behind the maximum block height of the swarm.
+    MaxBlockLag uint64
+    // the maximum tolerated drift between the clock of a
+    // candidate and the clock of this program.
+    MaxClockDrift time.Duration
+    // the time to wait for the promoted candidate to report
+    // the leader assignments of the current epoch.
+    ScheduleConfirmationTimeout time.Duration
+    // interval in which the promoted candidate is polled for
+    // its leader assignments.
+    ScheduleConfirmationInterval time.Duration
+}
+
+type notReady struct {
+    Node   string
+    Reason string
+}
+
+func (error notReady) Error() string {
+    return fmt.Sprintf("Node %v is not ready for promotion. %v", error.Node, error.Reason)
+}
+
+// checks whether the given candidate is ready to be promoted to leader. A
+// candidate is ready, if it is at the tip of the swarm, agrees with the hash of
+// the most recent block, has computed the correct schedule for the current epoch
+// and if its clock is sane. nil is returned for a ready node, otherwise an error
+// with the reason.
+func (jury *Jury) checkReadiness(node monitor.Node, latestBlockStats map[string]api.NodeStatistic) error {
+    stats, found := latestBlockStats[node.Name]
+    if !found || stats.LastBlockHeight == nil {
+        return notReady{Node: node.Name, Reason: "No recent node statistics are available."}
+    }
+    // height against swarm maximum.
+    blockHeightMap := make(map[string]*big.Int)
+    for name, nodeStats := range latestBlockStats {
+        if nodeStats.LastBlockHeight != nil {
+            blockHeightMap[name] = nodeStats.LastBlockHeight
+        }
+    }
+    maxHeight, maxHeightNodes := utils.MaxInt(blockHeightMap)
+    lag := new(big.Int).Sub(maxHeight, stats.LastBlockHeight)
+    if lag.Cmp(new(big.Int).SetUint64(jury.settings.Readiness.MaxBlockLag)) > 0 {
+        return notReady{Node: node.Name, Reason: fmt.Sprintf("It lags %v blocks behind the swarm.", lag.String())}
+    }
+    // hash of the most recent block must be the one of the swarm.
+    if stats.LastBlockHeight.Cmp(maxHeight) == 0 {
+        for _, name := range maxHeightNodes {
+            otherHash := latestBlockStats[name].LastBlockHash
+            if otherHash != stats.LastBlockHash {
+                return notReady{Node: node.Name, Reason: fmt.Sprintf("Its last block hash %v differs from the one of %v (%v).",
+                    shortHash(stats.LastBlockHash), name, shortHash(otherHash))}
+            }
+        }
+    }
+    // schedule must have been computed correctly.
+    if !containsLeader(jury.watchDog.GetViableLeaderNodes(), node.Name) {
+        return notReady{Node: node.Name, Reason: "It has not been declared viable by the schedule watchdog."}
+    }
+    // clock must be sane.
+    return jury.checkClock(node, stats)
+}
+
+// checks the clock of the given node by looking at the time at which the node
+// claims to have received the most recent block. This time must neither lie
+// before the start of the slot of this block, nor in the future.
+func (jury *Jury) checkClock(node monitor.Node, stats api.NodeStatistic) error {
+    if stats.LastBlockDate == nil || stats.LastBlockTime.IsZero() || jury.settings.Readiness.MaxClockDrift <= 0 {
+        return nil
+    }
+    slotStart := cardano.MakeFullSlotDate(stats.LastBlockDate, *jury.settings.TimeSettings).GetStartDateTime()
+    if stats.LastBlockTime.Before(slotStart.Add(-jury.settings.Readiness.MaxClockDrift)) {
+        return notReady{Node: node.Name, Reason: fmt.Sprintf("Its clock is behind, block of slot %v received %v before the slot started.",
+            stats.LastBlockDate.String(), utils.GetHumanReadableUpTime(slotStart.Sub(stats.LastBlockTime)))}
+    }
+    if stats.LastBlockTime.After(jury.settings.Clock.Now().Add(jury.settings.Readiness.MaxClockDrift)) {
+        return notReady{Node: node.Name, Reason: fmt.Sprintf("Its clock is ahead, block has been received %v in the future.",
+            utils.GetHumanReadableUpTime(stats.LastBlockTime.Sub(jury.settings.Clock.Now())))}
+    }
+    return nil
+}
+
+// polls the given promoted node until it reports the leader assignments of the
+// current epoch that are still ahead, i.e. exactly the expected slots. true is
+// returned, if the assignments are present before the timeout, otherwise false.
+// in shadow mode, the node has not been promoted and the schedule is assumed
+// to be confirmed.
+func (jury *Jury) confirmSchedule(node monitor.Node) bool {
+    if jury.inShadowMode() {
+        return true
+    }
+    currentSlotDate, _ := jury.settings.TimeSettings.GetSlotDateFor(jury.settings.Clock.Now())
+    schedule, found := jury.watchDog.GetScheduleFor(currentSlotDate.GetEpoch())
+    if !found || len(api.FilterLeaderLogsBefore(jury.settings.Clock.Now(), schedule)) == 0 {
+        return true
+    }
+    deadline := jury.settings.Clock.Now().Add(jury.settings.Readiness.ScheduleConfirmationTimeout)
+    for ; ; {
+        nodeSchedule, err := node.API.GetLeadersSchedule()
+        if err == nil {
+            testTime := jury.settings.Clock.Now()
+            expected := api.FilterLeaderLogsBefore(testTime, schedule)
+            actual := api.FilterLeaderLogsBefore(testTime, api.GetLeaderLogsInEpoch(currentSlotDate.GetEpoch(), nodeSchedule))
+            difference := monitor.CompareSchedules(expected, actual)
+            if len(expected) == 0 || difference.IsEmpty() {
+                return true
+            }
+            log.Debugf("[LEADER JURY][%v] Reports a different schedule ahead, %v.", node.Name, difference.String())
+        } else {
+            log.Warnf("[LEADER JURY][%v] Could not fetch the leader schedule. %v", node.Name, err.Error())
+        }
+        if jury.settings.Clock.Now().Add(jury.settings.Readiness.ScheduleConfirmationInterval).After(deadline) {
+            return false
+        }
+        jury.settings.Clock.Sleep(jury.settings.Readiness.ScheduleConfirmationInterval)
+    }
+}
+
+// returns the first eight characters of the given hash.
+func shortHash(hash string) string {
+    if len(hash) > 8 {
+        return hash[:8]
+    }
+    return hash
+}
@@ -3,6 +3,7 @@ package leader
 import (
     log "github.com/sirupsen/logrus"
     "github.com/sobitada/go-jormungandr/api"
+    "github.com/sobitada/thor/audit"
     "github.com/sobitada/thor/monitor"
     "github.com/sobitada/thor/threading"
     "github.com/sobitada/thor/utils"
@@ -45,21 +46,21 @@ func performSanityCheck(input interface{}) threading.Response {
 func (jury *Jury) startSanityChecks() {
     for ; ; {
         assignments := <-jury.scheduleChannel
-        currentSlotDate, err := jury.settings.TimeSettings.GetSlotDateFor(time.Now())
+        currentSlotDate, err := jury.settings.TimeSettings.GetSlotDateFor(jury.settings.Clock.Now())
         if err != nil {
             log.Fatalf("[LEADER JURY][SANITY CHECK] Loop panicked: %v", err.Error())
-            time.Sleep(30 * time.Minute)
+            jury.settings.Clock.Sleep(30 * time.Minute)
             continue
         }
-        nextAssignments := api.FilterLeaderLogsBefore(time.Now().Add(2*time.Minute),
+        nextAssignments := api.FilterLeaderLogsBefore(jury.settings.Clock.Now().Add(2*time.Minute),
             api.SortLeaderLogsByScheduleTime(api.GetLeaderLogsInEpoch(currentSlotDate.GetEpoch(), assignments)))
         log.Debugf("[LEADER JURY][SANITY CHECK] Started sanity check for %v assignments ahead. ", len(nextAssignments))
         for i := 0; i < len(nextAssignments); i++ {
-            waitDuration := nextAssignments[i].ScheduleTime.Sub(time.Now()) - 1*time.Minute
+            waitDuration := nextAssignments[i].ScheduleTime.Sub(jury.settings.Clock.Now()) - 1*time.Minute
             if waitDuration > 0 { // no sanity check between slots that are too close to each other.
                 log.Infof("[LEADER JURY][SANITY CHECK] Waiting %v for the next sanity check.",
                     utils.GetHumanReadableUpTime(waitDuration))
-                time.Sleep(waitDuration)
+                jury.settings.Clock.Sleep(waitDuration)
                 log.Infof("[LEADER JURY][SANITY CHECK] Check for assignment %v.", nextAssignments[i].ScheduleTime)
                 // do sanity checking
                 jury.leaderMutex.Lock()
@@ -77,7 +78,7 @@ func (jury *Jury) startSanityChecks() {
                 jury.leaderMutex.Unlock()
             }
         }
-        time.Sleep(1 * time.Minute)
+        jury.settings.Clock.Sleep(1 * time.Minute)
     }
 }
 
@@ -102,7 +103,7 @@ func (jury *Jury) sanityCheckPassiveNode(node monitor.Node) {
         if len(leaderIDs) > 0 {
             log.Warnf("[LEADER JURY][SANITY CHECK][%v] In leader mode while jury promoted other node.", node.Name)
             for i := range leaderIDs {
-                demoteLeader(node, leaderIDs[i], 3)
+                jury.demoteLeader(node, leaderIDs[i], 3, "Sanity check, in leader mode while jury promoted other node.")
             }
         } else {
             log.Infof("[LEADER JURY][SANITY CHECK][%v] OK.", node.Name)
@@ -119,13 +120,16 @@ func (jury *Jury) sanityCheckLeaderNode(node monitor.Node) {
         leaderIDNumber := len(leaderIDs)
         if leaderIDNumber == 0 {
             log.Warnf("[LEADER JURY][SANITY CHECK][%v] Is not promoted to leader node as expected.", node.Name)
-            leaderID, err := node.API.PostLeader(jury.cert)
+            reason := "Sanity check, leader was not promoted as expected."
+            leaderID, err := jury.postLeader(node, reason)
             if err == nil {
-                jury.leader = &currentLeader{name: node.Name, leaderID: leaderID}
+                jury.record(node.Name, audit.Promotion, reason, audit.Success, &leaderID)
+                jury.setLeader(&currentLeader{name: node.Name, leaderID: leaderID})
                 log.Infof("[LEADER JURY] Node %v is elected and has ID=%v", node.Name, leaderID)
                 log.Infof("[LEADER JURY][SANITY CHECK][%v] OK.", node.Name)
             } else {
                 log.Errorf("[LEADER JURY][SANITY CHECK][%v] Could not change to leader. %v", node.Name, err.Error())
+                jury.record(node.Name, audit.Promotion, reason, audit.Failure+": "+err.Error(), nil)
             }
         } else if leaderIDNumber == 1 {
             log.Infof("[LEADER JURY][SANITY CHECK][%v] OK.", node.Name)
@@ -133,7 +137,7 @@ func (jury *Jury) sanityCheckLeaderNode(node monitor.Node) {
             log.Warnf("[LEADER JURY][SANITY CHECK][%v] Has more than one leader registered (%v).", node.Name, leaderIDNumber)
             for i := range leaderIDs {
                 if leaderIDs[i] != jury.leader.leaderID {
-                    demoteLeader(node, leaderIDs[i], 3)
+                    jury.demoteLeader(node, leaderIDs[i], 3, "Sanity check, more than one leader registered.")
                 }
             }
         }
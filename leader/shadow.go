@@ -0,0 +1,188 @@
+package leader
+
+import (
+    "encoding/json"
+    log "github.com/sirupsen/logrus"
+    "github.com/sobitada/thor/audit"
+    "github.com/sobitada/thor/monitor"
+    "net/http"
+    "sync"
+    "time"
+)
+
+// mode in which the leader jury is operating.
+type Mode string
+
+const (
+    // decisions of the jury are executed on the nodes.
+    Active Mode = "active"
+    // decisions of the jury are only logged and recorded,
+    // but never executed on the nodes.
+    Shadow Mode = "shadow"
+)
+
+// the maximum number of decisions kept in the shadow report.
+const shadowReportDecisions int = 100
+
+// a decision taken by the jury in shadow mode.
+type ShadowDecision struct {
+    Time         time.Time    `json:"time"`
+    Node         string       `json:"node"`
+    Action       audit.Action `json:"action"`
+    Reason       string       `json:"reason"`
+    ActualLeader string       `json:"actualLeader"`
+}
+
+// report comparing the decisions of the jury in shadow mode with
+// the leader that has actually been observed on the nodes.
+type ShadowReport struct {
+    Since time.Time `json:"since"`
+    // number of checkpoints judged by the jury.
+    Checkpoints uint64 `json:"checkpoints"`
+    // number of checkpoints at which the shadow leader was
+    // the actual leader.
+    AgreedCheckpoints uint64 `json:"agreedCheckpoints"`
+    // number of leader changes the jury would have taken.
+    ShadowLeaderChanges uint64 `json:"shadowLeaderChanges"`
+    // number of leader changes that have been observed.
+    ActualLeaderChanges uint64           `json:"actualLeaderChanges"`
+    ShadowLeader        string           `json:"shadowLeader"`
+    ActualLeader        string           `json:"actualLeader"`
+    Decisions           []ShadowDecision `json:"decisions"`
+}
+
+type shadowState struct {
+    report ShadowReport
+    mutex  *sync.Mutex
+}
+
+func newShadowState(since time.Time) *shadowState {
+    return &shadowState{
+        report: ShadowReport{Since: since, Decisions: []ShadowDecision{}},
+        mutex:  &sync.Mutex{},
+    }
+}
+
+// checks whether the jury is operating in shadow mode.
+func (jury *Jury) inShadowMode() bool {
+    return jury.settings.Mode == Shadow
+}
+
+// promotes the given node to leader, or only pretends to do so in shadow mode.
+func (jury *Jury) postLeader(node monitor.Node, reason string) (uint64, error) {
+    if jury.inShadowMode() {
+        jury.recordShadowDecision(node.Name, audit.Promotion, reason)
+        return 0, nil
+    }
+    return node.API.PostLeader(jury.cert)
+}
+
+// removes the leader with the given ID from the given node, or only pretends to
+// do so in shadow mode.
+func (jury *Jury) removeLeader(node monitor.Node, ID uint64, reason string) (bool, error) {
+    if jury.inShadowMode() {
+        jury.recordShadowDecision(node.Name, audit.Demotion, reason)
+        return true, nil
+    }
+    return node.API.RemoveRegisteredLeader(ID)
+}
+
+// shuts down the given node, or only pretends to do so in shadow mode.
+func (jury *Jury) shutDown(node monitor.Node, cause string, reason string) {
+    if jury.inShadowMode() {
+        jury.recordShadowDecision(node.Name, audit.Shutdown, reason)
+        return
+    }
+    monitor.ShutDownAndRecord(node, jury.auditLog, cause, reason)
+}
+
+// records the given action in the audit log. in shadow mode, nothing is
+// recorded, because the decisions are already recorded as shadow decisions.
+func (jury *Jury) record(node string, action audit.Action, reason string, outcome string, leaderID *uint64) {
+    if jury.inShadowMode() {
+        return
+    }
+    jury.auditLog.Record(node, action, reason, outcome, leaderID)
+}
+
+// logs and records the given decision of the jury in shadow mode.
+func (jury *Jury) recordShadowDecision(node string, action audit.Action, reason string) {
+    log.Infof("[LEADER JURY][SHADOW] Would perform %v of node %v. %v", action, node, reason)
+    jury.auditLog.Record(node, action, reason, audit.Shadow, nil)
+    jury.shadow.mutex.Lock()
+    defer jury.shadow.mutex.Unlock()
+    decision := ShadowDecision{
+        Time:         jury.settings.Clock.Now(),
+        Node:         node,
+        Action:       action,
+        Reason:       reason,
+        ActualLeader: jury.shadow.report.ActualLeader,
+    }
+    decisions := append(jury.shadow.report.Decisions, decision)
+    if len(decisions) > shadowReportDecisions {
+        decisions = decisions[len(decisions)-shadowReportDecisions:]
+    }
+    jury.shadow.report.Decisions = decisions
+}
+
+// observes the leader among the nodes, i.e. the first node that has
+// a registered leader. an empty string is returned, if there is none.
+func (jury *Jury) observeActualLeader() string {
+    for name, node := range jury.nodes {
+        leaderIDs, err := node.API.GetRegisteredLeaders()
+        if err == nil && len(leaderIDs) > 0 {
+            return name
+        }
+    }
+    return ""
+}
+
+// compares the leader of the jury with the actual leader at a checkpoint
+// and updates the shadow report accordingly.
+func (jury *Jury) compareWithActualLeader() {
+    actualLeader := jury.observeActualLeader()
+    shadowLeader := ""
+    jury.leaderMutex.Lock()
+    if jury.leader != nil {
+        shadowLeader = jury.leader.name
+    }
+    jury.leaderMutex.Unlock()
+    jury.shadow.mutex.Lock()
+    defer jury.shadow.mutex.Unlock()
+    report := &jury.shadow.report
+    if report.Checkpoints > 0 {
+        if report.ActualLeader != actualLeader {
+            report.ActualLeaderChanges++
+        }
+        if report.ShadowLeader != shadowLeader {
+            report.ShadowLeaderChanges++
+        }
+    }
+    report.Checkpoints++
+    if shadowLeader == actualLeader {
+        report.AgreedCheckpoints++
+    } else {
+        log.Infof("[LEADER JURY][SHADOW] Shadow leader is %v, but actual leader is %v.", shadowLeader, actualLeader)
+    }
+    report.ShadowLeader = shadowLeader
+    report.ActualLeader = actualLeader
+}
+
+// gets the report comparing the shadow decisions with the actual leader.
+func (jury *Jury) GetShadowReport() ShadowReport {
+    jury.shadow.mutex.Lock()
+    defer jury.shadow.mutex.Unlock()
+    report := jury.shadow.report
+    report.Decisions = append([]ShadowDecision{}, report.Decisions...)
+    return report
+}
+
+// serves the shadow report as JSON.
+func (jury *Jury) ServeShadowReport(writer http.ResponseWriter, request *http.Request) {
+    if !jury.inShadowMode() {
+        http.Error(writer, "The leader jury is not operating in shadow mode.", http.StatusNotFound)
+        return
+    }
+    writer.Header().Set("Content-Type", "application/json")
+    _ = json.NewEncoder(writer).Encode(jury.GetShadowReport())
+}
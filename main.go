@@ -3,15 +3,23 @@ package main
 import (
     "flag"
     "fmt"
-    "github.com/boltdb/bolt"
     log "github.com/sirupsen/logrus"
+    "github.com/sobitada/thor/alert"
+    "github.com/sobitada/thor/audit"
+    "github.com/sobitada/thor/blocks"
+    "github.com/sobitada/thor/clock"
     "github.com/sobitada/thor/config"
+    "github.com/sobitada/thor/history"
     "github.com/sobitada/thor/leader"
+    "github.com/sobitada/thor/maintenance"
+    "github.com/sobitada/thor/metrics"
     "github.com/sobitada/thor/monitor"
-    "gopkg.in/yaml.v2"
-    "io/ioutil"
+    "github.com/sobitada/thor/pooltool"
+    "github.com/sobitada/thor/prometheus"
+    "github.com/sobitada/thor/report"
+    "github.com/sobitada/thor/storage"
+    "net/http"
     "os"
-    "path"
 )
 
 const ApplicationName string = "thor"
@@ -19,12 +27,16 @@ const ApplicationVersion string = "0.3.0-b1"
 
 func printUsage() {
     fmt.Printf(`Usage:
-  %v <config> or %v [-help | -version]
+  %v <config> or %v <command> [options] <config> or %v [-help | -version]
 
 Arguments:
   <config>
         YAML configuration for this thor instance.
-`, ApplicationName, ApplicationName)
+
+Commands:
+`, ApplicationName, ApplicationName, ApplicationName)
+    printCommands()
+    fmt.Println()
     flag.PrintDefaults()
 }
 
@@ -52,86 +64,229 @@ func main() {
         printVersion()
     } else {
         args := flag.Args()
-        if len(args) == 1 {
+        if len(args) > 0 && isCommand(args[0]) {
+            runCommand(args[0], args[1:])
+        } else if len(args) == 1 {
             printProlog()
-            data, err := ioutil.ReadFile(args[0])
+            conf, err := readConfig(args[0])
             if err == nil {
-                var conf config.General
-                err = yaml.UnmarshalStrict(data, &conf)
+                setLoggingConfiguration(conf)
+                nodes, err := config.GetNodesFromConfig(conf)
                 if err == nil {
-                    setLoggingConfiguration(conf)
-                    nodes, err := config.GetNodesFromConfig(conf)
-                    if err == nil {
-                        if len(nodes) > 0 {
-                            dataDirPath := os.Getenv("THOR_DATA_DIR")
-                            if len(dataDirPath) == 0 {
-                                dataDirPath = "data"
-                            }
-                            dataDirPath = path.Join(dataDirPath, "thor.db")
-                            db, err := bolt.Open(dataDirPath, 0600, nil)
+                    if len(nodes) > 0 {
+                        storeSettings, err := config.GetStoreSettings(conf)
+                        if err != nil {
+                            log.Fatal(err)
+                        }
+                        store, err := storage.Open(storeSettings)
+                        if err != nil {
+                            log.Fatal(err)
+                        }
+                        defer store.Close()
+                        timeSettings, err := config.GetTimeSettings(*conf.Blockchain)
+                        if err != nil {
+                            log.Warnf("Could not parse the time settings of blockchain. %v", err.Error())
+                        }
+                        // try to establish the retention of old data.
+                        var retention *storage.Retention = nil
+                        retentionSettings := config.GetRetentionSettings(conf)
+                        if timeSettings != nil && retentionSettings.Epochs > 0 {
+                            retentionSettings.Clock = clock.Real()
+                            retention = storage.NewRetention(store, timeSettings, retentionSettings)
+                        }
+                        // try to establish the status API.
+                        statusServer, err := config.ParseStatusConfig(conf)
+                        if err != nil {
+                            log.Warnf("The status API could not be started. %v", err.Error())
+                        }
+                        // establish the audit log.
+                        auditLog, err := audit.NewLog(store, timeSettings, clock.Real())
+                        if err != nil {
+                            log.Fatal(err)
+                        }
+                        if statusServer != nil {
+                            statusServer.Handle("/audit", auditLog)
+                        }
+                        // try to establish a schedule watchdog.
+                        var watchdog *monitor.ScheduleWatchDog = nil
+                        if timeSettings != nil {
+                            scheduleSettings, err := config.GetScheduleSettings(conf)
                             if err != nil {
                                 log.Fatal(err)
                             }
-                            defer db.Close()
-                            timeSettings, err := config.GetTimeSettings(*conf.Blockchain)
+                            scheduleSettings.Clock = clock.Real()
+                            watchdog = monitor.NewScheduleWatchDog(nodes, timeSettings, store, scheduleSettings)
+                        } else {
+                            log.Warnf("You have to set the time settings for the block chain for schedule watchdog.")
+                        }
+                        // try to establish the monitor.
+                        behaviour, err := config.GetNodeMonitorBehaviour(conf)
+                        if err != nil {
+                            log.Fatal(err)
+                        }
+                        nodeMonitor := monitor.GetNodeMonitor(nodes, behaviour, parseActions(), watchdog,
+                            timeSettings, auditLog)
+                        // establish the history of the node statistics.
+                        historySettings, err := config.GetHistorySettings(conf)
+                        if err != nil {
+                            log.Fatal(err)
+                        }
+                        historySettings.Clock = clock.Real()
+                        nodeHistory, err := history.NewHistory(store, nodeMonitor, historySettings)
+                        if err != nil {
+                            log.Fatal(err)
+                        }
+                        if statusServer != nil {
+                            statusServer.Handle("/history", nodeHistory)
+                        }
+                        // try to establish the tracker of block outcomes.
+                        var tracker *blocks.Tracker = nil
+                        if watchdog != nil {
+                            trackerSettings := config.GetBlockTrackerSettings(conf)
+                            trackerSettings.Clock = clock.Real()
+                            tracker, err = blocks.NewTracker(nodes, watchdog, store, timeSettings, trackerSettings)
                             if err != nil {
-                                log.Warnf("Could not parse the time settings of blockchain. %v", err.Error())
+                                log.Errorf("The tracker of block outcomes could not be started. %v", err.Error())
                             }
-                            // try to establish a schedule watchdog.
-                            var watchdog *monitor.ScheduleWatchDog = nil
-                            if timeSettings != nil {
-                                watchdog = monitor.NewScheduleWatchDog(nodes, timeSettings, db)
-                            } else {
-                                log.Warnf("You have to set the time settings for the block chain for schedule watchdog.")
+                        }
+                        // try to establish the epoch reports.
+                        var reporter *report.Reporter = nil
+                        if timeSettings != nil {
+                            reportSettings, err := config.GetReportSettings(conf)
+                            if err != nil {
+                                log.Fatal(err)
                             }
-                            // try to establish the monitor.
-                            nodeMonitor := monitor.GetNodeMonitor(nodes, config.GetNodeMonitorBehaviour(conf),
-                                parseActions(), watchdog, timeSettings)
-                            // try to establish the pool tool updater.
-                            poolTool, err := config.ParsePoolToolConfig(nodeMonitor, watchdog, timeSettings, db, conf)
+                            reportSettings.Clock = clock.Real()
+                            reporter, err = report.NewReporter(store, auditLog, watchdog, tracker, timeSettings, reportSettings)
                             if err != nil {
-                                log.Warnf("The pool tool update could not be started. %v", err.Error())
+                                log.Errorf("The epoch reports could not be started. %v", err.Error())
                             }
-                            // try to establish the prometheus client
-                            prometheus, err := config.ParsePrometheusConfig(nodeMonitor, conf)
+                        }
+                        // try to establish the leader jurry.
+                        var leaderJurry *leader.Jury = nil
+                        if timeSettings != nil {
+                            leaderJurry, err = config.GetLeaderJury(nodes, nodeMonitor, watchdog, auditLog, timeSettings, conf)
                             if err != nil {
-                                log.Warnf("The Prometheus client could not be started. %v", err.Error())
+                                log.Errorf("Leader jury was not configured correctly. %v", err.Error())
                             }
-                            // try to establish the leader jurry.
-                            var leaderJurry *leader.Jury = nil
-                            if timeSettings != nil {
-                                leaderJurry, err = config.GetLeaderJury(nodes, nodeMonitor, watchdog, timeSettings, conf)
-                                if err != nil {
-                                    log.Errorf("Leader jury was not configured correctly. %v", err.Error())
-                                }
-                            } else {
-                                log.Warnf("You have to set the time settings for the block chain for leader jury.")
+                        } else {
+                            log.Warnf("You have to set the time settings for the block chain for leader jury.")
+                        }
+                        // try to establish the pool tool updater.
+                        poolTool, err := config.ParsePoolToolConfig(pooltool.Sources{
+                            Monitor:      nodeMonitor,
+                            WatchDog:     watchdog,
+                            Tracker:      tracker,
+                            Jury:         leaderJurry,
+                            TimeSettings: timeSettings,
+                        }, store, conf)
+                        if err != nil {
+                            log.Warnf("The pool tool update could not be started. %v", err.Error())
+                        }
+                        // try to establish the prometheus client
+                        prometheusClient, err := config.ParsePrometheusConfig(prometheus.Sources{
+                            Monitor:      nodeMonitor,
+                            Tracker:      tracker,
+                            WatchDog:     watchdog,
+                            Jury:         leaderJurry,
+                            AuditLog:     auditLog,
+                            TimeSettings: timeSettings,
+                        }, conf)
+                        if err != nil {
+                            log.Warnf("The Prometheus client could not be started. %v", err.Error())
+                        }
+                        // try to establish the exporter of the metrics
+                        metricsExporter, err := config.ParseMetricsConfig(metrics.Sources{
+                            Monitor:      nodeMonitor,
+                            Tracker:      tracker,
+                            WatchDog:     watchdog,
+                            Jury:         leaderJurry,
+                            AuditLog:     auditLog,
+                            TimeSettings: timeSettings,
+                        }, conf)
+                        if err != nil {
+                            log.Warnf("The exporter of the metrics could not be started. %v", err.Error())
+                        }
+                        // try to establish the alert engine.
+                        var alertEngine *alert.Engine = nil
+                        alertSettings, alerting, err := config.GetAlertSettings(conf)
+                        if err != nil {
+                            log.Fatal(err)
+                        }
+                        if alerting {
+                            alertSettings.Clock = clock.Real()
+                            alertEngine, err = alert.NewEngine(store, alert.Sources{
+                                Monitor:      nodeMonitor,
+                                WatchDog:     watchdog,
+                                Jury:         leaderJurry,
+                                TimeSettings: timeSettings,
+                            }, alertSettings)
+                            if err != nil {
+                                log.Fatal(err)
                             }
-                            // start all tools
-                            if poolTool != nil {
-                                go poolTool.Start()
+                        }
+                        // start all tools
+                        if poolTool != nil {
+                            go poolTool.Start()
+                        }
+                        if watchdog != nil {
+                            if statusServer != nil {
+                                statusServer.Handle("/schedule/viability", http.HandlerFunc(watchdog.ServeViabilityReport))
+                                statusServer.Handle("/schedule/export", http.HandlerFunc(watchdog.ServeScheduleExport))
+                                maintenanceSettings := config.GetMaintenanceSettings(conf)
+                                maintenanceSettings.Clock = clock.Real()
+                                planner := maintenance.NewPlanner(nodes, watchdog, timeSettings, maintenanceSettings)
+                                statusServer.Handle("/maintenance/windows", http.HandlerFunc(planner.ServeWindows))
+                                statusServer.Handle("/maintenance/check", http.HandlerFunc(planner.ServeCheck))
                             }
-                            if watchdog != nil {
-                                go watchdog.Watch()
+                            go watchdog.Watch()
+                        }
+                        if tracker != nil {
+                            if statusServer != nil {
+                                statusServer.Handle("/blocks", tracker)
                             }
-                            if leaderJurry != nil {
-                                go leaderJurry.Judge()
+                            go tracker.Track()
+                        }
+                        if reporter != nil {
+                            if statusServer != nil {
+                                statusServer.Handle("/report/epoch", reporter)
                             }
-                            if prometheus != nil {
-                                go prometheus.Run()
+                            go reporter.Run()
+                        }
+                        if leaderJurry != nil {
+                            if statusServer != nil {
+                                statusServer.Handle("/shadow", http.HandlerFunc(leaderJurry.ServeShadowReport))
                             }
-                            nodeMonitor.Watch()
-                        } else {
-                            fmt.Printf("No passive/leader nodes specified. Nothing to do.")
-                            os.Exit(0)
+                            go leaderJurry.Judge()
+                        }
+                        go nodeHistory.Run()
+                        if retention != nil {
+                            go retention.Run()
+                        }
+                        if prometheusClient != nil {
+                            go prometheusClient.Run()
+                        }
+                        if metricsExporter != nil {
+                            go metricsExporter.Run()
+                        }
+                        if alertEngine != nil {
+                            if statusServer != nil {
+                                statusServer.Handle("/alerts", http.HandlerFunc(alertEngine.ServeAlerts))
+                                statusServer.Handle("/alerts/silences", http.HandlerFunc(alertEngine.ServeSilences))
+                            }
+                            go alertEngine.Run()
+                        }
+                        if statusServer != nil {
+                            go statusServer.Run()
                         }
+                        nodeMonitor.Watch()
                     } else {
-                        fmt.Printf("Peers cannot be parsed. %v", err.Error())
+                        fmt.Printf("No passive/leader nodes specified. Nothing to do.")
                         os.Exit(0)
                     }
                 } else {
-                    fmt.Printf("Could not parse the config file. %s", err.Error())
-                    os.Exit(1)
+                    fmt.Printf("Peers cannot be parsed. %v", err.Error())
+                    os.Exit(0)
                 }
             } else {
                 fmt.Printf("Could not parse the config file. %s", err.Error())
@@ -0,0 +1,53 @@
+package maintenance
+
+import (
+    "encoding/json"
+    "net/http"
+    "time"
+)
+
+// serves the safe windows and blocked intervals of the current epoch as JSON.
+func (planner *Planner) ServeWindows(writer http.ResponseWriter, request *http.Request) {
+    windows, err := planner.GetWindows()
+    if err != nil && err != ErrScheduleUnknown {
+        http.Error(writer, err.Error(), http.StatusInternalServerError)
+        return
+    }
+    writer.Header().Set("Content-Type", "application/json")
+    _ = json.NewEncoder(writer).Encode(windows)
+}
+
+// serves the decision whether the node given by the query parameter 'node'
+// can be taken down for the duration given by the query parameter 'duration'
+// (e.g. 5m) now. if the query parameter 'wait' is given (e.g. 1h), then the
+// request blocks at most this duration until a safe window opens.
+func (planner *Planner) ServeCheck(writer http.ResponseWriter, request *http.Request) {
+    query := request.URL.Query()
+    if query.Get("node") == "" {
+        http.Error(writer, "The node must be specified.", http.StatusBadRequest)
+        return
+    }
+    duration, err := time.ParseDuration(query.Get("duration"))
+    if err != nil || duration <= 0 {
+        http.Error(writer, "The duration must be a positive duration such as 5m.", http.StatusBadRequest)
+        return
+    }
+    var decision Decision
+    if query.Get("wait") != "" {
+        var timeout time.Duration
+        timeout, err = time.ParseDuration(query.Get("wait"))
+        if err != nil || timeout < 0 {
+            http.Error(writer, "The wait timeout must be a non-negative duration such as 1h.", http.StatusBadRequest)
+            return
+        }
+        decision, err = planner.WaitFor(query.Get("node"), duration, timeout)
+    } else {
+        decision, err = planner.Check(query.Get("node"), duration)
+    }
+    if err != nil {
+        http.Error(writer, err.Error(), http.StatusNotFound)
+        return
+    }
+    writer.Header().Set("Content-Type", "application/json")
+    _ = json.NewEncoder(writer).Encode(decision)
+}
@@ -0,0 +1,222 @@
+package maintenance
+
+import (
+    "errors"
+    "fmt"
+    "github.com/sobitada/go-cardano"
+    "github.com/sobitada/thor/clock"
+    "github.com/sobitada/thor/monitor"
+    "sort"
+    "time"
+)
+
+// the schedule of the current epoch has not been fetched yet, and hence
+// no safe windows can be computed.
+var ErrScheduleUnknown = errors.New("the leader schedule of the current epoch is not known yet")
+
+// settings for the computation of safe maintenance windows.
+type Settings struct {
+    // time in front of a scheduled block that is not safe.
+    BeforeBlock time.Duration
+    // time after the slot of a scheduled block that is not
+    // safe, e.g. for the propagation of the block.
+    AfterBlock time.Duration
+    // time in front of an epoch turn over that is not safe.
+    BeforeTurnOver time.Duration
+    // time after an epoch turn over that is not safe, i.e. in
+    // which the schedule is fetched and checked.
+    AfterTurnOver time.Duration
+    // clock used for computing the windows, the clock of the
+    // system is used if it is nil.
+    Clock clock.Clock
+}
+
+// interval of time, which is either blocked for the given reason
+// or a safe window.
+type Interval struct {
+    Start  time.Time `json:"start"`
+    End    time.Time `json:"end"`
+    Reason string    `json:"reason,omitempty"`
+}
+
+// gets the duration of the interval.
+func (interval Interval) Duration() time.Duration {
+    return interval.End.Sub(interval.Start)
+}
+
+// safe windows and blocked intervals in the current epoch.
+type Windows struct {
+    Epoch uint64    `json:"epoch"`
+    Now   time.Time `json:"now"`
+    // end of the computed windows, the windows after it depend on
+    // the schedule of the next epoch.
+    Horizon time.Time  `json:"horizon"`
+    Safe    []Interval `json:"safe"`
+    Blocked []Interval `json:"blocked"`
+}
+
+// decision whether a node can be taken down for maintenance.
+type Decision struct {
+    Node     string        `json:"node"`
+    Duration time.Duration `json:"duration"`
+    Safe     bool          `json:"safe"`
+    Reason   string        `json:"reason"`
+    // the safe window covering the maintenance, if it is safe.
+    Window *Interval `json:"window,omitempty"`
+    // the next safe window long enough for the maintenance, if
+    // it is not safe now.
+    NextWindow *Interval `json:"nextWindow,omitempty"`
+}
+
+// planner computing safe windows between scheduled blocks, in which
+// leader candidates can be taken down for maintenance.
+type Planner struct {
+    nodes        map[string]monitor.Node
+    watchDog     *monitor.ScheduleWatchDog
+    timeSettings *cardano.TimeSettings
+    settings     Settings
+}
+
+// creates a new planner for the given nodes, which uses the schedules
+// fetched by the given watchdog.
+func NewPlanner(nodes []monitor.Node, watchDog *monitor.ScheduleWatchDog, timeSettings *cardano.TimeSettings,
+    settings Settings) *Planner {
+    nodeMap := make(map[string]monitor.Node)
+    for _, node := range nodes {
+        nodeMap[node.Name] = node
+    }
+    settings.Clock = clock.OrReal(settings.Clock)
+    return &Planner{nodes: nodeMap, watchDog: watchDog, timeSettings: timeSettings, settings: settings}
+}
+
+// gets the safe windows and blocked intervals from now until the end of
+// the turn over into the next epoch.
+func (planner *Planner) GetWindows() (Windows, error) {
+    now := planner.settings.Clock.Now()
+    currentSlotDate, err := planner.timeSettings.GetSlotDateFor(now)
+    if err != nil {
+        return Windows{}, err
+    }
+    epoch := currentSlotDate.GetEpoch()
+    windows := Windows{Epoch: epoch.Uint64(), Now: now, Safe: []Interval{}, Blocked: []Interval{}}
+    schedule, found := planner.watchDog.GetScheduleFor(epoch)
+    if !found {
+        return windows, ErrScheduleUnknown
+    }
+    epochStart := cardano.FullSlotDateFromInt(epoch.Uint64(), 0, *planner.timeSettings).GetStartDateTime()
+    nextEpochStart := cardano.FullSlotDateFromInt(epoch.Uint64()+1, 0, *planner.timeSettings).GetStartDateTime()
+    windows.Horizon = nextEpochStart.Add(planner.settings.AfterTurnOver)
+    blocked := []Interval{
+        {
+            Start:  epochStart.Add(-planner.settings.BeforeTurnOver),
+            End:    epochStart.Add(planner.settings.AfterTurnOver),
+            Reason: fmt.Sprintf("turn over to epoch %v", epoch.Uint64()),
+        },
+        {
+            Start:  nextEpochStart.Add(-planner.settings.BeforeTurnOver),
+            End:    windows.Horizon,
+            Reason: fmt.Sprintf("turn over to epoch %v", epoch.Uint64()+1),
+        },
+    }
+    for _, assignment := range schedule {
+        date := ""
+        if assignment.ScheduleBlockDate != nil {
+            date = assignment.ScheduleBlockDate.String()
+        }
+        blocked = append(blocked, Interval{
+            Start:  assignment.ScheduleTime.Add(-planner.settings.BeforeBlock),
+            End:    assignment.ScheduleTime.Add(planner.timeSettings.SlotDuration + planner.settings.AfterBlock),
+            Reason: fmt.Sprintf("scheduled block at %v", date),
+        })
+    }
+    sort.Slice(blocked, func(i, j int) bool { return blocked[i].Start.Before(blocked[j].Start) })
+    // the safe windows are the gaps between the blocked intervals.
+    cursor := now
+    for _, interval := range blocked {
+        if !interval.End.After(now) || !interval.Start.Before(windows.Horizon) {
+            continue
+        }
+        windows.Blocked = append(windows.Blocked, interval)
+        if interval.Start.After(cursor) {
+            windows.Safe = append(windows.Safe, Interval{Start: cursor, End: interval.Start})
+        }
+        if interval.End.After(cursor) {
+            cursor = interval.End
+        }
+    }
+    if windows.Horizon.After(cursor) {
+        windows.Safe = append(windows.Safe, Interval{Start: cursor, End: windows.Horizon})
+    }
+    return windows, nil
+}
+
+// checks whether the node with the given name can be taken down for the given
+// duration now. passive nodes can always be taken down, leader candidates only
+// in a safe window long enough for the maintenance.
+func (planner *Planner) Check(name string, duration time.Duration) (Decision, error) {
+    decision := Decision{Node: name, Duration: duration}
+    node, found := planner.nodes[name]
+    if !found {
+        return decision, fmt.Errorf("the node '%v' is unknown", name)
+    }
+    if node.Type != monitor.LeaderCandidate {
+        decision.Safe = true
+        decision.Reason = "The node is not a leader candidate."
+        return decision, nil
+    }
+    windows, err := planner.GetWindows()
+    if err != nil {
+        decision.Reason = err.Error()
+        return decision, nil
+    }
+    for i := range windows.Safe {
+        window := windows.Safe[i]
+        if window.Duration() < duration {
+            continue
+        }
+        if !window.Start.After(windows.Now) {
+            decision.Safe = true
+            decision.Window = &window
+            decision.Reason = fmt.Sprintf("The maintenance fits into the safe window until %v.",
+                window.End.Format(time.RFC3339))
+            return decision, nil
+        } else if decision.NextWindow == nil {
+            decision.NextWindow = &window
+        }
+    }
+    if len(windows.Blocked) > 0 && !windows.Blocked[0].Start.After(windows.Now) {
+        decision.Reason = fmt.Sprintf("The maintenance would overlap with the %v.", windows.Blocked[0].Reason)
+    } else if len(windows.Blocked) > 0 {
+        decision.Reason = fmt.Sprintf("The maintenance would overlap with the %v at %v.", windows.Blocked[0].Reason,
+            windows.Blocked[0].Start.Format(time.RFC3339))
+    } else {
+        decision.Reason = "There is no safe window long enough for the maintenance in this epoch."
+    }
+    return decision, nil
+}
+
+// waits until the node with the given name can be taken down for the given
+// duration, or the given timeout has passed. the last decision is returned.
+func (planner *Planner) WaitFor(name string, duration time.Duration, timeout time.Duration) (Decision, error) {
+    deadline := planner.settings.Clock.Now().Add(timeout)
+    for ; ; {
+        decision, err := planner.Check(name, duration)
+        if err != nil || decision.Safe {
+            return decision, err
+        }
+        now := planner.settings.Clock.Now()
+        if !now.Before(deadline) {
+            return decision, nil
+        }
+        // re-check at the start of the next window, but at least every
+        // slot as the schedule might have been fetched in between.
+        wait := deadline.Sub(now)
+        if decision.NextWindow != nil && decision.NextWindow.Start.Sub(now) < wait {
+            wait = decision.NextWindow.Start.Sub(now)
+        }
+        if decision.NextWindow == nil && planner.timeSettings.SlotDuration < wait {
+            wait = planner.timeSettings.SlotDuration
+        }
+        planner.settings.Clock.Sleep(wait)
+    }
+}
@@ -0,0 +1,129 @@
+package maintenance
+
+import (
+    "github.com/sobitada/go-cardano"
+    jor "github.com/sobitada/go-jormungandr/api"
+    "github.com/sobitada/thor/jortest"
+    "github.com/sobitada/thor/monitor"
+    "github.com/sobitada/thor/storage"
+    "github.com/stretchr/testify/assert"
+    "testing"
+    "time"
+)
+
+// creates a planner for a leader candidate 'a' with assignments at the given
+// slots in epoch 5 and a passive node 'p'. the schedule is unknown, if no slots
+// are given. the clock is at slot 100 of epoch 5.
+func newTestPlanner(t *testing.T, slots ...uint64) (*Planner, *jortest.Clock, *cardano.TimeSettings, func()) {
+    now := time.Now()
+    clock := jortest.NewClock(now)
+    timeSettings := jortest.TimeSettingsAt(now, 5, 100, time.Second, 1000)
+    var schedule []jor.LeaderAssignment = nil
+    for _, slot := range slots {
+        schedule = append(schedule, jortest.Assignment(5, slot, *timeSettings))
+    }
+    a := jortest.NewNodeWithClock(clock)
+    nodes := []monitor.Node{
+        {Name: "a", Type: monitor.LeaderCandidate, API: a.API(time.Second)},
+        {Name: "p", Type: monitor.Passive},
+    }
+    db := storage.NewMemory()
+    cleanUp := func() {
+        _ = db.Close()
+    }
+    scheduleSettings := monitor.DefaultScheduleSettings()
+    scheduleSettings.Clock = clock
+    watchDog := monitor.NewScheduleWatchDog(nodes[:1], timeSettings, db, scheduleSettings)
+    if schedule != nil {
+        a.SetSchedule(schedule)
+        go watchDog.Watch()
+        assert.Eventually(t, func() bool {
+            _, found := watchDog.GetScheduleFor(cardano.PlainSlotDateFromInt(5, 0).GetEpoch())
+            return found
+        }, 5*time.Second, 10*time.Millisecond)
+    }
+    planner := NewPlanner(nodes, watchDog, timeSettings, Settings{
+        BeforeBlock:    10 * time.Second,
+        AfterBlock:     5 * time.Second,
+        BeforeTurnOver: 20 * time.Second,
+        AfterTurnOver:  30 * time.Second,
+        Clock:          clock,
+    })
+    return planner, clock, timeSettings, func() {
+        a.Close()
+        cleanUp()
+    }
+}
+
+func slotTime(settings *cardano.TimeSettings, epoch uint64, slot uint64) time.Time {
+    return cardano.FullSlotDateFromInt(epoch, slot, *settings).GetStartDateTime()
+}
+
+func TestPlanner_GetWindows_mustReturnGapsBetweenBlocksAndTurnOver(t *testing.T) {
+    planner, _, timeSettings, cleanUp := newTestPlanner(t, 300, 200)
+    defer cleanUp()
+    windows, err := planner.GetWindows()
+    if assert.NoError(t, err) {
+        assert.Equal(t, uint64(5), windows.Epoch)
+        assert.Len(t, windows.Blocked, 3)
+        if assert.Len(t, windows.Safe, 3) {
+            assert.True(t, slotTime(timeSettings, 5, 190).Equal(windows.Safe[0].End))
+            assert.True(t, slotTime(timeSettings, 5, 206).Equal(windows.Safe[1].Start))
+            assert.True(t, slotTime(timeSettings, 5, 290).Equal(windows.Safe[1].End))
+            assert.True(t, slotTime(timeSettings, 5, 306).Equal(windows.Safe[2].Start))
+            assert.True(t, slotTime(timeSettings, 5, 980).Equal(windows.Safe[2].End))
+        }
+        assert.True(t, slotTime(timeSettings, 6, 30).Equal(windows.Horizon))
+    }
+}
+
+func TestPlanner_Check_mustOnlyAllowMaintenanceFittingIntoWindow(t *testing.T) {
+    planner, _, timeSettings, cleanUp := newTestPlanner(t, 200, 300)
+    defer cleanUp()
+    decision, err := planner.Check("a", time.Minute)
+    if assert.NoError(t, err) {
+        assert.True(t, decision.Safe)
+    }
+    decision, err = planner.Check("a", 2*time.Minute)
+    if assert.NoError(t, err) {
+        assert.False(t, decision.Safe)
+        if assert.NotNil(t, decision.NextWindow) {
+            assert.True(t, slotTime(timeSettings, 5, 306).Equal(decision.NextWindow.Start))
+        }
+    }
+    decision, err = planner.Check("p", time.Hour)
+    if assert.NoError(t, err) {
+        assert.True(t, decision.Safe)
+    }
+    _, err = planner.Check("x", time.Minute)
+    assert.Error(t, err)
+}
+
+func TestPlanner_WaitFor_mustReturnOnceWindowOpens(t *testing.T) {
+    planner, clock, timeSettings, cleanUp := newTestPlanner(t, 200, 300)
+    defer cleanUp()
+    decisions := make(chan Decision)
+    go func() {
+        decision, _ := planner.WaitFor("a", 2*time.Minute, time.Hour)
+        decisions <- decision
+    }()
+    // the watchdog and the planner are waiting.
+    assert.True(t, clock.WaitForTimers(2, time.Second))
+    clock.AdvanceTo(slotTime(timeSettings, 5, 306))
+    select {
+    case decision := <-decisions:
+        assert.True(t, decision.Safe)
+    case <-time.After(5 * time.Second):
+        t.Fatal("the planner has not returned after the window opened")
+    }
+}
+
+func TestPlanner_Check_UnknownSchedule_mustNotBeSafe(t *testing.T) {
+    planner, _, _, cleanUp := newTestPlanner(t)
+    defer cleanUp()
+    decision, err := planner.Check("a", time.Minute)
+    if assert.NoError(t, err) {
+        assert.False(t, decision.Safe)
+        assert.Equal(t, ErrScheduleUnknown.Error(), decision.Reason)
+    }
+}
@@ -0,0 +1,111 @@
+package metrics
+
+import (
+    "bytes"
+    "fmt"
+    "io/ioutil"
+    "net/http"
+    "sort"
+    "strconv"
+    "strings"
+    "time"
+)
+
+var influxEscaper = strings.NewReplacer(",", "\\,", "=", "\\=", " ", "\\ ")
+var measurementEscaper = strings.NewReplacer(",", "\\,", " ", "\\ ")
+
+// writer sending the points in the InfluxDB line protocol over HTTP.
+type influxWriter struct {
+    url      string
+    token    string
+    username string
+    password string
+    tags     map[string]string
+    client   *http.Client
+}
+
+func newInfluxWriter(settings Settings) *influxWriter {
+    client := settings.Client
+    if client == nil {
+        client = &http.Client{Timeout: 10 * time.Second}
+    }
+    return &influxWriter{
+        url:      settings.URL,
+        token:    settings.Token,
+        username: settings.Username,
+        password: settings.Password,
+        tags:     settings.Tags,
+        client:   client,
+    }
+}
+
+// gets the given point in the line protocol with a timestamp in nanoseconds.
+// the given tags are added to the tags of the point. the tags and fields are
+// sorted by their key.
+func toInfluxLine(point Point, extraTags map[string]string) string {
+    tags := make(map[string]string, len(point.Tags)+len(extraTags))
+    for key, value := range extraTags {
+        tags[key] = value
+    }
+    for key, value := range point.Tags {
+        tags[key] = value
+    }
+    keys := make([]string, 0, len(tags))
+    for key, value := range tags {
+        if value != "" {
+            keys = append(keys, key)
+        }
+    }
+    sort.Strings(keys)
+    var line strings.Builder
+    line.WriteString(measurementEscaper.Replace(point.Measurement))
+    for _, key := range keys {
+        line.WriteString(fmt.Sprintf(",%v=%v", influxEscaper.Replace(key), influxEscaper.Replace(tags[key])))
+    }
+    fields := make([]string, 0, len(point.Fields))
+    for field := range point.Fields {
+        fields = append(fields, field)
+    }
+    sort.Strings(fields)
+    for i, field := range fields {
+        separator := ","
+        if i == 0 {
+            separator = " "
+        }
+        line.WriteString(fmt.Sprintf("%v%v=%v", separator, influxEscaper.Replace(field),
+            strconv.FormatFloat(point.Fields[field], 'f', -1, 64)))
+    }
+    line.WriteString(fmt.Sprintf(" %v", point.Time.UnixNano()))
+    return line.String()
+}
+
+func (writer *influxWriter) write(points []Point) error {
+    var body bytes.Buffer
+    for _, point := range points {
+        if len(point.Fields) == 0 {
+            continue
+        }
+        body.WriteString(toInfluxLine(point, writer.tags))
+        body.WriteByte('\n')
+    }
+    request, err := http.NewRequest(http.MethodPost, writer.url, &body)
+    if err != nil {
+        return err
+    }
+    request.Header.Set("Content-Type", "text/plain; charset=utf-8")
+    if writer.token != "" {
+        request.Header.Set("Authorization", "Token "+writer.token)
+    } else if writer.username != "" {
+        request.SetBasicAuth(writer.username, writer.password)
+    }
+    response, err := writer.client.Do(request)
+    if err != nil {
+        return err
+    }
+    defer response.Body.Close()
+    if response.StatusCode/100 != 2 {
+        message, _ := ioutil.ReadAll(response.Body)
+        return fmt.Errorf("the InfluxDB answered with status code %v. %s", response.StatusCode, message)
+    }
+    return nil
+}
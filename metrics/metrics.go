@@ -0,0 +1,165 @@
+package metrics
+
+import (
+    "fmt"
+    log "github.com/sirupsen/logrus"
+    "github.com/sobitada/go-cardano"
+    "github.com/sobitada/thor/audit"
+    "github.com/sobitada/thor/blocks"
+    "github.com/sobitada/thor/clock"
+    "github.com/sobitada/thor/leader"
+    "github.com/sobitada/thor/monitor"
+    "net/http"
+    "time"
+)
+
+// protocol with which the metrics are sent.
+type Protocol string
+
+const (
+    // StatsD over UDP.
+    StatsD Protocol = "statsd"
+    // InfluxDB line protocol over HTTP.
+    Influx Protocol = "influx"
+)
+
+// sources of the emitted metrics. all of them except the monitor are
+// optional and can be nil, the corresponding metrics are then missing.
+type Sources struct {
+    Monitor      *monitor.NodeMonitor
+    Tracker      *blocks.Tracker
+    WatchDog     *monitor.ScheduleWatchDog
+    Jury         *leader.Jury
+    AuditLog     *audit.Log
+    TimeSettings *cardano.TimeSettings
+}
+
+// settings of an exporter.
+type Settings struct {
+    Protocol Protocol
+    // address of the StatsD server as host:port.
+    Address string
+    // prefix of all StatsD metric names.
+    Prefix string
+    // URL of the write endpoint of the InfluxDB.
+    URL string
+    // token or credentials for the write endpoint of the InfluxDB, none
+    // are used if they are empty.
+    Token    string
+    Username string
+    Password string
+    // tags added to all InfluxDB points.
+    Tags map[string]string
+    // client used for the requests to the InfluxDB.
+    Client *http.Client
+    // clock used for timing, the clock of the system is used if
+    // it is nil.
+    Clock clock.Clock
+}
+
+// a point of a measurement with its tags and fields.
+type Point struct {
+    Measurement string
+    Tags        map[string]string
+    Fields      map[string]float64
+    Time        time.Time
+}
+
+// writer sending points over a protocol.
+type writer interface {
+    write(points []Point) error
+}
+
+// exporter, which emits the statistics of the nodes and the state of this
+// tool as points whenever the monitor has checked the nodes.
+type Exporter struct {
+    settings            Settings
+    sources             Sources
+    writer              writer
+    checkChannel        chan monitor.Check
+    blockOutcomeChannel chan blocks.BlockOutcome
+    auditChannel        chan audit.Entry
+    batches             chan []Point
+    counters            map[string]*counter
+}
+
+// gets a new exporter for the metrics taken from the given sources.
+func NewExporter(settings Settings, sources Sources) (*Exporter, error) {
+    exporter, err := newExporter(settings, sources)
+    if err != nil {
+        return nil, err
+    }
+    exporter.checkChannel = make(chan monitor.Check)
+    sources.Monitor.ListenerManager.RegisterCheckListener(exporter.checkChannel)
+    if sources.Tracker != nil {
+        exporter.blockOutcomeChannel = make(chan blocks.BlockOutcome)
+        sources.Tracker.RegisterListener(exporter.blockOutcomeChannel)
+    }
+    if sources.AuditLog != nil {
+        exporter.auditChannel = make(chan audit.Entry, audit.ListenerCapacity)
+        sources.AuditLog.RegisterListener(exporter.auditChannel)
+    }
+    return exporter, nil
+}
+
+// creates an exporter with its writer, which is not yet listening to any
+// of the sources.
+func newExporter(settings Settings, sources Sources) (*Exporter, error) {
+    settings.Clock = clock.OrReal(settings.Clock)
+    exporter := &Exporter{
+        settings: settings,
+        sources:  sources,
+        batches:  make(chan []Point, 16),
+        counters: map[string]*counter{},
+    }
+    switch settings.Protocol {
+    case StatsD:
+        statsD, err := newStatsDWriter(settings.Address, settings.Prefix)
+        if err != nil {
+            return nil, err
+        }
+        exporter.writer = statsD
+    case Influx:
+        exporter.writer = newInfluxWriter(settings)
+    default:
+        return nil, fmt.Errorf("the protocol '%v' is unknown", settings.Protocol)
+    }
+    return exporter, nil
+}
+
+// hands the given points over to the writer. the points are dropped, if the
+// writer cannot keep up, such that the monitor is never blocked.
+func (exporter *Exporter) emit(points []Point) {
+    select {
+    case exporter.batches <- points:
+    default:
+        log.Warnf("[METRICS] Dropping %v points, the %v endpoint cannot keep up.", len(points),
+            exporter.settings.Protocol)
+    }
+}
+
+func (exporter *Exporter) write() {
+    for points := range exporter.batches {
+        err := exporter.writer.write(points)
+        if err != nil {
+            log.Errorf("[METRICS] Could not send the metrics over %v. %v", exporter.settings.Protocol, err.Error())
+        }
+    }
+}
+
+// a blocking call, which emits the metrics whenever the monitor has checked
+// the nodes.
+func (exporter *Exporter) Run() {
+    log.Infof("[METRICS] Sending the metrics over %v.", exporter.settings.Protocol)
+    go exporter.write()
+    for ; ; {
+        select {
+        case check := <-exporter.checkChannel:
+            exporter.emit(exporter.getPoints(check))
+        case outcome := <-exporter.blockOutcomeChannel:
+            exporter.updateBlockOutcome(outcome)
+        case entry := <-exporter.auditChannel:
+            exporter.updateAuditEntry(entry)
+        }
+    }
+}
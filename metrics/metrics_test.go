@@ -0,0 +1,145 @@
+package metrics
+
+import (
+    "errors"
+    jor "github.com/sobitada/go-jormungandr/api"
+    "github.com/sobitada/thor/audit"
+    "github.com/sobitada/thor/monitor"
+    "github.com/stretchr/testify/assert"
+    "io/ioutil"
+    "math/big"
+    "net"
+    "net/http"
+    "net/http/httptest"
+    "strings"
+    "testing"
+    "time"
+)
+
+var start = time.Date(2020, 6, 1, 12, 0, 0, 0, time.UTC)
+
+func getCheck() monitor.Check {
+    return monitor.Check{
+        Time: start,
+        Nodes: []monitor.NodeCheck{
+            {Name: "a", Statistic: &jor.NodeStatistic{JormungandrVersion: "0.8.19", LastBlockHeight: big.NewInt(100),
+                LastBlockTime: start.Add(-30 * time.Second)}, Latency: 20 * time.Millisecond},
+            {Name: "b", Statistic: &jor.NodeStatistic{JormungandrVersion: "0.8.19", LastBlockHeight: big.NewInt(97)}},
+            {Name: "c", Error: errors.New("timeout")},
+        },
+        MaximumBlockHeight: big.NewInt(100),
+    }
+}
+
+func TestExporter_GetPoints_mustContainLagAndErrors(t *testing.T) {
+    exporter, err := newExporter(Settings{Protocol: Influx}, Sources{})
+    if assert.Nil(t, err) {
+        points := exporter.getPoints(getCheck())
+        if assert.Len(t, points, 3) {
+            assert.Equal(t, 0.0, points[0].Fields["block_lag"])
+            assert.Equal(t, 30.0, points[0].Fields["seconds_since_last_block"])
+            assert.Equal(t, 3.0, points[1].Fields["block_lag"])
+            assert.Equal(t, 1.0, points[2].Fields["api_error"])
+            _, found := points[2].Fields["block_lag"]
+            assert.False(t, found)
+        }
+    }
+}
+
+func TestExporter_GetPoints_mustContainLagBehindReferenceHeights(t *testing.T) {
+    exporter, err := newExporter(Settings{Protocol: Influx}, Sources{})
+    if assert.Nil(t, err) {
+        check := getCheck()
+        check.ReferenceHeights = map[string]*big.Int{"pooltool": big.NewInt(104)}
+        points := exporter.getPoints(check)
+        if assert.Len(t, points, 4) {
+            assert.Equal(t, "thor_reference", points[3].Measurement)
+            assert.Equal(t, "pooltool", points[3].Tags["source"])
+            assert.Equal(t, 4.0, points[3].Fields["lag"])
+        }
+    }
+}
+
+func TestExporter_AuditEntries_mustBeEmittedAsTotals(t *testing.T) {
+    exporter, err := newExporter(Settings{Protocol: Influx}, Sources{})
+    if assert.Nil(t, err) {
+        exporter.updateAuditEntry(audit.Entry{Node: "a", Action: audit.Promotion, Outcome: audit.Success})
+        exporter.updateAuditEntry(audit.Entry{Node: "b", Action: audit.Promotion, Outcome: audit.Success})
+        exporter.updateAuditEntry(audit.Entry{Node: "b", Action: audit.Shutdown, Cause: monitor.LagCause,
+            Outcome: audit.Shadow})
+        points := exporter.getPoints(monitor.Check{Time: start})
+        if assert.Len(t, points, 1) {
+            assert.Equal(t, "thor_leader_changes", points[0].Measurement)
+            assert.Equal(t, 2.0, points[0].Fields["total"])
+        }
+    }
+}
+
+func TestInfluxLine_mustEscapeAndSortTagsAndFields(t *testing.T) {
+    line := toInfluxLine(Point{
+        Measurement: "thor_node",
+        Tags:        map[string]string{"name": "Local 1", "version": ""},
+        Fields:      map[string]float64{"block_lag": 2, "api_error": 0},
+        Time:        start,
+    }, map[string]string{"instance": "pool=1"})
+    assert.Equal(t, "thor_node,instance=pool\\=1,name=Local\\ 1 api_error=0,block_lag=2 1591012800000000000", line)
+}
+
+func TestStatsDLines_mustUseTagValuesInName(t *testing.T) {
+    lines := toStatsDLines("thor", Point{
+        Measurement: "thor_node",
+        Tags:        map[string]string{"name": "Local 1", "version": "0.8.19"},
+        Fields:      map[string]float64{"block_lag": 2, "uptime": 1.5},
+    })
+    assert.Equal(t, []string{"thor.thor_node.Local_1.block_lag:2|g", "thor.thor_node.Local_1.uptime:1.5|g"}, lines)
+}
+
+func TestExporter_StatsD_mustSendGaugesOverUDP(t *testing.T) {
+    conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+    if !assert.Nil(t, err) {
+        return
+    }
+    defer conn.Close()
+    exporter, err := newExporter(Settings{Protocol: StatsD, Address: conn.LocalAddr().String(), Prefix: "thor"},
+        Sources{})
+    if assert.Nil(t, err) {
+        assert.Nil(t, exporter.writer.write(exporter.getPoints(getCheck())))
+        buffer := make([]byte, maxPacketSize)
+        _ = conn.SetReadDeadline(time.Now().Add(time.Second))
+        n, _, err := conn.ReadFrom(buffer)
+        if assert.Nil(t, err) {
+            assert.Contains(t, strings.Split(string(buffer[:n]), "\n"), "thor.thor_node.b.block_lag:3|g")
+        }
+    }
+}
+
+func TestExporter_Influx_mustPostLinesWithToken(t *testing.T) {
+    var authorization string
+    var body []byte
+    server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+        authorization = request.Header.Get("Authorization")
+        body, _ = ioutil.ReadAll(request.Body)
+        writer.WriteHeader(http.StatusNoContent)
+    }))
+    defer server.Close()
+    exporter, err := newExporter(Settings{Protocol: Influx, URL: server.URL, Token: "secret",
+        Tags: map[string]string{"instance": "pool-1"}}, Sources{})
+    if assert.Nil(t, err) {
+        assert.Nil(t, exporter.writer.write(exporter.getPoints(getCheck())))
+        assert.Equal(t, "Token secret", authorization)
+        lines := strings.Split(strings.TrimSpace(string(body)), "\n")
+        assert.Len(t, lines, 3)
+        assert.True(t, strings.HasPrefix(lines[1], "thor_node,instance=pool-1,name=b,version=0.8.19 "))
+    }
+}
+
+func TestExporter_Influx_mustReportServerErrors(t *testing.T) {
+    server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+        writer.WriteHeader(http.StatusInternalServerError)
+    }))
+    defer server.Close()
+    exporter, err := newExporter(Settings{Protocol: Influx, URL: server.URL}, Sources{})
+    if assert.Nil(t, err) {
+        assert.NotNil(t, exporter.writer.write(exporter.getPoints(getCheck())))
+    }
+}
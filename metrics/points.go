@@ -0,0 +1,189 @@
+package metrics
+
+import (
+    "github.com/sobitada/go-cardano"
+    "github.com/sobitada/thor/audit"
+    "github.com/sobitada/thor/blocks"
+    "github.com/sobitada/thor/monitor"
+    "math/big"
+    "sort"
+)
+
+// a counter, whose total is emitted with every check.
+type counter struct {
+    measurement string
+    tags        map[string]string
+    total       float64
+}
+
+// increments the counter of the given measurement with the given tags.
+func (exporter *Exporter) increment(measurement string, tags map[string]string) {
+    keys := make([]string, 0, len(tags))
+    for key := range tags {
+        keys = append(keys, key)
+    }
+    sort.Strings(keys)
+    id := measurement
+    for _, key := range keys {
+        id += "," + key + "=" + tags[key]
+    }
+    c, found := exporter.counters[id]
+    if !found {
+        c = &counter{measurement: measurement, tags: tags}
+        exporter.counters[id] = c
+    }
+    c.total++
+}
+
+// counts the given final outcome of a leader assignment.
+func (exporter *Exporter) updateBlockOutcome(outcome blocks.BlockOutcome) {
+    if outcome.IsFinal() {
+        exporter.increment("thor_block_outcomes", map[string]string{"outcome": string(outcome.Outcome)})
+    }
+}
+
+// counts the leader changes and shutdowns with the given entry of the audit
+// log. decisions in shadow mode are ignored.
+func (exporter *Exporter) updateAuditEntry(entry audit.Entry) {
+    if entry.IsLeaderChange() {
+        exporter.increment("thor_leader_changes", nil)
+    } else if cause, outcome, ok := entry.GetShutdownLabels(); ok {
+        exporter.increment("thor_shutdowns", map[string]string{"name": entry.Node, "cause": cause,
+            "outcome": outcome})
+    }
+}
+
+func toFloat(value *big.Int) float64 {
+    f, _ := new(big.Float).SetInt(value).Float64()
+    return f
+}
+
+func toBinary(value bool) float64 {
+    if value {
+        return 1
+    }
+    return 0
+}
+
+// gets the points for the given check of the monitor, and the state of the
+// other sources at this time.
+func (exporter *Exporter) getPoints(check monitor.Check) []Point {
+    sources := exporter.sources
+    var viableNodes map[string]bool = nil
+    if sources.WatchDog != nil {
+        viableNodes = map[string]bool{}
+        for _, name := range sources.WatchDog.GetViableLeaderNodes() {
+            viableNodes[name] = true
+        }
+    }
+    var healthScores map[string]float64 = nil
+    leaderName, hasLeader := "", false
+    if sources.Jury != nil {
+        healthScores = sources.Jury.GetHealthScores()
+        leaderName, hasLeader = sources.Jury.GetLeader()
+    }
+    points := make([]Point, 0, len(check.Nodes)+len(check.ReferenceHeights)+len(exporter.counters)+2)
+    for _, nodeCheck := range check.Nodes {
+        point := Point{
+            Measurement: "thor_node",
+            Tags:        map[string]string{"name": nodeCheck.Name},
+            Fields: map[string]float64{
+                "api_latency_seconds": nodeCheck.Latency.Seconds(),
+                "api_error":           toBinary(nodeCheck.Error != nil),
+                "bootstrapping":       toBinary(nodeCheck.Bootstrapping),
+            },
+            Time: check.Time,
+        }
+        if viableNodes != nil && nodeCheck.Type == monitor.LeaderCandidate {
+            point.Fields["viable"] = toBinary(viableNodes[nodeCheck.Name])
+        }
+        if score, found := healthScores[nodeCheck.Name]; found {
+            point.Fields["health_score"] = score
+        }
+        if sources.Jury != nil && nodeCheck.Type == monitor.LeaderCandidate {
+            point.Fields["leader"] = toBinary(hasLeader && leaderName == nodeCheck.Name)
+        }
+        if stats := nodeCheck.Statistic; stats != nil {
+            point.Tags["version"] = stats.JormungandrVersion
+            point.Fields["uptime"] = stats.UpTime.Seconds()
+            if stats.LastBlockHeight != nil {
+                point.Fields["last_block_height"] = toFloat(stats.LastBlockHeight)
+                if check.MaximumBlockHeight != nil {
+                    point.Fields["block_lag"] = toFloat(new(big.Int).Sub(check.MaximumBlockHeight,
+                        stats.LastBlockHeight))
+                }
+            }
+            if stats.ReceivedTransactions != nil {
+                point.Fields["tx_received_count"] = toFloat(stats.ReceivedTransactions)
+            }
+            if stats.PeerAvailableCount != nil {
+                point.Fields["peer_available_count"] = float64(*stats.PeerAvailableCount)
+            }
+            if stats.PeerQuarantinedCount != nil {
+                point.Fields["peer_quarantined_count"] = float64(*stats.PeerQuarantinedCount)
+            }
+            if stats.PeerUnreachableCnt != nil {
+                point.Fields["peer_unreachable_count"] = float64(*stats.PeerUnreachableCnt)
+            }
+            if sources.TimeSettings != nil && stats.LastBlockDate != nil {
+                lastBlock := cardano.MakeFullSlotDate(stats.LastBlockDate, *sources.TimeSettings).GetEndDateTime()
+                point.Fields["seconds_since_last_block"] = check.Time.Sub(lastBlock).Seconds()
+            } else if !stats.LastBlockTime.IsZero() {
+                point.Fields["seconds_since_last_block"] = check.Time.Sub(stats.LastBlockTime).Seconds()
+            }
+        }
+        points = append(points, point)
+    }
+    if check.MaximumBlockHeight != nil {
+        for source, height := range check.ReferenceHeights {
+            points = append(points, Point{
+                Measurement: "thor_reference",
+                Tags:        map[string]string{"source": source},
+                Fields:      map[string]float64{"lag": toFloat(new(big.Int).Sub(height, check.MaximumBlockHeight))},
+                Time:        check.Time,
+            })
+        }
+    }
+    if sources.WatchDog != nil {
+        if next, found := sources.WatchDog.GetNextScheduledBlock(check.Time); found {
+            points = append(points, Point{
+                Measurement: "thor_next_block",
+                Tags:        map[string]string{},
+                Fields:      map[string]float64{"seconds_until": next.Sub(check.Time).Seconds()},
+                Time:        check.Time,
+            })
+        }
+    }
+    if tracker := sources.Tracker; tracker != nil {
+        epochOutcomes, err := tracker.GetEpochOutcomes(tracker.CurrentEpoch())
+        if err == nil {
+            summary := epochOutcomes.Summary
+            points = append(points, Point{
+                Measurement: "thor_epoch_blocks",
+                Tags:        map[string]string{},
+                Fields: map[string]float64{
+                    "scheduled":            float64(summary.Scheduled),
+                    "pending":              float64(summary.Pending),
+                    string(blocks.Minted):  float64(summary.Minted),
+                    string(blocks.Adopted): float64(summary.Adopted),
+                    string(blocks.Lost):    float64(summary.Lost),
+                    string(blocks.Missed):  float64(summary.Missed),
+                },
+                Time: check.Time,
+            })
+        }
+    }
+    for _, c := range exporter.counters {
+        tags := make(map[string]string, len(c.tags))
+        for key, value := range c.tags {
+            tags[key] = value
+        }
+        points = append(points, Point{
+            Measurement: c.measurement,
+            Tags:        tags,
+            Fields:      map[string]float64{"total": c.total},
+            Time:        check.Time,
+        })
+    }
+    return points
+}
@@ -0,0 +1,89 @@
+package metrics
+
+import (
+    "bytes"
+    "fmt"
+    "net"
+    "regexp"
+    "sort"
+    "strconv"
+    "strings"
+)
+
+// maximum size of a StatsD packet, such that it fits into a single datagram
+// of a common network.
+const maxPacketSize = 1432
+
+// tags, which are not part of the StatsD metric names, since a change of their
+// value would start a new series.
+var statsDIgnoredTags = map[string]bool{"version": true}
+
+var invalidNameCharacters = regexp.MustCompile("[^a-zA-Z0-9_-]")
+
+// writer sending the points as StatsD gauges over UDP.
+type statsDWriter struct {
+    prefix string
+    conn   net.Conn
+}
+
+func newStatsDWriter(address string, prefix string) (*statsDWriter, error) {
+    conn, err := net.Dial("udp", address)
+    if err != nil {
+        return nil, err
+    }
+    return &statsDWriter{prefix: prefix, conn: conn}, nil
+}
+
+// gets the StatsD lines for the given point, where the values of the tags
+// are part of the name. all values are sent as gauges.
+func toStatsDLines(prefix string, point Point) []string {
+    parts := make([]string, 0)
+    if prefix != "" {
+        parts = append(parts, prefix)
+    }
+    parts = append(parts, point.Measurement)
+    keys := make([]string, 0, len(point.Tags))
+    for key := range point.Tags {
+        if !statsDIgnoredTags[key] {
+            keys = append(keys, key)
+        }
+    }
+    sort.Strings(keys)
+    for _, key := range keys {
+        parts = append(parts, invalidNameCharacters.ReplaceAllString(point.Tags[key], "_"))
+    }
+    name := strings.Join(parts, ".")
+    fields := make([]string, 0, len(point.Fields))
+    for field := range point.Fields {
+        fields = append(fields, field)
+    }
+    sort.Strings(fields)
+    lines := make([]string, len(fields))
+    for i, field := range fields {
+        lines[i] = fmt.Sprintf("%v.%v:%v|g", name, field, strconv.FormatFloat(point.Fields[field], 'f', -1, 64))
+    }
+    return lines
+}
+
+func (writer *statsDWriter) write(points []Point) error {
+    var packet bytes.Buffer
+    for _, point := range points {
+        for _, line := range toStatsDLines(writer.prefix, point) {
+            if packet.Len() > 0 && packet.Len()+len(line)+1 > maxPacketSize {
+                if _, err := writer.conn.Write(packet.Bytes()); err != nil {
+                    return err
+                }
+                packet.Reset()
+            }
+            if packet.Len() > 0 {
+                packet.WriteByte('\n')
+            }
+            packet.WriteString(line)
+        }
+    }
+    if packet.Len() > 0 {
+        _, err := writer.conn.Write(packet.Bytes())
+        return err
+    }
+    return nil
+}
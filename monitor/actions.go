@@ -1,12 +1,14 @@
 package monitor
 
 import (
+    "fmt"
     log "github.com/sirupsen/logrus"
     "github.com/sobitada/go-cardano"
     jor "github.com/sobitada/go-jormungandr/api"
+    "github.com/sobitada/thor/audit"
+    "github.com/sobitada/thor/clock"
     "github.com/sobitada/thor/utils"
     "math/big"
-    "time"
 )
 
 type ActionContext struct {
@@ -15,6 +17,8 @@ type ActionContext struct {
     MaximumBlockHeight   *big.Int
     UpToDateNodes        []string
     LastNodeStatisticMap map[string]jor.NodeStatistic
+    AuditLog             *audit.Log
+    Clock                clock.Clock
 }
 
 type Action interface {
@@ -38,7 +42,8 @@ func (action ShutDownWithBlockLagAction) execute(nodes []Node, context ActionCon
             lag := new(big.Int).Sub(context.MaximumBlockHeight, peerBlockHeight)
             if lag.Cmp(new(big.Int).SetUint64(peer.MaxBlockLag)) >= 0 {
                 log.Warnf("[%s] Pool has fallen behind %v blocks.", peer.Name, lag.String())
-                go ShutDownNode(peer)
+                go ShutDownAndRecord(peer, context.AuditLog, LagCause, fmt.Sprintf("Fallen behind %v blocks (height %v, max %v).",
+                    lag.String(), peerBlockHeight.String(), context.MaximumBlockHeight.String()))
             }
         }
     }
@@ -60,10 +65,11 @@ func (action ShutDownWhenStuck) execute(nodes []Node, context ActionContext) {
             }
             if found {
                 mostRecentBlockDate := cardano.MakeFullSlotDate(lastBlock.LastBlockDate, *context.TimeSettings)
-                diff := time.Now().Sub(mostRecentBlockDate.GetEndDateTime())
+                diff := clock.OrReal(context.Clock).Now().Sub(mostRecentBlockDate.GetEndDateTime())
                 if diff > peer.MaxTimeSinceLastBlock {
                     log.Warnf("[%s] Most recent received block is %v old.", peer.Name, utils.GetHumanReadableUpTime(diff))
-                    go ShutDownNode(peer)
+                    go ShutDownAndRecord(peer, context.AuditLog, StuckCause, fmt.Sprintf("Stuck, most recent received block is %v old.",
+                        utils.GetHumanReadableUpTime(diff)))
                 }
             }
         }
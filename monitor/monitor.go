@@ -1,9 +1,13 @@
 package monitor
 
 import (
+    "fmt"
     log "github.com/sirupsen/logrus"
     "github.com/sobitada/go-cardano"
     jor "github.com/sobitada/go-jormungandr/api"
+    "github.com/sobitada/thor/audit"
+    "github.com/sobitada/thor/clock"
+    "github.com/sobitada/thor/jormungandr"
     "github.com/sobitada/thor/threading"
     "github.com/sobitada/thor/utils"
     "math/big"
@@ -20,13 +24,44 @@ const (
     LeaderCandidate          = "leader-candidate"
 )
 
+// API of a Jormungandr node, which is used by this program to
+// access details about the node and to manage its leaders.
+type NodeAPI interface {
+    // gets the node statistics, the second return value indicates
+    // whether the node is bootstrapping.
+    GetNodeStatistics() (*jor.NodeStatistic, bool, error)
+    // gets the IDs of the leaders registered at the node.
+    GetRegisteredLeaders() ([]uint64, error)
+    // removes the leader with the given ID, returns false if
+    // no such leader has been registered.
+    RemoveRegisteredLeader(leaderID uint64) (bool, error)
+    // registers the given leader and returns its ID.
+    PostLeader(leaderCertificate jor.LeaderCertificate) (uint64, error)
+    // gets the leader schedule of the node.
+    GetLeadersSchedule() ([]jor.LeaderAssignment, error)
+    // shuts down the node.
+    Shutdown() error
+}
+
+// API of a Jormungandr node to access the status of leader
+// assignments and the main chain, which is not covered by NodeAPI.
+type BlockAPI interface {
+    // gets the logs of the leader assignments including their status.
+    GetLeaderLogs() ([]jormungandr.LeaderLog, error)
+    // checks whether the block with the given hash is in the main chain.
+    IsInMainChain(blockHash string) (bool, error)
+}
+
 type Node struct {
     // passive or leader node
     Type NodeType
     // unique name of the node
     Name string
     // api to access details about the node
-    API *jor.JormungandrAPI
+    API NodeAPI
+    // api to access the status of leader assignments
+    // and the main chain of the node.
+    BlockAPI BlockAPI
     // the maximal number of blocks this node
     // is allowed to lag behind.
     MaxBlockLag uint64
@@ -43,21 +78,89 @@ type NodeMonitor struct {
     ListenerManager *ListenerManager
     watchDog        *ScheduleWatchDog
     timeSettings    *cardano.TimeSettings
+    auditLog        *audit.Log
+    references      map[string]referenceHeight
+    referenceMutex  *sync.Mutex
+    // start times of the nodes derived from their uptime.
+    startTimes map[string]time.Time
+}
+
+// block height reported by a source outside of the swarm, which is
+// valid until the given time.
+type referenceHeight struct {
+    height     *big.Int
+    validUntil time.Time
 }
 
 type NodeMonitorBehaviour struct {
     // interval of the monitor checking the status of nodes.
     Interval time.Duration
+    // quiet period around scheduled blocks for leader candidates
+    // and passive nodes. the default quiet period is used for
+    // an unspecified suppression.
+    CandidateQuietPeriod QuietPeriod
+    PassiveQuietPeriod   QuietPeriod
+    // clock used for waiting between checks, the clock
+    // of the system is used if it is nil.
+    Clock clock.Clock
+}
+
+// what is suppressed in a quiet period.
+type Suppression string
+
+const (
+    SuppressNone    Suppression = "none"
+    SuppressPolling Suppression = "polling"
+    SuppressActions Suppression = "actions"
+    SuppressBoth    Suppression = "both"
+)
+
+// checks whether the API polling is suppressed.
+func (suppression Suppression) suppressesPolling() bool {
+    return suppression == SuppressPolling || suppression == SuppressBoth
+}
+
+// checks whether the remediation actions are suppressed.
+func (suppression Suppression) suppressesActions() bool {
+    return suppression == SuppressActions || suppression == SuppressBoth
+}
+
+// period around a scheduled block in which the nodes shall not be
+// bothered with API requests and/or remediation actions.
+type QuietPeriod struct {
+    // number of slots in front of the scheduled slot.
+    BeforeSlots uint64
+    // number of slots after the start of the scheduled slot.
+    AfterSlots uint64
+    // what is suppressed in the quiet period.
+    Suppress Suppression
+}
+
+// gets the default quiet period, which suppresses polling and actions
+// 10 slots before until 2 slots after the scheduled slot.
+func DefaultQuietPeriod() QuietPeriod {
+    return QuietPeriod{BeforeSlots: 10, AfterSlots: 2, Suppress: SuppressBoth}
 }
 
 func GetNodeMonitor(nodes []Node, behaviour NodeMonitorBehaviour, actions []Action,
-    watchdog *ScheduleWatchDog, settings *cardano.TimeSettings) *NodeMonitor {
+    watchdog *ScheduleWatchDog, settings *cardano.TimeSettings, auditLog *audit.Log) *NodeMonitor {
+    behaviour.Clock = clock.OrReal(behaviour.Clock)
+    if behaviour.CandidateQuietPeriod.Suppress == "" {
+        behaviour.CandidateQuietPeriod = DefaultQuietPeriod()
+    }
+    if behaviour.PassiveQuietPeriod.Suppress == "" {
+        behaviour.PassiveQuietPeriod = DefaultQuietPeriod()
+    }
     return &NodeMonitor{
-        nodes:        nodes,
-        behaviour:    behaviour,
-        actions:      actions,
-        timeSettings: settings,
-        watchDog:     watchdog,
+        nodes:          nodes,
+        behaviour:      behaviour,
+        actions:        actions,
+        timeSettings:   settings,
+        watchDog:       watchdog,
+        auditLog:       auditLog,
+        references:     make(map[string]referenceHeight),
+        referenceMutex: &sync.Mutex{},
+        startTimes:     make(map[string]time.Time),
         ListenerManager: &ListenerManager{
             mutex: &sync.Mutex{},
         },
@@ -66,9 +169,92 @@ func GetNodeMonitor(nodes []Node, behaviour NodeMonitorBehaviour, actions []Acti
 
 type ListenerManager struct {
     nodeStatsListeners []chan map[string]jor.NodeStatistic
+    checkListeners     []chan Check
     mutex              *sync.Mutex
 }
 
+// outcome of polling a single node in a check of the monitor.
+type NodeCheck struct {
+    Name string
+    Type NodeType
+    // statistics of the node, which are nil, if they could not be
+    // fetched or the node is bootstrapping.
+    Statistic     *jor.NodeStatistic
+    Bootstrapping bool
+    // error of the request for the statistics, if it failed.
+    Error error
+    // time it took the API of the node to respond.
+    Latency time.Duration
+}
+
+// outcome of a check of the monitor, which lists all the polled nodes.
+type Check struct {
+    Time  time.Time
+    Nodes []NodeCheck
+    // maximum block height among the polled nodes, nil if no
+    // statistics could be fetched.
+    MaximumBlockHeight *big.Int
+    // block heights reported by sources outside of the swarm such
+    // as the tip of the network according to Pool Tool.
+    ReferenceHeights map[string]*big.Int
+}
+
+// deviation of the start time of a node derived from its uptime, up to which
+// the node is not considered to have been restarted.
+const restartTolerance = time.Minute
+
+// records a restart of the given node in the audit log, if its start time
+// derived from the given uptime at the given time moved forward, i.e. its
+// uptime has been reset. this covers crashes and restarts by an operator
+// as well as the shutdowns issued by this tool.
+func (nodeMonitor *NodeMonitor) detectRestart(node Node, now time.Time, upTime time.Duration) {
+    start := now.Add(-upTime)
+    previousStart, found := nodeMonitor.startTimes[node.Name]
+    nodeMonitor.startTimes[node.Name] = start
+    if found && start.Sub(previousStart) > restartTolerance {
+        reason := fmt.Sprintf("The uptime has been reset to %v.", utils.GetHumanReadableUpTime(upTime))
+        log.Warnf("[MONITOR][%s][%s] %v", node.Name, getTypeAbbreviation(node.Type), reason)
+        nodeMonitor.auditLog.Record(node.Name, audit.Restart, reason, audit.Success, nil)
+    }
+}
+
+// gets the names of all the monitored nodes.
+func (nodeMonitor *NodeMonitor) GetNodeNames() []string {
+    names := make([]string, len(nodeMonitor.nodes))
+    for i, node := range nodeMonitor.nodes {
+        names[i] = node.Name
+    }
+    return names
+}
+
+// sets the block height reported by the given source outside of the swarm,
+// which is taken as reference in the checks for the given duration.
+func (nodeMonitor *NodeMonitor) SetReferenceHeight(source string, height *big.Int, validFor time.Duration) {
+    nodeMonitor.referenceMutex.Lock()
+    defer nodeMonitor.referenceMutex.Unlock()
+    nodeMonitor.references[source] = referenceHeight{
+        height:     height,
+        validUntil: nodeMonitor.behaviour.Clock.Now().Add(validFor),
+    }
+}
+
+// gets the block heights reported by sources outside of the swarm, which
+// are still valid.
+func (nodeMonitor *NodeMonitor) GetReferenceHeights() map[string]*big.Int {
+    nodeMonitor.referenceMutex.Lock()
+    defer nodeMonitor.referenceMutex.Unlock()
+    now := nodeMonitor.behaviour.Clock.Now()
+    heights := make(map[string]*big.Int)
+    for source, reference := range nodeMonitor.references {
+        if now.Before(reference.validUntil) {
+            heights[source] = reference.height
+        } else {
+            delete(nodeMonitor.references, source)
+        }
+    }
+    return heights
+}
+
 // register a listener for getting the most recent fetched node statistics for all
 // monitored nodes.
 func (listenerManager *ListenerManager) RegisterNodeStatisticListener(listener chan map[string]jor.NodeStatistic) {
@@ -81,6 +267,14 @@ func (listenerManager *ListenerManager) RegisterNodeStatisticListener(listener c
     }
 }
 
+// register a listener for getting the outcome of each check of the monitor,
+// including the latency of the APIs and failed requests.
+func (listenerManager *ListenerManager) RegisterCheckListener(listener chan Check) {
+    listenerManager.mutex.Lock()
+    defer listenerManager.mutex.Unlock()
+    listenerManager.checkListeners = append(listenerManager.checkListeners, listener)
+}
+
 func getTypeAbbreviation(t NodeType) string {
     switch t {
     case Passive:
@@ -96,105 +290,206 @@ func getTypeAbbreviation(t NodeType) string {
 func (nodeMonitor *NodeMonitor) Watch() {
     log.Infof("Starting to watch nodes.")
     for ; ; {
-        start := time.Now()
-        // skip monitor checks before scheduled block
-        if nodeMonitor.watchDog != nil {
-            currentSlotDate, _ := nodeMonitor.timeSettings.GetSlotDateFor(time.Now())
-            schedule, found := nodeMonitor.watchDog.GetScheduleFor(currentSlotDate.GetEpoch())
-            if found {
-                if len(schedule) > 0 {
-                    log.Infof("[SCHEDULE] %v leader assignments for epoch %v.", len(schedule),
-                        currentSlotDate.GetEpoch().String())
-                    futureSchedule := jor.FilterLeaderLogsBefore(time.Now().Add(-2*nodeMonitor.timeSettings.SlotDuration), schedule)
-                    if len(futureSchedule) > 0 {
-                        log.Infof("[SCHEDULE] Number of leader assignments ahead: %v", len(futureSchedule))
-                        log.Infof("[SCHEDULE] Next leader assignments at %v", futureSchedule[0].ScheduleTime.String())
-                        timeToNextBlock := futureSchedule[0].ScheduleTime.Sub(time.Now())
-                        if timeToNextBlock < 10*nodeMonitor.timeSettings.SlotDuration {
-                            time.Sleep(nodeMonitor.behaviour.Interval)
-                            continue
-                        }
-                    } else {
-                        log.Infof("[SCHEDULE] No leader assignments ahead.")
-                    }
-                }
+        start := nodeMonitor.behaviour.Clock.Now()
+        candidateSuppression, passiveSuppression := nodeMonitor.getSuppressions()
+        // skip monitor checks in the quiet period before scheduled block
+        if candidateSuppression.suppressesPolling() && passiveSuppression.suppressesPolling() {
+            nodeMonitor.behaviour.Clock.Sleep(nodeMonitor.behaviour.Interval)
+            continue
+        }
+        nodeMonitor.checkWith(candidateSuppression, passiveSuppression)
+        diff := start.Add(nodeMonitor.behaviour.Interval).Sub(nodeMonitor.behaviour.Clock.Now())
+        if diff > 0 {
+            nodeMonitor.behaviour.Clock.Sleep(diff)
+        }
+    }
+}
+
+// gets the suppression in effect for leader candidates and passive nodes
+// right now, i.e. whether they are in their quiet period. each quiet period
+// is evaluated on its own, with its own number of slots after a block.
+func (nodeMonitor *NodeMonitor) getSuppressions() (Suppression, Suppression) {
+    now := nodeMonitor.behaviour.Clock.Now()
+    schedule, found := nodeMonitor.getCurrentSchedule(now)
+    if !found {
+        return SuppressNone, SuppressNone
+    }
+    return nodeMonitor.getSuppression(nodeMonitor.behaviour.CandidateQuietPeriod, schedule, now),
+        nodeMonitor.getSuppression(nodeMonitor.behaviour.PassiveQuietPeriod, schedule, now)
+}
+
+// gets the suppression of the given quiet period, if any of the given
+// assignments lies within it at the given time, i.e. it is scheduled in
+// less than the slots before or at most the slots after the time.
+func (nodeMonitor *NodeMonitor) getSuppression(period QuietPeriod, schedule []jor.LeaderAssignment,
+    now time.Time) Suppression {
+    slotDuration := nodeMonitor.timeSettings.SlotDuration
+    for i := range schedule {
+        timeToBlock := schedule[i].ScheduleTime.Sub(now)
+        if timeToBlock < time.Duration(period.BeforeSlots)*slotDuration &&
+            timeToBlock >= -time.Duration(period.AfterSlots)*slotDuration {
+            return period.Suppress
+        }
+    }
+    return SuppressNone
+}
+
+// gets the leader assignments of the epoch of the given time. false is
+// returned, if no such assignment is known.
+func (nodeMonitor *NodeMonitor) getCurrentSchedule(now time.Time) ([]jor.LeaderAssignment, bool) {
+    if nodeMonitor.watchDog != nil {
+        currentSlotDate, _ := nodeMonitor.timeSettings.GetSlotDateFor(now)
+        schedule, found := nodeMonitor.watchDog.GetScheduleFor(currentSlotDate.GetEpoch())
+        if found && len(schedule) > 0 {
+            log.Infof("[SCHEDULE] %v leader assignments for epoch %v.", len(schedule),
+                currentSlotDate.GetEpoch().String())
+            futureSchedule := jor.FilterLeaderLogsBefore(now, schedule)
+            if len(futureSchedule) > 0 {
+                log.Infof("[SCHEDULE] Number of leader assignments ahead: %v", len(futureSchedule))
+                log.Infof("[SCHEDULE] Next leader assignments at %v", futureSchedule[0].ScheduleTime.String())
+            } else {
+                log.Infof("[SCHEDULE] No leader assignments ahead.")
             }
+            return schedule, true
         }
-        // get node statistics
-        blockHeightMap := make(map[string]*big.Int)
-        lastBlockMap := make(map[string]jor.NodeStatistic)
-        inputs := make([]interface{}, len(nodeMonitor.nodes))
-        for i, node := range nodeMonitor.nodes {
-            inputs[i] = node
+    }
+    return nil, false
+}
+
+// fetches the node statistics of all the nodes, informs the listeners about
+// them and performs the actions of this monitor. the fetched node statistics
+// are returned.
+func (nodeMonitor *NodeMonitor) check() map[string]jor.NodeStatistic {
+    return nodeMonitor.checkWith(SuppressNone, SuppressNone)
+}
+
+// gets the suppression for the given node out of the given suppressions for
+// leader candidates and passive nodes.
+func getSuppressionFor(node Node, candidateSuppression Suppression, passiveSuppression Suppression) Suppression {
+    if node.Type == LeaderCandidate {
+        return candidateSuppression
+    }
+    return passiveSuppression
+}
+
+// fetches the node statistics of all the nodes, whose polling is not suppressed
+// by the given suppressions for leader candidates and passive nodes, and performs
+// the actions of this monitor for the nodes, whose actions are not suppressed.
+// the listeners are only informed, if the leader candidates have been polled.
+func (nodeMonitor *NodeMonitor) checkWith(candidateSuppression Suppression,
+    passiveSuppression Suppression) map[string]jor.NodeStatistic {
+    // get node statistics
+    check := Check{Time: nodeMonitor.behaviour.Clock.Now(), Nodes: make([]NodeCheck, 0, len(nodeMonitor.nodes))}
+    blockHeightMap := make(map[string]*big.Int)
+    lastBlockMap := make(map[string]jor.NodeStatistic)
+    inputs := make([]interface{}, 0, len(nodeMonitor.nodes))
+    actionNodes := make([]Node, 0, len(nodeMonitor.nodes))
+    for _, node := range nodeMonitor.nodes {
+        suppression := getSuppressionFor(node, candidateSuppression, passiveSuppression)
+        if !suppression.suppressesPolling() {
+            inputs = append(inputs, node)
         }
-        responses := threading.Complete(inputs, getNodeStatistics)
-        sort.SliceStable(responses, func(i, j int) bool {
-            nodeA := responses[i].Context.(Node)
-            nodeB := responses[j].Context.(Node)
-            return nodeA.Name < nodeB.Name
-        })
-        for _, response := range responses {
-            node := response.Context.(Node)
-            if response.Error == nil && response.Data != nil {
-                statsResponse := response.Data.(*nodeStatisticResponse)
-                if !statsResponse.bootstrapping {
-                    if statsResponse.nodeStats != nil {
-                        lastBlockMap[node.Name] = *statsResponse.nodeStats
-                        log.Infof("[MONITOR][%s][%s] Block Height: <%v>, Date: <%v>, Hash: <%v>, UpTime: <%v>", node.Name,
-                            getTypeAbbreviation(node.Type), statsResponse.nodeStats.LastBlockHeight.String(),
-                            statsResponse.nodeStats.LastBlockDate.String(),
-                            statsResponse.nodeStats.LastBlockHash[:8],
-                            utils.GetHumanReadableUpTime(statsResponse.nodeStats.UpTime),
-                        )
-                        blockHeightMap[node.Name] = statsResponse.nodeStats.LastBlockHeight
-                    } else {
-                        log.Errorf("[MONITOR][%s][%s] Node statistics cannot be fetched.", node.Name, getTypeAbbreviation(node.Type))
-                    }
+        if !suppression.suppressesActions() {
+            actionNodes = append(actionNodes, node)
+        }
+    }
+    responses := threading.Complete(inputs, getNodeStatistics)
+    sort.SliceStable(responses, func(i, j int) bool {
+        nodeA := responses[i].Context.(Node)
+        nodeB := responses[j].Context.(Node)
+        return nodeA.Name < nodeB.Name
+    })
+    for _, response := range responses {
+        node := response.Context.(Node)
+        nodeCheck := NodeCheck{Name: node.Name, Type: node.Type, Error: response.Error}
+        if response.Data != nil {
+            statsResponse := response.Data.(*nodeStatisticResponse)
+            nodeCheck.Latency = statsResponse.latency
+            nodeCheck.Bootstrapping = statsResponse.bootstrapping
+            if response.Error == nil && !statsResponse.bootstrapping {
+                nodeCheck.Statistic = statsResponse.nodeStats
+            }
+        }
+        check.Nodes = append(check.Nodes, nodeCheck)
+        if response.Error == nil && response.Data != nil {
+            statsResponse := response.Data.(*nodeStatisticResponse)
+            if !statsResponse.bootstrapping {
+                if statsResponse.nodeStats != nil {
+                    lastBlockMap[node.Name] = *statsResponse.nodeStats
+                    log.Infof("[MONITOR][%s][%s] Block Height: <%v>, Date: <%v>, Hash: <%v>, UpTime: <%v>", node.Name,
+                        getTypeAbbreviation(node.Type), statsResponse.nodeStats.LastBlockHeight.String(),
+                        statsResponse.nodeStats.LastBlockDate.String(),
+                        statsResponse.nodeStats.LastBlockHash[:8],
+                        utils.GetHumanReadableUpTime(statsResponse.nodeStats.UpTime),
+                    )
+                    blockHeightMap[node.Name] = statsResponse.nodeStats.LastBlockHeight
+                    nodeMonitor.detectRestart(node, check.Time, statsResponse.nodeStats.UpTime)
                 } else {
-                    log.Infof("[MONITOR][%s][%s] --- bootstrapping ---", node.Name, getTypeAbbreviation(node.Type))
+                    log.Errorf("[MONITOR][%s][%s] Node statistics cannot be fetched.", node.Name, getTypeAbbreviation(node.Type))
                 }
             } else {
-                log.Infof("[MONITOR][%s][%s] Node statistics cannot be fetched.", node.Name,
-                    getTypeAbbreviation(node.Type))
-                log.Errorf("[MONITOR][%s][%s] Error: %v", response.Error.Error())
+                log.Infof("[MONITOR][%s][%s] --- bootstrapping ---", node.Name, getTypeAbbreviation(node.Type))
             }
+        } else {
+            log.Infof("[MONITOR][%s][%s] Node statistics cannot be fetched.", node.Name,
+                getTypeAbbreviation(node.Type))
+            log.Errorf("[MONITOR][%s][%s] Error: %v", node.Name, getTypeAbbreviation(node.Type), response.Error.Error())
         }
-        // send block infos to leader jury
+    }
+    // send block infos to leader jury, the candidates would be considered
+    // unhealthy, if their statistics are missing due to the quiet period.
+    if !candidateSuppression.suppressesPolling() {
         nodeMonitor.ListenerManager.mutex.Lock()
         for i := range nodeMonitor.ListenerManager.nodeStatsListeners {
             nodeMonitor.ListenerManager.nodeStatsListeners[i] <- lastBlockMap
         }
         nodeMonitor.ListenerManager.mutex.Unlock()
-        maxHeight, nodes := utils.MaxInt(blockHeightMap)
-        // perform actions
-        for n := range nodeMonitor.actions {
-            go nodeMonitor.actions[n].execute(nodeMonitor.nodes, ActionContext{
-                TimeSettings:         nodeMonitor.timeSettings,
-                BlockHeightMap:       blockHeightMap,
-                MaximumBlockHeight:   maxHeight,
-                UpToDateNodes:        nodes,
-                LastNodeStatisticMap: lastBlockMap,
-            })
-        }
-        diff := start.Add(nodeMonitor.behaviour.Interval).Sub(time.Now())
-        if diff > 0 {
-            time.Sleep(diff)
+    }
+    maxHeight, nodes := utils.MaxInt(blockHeightMap)
+    check.MaximumBlockHeight = maxHeight
+    check.ReferenceHeights = nodeMonitor.GetReferenceHeights()
+    for source, height := range check.ReferenceHeights {
+        if maxHeight != nil {
+            log.Infof("[MONITOR][%s] Reference Block Height: <%v>, Lag: <%v>", source, height.String(),
+                new(big.Int).Sub(height, maxHeight).String())
         }
     }
+    nodeMonitor.ListenerManager.mutex.Lock()
+    for i := range nodeMonitor.ListenerManager.checkListeners {
+        nodeMonitor.ListenerManager.checkListeners[i] <- check
+    }
+    nodeMonitor.ListenerManager.mutex.Unlock()
+    // perform actions
+    for n := range nodeMonitor.actions {
+        go nodeMonitor.actions[n].execute(actionNodes, ActionContext{
+            TimeSettings:         nodeMonitor.timeSettings,
+            BlockHeightMap:       blockHeightMap,
+            MaximumBlockHeight:   maxHeight,
+            UpToDateNodes:        nodes,
+            LastNodeStatisticMap: lastBlockMap,
+            AuditLog:             nodeMonitor.auditLog,
+            Clock:                nodeMonitor.behaviour.Clock,
+        })
+    }
+    return lastBlockMap
 }
 
 type nodeStatisticResponse struct {
     bootstrapping bool
     nodeStats     *jor.NodeStatistic
+    latency       time.Duration
 }
 
 // gets the node statistics for the given n
 func getNodeStatistics(input interface{}) threading.Response {
     node := input.(Node)
+    start := time.Now()
     nodeStats, bootstrapping, err := node.API.GetNodeStatistics()
+    latency := time.Since(start)
     if err != nil {
         return threading.Response{
             Context: node,
+            Data:    &nodeStatisticResponse{latency: latency},
             Error:   err,
         }
     } else {
@@ -203,6 +498,7 @@ func getNodeStatistics(input interface{}) threading.Response {
             Data: &nodeStatisticResponse{
                 bootstrapping: bootstrapping,
                 nodeStats:     nodeStats,
+                latency:       latency,
             },
         }
     }
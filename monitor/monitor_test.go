@@ -0,0 +1,260 @@
+package monitor
+
+import (
+    "github.com/sobitada/go-cardano"
+    jor "github.com/sobitada/go-jormungandr/api"
+    "github.com/sobitada/thor/audit"
+    "github.com/sobitada/thor/jortest"
+    "github.com/sobitada/thor/storage"
+    "github.com/stretchr/testify/assert"
+    "math/big"
+    "net/http"
+    "testing"
+    "time"
+)
+
+func TestNodeMonitor_NodeLaggingBehind_mustBeShutDown(t *testing.T) {
+    clock := jortest.NewClock(time.Now())
+    a, b, c := jortest.NewNodeWithClock(clock), jortest.NewNodeWithClock(clock), jortest.NewNodeWithClock(clock)
+    defer a.Close()
+    defer b.Close()
+    defer c.Close()
+    a.SetTip(100, jortest.Hash(100), cardano.PlainSlotDateFromInt(5, 100))
+    b.SetTip(100, jortest.Hash(100), cardano.PlainSlotDateFromInt(5, 100))
+    c.SetTip(80, jortest.Hash(80), cardano.PlainSlotDateFromInt(5, 60))
+    clock.Advance(1 * time.Hour)
+    nodes := []Node{
+        {Name: "a", API: a.API(time.Second), MaxBlockLag: 10, WarmUpTime: 10 * time.Minute},
+        {Name: "b", API: b.API(time.Second), MaxBlockLag: 10, WarmUpTime: 10 * time.Minute},
+        {Name: "c", API: c.API(time.Second), MaxBlockLag: 10, WarmUpTime: 10 * time.Minute},
+    }
+    mon := GetNodeMonitor(nodes, NodeMonitorBehaviour{Interval: time.Second},
+        []Action{ShutDownWithBlockLagAction{}}, nil, nil, nil)
+    stats := mon.check()
+    assert.Len(t, stats, 3)
+    assert.Eventually(t, func() bool { return c.Shutdowns() > 0 }, 2*time.Second, 10*time.Millisecond)
+    assert.Zero(t, a.Shutdowns())
+    assert.Zero(t, b.Shutdowns())
+}
+
+func TestNodeMonitor_NodeInWarmUp_mustNotBeShutDown(t *testing.T) {
+    a, b := jortest.NewNode(), jortest.NewNode()
+    defer a.Close()
+    defer b.Close()
+    a.SetTip(100, jortest.Hash(100), cardano.PlainSlotDateFromInt(5, 100))
+    b.SetTip(20, jortest.Hash(20), cardano.PlainSlotDateFromInt(5, 10))
+    nodes := []Node{
+        {Name: "a", API: a.API(time.Second), MaxBlockLag: 10, WarmUpTime: 10 * time.Minute},
+        {Name: "b", API: b.API(time.Second), MaxBlockLag: 10, WarmUpTime: 10 * time.Minute},
+    }
+    mon := GetNodeMonitor(nodes, NodeMonitorBehaviour{Interval: time.Second},
+        []Action{ShutDownWithBlockLagAction{}}, nil, nil, nil)
+    mon.check()
+    time.Sleep(300 * time.Millisecond)
+    assert.Zero(t, b.Shutdowns())
+}
+
+func TestNodeMonitor_StuckNode_mustBeShutDown(t *testing.T) {
+    now := time.Now()
+    clock := jortest.NewClock(now)
+    settings := jortest.TimeSettingsAt(now, 10, 50, time.Second, 100)
+    a := jortest.NewNodeWithClock(clock)
+    defer a.Close()
+    a.SetTip(100, jortest.Hash(100), cardano.PlainSlotDateFromInt(10, 0))
+    clock.Advance(1 * time.Hour)
+    nodes := []Node{{Name: "a", API: a.API(time.Second), MaxTimeSinceLastBlock: 30 * time.Second}}
+    mon := GetNodeMonitor(nodes, NodeMonitorBehaviour{Interval: time.Second},
+        []Action{ShutDownWhenStuck{}}, nil, settings, nil)
+    mon.check()
+    assert.Eventually(t, func() bool { return a.Shutdowns() > 0 }, 2*time.Second, 10*time.Millisecond)
+}
+
+func TestNodeMonitor_BootstrappingAndFailingNodes_mustBeIgnored(t *testing.T) {
+    a, b, c := jortest.NewNode(), jortest.NewNode(), jortest.NewNode()
+    defer a.Close()
+    defer b.Close()
+    defer c.Close()
+    a.SetTip(100, jortest.Hash(100), cardano.PlainSlotDateFromInt(5, 100))
+    b.SetBootstrapping(true)
+    c.Fail(jortest.StatsEndpoint, http.StatusInternalServerError)
+    nodes := []Node{
+        {Name: "a", API: a.API(time.Second)},
+        {Name: "b", API: b.API(time.Second)},
+        {Name: "c", API: c.API(time.Second)},
+    }
+    mon := GetNodeMonitor(nodes, NodeMonitorBehaviour{Interval: time.Second}, []Action{}, nil, nil, nil)
+    stats := mon.check()
+    if assert.Len(t, stats, 1) {
+        assert.Equal(t, uint64(100), stats["a"].LastBlockHeight.Uint64())
+    }
+}
+
+func TestNodeMonitor_CheckListener_mustReceiveOutcomeOfAllPolledNodes(t *testing.T) {
+    a, b, c := jortest.NewNode(), jortest.NewNode(), jortest.NewNode()
+    defer a.Close()
+    defer b.Close()
+    defer c.Close()
+    a.SetTip(100, jortest.Hash(100), cardano.PlainSlotDateFromInt(5, 100))
+    b.SetBootstrapping(true)
+    c.Fail(jortest.StatsEndpoint, http.StatusInternalServerError)
+    nodes := []Node{
+        {Name: "a", API: a.API(time.Second)},
+        {Name: "b", API: b.API(time.Second)},
+        {Name: "c", API: c.API(time.Second)},
+    }
+    mon := GetNodeMonitor(nodes, NodeMonitorBehaviour{Interval: time.Second}, []Action{}, nil, nil, nil)
+    checks := make(chan Check, 1)
+    mon.ListenerManager.RegisterCheckListener(checks)
+    mon.check()
+    check := <-checks
+    if assert.Len(t, check.Nodes, 3) {
+        assert.NotNil(t, check.Nodes[0].Statistic)
+        assert.True(t, check.Nodes[0].Latency > 0)
+        assert.True(t, check.Nodes[1].Bootstrapping)
+        assert.Nil(t, check.Nodes[1].Statistic)
+        assert.NotNil(t, check.Nodes[2].Error)
+    }
+    assert.Equal(t, uint64(100), check.MaximumBlockHeight.Uint64())
+}
+
+func TestNodeMonitor_BlockScheduledSoon_mustSkipCheck(t *testing.T) {
+    now := time.Now()
+    settings := jortest.TimeSettingsAt(now, 5, 10, time.Second, 1000)
+    a := jortest.NewNode()
+    defer a.Close()
+    a.SetSchedule([]jor.LeaderAssignment{jortest.Assignment(5, 15, *settings)})
+    nodes := []Node{{Name: "a", Type: LeaderCandidate, API: a.API(time.Second)}}
+    db := storage.NewMemory()
+    defer db.Close()
+    watchDog := NewScheduleWatchDog(nodes, settings, db, DefaultScheduleSettings())
+    watchDog.watchCurrentEpoch()
+    mon := GetNodeMonitor(nodes, NodeMonitorBehaviour{Interval: time.Second}, []Action{}, watchDog, settings, nil)
+    candidateSuppression, passiveSuppression := mon.getSuppressions()
+    assert.Equal(t, SuppressBoth, candidateSuppression)
+    assert.Equal(t, SuppressBoth, passiveSuppression)
+}
+
+func TestNodeMonitor_AsymmetricQuietPeriods_mustBeEvaluatedSeparately(t *testing.T) {
+    now := time.Now()
+    clock := jortest.NewClock(now)
+    settings := jortest.TimeSettingsAt(now, 5, 10, time.Second, 1000)
+    db := storage.NewMemory()
+    defer db.Close()
+    watchDog := NewScheduleWatchDog([]Node{}, settings, db, DefaultScheduleSettings())
+    mon := GetNodeMonitor([]Node{}, NodeMonitorBehaviour{
+        Interval:             time.Second,
+        CandidateQuietPeriod: QuietPeriod{BeforeSlots: 10, AfterSlots: 0, Suppress: SuppressPolling},
+        PassiveQuietPeriod:   QuietPeriod{BeforeSlots: 0, AfterSlots: 5, Suppress: SuppressActions},
+        Clock:                clock,
+    }, []Action{}, watchDog, settings, nil)
+    // a block 3 slots ago and one 5 slots ahead.
+    watchDog.scheduleMap["5"] = []jor.LeaderAssignment{jortest.Assignment(5, 7, *settings),
+        jortest.Assignment(5, 15, *settings)}
+    candidateSuppression, passiveSuppression := mon.getSuppressions()
+    assert.Equal(t, SuppressPolling, candidateSuppression)
+    assert.Equal(t, SuppressActions, passiveSuppression)
+    // only the block 3 slots ago.
+    watchDog.scheduleMap["5"] = []jor.LeaderAssignment{jortest.Assignment(5, 7, *settings)}
+    candidateSuppression, passiveSuppression = mon.getSuppressions()
+    assert.Equal(t, SuppressNone, candidateSuppression)
+    assert.Equal(t, SuppressActions, passiveSuppression)
+}
+
+func TestNodeMonitor_QuietPeriodOnlyForCandidates_mustStillWatchPassiveNodes(t *testing.T) {
+    now := time.Now()
+    clock := jortest.NewClock(now)
+    settings := jortest.TimeSettingsAt(now, 5, 10, time.Second, 1000)
+    a, p := jortest.NewNodeWithClock(clock), jortest.NewNodeWithClock(clock)
+    defer a.Close()
+    defer p.Close()
+    a.SetSchedule([]jor.LeaderAssignment{jortest.Assignment(5, 15, *settings)})
+    a.SetTip(100, jortest.Hash(100), cardano.PlainSlotDateFromInt(5, 9))
+    p.SetTip(80, jortest.Hash(80), cardano.PlainSlotDateFromInt(5, 1))
+    nodes := []Node{
+        {Name: "a", Type: LeaderCandidate, API: a.API(time.Second), MaxBlockLag: 10},
+        {Name: "p", Type: Passive, API: p.API(time.Second), MaxBlockLag: 10},
+    }
+    db := storage.NewMemory()
+    defer db.Close()
+    watchDog := NewScheduleWatchDog(nodes[:1], settings, db, DefaultScheduleSettings())
+    watchDog.watchCurrentEpoch()
+    mon := GetNodeMonitor(nodes, NodeMonitorBehaviour{
+        Interval:             time.Second,
+        CandidateQuietPeriod: QuietPeriod{BeforeSlots: 10, AfterSlots: 2, Suppress: SuppressPolling},
+        PassiveQuietPeriod:   QuietPeriod{Suppress: SuppressNone},
+        Clock:                clock,
+    }, []Action{ShutDownWithBlockLagAction{}}, watchDog, settings, nil)
+    listener := make(chan map[string]jor.NodeStatistic, 1)
+    mon.ListenerManager.RegisterNodeStatisticListener(listener)
+
+    candidateSuppression, passiveSuppression := mon.getSuppressions()
+    assert.Equal(t, SuppressPolling, candidateSuppression)
+    assert.Equal(t, SuppressNone, passiveSuppression)
+    stats := mon.checkWith(candidateSuppression, passiveSuppression)
+    assert.Len(t, stats, 1)
+    assert.Contains(t, stats, "p")
+    assert.Zero(t, a.Requests(jortest.StatsEndpoint))
+    // the jury must not see the candidate missing.
+    assert.Len(t, listener, 0)
+}
+
+func TestNodeMonitor_ActionsSuppressed_mustNotShutDownNode(t *testing.T) {
+    clock := jortest.NewClock(time.Now())
+    a, b := jortest.NewNodeWithClock(clock), jortest.NewNodeWithClock(clock)
+    defer a.Close()
+    defer b.Close()
+    a.SetTip(100, jortest.Hash(100), cardano.PlainSlotDateFromInt(5, 100))
+    b.SetTip(80, jortest.Hash(80), cardano.PlainSlotDateFromInt(5, 60))
+    clock.Advance(1 * time.Hour)
+    nodes := []Node{
+        {Name: "a", Type: LeaderCandidate, API: a.API(time.Second), MaxBlockLag: 10},
+        {Name: "b", Type: LeaderCandidate, API: b.API(time.Second), MaxBlockLag: 10},
+    }
+    mon := GetNodeMonitor(nodes, NodeMonitorBehaviour{Interval: time.Second},
+        []Action{ShutDownWithBlockLagAction{}}, nil, nil, nil)
+    stats := mon.checkWith(SuppressActions, SuppressNone)
+    assert.Len(t, stats, 2)
+    time.Sleep(300 * time.Millisecond)
+    assert.Zero(t, b.Shutdowns())
+}
+
+func TestNodeMonitor_ReferenceHeight_mustBeReportedUntilExpired(t *testing.T) {
+    clock := jortest.NewClock(time.Now())
+    a := jortest.NewNodeWithClock(clock)
+    defer a.Close()
+    a.SetTip(100, jortest.Hash(100), cardano.PlainSlotDateFromInt(5, 100))
+    mon := GetNodeMonitor([]Node{{Name: "a", API: a.API(time.Second)}},
+        NodeMonitorBehaviour{Interval: time.Second, Clock: clock}, nil, nil, nil, nil)
+    checks := make(chan Check, 2)
+    mon.ListenerManager.RegisterCheckListener(checks)
+    mon.SetReferenceHeight("pooltool", big.NewInt(110), time.Minute)
+    mon.check()
+    check := <-checks
+    if assert.Len(t, check.ReferenceHeights, 1) {
+        assert.Equal(t, big.NewInt(110), check.ReferenceHeights["pooltool"])
+    }
+    clock.Advance(2 * time.Minute)
+    mon.check()
+    check = <-checks
+    assert.Empty(t, check.ReferenceHeights)
+}
+
+func TestNodeMonitor_UptimeReset_mustBeRecordedAsRestart(t *testing.T) {
+    auditLog, err := audit.NewLog(storage.NewMemory(), nil, nil)
+    if err != nil {
+        t.Fatal(err)
+    }
+    node := Node{Name: "a", Type: Passive}
+    mon := GetNodeMonitor([]Node{node}, NodeMonitorBehaviour{Interval: time.Second}, nil, nil, nil, auditLog)
+    now := time.Date(2020, 6, 1, 12, 0, 0, 0, time.UTC)
+    mon.detectRestart(node, now, 2*time.Hour)
+    mon.detectRestart(node, now.Add(time.Minute), 2*time.Hour+time.Minute+time.Second)
+    entries, _ := auditLog.Query(audit.Filter{Action: audit.Restart})
+    assert.Empty(t, entries)
+    mon.detectRestart(node, now.Add(2*time.Minute), 30*time.Second)
+    entries, _ = auditLog.Query(audit.Filter{Action: audit.Restart})
+    if assert.Len(t, entries, 1) {
+        assert.Equal(t, "a", entries[0].Node)
+        assert.Equal(t, audit.Success, entries[0].Outcome)
+    }
+}
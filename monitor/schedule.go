@@ -2,11 +2,11 @@ package monitor
 
 import (
     "encoding/json"
-    "errors"
-    "github.com/boltdb/bolt"
     log "github.com/sirupsen/logrus"
     "github.com/sobitada/go-cardano"
     "github.com/sobitada/go-jormungandr/api"
+    "github.com/sobitada/thor/clock"
+    "github.com/sobitada/thor/storage"
     "github.com/sobitada/thor/threading"
     "github.com/sobitada/thor/utils"
     "math/big"
@@ -16,32 +16,126 @@ import (
 
 type ScheduleWatchDog struct {
     nodes             []Node
-    db                *bolt.DB
+    store             storage.Store
+    settings          ScheduleSettings
     viableLeaderNodes viableLeaderNodes
     scheduleMap       map[string][]api.LeaderAssignment
     timeSettings      *cardano.TimeSettings
     mutex             *sync.RWMutex
     listeners         listener
+    clock             clock.Clock
 }
 
 type viableLeaderNodes struct {
-    epochMap map[string][]string
-    mutex    *sync.Mutex
+    epochMap      map[string][]string
+    differenceMap map[string]map[string]ScheduleDifference
+    eventMap      map[string][]ViabilityEvent
+    mutex         *sync.Mutex
+}
+
+// removes the viability of the epochs before the previous epoch of the
+// given epoch. the caller must hold the mutex.
+func (viable viableLeaderNodes) prune(epoch *big.Int) {
+    for key := range viable.epochMap {
+        if isBeforePreviousEpoch(key, epoch) {
+            delete(viable.epochMap, key)
+        }
+    }
+    for key := range viable.differenceMap {
+        if isBeforePreviousEpoch(key, epoch) {
+            delete(viable.differenceMap, key)
+        }
+    }
+    for key := range viable.eventMap {
+        if isBeforePreviousEpoch(key, epoch) {
+            delete(viable.eventMap, key)
+        }
+    }
+}
+
+// checks whether the given key denotes an epoch before the previous epoch
+// of the given epoch.
+func isBeforePreviousEpoch(key string, epoch *big.Int) bool {
+    keyEpoch, ok := new(big.Int).SetString(key, 10)
+    return ok && keyEpoch.Cmp(new(big.Int).Sub(epoch, new(big.Int).SetInt64(1))) < 0
 }
 
 type listener struct {
-    list  []chan []api.LeaderAssignment
-    mutex *sync.Mutex
+    list          []chan []api.LeaderAssignment
+    viabilityList []chan ViabilityEvent
+    mutex         *sync.Mutex
+}
+
+// settings for the cadence of the schedule watchdog.
+type ScheduleSettings struct {
+    // number of slots after the epoch turn over in which
+    // the fetching of a missing schedule is retried often.
+    EarlyEpochSlots uint64
+    // number of slots between attempts to fetch a missing
+    // schedule early in the epoch.
+    EarlyRetrySlots uint64
+    // time between attempts to fetch a missing schedule
+    // later in the epoch.
+    RetryInterval time.Duration
+    // minimum time between re-checks of the viability of a
+    // candidate, the time grows with the time since the turn over.
+    ViabilityMinRetryInterval time.Duration
+    // maximum time between re-checks of the viability.
+    ViabilityMaxRetryInterval time.Duration
+    // time zone in which the schedule is exported, UTC is
+    // used if it is nil.
+    Location *time.Location
+    // clock used for waiting between checks, the clock
+    // of the system is used if it is nil.
+    Clock clock.Clock
+}
+
+// gets the default settings of the schedule watchdog.
+func DefaultScheduleSettings() ScheduleSettings {
+    return ScheduleSettings{
+        EarlyEpochSlots:           500,
+        EarlyRetrySlots:           50,
+        RetryInterval:             10 * time.Minute,
+        ViabilityMinRetryInterval: 10 * time.Second,
+        ViabilityMaxRetryInterval: 10 * time.Minute,
+        Location:                  time.UTC,
+    }
+}
+
+// gets the time to wait before the next re-check of the viability, given the
+// time that has passed since the epoch turn over. it is as long as the passed
+// time within the bounds, such that candidates are re-checked often right after
+// the turn over and less often later.
+func (settings ScheduleSettings) viabilityRetryInterval(sinceTurnOver time.Duration) time.Duration {
+    interval := utils.MinDuration(sinceTurnOver, settings.ViabilityMaxRetryInterval)
+    if interval < settings.ViabilityMinRetryInterval {
+        return settings.ViabilityMinRetryInterval
+    }
+    return interval
+}
+
+// event informing that a leader candidate has become viable.
+type ViabilityEvent struct {
+    // epoch in which the candidate has become viable.
+    Epoch string `json:"epoch"`
+    // name of the candidate.
+    Node string `json:"node"`
+    // time at which the candidate has become viable.
+    Time time.Time `json:"time"`
+    // number of checks needed until the candidate has
+    // become viable.
+    Checks int `json:"checks"`
 }
 
 // creates a new schedule watchdog for the given nodes and time
-// settings of the block chain. both are required.
-func NewScheduleWatchDog(nodes []Node, timeSettings *cardano.TimeSettings, db *bolt.DB) *ScheduleWatchDog {
+// settings of the block chain. both are required. the settings
+// specify the cadence of the watchdog.
+func NewScheduleWatchDog(nodes []Node, timeSettings *cardano.TimeSettings, store storage.Store,
+    settings ScheduleSettings) *ScheduleWatchDog {
     scheduleMap := make(map[string][]api.LeaderAssignment)
     listenerList := make([]chan []api.LeaderAssignment, 0)
-    err := db.Update(func(tx *bolt.Tx) error {
-        _, err := tx.CreateBucketIfNotExists([]byte("schedule"))
-        return err
+    err := store.Update(func(tx storage.Tx) error {
+        return tx.CreateBucket(storage.ScheduleBucket)
     })
     if err != nil {
         log.Fatal(err.Error())
@@ -52,14 +146,18 @@ func NewScheduleWatchDog(nodes []Node, timeSettings *cardano.TimeSettings, db *b
         timeSettings: timeSettings,
         mutex:        &sync.RWMutex{},
         viableLeaderNodes: viableLeaderNodes{
-            epochMap: map[string][]string{},
-            mutex:    &sync.Mutex{},
+            epochMap:      map[string][]string{},
+            differenceMap: map[string]map[string]ScheduleDifference{},
+            eventMap:      map[string][]ViabilityEvent{},
+            mutex:         &sync.Mutex{},
         },
-        db: db,
+        store: store,
         listeners: listener{
             list:  listenerList,
             mutex: &sync.Mutex{},
         },
+        settings: settings,
+        clock:    clock.OrReal(settings.Clock),
     }
 }
 
@@ -70,6 +168,14 @@ func (watchDog *ScheduleWatchDog) RegisterListener(listener chan []api.LeaderAss
     watchDog.listeners.list = append(watchDog.listeners.list, listener)
 }
 
+// registers a listener that will be informed, whenever a leader candidate
+// has become viable. the listener must continuously consume the events.
+func (watchDog *ScheduleWatchDog) RegisterViabilityListener(listener chan ViabilityEvent) {
+    watchDog.listeners.mutex.Lock()
+    defer watchDog.listeners.mutex.Unlock()
+    watchDog.listeners.viabilityList = append(watchDog.listeners.viabilityList, listener)
+}
+
 // gets the schedule for the given epoch and boolean value indicating, whether
 // the schedule has been fetched.
 func (watchDog *ScheduleWatchDog) GetScheduleFor(epoch *big.Int) ([]api.LeaderAssignment, bool) {
@@ -79,15 +185,67 @@ func (watchDog *ScheduleWatchDog) GetScheduleFor(epoch *big.Int) ([]api.LeaderAs
     return schedule, found
 }
 
+// gets the time of the next scheduled block in the current epoch after the
+// given time. false is returned, if there is no such block or it is unknown.
+func (watchDog *ScheduleWatchDog) GetNextScheduledBlock(now time.Time) (time.Time, bool) {
+    slotDate, err := watchDog.timeSettings.GetSlotDateFor(now)
+    if err != nil {
+        return time.Time{}, false
+    }
+    schedule, found := watchDog.GetScheduleFor(slotDate.GetEpoch())
+    if !found {
+        return time.Time{}, false
+    }
+    var next *time.Time = nil
+    for i := range schedule {
+        scheduleTime := schedule[i].ScheduleTime
+        if scheduleTime.After(now) && (next == nil || scheduleTime.Before(*next)) {
+            next = &scheduleTime
+        }
+    }
+    if next == nil {
+        return time.Time{}, false
+    }
+    return *next, true
+}
+
+// gets the schedule for the given epoch, which is looked up in the db, if it
+// is not in memory. the boolean value indicates, whether a schedule has been
+// stored for the epoch.
+func (watchDog *ScheduleWatchDog) GetStoredScheduleFor(epoch *big.Int) ([]api.LeaderAssignment, bool) {
+    schedule, found := watchDog.GetScheduleFor(epoch)
+    if found {
+        return schedule, true
+    }
+    storedSchedule, err := watchDog.getFromDB(epoch)
+    if err != nil {
+        log.Errorf("[SCHEDULE] Could not fetch schedule from the DB. %v", err.Error())
+    }
+    return storedSchedule, storedSchedule != nil
+}
+
 // gets viable leader nodes, i.e. nodes that have computed the
 // identical leader schedule.
 func (watchDog *ScheduleWatchDog) GetViableLeaderNodes() []string {
-    currentSlotDate, _ := watchDog.timeSettings.GetSlotDateFor(time.Now())
+    currentSlotDate, _ := watchDog.timeSettings.GetSlotDateFor(watchDog.clock.Now())
     watchDog.viableLeaderNodes.mutex.Lock()
     defer watchDog.viableLeaderNodes.mutex.Unlock()
     return watchDog.viableLeaderNodes.epochMap[currentSlotDate.GetEpoch().String()]
 }
 
+// gets the differences of the schedules of non-viable leader nodes to
+// the expected schedule of the current epoch.
+func (watchDog *ScheduleWatchDog) GetScheduleDifferences() map[string]ScheduleDifference {
+    currentSlotDate, _ := watchDog.timeSettings.GetSlotDateFor(watchDog.clock.Now())
+    watchDog.viableLeaderNodes.mutex.Lock()
+    defer watchDog.viableLeaderNodes.mutex.Unlock()
+    differences := make(map[string]ScheduleDifference)
+    for name, difference := range watchDog.viableLeaderNodes.differenceMap[currentSlotDate.GetEpoch().String()] {
+        differences[name] = difference
+    }
+    return differences
+}
+
 func nextEpochStart(slotDate *cardano.FullSlotDate, timeSettings cardano.TimeSettings) *cardano.FullSlotDate {
     epochDate, _ := cardano.FullSlotDateFrom(new(big.Int).Add(slotDate.GetEpoch(), new(big.Int).SetInt64(1)),
         new(big.Int).SetInt64(2), timeSettings)
@@ -117,26 +275,33 @@ func (watchDog *ScheduleWatchDog) checkViabilityAndExclude(epoch *big.Int, sched
 
 func (watchDog *ScheduleWatchDog) checkViabilityOf(node Node, epoch *big.Int, schedule []api.LeaderAssignment) {
     if schedule != nil && len(schedule) > 0 {
-        for ; ; {
-            currentSlotDate, _ := watchDog.timeSettings.GetSlotDateFor(time.Now())
+        epochStart, _ := cardano.FullSlotDateFrom(epoch, new(big.Int).SetInt64(0), *watchDog.timeSettings)
+        for attempt := 0; ; attempt++ {
+            // re-check often right after the turn over, and less often later.
+            if attempt > 0 {
+                sinceTurnOver := watchDog.clock.Now().Sub(epochStart.GetStartDateTime())
+                wait := watchDog.settings.viabilityRetryInterval(sinceTurnOver)
+                log.Infof("[SCHEDULE] Waiting %v for checking the viability of '%v' again.",
+                    utils.GetHumanReadableUpTime(wait), node.Name)
+                watchDog.clock.Sleep(wait)
+            }
+            currentSlotDate, _ := watchDog.timeSettings.GetSlotDateFor(watchDog.clock.Now())
             if currentSlotDate.GetEpoch().Cmp(epoch) != 0 {
                 break
             }
             log.Infof("[SCHEDULE] Starting to check viability of '%v'.", node.Name)
-            newSchedule, err := node.API.GetLeadersSchedule()
+            newSchedule, err := getCurrentSchedule(epoch, node)
             if err == nil {
                 if newSchedule != nil && len(newSchedule) > 0 {
-                    testTime := time.Now()
-                    expectedSchedule := api.FilterLeaderLogsBefore(testTime, schedule)
-                    newSchedule = api.FilterLeaderLogsBefore(testTime, newSchedule)
-                    if len(expectedSchedule) == len(newSchedule) {
-                        watchDog.viableLeaderNodes.mutex.Lock()
-                        watchDog.viableLeaderNodes.epochMap[epoch.String()] = append(watchDog.viableLeaderNodes.epochMap[epoch.String()], node.Name)
-                        watchDog.viableLeaderNodes.mutex.Unlock()
+                    testTime := watchDog.clock.Now()
+                    difference := CompareSchedules(api.FilterLeaderLogsBefore(testTime, schedule),
+                        api.FilterLeaderLogsBefore(testTime, newSchedule))
+                    if difference.IsEmpty() {
+                        watchDog.setViable(epoch, node.Name, attempt+1)
                         break
                     } else {
-                        log.Warnf("[SCHEDULE] The leader schedule of node %v is of different length. Expected %v, but was %v.",
-                            node.Name, len(newSchedule), len(schedule))
+                        watchDog.setDifference(epoch, node.Name, difference)
+                        log.Warnf("[SCHEDULE] The leader schedule of node %v differs: %v.", node.Name, difference.String())
                     }
                 } else {
                     log.Warnf("[SCHEDULE] Could not fetch schedule from %v.", node.Name)
@@ -144,11 +309,45 @@ func (watchDog *ScheduleWatchDog) checkViabilityOf(node Node, epoch *big.Int, sc
             } else {
                 log.Warnf("[SCHEDULE] Could not fetch schedule from %v. %v", node.Name, err.Error())
             }
-            time.Sleep(10 * time.Minute)
         }
     }
 }
 
+// marks the node with the given name as viable in the given epoch after
+// the given number of checks, and informs the listeners about it.
+func (watchDog *ScheduleWatchDog) setViable(epoch *big.Int, name string, checks int) {
+    event := ViabilityEvent{Epoch: epoch.String(), Node: name, Time: watchDog.clock.Now(), Checks: checks}
+    watchDog.viableLeaderNodes.mutex.Lock()
+    watchDog.viableLeaderNodes.epochMap[epoch.String()] = append(watchDog.viableLeaderNodes.epochMap[epoch.String()], name)
+    delete(watchDog.viableLeaderNodes.differenceMap[epoch.String()], name)
+    watchDog.viableLeaderNodes.eventMap[epoch.String()] = append(watchDog.viableLeaderNodes.eventMap[epoch.String()], event)
+    watchDog.viableLeaderNodes.mutex.Unlock()
+    log.Infof("[SCHEDULE] Node %v has become viable in epoch %v after %v checks.", name, event.Epoch, checks)
+    watchDog.informListenerAboutViability(event)
+}
+
+// informs all the registered listener about the given viability event.
+func (watchDog *ScheduleWatchDog) informListenerAboutViability(event ViabilityEvent) {
+    watchDog.listeners.mutex.Lock()
+    defer watchDog.listeners.mutex.Unlock()
+    for i := range watchDog.listeners.viabilityList {
+        watchDog.listeners.viabilityList[i] <- event
+    }
+}
+
+// records the difference of the schedule of the node with the given name in
+// the given epoch.
+func (watchDog *ScheduleWatchDog) setDifference(epoch *big.Int, name string, difference ScheduleDifference) {
+    watchDog.viableLeaderNodes.mutex.Lock()
+    defer watchDog.viableLeaderNodes.mutex.Unlock()
+    differences, found := watchDog.viableLeaderNodes.differenceMap[epoch.String()]
+    if !found {
+        differences = make(map[string]ScheduleDifference)
+        watchDog.viableLeaderNodes.differenceMap[epoch.String()] = differences
+    }
+    differences[name] = difference
+}
+
 func getCurrentSchedule(epoch *big.Int, node Node) ([]api.LeaderAssignment, error) {
     schedule, err := node.API.GetLeadersSchedule()
     if err == nil && schedule != nil {
@@ -188,40 +387,30 @@ func (watchDog *ScheduleWatchDog) informListenerAboutSchedule(schedule []api.Lea
 }
 
 func (watchDog *ScheduleWatchDog) storeToDB(epoch *big.Int, schedule []api.LeaderAssignment) error {
-    err := watchDog.db.Update(func(tx *bolt.Tx) error {
-        b := tx.Bucket([]byte("schedule"))
-        if b == nil {
-            return errors.New("the bucket 'schedule' could not be found")
-        } else {
-            jsonData, err := json.Marshal(schedule)
-            if err == nil && jsonData != nil {
-                return b.Put([]byte(epoch.String()), jsonData)
-            }
-            return err
+    err := watchDog.store.Update(func(tx storage.Tx) error {
+        jsonData, err := json.Marshal(schedule)
+        if err == nil && jsonData != nil {
+            return tx.Put(storage.ScheduleBucket, epoch.String(), jsonData)
         }
+        return err
     })
     return err
 }
 
 func (watchDog *ScheduleWatchDog) getFromDB(epoch *big.Int) ([]api.LeaderAssignment, error) {
     var storedSchedule *[]api.LeaderAssignment = nil
-    err := watchDog.db.View(func(tx *bolt.Tx) error {
-        b := tx.Bucket([]byte("schedule"))
-        if b == nil {
-            return errors.New("the bucket 'schedule' could not be found.")
-        } else {
-            response := b.Get([]byte(epoch.String()))
-            if response != nil {
-                var unmarshalledSchedule []api.LeaderAssignment
-                err := json.Unmarshal(response, &unmarshalledSchedule)
-                if err != nil {
-                    return err
-                } else {
-                    storedSchedule = &unmarshalledSchedule
-                }
+    err := watchDog.store.View(func(tx storage.Tx) error {
+        response, err := tx.Get(storage.ScheduleBucket, epoch.String())
+        if err == nil && response != nil {
+            var unmarshalledSchedule []api.LeaderAssignment
+            err := json.Unmarshal(response, &unmarshalledSchedule)
+            if err != nil {
+                return err
+            } else {
+                storedSchedule = &unmarshalledSchedule
             }
         }
-        return nil
+        return err
     })
     if err == nil && storedSchedule != nil {
         return *storedSchedule, nil
@@ -229,8 +418,9 @@ func (watchDog *ScheduleWatchDog) getFromDB(epoch *big.Int) ([]api.LeaderAssignm
     return nil, err
 }
 
-func (watchDog *ScheduleWatchDog) fetchFromNodes(epoch *big.Int) ([]api.LeaderAssignment, []string) {
-    var newSchedule []api.LeaderAssignment = nil
+// fetches the schedule for the given epoch from all nodes, and lets the nodes
+// vote on the schedule.
+func (watchDog *ScheduleWatchDog) fetchFromNodes(epoch *big.Int) scheduleVote {
     inputs := make([]interface{}, len(watchDog.nodes))
     for i, node := range watchDog.nodes {
         inputs[i] = sInput{
@@ -238,33 +428,20 @@ func (watchDog *ScheduleWatchDog) fetchFromNodes(epoch *big.Int) ([]api.LeaderAs
             epoch: epoch,
         }
     }
-    viableLeaderNodes := make([]string, 0)
+    schedules := make(map[string][]api.LeaderAssignment)
     responses := threading.Complete(inputs, fetchSchedule)
     for _, response := range responses {
         node := response.Context.(Node)
         if response.Error == nil {
             schedule := response.Data.([]api.LeaderAssignment)
             if schedule != nil && len(schedule) > 0 {
-                if newSchedule == nil {
-                    newSchedule = schedule
-                    viableLeaderNodes = append(viableLeaderNodes, node.Name)
-                } else {
-                    testTime := time.Now()
-                    expectedSchedule := api.FilterLeaderLogsBefore(testTime, schedule)
-                    newSchedule = api.FilterLeaderLogsBefore(testTime, newSchedule)
-                    if len(expectedSchedule) == len(newSchedule) {
-                        viableLeaderNodes = append(viableLeaderNodes, node.Name)
-                    } else {
-                        log.Warnf("[SCHEDULE] The leader schedule of node %v is of different length. Expected %v, but was %v.",
-                            node.Name, len(newSchedule), len(schedule))
-                    }
-                }
+                schedules[node.Name] = schedule
             }
         } else {
             log.Warnf("[SCHEDULE] Could not fetch the leader schedule for %s.", node.Name)
         }
     }
-    return newSchedule, viableLeaderNodes
+    return voteOnSchedule(schedules, watchDog.clock.Now())
 }
 
 // watches for the schedules computed for epochs, and checks whether the
@@ -273,77 +450,95 @@ func (watchDog *ScheduleWatchDog) Watch() {
     log.Info("[SCHEDULE] Starting to watch the schedule.")
     var next time.Duration = 0
     for ; ; {
-        time.Sleep(next)
-        shouldIssueWatchDog := true
-        shouldFetchFromNodes := true
-        currentSlotDate, _ := watchDog.timeSettings.GetSlotDateFor(time.Now())
-        watchDog.mutex.RLock()
-        schedule, found := watchDog.scheduleMap[currentSlotDate.GetEpoch().String()]
-        if found && schedule != nil && len(schedule) > 0 {
-            shouldIssueWatchDog = false
-        } else {
-            storedSchedule, err := watchDog.getFromDB(currentSlotDate.GetEpoch())
-            if err == nil && storedSchedule != nil {
-                log.Infof("[SCHEDULE] Fetched schedule from DB for epoch '%v'.",
-                    currentSlotDate.GetEpoch().String())
-                shouldFetchFromNodes = false
-                schedule = storedSchedule
-            } else if err != nil {
-                log.Errorf("[SCHEDULE] Could not fetch schedule from the DB. %v", err.Error())
-            }
+        watchDog.clock.Sleep(next)
+        next = watchDog.watchCurrentEpoch()
+        log.Infof("[SCHEDULE] Waiting %v for next check.", utils.GetHumanReadableUpTime(next))
+    }
+}
+
+// fetches the schedule for the current epoch, if it has not been fetched yet,
+// and checks the viability of the nodes. it returns the duration to wait for
+// the next check.
+func (watchDog *ScheduleWatchDog) watchCurrentEpoch() time.Duration {
+    var next time.Duration
+    shouldIssueWatchDog := true
+    shouldFetchFromNodes := true
+    currentSlotDate, _ := watchDog.timeSettings.GetSlotDateFor(watchDog.clock.Now())
+    watchDog.mutex.RLock()
+    schedule, found := watchDog.scheduleMap[currentSlotDate.GetEpoch().String()]
+    if found && schedule != nil && len(schedule) > 0 {
+        shouldIssueWatchDog = false
+    } else {
+        storedSchedule, err := watchDog.getFromDB(currentSlotDate.GetEpoch())
+        if err == nil && storedSchedule != nil {
+            log.Infof("[SCHEDULE] Fetched schedule from DB for epoch '%v'.",
+                currentSlotDate.GetEpoch().String())
+            shouldFetchFromNodes = false
+            schedule = storedSchedule
+        } else if err != nil {
+            log.Errorf("[SCHEDULE] Could not fetch schedule from the DB. %v", err.Error())
         }
-        watchDog.mutex.RUnlock()
-        if !shouldIssueWatchDog {
-            log.Infof("[SCHEDULE] The schedule has already been fetched for epoch %v. (%v) entries.",
-                currentSlotDate.GetEpoch().String(), len(schedule))
-            next = nextEpochStart(currentSlotDate, *watchDog.timeSettings).GetEndDateTime().Sub(time.Now())
-            continue
-        } else {
-            viableLeaderNodes := make([]string, 0)
-            //for _, node := range watchDog.nodes {
-            //    viableLeaderNodes = append(viableLeaderNodes, node.Name)
-            //}
-            // make none of the leader candidates viable.
+    }
+    watchDog.mutex.RUnlock()
+    if !shouldIssueWatchDog {
+        log.Infof("[SCHEDULE] The schedule has already been fetched for epoch %v. (%v) entries.",
+            currentSlotDate.GetEpoch().String(), len(schedule))
+        return nextEpochStart(currentSlotDate, *watchDog.timeSettings).GetEndDateTime().Sub(watchDog.clock.Now())
+    } else {
+        viableLeaderNodes := make([]string, 0)
+        differences := make(map[string]ScheduleDifference)
+        // make none of the leader candidates viable, and forget about
+        // the viability in past epochs.
+        watchDog.viableLeaderNodes.mutex.Lock()
+        watchDog.viableLeaderNodes.epochMap[currentSlotDate.GetEpoch().String()] = []string{}
+        watchDog.viableLeaderNodes.prune(currentSlotDate.GetEpoch())
+        watchDog.viableLeaderNodes.mutex.Unlock()
+        // fetch the schedule
+        if shouldFetchFromNodes {
+            log.Infof("[SCHEDULE] The schedule for epoch %v will be fetched.",
+                currentSlotDate.GetEpoch().String())
+            vote := watchDog.fetchFromNodes(currentSlotDate.GetEpoch())
+            schedule, viableLeaderNodes, differences = vote.schedule, vote.majority, vote.differences
+        }
+        if schedule != nil && len(schedule) > 0 {
+            // set schedule for this epoch, older schedules are
+            // looked up in the DB.
+            watchDog.mutex.Lock()
+            watchDog.scheduleMap[currentSlotDate.GetEpoch().String()] = schedule
+            for key := range watchDog.scheduleMap {
+                if isBeforePreviousEpoch(key, currentSlotDate.GetEpoch()) {
+                    delete(watchDog.scheduleMap, key)
+                }
+            }
+            watchDog.mutex.Unlock()
+            // inform listeners about schedule.
+            watchDog.informListenerAboutSchedule(schedule)
+            // store to DB
+            err := watchDog.storeToDB(currentSlotDate.GetEpoch(), schedule)
+            if err != nil {
+                log.Errorf("[SCHEDULE] Could not store schedule for epoch %v. %v",
+                    currentSlotDate.GetEpoch().String(), err.Error())
+            }
+            // set the viable leader nodes.
             watchDog.viableLeaderNodes.mutex.Lock()
-            watchDog.viableLeaderNodes.epochMap[currentSlotDate.GetEpoch().String()] = []string{}
+            watchDog.viableLeaderNodes.differenceMap[currentSlotDate.GetEpoch().String()] = differences
             watchDog.viableLeaderNodes.mutex.Unlock()
-            // fetch the schedule
-            if shouldFetchFromNodes {
-                log.Infof("[SCHEDULE] The schedule for epoch %v will be fetched.",
-                    currentSlotDate.GetEpoch().String())
-                schedule, viableLeaderNodes = watchDog.fetchFromNodes(currentSlotDate.GetEpoch())
+            for _, name := range viableLeaderNodes {
+                watchDog.setViable(currentSlotDate.GetEpoch(), name, 1)
             }
-            if schedule != nil && len(schedule) > 0 {
-                // set schedule for this epoch.
-                watchDog.mutex.Lock()
-                watchDog.scheduleMap[currentSlotDate.GetEpoch().String()] = schedule
-                watchDog.mutex.Unlock()
-                // inform listeners about schedule.
-                watchDog.informListenerAboutSchedule(schedule)
-                // store to DB
-                err := watchDog.storeToDB(currentSlotDate.GetEpoch(), schedule)
-                if err != nil {
-                    log.Errorf("[SCHEDULE] Could not store schedule for epoch %v. %v",
-                        currentSlotDate.GetEpoch().String(), err.Error())
-                }
-                // set the viable leader nodes.
-                watchDog.viableLeaderNodes.mutex.Lock()
-                watchDog.viableLeaderNodes.epochMap[currentSlotDate.GetEpoch().String()] = viableLeaderNodes
-                watchDog.viableLeaderNodes.mutex.Unlock()
-                // check viability of non viable nodes periodically.
-                watchDog.checkViabilityAndExclude(currentSlotDate.GetEpoch(), schedule, viableLeaderNodes)
-                log.Infof("[SCHEDULE] Watchdog fetched %v leader assignments for epoch %v.",
-                    len(schedule), currentSlotDate.GetEpoch().String())
-                next = nextEpochStart(currentSlotDate, *watchDog.timeSettings).GetEndDateTime().Sub(time.Now())
+            // check viability of non viable nodes periodically.
+            watchDog.checkViabilityAndExclude(currentSlotDate.GetEpoch(), schedule, viableLeaderNodes)
+            log.Infof("[SCHEDULE] Watchdog fetched %v leader assignments for epoch %v.",
+                len(schedule), currentSlotDate.GetEpoch().String())
+            next = nextEpochStart(currentSlotDate, *watchDog.timeSettings).GetEndDateTime().Sub(watchDog.clock.Now())
+        } else {
+            if currentSlotDate.GetSlot().Cmp(new(big.Int).SetUint64(watchDog.settings.EarlyEpochSlots)) <= 0 {
+                next = time.Duration(watchDog.settings.EarlyRetrySlots) * watchDog.timeSettings.SlotDuration
             } else {
-                if currentSlotDate.GetSlot().Cmp(new(big.Int).SetInt64(500)) <= 0 {
-                    next = 50 * watchDog.timeSettings.SlotDuration
-                } else {
-                    next = utils.MinDuration(10*time.Minute,
-                        nextEpochStart(currentSlotDate, *watchDog.timeSettings).GetEndDateTime().Sub(time.Now()))
-                }
+                next = utils.MinDuration(watchDog.settings.RetryInterval,
+                    nextEpochStart(currentSlotDate, *watchDog.timeSettings).GetEndDateTime().Sub(watchDog.clock.Now()))
             }
         }
-        log.Infof("[SCHEDULE] Waiting %v for next check.", utils.GetHumanReadableUpTime(next))
     }
+    return next
 }
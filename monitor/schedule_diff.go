@@ -0,0 +1,172 @@
+package monitor
+
+import (
+    "fmt"
+    log "github.com/sirupsen/logrus"
+    "github.com/sobitada/go-jormungandr/api"
+    "sort"
+    "strings"
+    "time"
+)
+
+// slot to which the leader has been assigned, i.e. the epoch and
+// slot as well as the time at which the block is scheduled.
+type ScheduledSlot struct {
+    Date string    `json:"date"`
+    Time time.Time `json:"time"`
+}
+
+// differences of the leader schedule of a node to the
+// expected leader schedule.
+type ScheduleDifference struct {
+    // assignments that are expected, but have not been
+    // computed by the node.
+    Missing []ScheduledSlot `json:"missing"`
+    // assignments that have been computed by the node,
+    // but are not expected.
+    Unexpected []ScheduledSlot `json:"unexpected"`
+}
+
+// checks whether the schedules are identical.
+func (difference ScheduleDifference) IsEmpty() bool {
+    return len(difference.Missing) == 0 && len(difference.Unexpected) == 0
+}
+
+func (difference ScheduleDifference) String() string {
+    return fmt.Sprintf("missing [%v], unexpected [%v]", joinSlots(difference.Missing),
+        joinSlots(difference.Unexpected))
+}
+
+func joinSlots(slots []ScheduledSlot) string {
+    texts := make([]string, len(slots))
+    for i, slot := range slots {
+        texts[i] = fmt.Sprintf("%v at %v", slot.Date, slot.Time.Format(time.RFC3339))
+    }
+    return strings.Join(texts, ", ")
+}
+
+// gets the scheduled slot of the given assignment.
+func getScheduledSlot(assignment api.LeaderAssignment) ScheduledSlot {
+    date := ""
+    if assignment.ScheduleBlockDate != nil {
+        date = assignment.ScheduleBlockDate.String()
+    }
+    return ScheduledSlot{Date: date, Time: assignment.ScheduleTime}
+}
+
+// gets a key identifying the epoch, slot and time of the given slot.
+func (slot ScheduledSlot) key() string {
+    return fmt.Sprintf("%v@%v", slot.Date, slot.Time.UnixNano())
+}
+
+// gets the sorted scheduled slots of the given schedule.
+func getScheduledSlots(schedule []api.LeaderAssignment) []ScheduledSlot {
+    slots := make([]ScheduledSlot, len(schedule))
+    for i := range schedule {
+        slots[i] = getScheduledSlot(schedule[i])
+    }
+    sort.SliceStable(slots, func(i, j int) bool {
+        if slots[i].Time.Equal(slots[j].Time) {
+            return slots[i].Date < slots[j].Date
+        }
+        return slots[i].Time.Before(slots[j].Time)
+    })
+    return slots
+}
+
+// compares the actual schedule of a node with the expected schedule, and
+// returns the differences between them.
+func CompareSchedules(expected []api.LeaderAssignment, actual []api.LeaderAssignment) ScheduleDifference {
+    difference := ScheduleDifference{Missing: []ScheduledSlot{}, Unexpected: []ScheduledSlot{}}
+    expectedSlots := getScheduledSlots(expected)
+    actualSlots := getScheduledSlots(actual)
+    actualKeys := make(map[string]bool)
+    for _, slot := range actualSlots {
+        actualKeys[slot.key()] = true
+    }
+    expectedKeys := make(map[string]bool)
+    for _, slot := range expectedSlots {
+        expectedKeys[slot.key()] = true
+        if !actualKeys[slot.key()] {
+            difference.Missing = append(difference.Missing, slot)
+        }
+    }
+    for _, slot := range actualSlots {
+        if !expectedKeys[slot.key()] {
+            difference.Unexpected = append(difference.Unexpected, slot)
+        }
+    }
+    return difference
+}
+
+// gets a fingerprint of the given schedule, which is identical for
+// schedules with the same slots.
+func getScheduleFingerprint(schedule []api.LeaderAssignment) string {
+    slots := getScheduledSlots(schedule)
+    keys := make([]string, len(slots))
+    for i := range slots {
+        keys[i] = slots[i].key()
+    }
+    return strings.Join(keys, ";")
+}
+
+// result of the vote of the nodes on the schedule.
+type scheduleVote struct {
+    // schedule agreed on by the majority, it is nil,
+    // if there is no majority.
+    schedule []api.LeaderAssignment
+    // names of the nodes, which voted for the schedule.
+    majority []string
+    // differences of the other nodes to the schedule.
+    differences map[string]ScheduleDifference
+}
+
+// lets the nodes vote on the schedule. only the assignments after the given
+// time are compared, since nodes might have been restarted and lost the
+// assignments of the past. a schedule is only agreed on, if more than half of
+// the nodes computed it.
+func voteOnSchedule(schedules map[string][]api.LeaderAssignment, testTime time.Time) scheduleVote {
+    vote := scheduleVote{majority: []string{}, differences: map[string]ScheduleDifference{}}
+    names := make([]string, 0, len(schedules))
+    for name := range schedules {
+        names = append(names, name)
+    }
+    sort.Strings(names)
+    groups := make(map[string][]string)
+    for _, name := range names {
+        fingerprint := getScheduleFingerprint(api.FilterLeaderLogsBefore(testTime, schedules[name]))
+        groups[fingerprint] = append(groups[fingerprint], name)
+    }
+    var majorityFingerprint string
+    for fingerprint, group := range groups {
+        if len(group) > len(vote.majority) {
+            majorityFingerprint = fingerprint
+            vote.majority = group
+        }
+    }
+    if len(vote.majority)*2 <= len(names) {
+        groupTexts := make([]string, 0, len(groups))
+        for _, group := range groups {
+            groupTexts = append(groupTexts, "["+strings.Join(group, ",")+"]")
+        }
+        sort.Strings(groupTexts)
+        log.Errorf("[SCHEDULE] The nodes disagree on the schedule without a majority: %v.", strings.Join(groupTexts, " "))
+        vote.majority = []string{}
+        return vote
+    }
+    // the schedule with most assignments of the past is taken.
+    for _, name := range vote.majority {
+        if vote.schedule == nil || len(schedules[name]) > len(vote.schedule) {
+            vote.schedule = schedules[name]
+        }
+    }
+    expectedSchedule := api.FilterLeaderLogsBefore(testTime, vote.schedule)
+    for _, name := range names {
+        if getScheduleFingerprint(api.FilterLeaderLogsBefore(testTime, schedules[name])) != majorityFingerprint {
+            difference := CompareSchedules(expectedSchedule, api.FilterLeaderLogsBefore(testTime, schedules[name]))
+            vote.differences[name] = difference
+            log.Warnf("[SCHEDULE] The leader schedule of node %v differs from the majority: %v.", name, difference.String())
+        }
+    }
+    return vote
+}
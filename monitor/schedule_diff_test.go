@@ -0,0 +1,59 @@
+package monitor
+
+import (
+    jor "github.com/sobitada/go-jormungandr/api"
+    "github.com/sobitada/thor/jortest"
+    "github.com/stretchr/testify/assert"
+    "testing"
+    "time"
+)
+
+func TestCompareSchedules_SameLengthDifferentSlots_mustReportDifferences(t *testing.T) {
+    settings := jortest.TimeSettingsAt(time.Now(), 5, 10, time.Second, 1000)
+    expected := []jor.LeaderAssignment{jortest.Assignment(5, 100, *settings), jortest.Assignment(5, 200, *settings)}
+    actual := []jor.LeaderAssignment{jortest.Assignment(5, 100, *settings), jortest.Assignment(5, 300, *settings)}
+    difference := CompareSchedules(expected, actual)
+    assert.False(t, difference.IsEmpty())
+    if assert.Len(t, difference.Missing, 1) {
+        assert.Equal(t, "5.200", difference.Missing[0].Date)
+    }
+    if assert.Len(t, difference.Unexpected, 1) {
+        assert.Equal(t, "5.300", difference.Unexpected[0].Date)
+    }
+    assert.True(t, CompareSchedules(expected, []jor.LeaderAssignment{expected[1], expected[0]}).IsEmpty())
+}
+
+func TestVoteOnSchedule_Disagreement_mustFollowMajority(t *testing.T) {
+    now := time.Now()
+    settings := jortest.TimeSettingsAt(now, 5, 10, time.Second, 1000)
+    schedule := []jor.LeaderAssignment{jortest.Assignment(5, 100, *settings), jortest.Assignment(5, 200, *settings)}
+    other := []jor.LeaderAssignment{jortest.Assignment(5, 100, *settings), jortest.Assignment(5, 250, *settings)}
+    vote := voteOnSchedule(map[string][]jor.LeaderAssignment{"a": other, "b": schedule, "c": schedule}, now)
+    assert.Equal(t, []string{"b", "c"}, vote.majority)
+    assert.Equal(t, schedule, vote.schedule)
+    if assert.Contains(t, vote.differences, "a") {
+        assert.Len(t, vote.differences["a"].Missing, 1)
+        assert.Len(t, vote.differences["a"].Unexpected, 1)
+    }
+}
+
+func TestVoteOnSchedule_PastAssignmentsLost_mustBeIgnored(t *testing.T) {
+    now := time.Now()
+    settings := jortest.TimeSettingsAt(now, 5, 10, time.Second, 1000)
+    schedule := []jor.LeaderAssignment{jortest.Assignment(5, 5, *settings), jortest.Assignment(5, 200, *settings)}
+    vote := voteOnSchedule(map[string][]jor.LeaderAssignment{"a": schedule, "b": schedule[1:]}, now)
+    assert.Equal(t, []string{"a", "b"}, vote.majority)
+    assert.Len(t, vote.schedule, 2)
+    assert.Empty(t, vote.differences)
+}
+
+func TestVoteOnSchedule_NoMajority_mustNotAgreeOnSchedule(t *testing.T) {
+    now := time.Now()
+    settings := jortest.TimeSettingsAt(now, 5, 10, time.Second, 1000)
+    vote := voteOnSchedule(map[string][]jor.LeaderAssignment{
+        "a": {jortest.Assignment(5, 100, *settings)},
+        "b": {jortest.Assignment(5, 200, *settings)},
+    }, now)
+    assert.Nil(t, vote.schedule)
+    assert.Empty(t, vote.majority)
+}
@@ -0,0 +1,203 @@
+package monitor
+
+import (
+    "encoding/csv"
+    "fmt"
+    "github.com/sobitada/go-jormungandr/api"
+    "github.com/sobitada/thor/storage"
+    "io"
+    "math/big"
+    "net/http"
+    "sort"
+    "strconv"
+    "strings"
+    "time"
+)
+
+// format in which the leader schedule can be exported.
+type ScheduleFormat string
+
+const (
+    // iCalendar with one event per scheduled slot.
+    ICS ScheduleFormat = "ics"
+    CSV ScheduleFormat = "csv"
+)
+
+// parses the given name of a schedule format, an empty name is parsed as ICS.
+func ParseScheduleFormat(name string) (ScheduleFormat, error) {
+    switch ScheduleFormat(name) {
+    case "", ICS:
+        return ICS, nil
+    case CSV:
+        return CSV, nil
+    }
+    return "", fmt.Errorf("unknown format '%v', it must be one of ics or csv", name)
+}
+
+// gets the MIME type of the given format.
+func (format ScheduleFormat) ContentType() string {
+    if format == CSV {
+        return "text/csv"
+    }
+    return "text/calendar"
+}
+
+// leader schedule of an epoch.
+type EpochSchedule struct {
+    Epoch       uint64
+    Assignments []api.LeaderAssignment
+}
+
+// gets the epochs for which a schedule has been fetched or stored in the
+// db in ascending order.
+func (watchDog *ScheduleWatchDog) GetStoredEpochs() ([]uint64, error) {
+    epochMap := make(map[uint64]bool)
+    watchDog.mutex.RLock()
+    for epoch := range watchDog.scheduleMap {
+        n, err := strconv.ParseUint(epoch, 10, 64)
+        if err == nil {
+            epochMap[n] = true
+        }
+    }
+    watchDog.mutex.RUnlock()
+    err := watchDog.store.View(func(tx storage.Tx) error {
+        return tx.ForEach(storage.ScheduleBucket, func(key string, value []byte) error {
+            n, err := strconv.ParseUint(key, 10, 64)
+            if err == nil {
+                epochMap[n] = true
+            }
+            return nil
+        })
+    })
+    epochs := make([]uint64, 0, len(epochMap))
+    for epoch := range epochMap {
+        epochs = append(epochs, epoch)
+    }
+    sort.Slice(epochs, func(i, j int) bool { return epochs[i] < epochs[j] })
+    return epochs, err
+}
+
+// gets the schedules of the given epochs, epochs without a schedule are
+// skipped. the assignments are sorted by their scheduled time.
+func (watchDog *ScheduleWatchDog) getEpochSchedules(epochs []uint64) []EpochSchedule {
+    schedules := make([]EpochSchedule, 0, len(epochs))
+    for _, epoch := range epochs {
+        schedule, found := watchDog.GetStoredScheduleFor(new(big.Int).SetUint64(epoch))
+        if found {
+            assignments := append([]api.LeaderAssignment{}, schedule...)
+            sort.Slice(assignments, func(i, j int) bool {
+                return assignments[i].ScheduleTime.Before(assignments[j].ScheduleTime)
+            })
+            schedules = append(schedules, EpochSchedule{Epoch: epoch, Assignments: assignments})
+        }
+    }
+    return schedules
+}
+
+// escapes the given text for a value of an iCalendar property.
+func escapeICSText(text string) string {
+    return strings.NewReplacer("\\", "\\\\", ";", "\\;", ",", "\\,", "\n", "\\n").Replace(text)
+}
+
+// writes the given schedules as iCalendar with one event per scheduled slot. the
+// events are in UTC, the given location is used for the time in the description
+// and as the display time zone of the calendar. the display time zone is left
+// out for the local time zone of the system, which has no IANA name.
+func WriteScheduleICS(writer io.Writer, schedules []EpochSchedule, slotDuration time.Duration,
+    location *time.Location, now time.Time) error {
+    lines := []string{
+        "BEGIN:VCALENDAR",
+        "VERSION:2.0",
+        "PRODID:-//SOBIT//thor//EN",
+        "CALSCALE:GREGORIAN",
+        "METHOD:PUBLISH",
+        "X-WR-CALNAME:Leader Schedule",
+    }
+    if location.String() != "Local" {
+        lines = append(lines, "X-WR-TIMEZONE:"+location.String())
+    }
+    for _, schedule := range schedules {
+        for _, assignment := range schedule.Assignments {
+            slot := getScheduledSlot(assignment)
+            description := fmt.Sprintf("Epoch: %v\nSlot: %v\nScheduled at: %v\nLeader ID: %v", schedule.Epoch,
+                slot.Date, assignment.ScheduleTime.In(location).Format("2006-01-02 15:04:05 MST"), assignment.LeaderID)
+            lines = append(lines,
+                "BEGIN:VEVENT",
+                fmt.Sprintf("UID:%v-%v@thor", slot.Date, assignment.LeaderID),
+                "DTSTAMP:"+now.UTC().Format("20060102T150405Z"),
+                "DTSTART:"+assignment.ScheduleTime.UTC().Format("20060102T150405Z"),
+                "DTEND:"+assignment.ScheduleTime.Add(slotDuration).UTC().Format("20060102T150405Z"),
+                "SUMMARY:"+escapeICSText(fmt.Sprintf("Scheduled block %v", slot.Date)),
+                "DESCRIPTION:"+escapeICSText(description),
+                "END:VEVENT")
+        }
+    }
+    lines = append(lines, "END:VCALENDAR")
+    _, err := io.WriteString(writer, strings.Join(lines, "\r\n")+"\r\n")
+    return err
+}
+
+// writes the given schedules as CSV with the epoch, slot, scheduled time in
+// the given location and the leader ID.
+func WriteScheduleCSV(writer io.Writer, schedules []EpochSchedule, location *time.Location) error {
+    csvWriter := csv.NewWriter(writer)
+    records := [][]string{{"epoch", "slot", "time", "leader_id"}}
+    for _, schedule := range schedules {
+        for _, assignment := range schedule.Assignments {
+            slot := ""
+            if assignment.ScheduleBlockDate != nil {
+                slot = assignment.ScheduleBlockDate.GetSlot().String()
+            }
+            records = append(records, []string{strconv.FormatUint(schedule.Epoch, 10), slot,
+                assignment.ScheduleTime.In(location).Format(time.RFC3339), strconv.FormatUint(assignment.LeaderID, 10)})
+        }
+    }
+    err := csvWriter.WriteAll(records)
+    if err != nil {
+        return err
+    }
+    csvWriter.Flush()
+    return csvWriter.Error()
+}
+
+// serves the leader schedule in the format specified by the query parameter
+// 'format' (ics or csv, by default ics). the query parameter 'epoch' specifies
+// the epoch, 'all' for all stored epochs or by default the current epoch.
+func (watchDog *ScheduleWatchDog) ServeScheduleExport(writer http.ResponseWriter, request *http.Request) {
+    query := request.URL.Query()
+    format, err := ParseScheduleFormat(query.Get("format"))
+    if err != nil {
+        http.Error(writer, err.Error(), http.StatusBadRequest)
+        return
+    }
+    var epochs []uint64
+    switch epochParam := query.Get("epoch"); epochParam {
+    case "":
+        currentSlotDate, _ := watchDog.timeSettings.GetSlotDateFor(watchDog.clock.Now())
+        epochs = []uint64{currentSlotDate.GetEpoch().Uint64()}
+    case "all":
+        epochs, err = watchDog.GetStoredEpochs()
+        if err != nil {
+            http.Error(writer, err.Error(), http.StatusInternalServerError)
+            return
+        }
+    default:
+        epoch, err := strconv.ParseUint(epochParam, 10, 64)
+        if err != nil {
+            http.Error(writer, "The epoch must be a non-negative number or 'all'.", http.StatusBadRequest)
+            return
+        }
+        epochs = []uint64{epoch}
+    }
+    location := watchDog.settings.Location
+    if location == nil {
+        location = time.UTC
+    }
+    schedules := watchDog.getEpochSchedules(epochs)
+    writer.Header().Set("Content-Type", format.ContentType())
+    if format == CSV {
+        _ = WriteScheduleCSV(writer, schedules, location)
+    } else {
+        _ = WriteScheduleICS(writer, schedules, watchDog.timeSettings.SlotDuration, location, watchDog.clock.Now())
+    }
+}
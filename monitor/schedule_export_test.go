@@ -0,0 +1,94 @@
+package monitor
+
+import (
+    "bytes"
+    jor "github.com/sobitada/go-jormungandr/api"
+    "github.com/sobitada/thor/jortest"
+    "github.com/sobitada/thor/storage"
+    "github.com/stretchr/testify/assert"
+    "math/big"
+    "net/http/httptest"
+    "strings"
+    "testing"
+    "time"
+)
+
+func TestWriteScheduleICS_TwoAssignments_mustWriteEventPerSlot(t *testing.T) {
+    now := time.Date(2020, 5, 1, 10, 0, 0, 0, time.UTC)
+    settings := jortest.TimeSettingsAt(now, 5, 0, 2*time.Second, 1000)
+    schedules := []EpochSchedule{{Epoch: 5, Assignments: []jor.LeaderAssignment{
+        jortest.Assignment(5, 10, *settings),
+        jortest.Assignment(5, 20, *settings),
+    }}}
+    location := time.FixedZone("UTC+2", 2*3600)
+    var output bytes.Buffer
+    if assert.NoError(t, WriteScheduleICS(&output, schedules, settings.SlotDuration, location, now)) {
+        ics := output.String()
+        assert.True(t, strings.HasPrefix(ics, "BEGIN:VCALENDAR\r\n"))
+        assert.True(t, strings.HasSuffix(ics, "END:VCALENDAR\r\n"))
+        assert.Equal(t, 2, strings.Count(ics, "BEGIN:VEVENT"))
+        assert.Contains(t, ics, "DTSTART:20200501T100020Z\r\nDTEND:20200501T100022Z")
+        assert.Contains(t, ics, "SUMMARY:Scheduled block 5.10")
+        assert.Contains(t, ics, "Epoch: 5\\nSlot: 5.20\\nScheduled at: 2020-05-01 12:00:40 UTC+2")
+    }
+}
+
+func TestWriteScheduleICS_LocalTimeZone_mustNotBeWrittenAsDisplayTimeZone(t *testing.T) {
+    now := time.Date(2020, 5, 1, 10, 0, 0, 0, time.UTC)
+    settings := jortest.TimeSettingsAt(now, 5, 0, 2*time.Second, 1000)
+    schedules := []EpochSchedule{{Epoch: 5, Assignments: []jor.LeaderAssignment{jortest.Assignment(5, 10, *settings)}}}
+    var output bytes.Buffer
+    if assert.NoError(t, WriteScheduleICS(&output, schedules, settings.SlotDuration, time.Local, now)) {
+        assert.NotContains(t, output.String(), "X-WR-TIMEZONE")
+    }
+    output.Reset()
+    if assert.NoError(t, WriteScheduleICS(&output, schedules, settings.SlotDuration,
+        DefaultScheduleSettings().Location, now)) {
+        assert.Contains(t, output.String(), "X-WR-TIMEZONE:UTC\r\n")
+    }
+}
+
+func TestWriteScheduleCSV_Assignment_mustUseLocation(t *testing.T) {
+    now := time.Date(2020, 5, 1, 10, 0, 0, 0, time.UTC)
+    settings := jortest.TimeSettingsAt(now, 5, 0, 2*time.Second, 1000)
+    schedules := []EpochSchedule{{Epoch: 5, Assignments: []jor.LeaderAssignment{jortest.Assignment(5, 10, *settings)}}}
+    var output bytes.Buffer
+    if assert.NoError(t, WriteScheduleCSV(&output, schedules, time.FixedZone("UTC+2", 2*3600))) {
+        assert.Equal(t, "epoch,slot,time,leader_id\n5,10,2020-05-01T12:00:20+02:00,1\n", output.String())
+    }
+}
+
+func TestScheduleWatchDog_ServeScheduleExport_AllEpochs_mustExportStoredSchedules(t *testing.T) {
+    now := time.Now()
+    settings := jortest.TimeSettingsAt(now, 6, 10, time.Second, 1000)
+    db := storage.NewMemory()
+    defer db.Close()
+    watchDog := NewScheduleWatchDog([]Node{}, settings, db, DefaultScheduleSettings())
+    for _, epoch := range []uint64{4, 5} {
+        err := watchDog.storeToDB(new(big.Int).SetUint64(epoch), []jor.LeaderAssignment{
+            jortest.Assignment(epoch, 300, *settings),
+            jortest.Assignment(epoch, 100, *settings),
+        })
+        if err != nil {
+            t.Fatal(err)
+        }
+    }
+    epochs, err := watchDog.GetStoredEpochs()
+    if assert.NoError(t, err) {
+        assert.Equal(t, []uint64{4, 5}, epochs)
+    }
+
+    recorder := httptest.NewRecorder()
+    watchDog.ServeScheduleExport(recorder, httptest.NewRequest("GET", "/schedule/export?epoch=all&format=csv", nil))
+    lines := strings.Split(strings.TrimSpace(recorder.Body.String()), "\n")
+    assert.Equal(t, "text/csv", recorder.Header().Get("Content-Type"))
+    if assert.Len(t, lines, 5) {
+        assert.True(t, strings.HasPrefix(lines[1], "4,100,"))
+        assert.True(t, strings.HasPrefix(lines[2], "4,300,"))
+        assert.True(t, strings.HasPrefix(lines[4], "5,300,"))
+    }
+
+    recorder = httptest.NewRecorder()
+    watchDog.ServeScheduleExport(recorder, httptest.NewRequest("GET", "/schedule/export?format=xml", nil))
+    assert.Equal(t, 400, recorder.Code)
+}
@@ -0,0 +1,215 @@
+package monitor
+
+import (
+    jor "github.com/sobitada/go-jormungandr/api"
+    "github.com/sobitada/thor/jortest"
+    "github.com/sobitada/thor/storage"
+    "github.com/stretchr/testify/assert"
+    "math/big"
+    "testing"
+    "time"
+)
+
+func TestScheduleWatchDog_NodeWithDifferentSchedule_mustNotBeViable(t *testing.T) {
+    now := time.Now()
+    settings := jortest.TimeSettingsAt(now, 5, 10, time.Second, 1000)
+    schedule := []jor.LeaderAssignment{
+        jortest.Assignment(5, 100, *settings),
+        jortest.Assignment(5, 200, *settings),
+        jortest.Assignment(5, 300, *settings),
+    }
+    a, b, c := jortest.NewNode(), jortest.NewNode(), jortest.NewNode()
+    defer a.Close()
+    defer b.Close()
+    defer c.Close()
+    a.SetSchedule(schedule)
+    b.SetSchedule(schedule)
+    c.SetSchedule([]jor.LeaderAssignment{schedule[0], schedule[1], jortest.Assignment(5, 400, *settings)})
+    // let the diverging node answer first.
+    a.SetLatency(100 * time.Millisecond)
+    b.SetLatency(100 * time.Millisecond)
+    nodes := []Node{
+        {Name: "a", Type: LeaderCandidate, API: a.API(time.Second)},
+        {Name: "b", Type: LeaderCandidate, API: b.API(time.Second)},
+        {Name: "c", Type: LeaderCandidate, API: c.API(time.Second)},
+    }
+    db := storage.NewMemory()
+    defer db.Close()
+    watchDog := NewScheduleWatchDog(nodes, settings, db, DefaultScheduleSettings())
+    next := watchDog.watchCurrentEpoch()
+    assert.True(t, next > 0)
+    fetchedSchedule, found := watchDog.GetScheduleFor(big.NewInt(5))
+    if assert.True(t, found) {
+        assert.Len(t, fetchedSchedule, 3)
+    }
+    assert.ElementsMatch(t, []string{"a", "b"}, watchDog.GetViableLeaderNodes())
+    differences := watchDog.GetScheduleDifferences()
+    if assert.Contains(t, differences, "c") {
+        assert.Len(t, differences["c"].Missing, 1)
+        assert.Len(t, differences["c"].Unexpected, 1)
+    }
+}
+
+func TestScheduleWatchDog_NoScheduleComputed_mustRetryEarlyInEpoch(t *testing.T) {
+    now := time.Now()
+    settings := jortest.TimeSettingsAt(now, 5, 10, time.Second, 1000)
+    a := jortest.NewNode()
+    defer a.Close()
+    nodes := []Node{{Name: "a", Type: LeaderCandidate, API: a.API(time.Second)}}
+    db := storage.NewMemory()
+    defer db.Close()
+    watchDog := NewScheduleWatchDog(nodes, settings, db, DefaultScheduleSettings())
+    next := watchDog.watchCurrentEpoch()
+    assert.Equal(t, 50*time.Second, next)
+    _, found := watchDog.GetScheduleFor(big.NewInt(5))
+    assert.False(t, found)
+    assert.Empty(t, watchDog.GetViableLeaderNodes())
+}
+
+func TestScheduleWatchDog_StoredSchedule_mustBeLoadedAfterRestart(t *testing.T) {
+    now := time.Now()
+    settings := jortest.TimeSettingsAt(now, 5, 10, time.Second, 1000)
+    a := jortest.NewNode()
+    defer a.Close()
+    a.SetSchedule([]jor.LeaderAssignment{jortest.Assignment(5, 100, *settings)})
+    nodes := []Node{{Name: "a", Type: LeaderCandidate, API: a.API(time.Second)}}
+    db := storage.NewMemory()
+    defer db.Close()
+    NewScheduleWatchDog(nodes, settings, db, DefaultScheduleSettings()).watchCurrentEpoch()
+    a.SetSchedule(nil)
+    restartedWatchDog := NewScheduleWatchDog(nodes, settings, db, DefaultScheduleSettings())
+    restartedWatchDog.watchCurrentEpoch()
+    schedule, found := restartedWatchDog.GetScheduleFor(big.NewInt(5))
+    if assert.True(t, found) {
+        assert.Len(t, schedule, 1)
+    }
+}
+
+func TestScheduleWatchDog_Watch_mustFetchScheduleOfNextEpoch(t *testing.T) {
+    clock := jortest.NewClock(time.Now())
+    settings := jortest.TimeSettingsAt(clock.Now(), 5, 10, time.Second, 1000)
+    a := jortest.NewNodeWithClock(clock)
+    defer a.Close()
+    a.SetSchedule([]jor.LeaderAssignment{
+        jortest.Assignment(5, 100, *settings),
+        jortest.Assignment(6, 100, *settings),
+        jortest.Assignment(6, 200, *settings),
+    })
+    nodes := []Node{{Name: "a", Type: LeaderCandidate, API: a.API(time.Second)}}
+    db := storage.NewMemory()
+    defer db.Close()
+    scheduleSettings := DefaultScheduleSettings()
+    scheduleSettings.Clock = clock
+    watchDog := NewScheduleWatchDog(nodes, settings, db, scheduleSettings)
+    go watchDog.Watch()
+    assert.Eventually(t, func() bool {
+        _, found := watchDog.GetScheduleFor(big.NewInt(5))
+        return found
+    }, 2*time.Second, 10*time.Millisecond)
+    // fast forward across the epoch turn over.
+    assert.True(t, clock.WaitForTimers(1, 2*time.Second))
+    clock.AdvanceToSlot(*settings, 6, 3)
+    assert.Eventually(t, func() bool {
+        schedule, found := watchDog.GetScheduleFor(big.NewInt(6))
+        viableNodes := watchDog.GetViableLeaderNodes()
+        return found && len(schedule) == 2 && len(viableNodes) == 1 && viableNodes[0] == "a"
+    }, 2*time.Second, 10*time.Millisecond)
+}
+
+func TestScheduleSettings_ViabilityRetryInterval_mustGrowWithTimeSinceTurnOver(t *testing.T) {
+    settings := ScheduleSettings{ViabilityMinRetryInterval: 10 * time.Second, ViabilityMaxRetryInterval: time.Minute}
+    assert.Equal(t, 10*time.Second, settings.viabilityRetryInterval(0))
+    assert.Equal(t, 10*time.Second, settings.viabilityRetryInterval(5*time.Second))
+    assert.Equal(t, 20*time.Second, settings.viabilityRetryInterval(20*time.Second))
+    assert.Equal(t, 40*time.Second, settings.viabilityRetryInterval(40*time.Second))
+    assert.Equal(t, time.Minute, settings.viabilityRetryInterval(2*time.Hour))
+}
+
+func TestScheduleWatchDog_CandidateFixingSchedule_mustBecomeViable(t *testing.T) {
+    clock := jortest.NewClock(time.Now())
+    settings := jortest.TimeSettingsAt(clock.Now(), 5, 10, time.Second, 1000)
+    schedule := []jor.LeaderAssignment{jortest.Assignment(5, 100, *settings), jortest.Assignment(5, 200, *settings)}
+    a, b, c := jortest.NewNode(), jortest.NewNode(), jortest.NewNode()
+    defer a.Close()
+    defer b.Close()
+    defer c.Close()
+    a.SetSchedule(schedule)
+    b.SetSchedule(schedule)
+    c.SetSchedule(schedule[:1])
+    nodes := []Node{
+        {Name: "a", Type: LeaderCandidate, API: a.API(time.Second)},
+        {Name: "b", Type: LeaderCandidate, API: b.API(time.Second)},
+        {Name: "c", Type: LeaderCandidate, API: c.API(time.Second)},
+    }
+    db := storage.NewMemory()
+    defer db.Close()
+    scheduleSettings := DefaultScheduleSettings()
+    scheduleSettings.Clock = clock
+    watchDog := NewScheduleWatchDog(nodes, settings, db, scheduleSettings)
+    events := make(chan ViabilityEvent, 10)
+    watchDog.RegisterViabilityListener(events)
+    watchDog.watchCurrentEpoch()
+    assert.ElementsMatch(t, []string{"a", "b"}, watchDog.GetViableLeaderNodes())
+    assert.Equal(t, "a", (<-events).Node)
+    assert.Equal(t, "b", (<-events).Node)
+    // the first re-checks happen right after the fetch, and after the minimum interval.
+    assert.True(t, clock.WaitForTimers(1, 2*time.Second))
+    clock.Advance(10 * time.Second)
+    assert.True(t, clock.WaitForTimers(1, 2*time.Second))
+    c.SetSchedule(schedule)
+    clock.Advance(20 * time.Second)
+    select {
+    case event := <-events:
+        assert.Equal(t, "c", event.Node)
+        assert.Equal(t, "5", event.Epoch)
+        assert.Equal(t, 3, event.Checks)
+    case <-time.After(2 * time.Second):
+        t.Fatal("the candidate has not become viable.")
+    }
+    assert.ElementsMatch(t, []string{"a", "b", "c"}, watchDog.GetViableLeaderNodes())
+    assert.Empty(t, watchDog.GetScheduleDifferences())
+}
+
+func TestScheduleWatchDog_GetNextScheduledBlock_mustBeEarliestAssignmentAfterNow(t *testing.T) {
+    now := time.Now()
+    settings := jortest.TimeSettingsAt(now, 5, 10, time.Second, 1000)
+    db := storage.NewMemory()
+    defer db.Close()
+    watchDog := NewScheduleWatchDog([]Node{}, settings, db, DefaultScheduleSettings())
+    _, found := watchDog.GetNextScheduledBlock(now)
+    assert.False(t, found)
+    watchDog.scheduleMap["5"] = []jor.LeaderAssignment{
+        jortest.Assignment(5, 5, *settings),
+        jortest.Assignment(5, 100, *settings),
+        jortest.Assignment(5, 50, *settings),
+    }
+    next, found := watchDog.GetNextScheduledBlock(now)
+    if assert.True(t, found) {
+        assert.Equal(t, jortest.Assignment(5, 50, *settings).ScheduleTime, next)
+    }
+    _, found = watchDog.GetNextScheduledBlock(next)
+    assert.True(t, found)
+    _, found = watchDog.GetNextScheduledBlock(jortest.Assignment(5, 100, *settings).ScheduleTime)
+    assert.False(t, found)
+}
+
+func TestViableLeaderNodes_Prune_mustKeepCurrentAndPreviousEpoch(t *testing.T) {
+    viable := viableLeaderNodes{
+        epochMap:      map[string][]string{"3": {"a"}, "4": {"a"}, "5": {"a"}},
+        differenceMap: map[string]map[string]ScheduleDifference{"3": {}, "5": {}},
+        eventMap:      map[string][]ViabilityEvent{"2": {}, "4": {}},
+    }
+    viable.prune(big.NewInt(5))
+    assert.Len(t, viable.epochMap, 2)
+    assert.Contains(t, viable.epochMap, "4")
+    assert.Contains(t, viable.epochMap, "5")
+    assert.Len(t, viable.differenceMap, 1)
+    assert.Len(t, viable.eventMap, 1)
+}
+
+func TestIsBeforePreviousEpoch(t *testing.T) {
+    assert.True(t, isBeforePreviousEpoch("3", big.NewInt(5)))
+    assert.False(t, isBeforePreviousEpoch("4", big.NewInt(5)))
+    assert.False(t, isBeforePreviousEpoch("5", big.NewInt(5)))
+    assert.False(t, isBeforePreviousEpoch("no epoch", big.NewInt(5)))
+}
@@ -1,6 +1,9 @@
 package monitor
 
-import "time"
+import (
+    "github.com/sobitada/thor/audit"
+    "time"
+)
 
 // gets all the node names of a node map.
 func GetNodeNames(nodeMap map[string]Node) []string {
@@ -11,9 +14,32 @@ func GetNodeNames(nodeMap map[string]Node) []string {
     return nodeNameList
 }
 
-// shuts down the given node.
-func ShutDownNode(node Node) {
-    _ = node.API.Shutdown()
+// shuts down the given node. an error is returned, if none
+// of the shutdown requests succeeded.
+func ShutDownNode(node Node) error {
+    err := node.API.Shutdown()
     time.Sleep(time.Duration(200) * time.Millisecond)
-    _ = node.API.Shutdown()
+    secondErr := node.API.Shutdown()
+    if err != nil {
+        return secondErr
+    }
+    return nil
+}
+
+// causes of the shutdowns issued by this tool.
+const (
+    LagCause            string = "lag"
+    StuckCause          string = "stuck"
+    DemotionFailedCause string = "demotion-failed"
+)
+
+// shuts down the given node and records the shutdown with the
+// given cause and reason in the audit log.
+func ShutDownAndRecord(node Node, auditLog *audit.Log, cause string, reason string) {
+    err := ShutDownNode(node)
+    if err == nil {
+        auditLog.RecordShutdown(node.Name, cause, reason, audit.Success)
+    } else {
+        auditLog.RecordShutdown(node.Name, cause, reason, audit.Failure+": "+err.Error())
+    }
 }
\ No newline at end of file
@@ -0,0 +1,46 @@
+package monitor
+
+import (
+    "encoding/json"
+    "net/http"
+)
+
+// report about the viability of the leader candidates in the current epoch.
+type ViabilityReport struct {
+    Epoch       string                        `json:"epoch"`
+    Assignments []ScheduledSlot               `json:"assignments"`
+    ViableNodes []string                      `json:"viableNodes"`
+    Differences map[string]ScheduleDifference `json:"differences"`
+    Events      []ViabilityEvent              `json:"events"`
+}
+
+// gets the report about the viability of the leader candidates in the
+// current epoch.
+func (watchDog *ScheduleWatchDog) GetViabilityReport() ViabilityReport {
+    currentSlotDate, _ := watchDog.timeSettings.GetSlotDateFor(watchDog.clock.Now())
+    schedule, _ := watchDog.GetScheduleFor(currentSlotDate.GetEpoch())
+    viableNodes := watchDog.GetViableLeaderNodes()
+    if viableNodes == nil {
+        viableNodes = []string{}
+    }
+    return ViabilityReport{
+        Epoch:       currentSlotDate.GetEpoch().String(),
+        Assignments: getScheduledSlots(schedule),
+        ViableNodes: viableNodes,
+        Differences: watchDog.GetScheduleDifferences(),
+        Events:      watchDog.getViabilityEvents(currentSlotDate.GetEpoch().String()),
+    }
+}
+
+// gets the events of candidates that have become viable in the given epoch.
+func (watchDog *ScheduleWatchDog) getViabilityEvents(epoch string) []ViabilityEvent {
+    watchDog.viableLeaderNodes.mutex.Lock()
+    defer watchDog.viableLeaderNodes.mutex.Unlock()
+    return append([]ViabilityEvent{}, watchDog.viableLeaderNodes.eventMap[epoch]...)
+}
+
+// serves the viability report of the current epoch as JSON.
+func (watchDog *ScheduleWatchDog) ServeViabilityReport(writer http.ResponseWriter, request *http.Request) {
+    writer.Header().Set("Content-Type", "application/json")
+    _ = json.NewEncoder(writer).Encode(watchDog.GetViabilityReport())
+}
@@ -0,0 +1,63 @@
+package notify
+
+import (
+    "bytes"
+    "fmt"
+    "io"
+    "net"
+    "net/http"
+    "net/smtp"
+    "strings"
+)
+
+// webhook, to which messages are posted.
+type Webhook struct {
+    URL string
+    // client used for the requests, the default client
+    // is used if it is nil.
+    Client *http.Client
+}
+
+// posts the given body with the given content type to the webhook, and
+// returns an error, if it did not respond with a success status code.
+func (webhook Webhook) Post(contentType string, body io.Reader) error {
+    client := webhook.Client
+    if client == nil {
+        client = http.DefaultClient
+    }
+    response, err := client.Post(webhook.URL, contentType, body)
+    if err != nil {
+        return err
+    }
+    defer response.Body.Close()
+    if response.StatusCode < 200 || response.StatusCode >= 300 {
+        return fmt.Errorf("the webhook responded with status code %v", response.StatusCode)
+    }
+    return nil
+}
+
+// mail server, over which messages are sent to the recipients. no
+// authentication is used, if the username is empty.
+type Mail struct {
+    Host     string
+    Port     string
+    Username string
+    Password string
+    From     string
+    To       []string
+}
+
+// sends a mail with the given subject and body of the given content type
+// over SMTP to the recipients.
+func (mail Mail) Send(subject string, contentType string, body []byte) error {
+    var message bytes.Buffer
+    _, _ = fmt.Fprintf(&message, "From: %v\r\nTo: %v\r\nSubject: %v\r\n", mail.From, strings.Join(mail.To, ", "),
+        subject)
+    _, _ = fmt.Fprintf(&message, "Content-Type: %v; charset=UTF-8\r\n\r\n", contentType)
+    message.Write(body)
+    var auth smtp.Auth = nil
+    if mail.Username != "" {
+        auth = smtp.PlainAuth("", mail.Username, mail.Password, mail.Host)
+    }
+    return smtp.SendMail(net.JoinHostPort(mail.Host, mail.Port), auth, mail.From, mail.To, message.Bytes())
+}
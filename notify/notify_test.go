@@ -0,0 +1,34 @@
+package notify
+
+import (
+    "github.com/stretchr/testify/assert"
+    "io/ioutil"
+    "net/http"
+    "net/http/httptest"
+    "strings"
+    "testing"
+)
+
+func TestWebhook_Post_mustSendBodyWithContentType(t *testing.T) {
+    var contentType, body string
+    server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+        contentType = request.Header.Get("Content-Type")
+        data, _ := ioutil.ReadAll(request.Body)
+        body = string(data)
+    }))
+    defer server.Close()
+    err := Webhook{URL: server.URL}.Post("text/markdown", strings.NewReader("# Report"))
+    if assert.Nil(t, err) {
+        assert.Equal(t, "text/markdown", contentType)
+        assert.Equal(t, "# Report", body)
+    }
+}
+
+func TestWebhook_Post_errorStatus_mustFail(t *testing.T) {
+    server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+        writer.WriteHeader(http.StatusBadGateway)
+    }))
+    defer server.Close()
+    err := Webhook{URL: server.URL}.Post("application/json", strings.NewReader("{}"))
+    assert.NotNil(t, err)
+}
@@ -0,0 +1,262 @@
+package pooltool
+
+import (
+    "bytes"
+    "encoding/json"
+    "fmt"
+    log "github.com/sirupsen/logrus"
+    "github.com/sobitada/thor/clock"
+    "io/ioutil"
+    "math/big"
+    "net/http"
+    "net/url"
+    "time"
+)
+
+// default endpoints of the Pool Tool API.
+const (
+    DefaultTipURL      string = "https://tamoq3vkbl.execute-api.us-west-2.amazonaws.com/prod/sharemytip"
+    DefaultScheduleURL string = "https://api.pooltool.io/v0/sendlogs"
+)
+
+// endpoint of Pool Tool publishing the statistics of the network, such as
+// the tip reported by the majority of the pools.
+const DefaultNetworkTipURL string = "https://pooltool.s3-us-west-2.amazonaws.com/stats/stats.json"
+
+// team of Pool Tool asked to keep rate low.
+const DefaultTipInterval time.Duration = 30 * time.Second
+
+// interval in which failed posts of the schedule are retried by default.
+const DefaultRetryInterval time.Duration = 5 * time.Minute
+
+// minimum interval between two posts of the schedule with changed outcomes
+// by default.
+const DefaultOutcomeInterval time.Duration = 30 * time.Minute
+
+// error message of Pool Tool, if the schedule of the previous epoch could not
+// be decrypted with the passed key.
+const malformedPreviousScheduleReason string = "We were unable to parse the decrypted json data.  That either means " +
+    "we were unable to decrypt it, or the json is not valid"
+
+// settings of the Pool Tool client.
+type Settings struct {
+    // IDs of the pool and the user in Pool Tool.
+    PoolID string
+    UserID string
+    // hash of the genesis block of the block chain.
+    GenesisHash string
+    // endpoints to which the tip and the schedule are sent, the
+    // default endpoints are used if they are empty.
+    TipURL      string
+    ScheduleURL string
+    // interval in which the tip is sent.
+    TipInterval time.Duration
+    // which tip is reported, the maximum tip is reported per default.
+    TipSource TipSource
+    // duration after which a tip that has not advanced is considered
+    // to be stale, and whether stale tips are suppressed or only flagged.
+    // stale tips are not detected, if the duration is zero.
+    StaleTipAfter  time.Duration
+    StaleTipAction StaleTipAction
+    // endpoint from which the tip of the network is fetched, it is not
+    // fetched if it is empty.
+    NetworkTipURL string
+    // interval in which failed posts of the schedule are retried.
+    RetryInterval time.Duration
+    // minimum interval between two posts of the schedule with
+    // changed outcomes.
+    OutcomeInterval time.Duration
+    // timeout of the requests, and the URL of a proxy. they are
+    // ignored, if a client is specified.
+    Timeout time.Duration
+    Proxy   *url.URL
+    // client used for the requests, a client with the timeout and
+    // proxy from above is used if it is nil.
+    Client *http.Client
+    // clock used for timing, the clock of the system is used if
+    // it is nil.
+    Clock clock.Clock
+}
+
+// client of the Pool Tool API.
+type Client struct {
+    settings Settings
+    client   *http.Client
+}
+
+// creates a new client of the Pool Tool API with the given settings. default
+// values are used for unspecified settings.
+func NewClient(settings Settings) *Client {
+    if settings.TipURL == "" {
+        settings.TipURL = DefaultTipURL
+    }
+    if settings.ScheduleURL == "" {
+        settings.ScheduleURL = DefaultScheduleURL
+    }
+    if settings.TipInterval == 0 {
+        settings.TipInterval = DefaultTipInterval
+    }
+    if settings.TipSource == "" {
+        settings.TipSource = MaxTip
+    }
+    if settings.StaleTipAction == "" {
+        settings.StaleTipAction = FlagStaleTip
+    }
+    if settings.RetryInterval == 0 {
+        settings.RetryInterval = DefaultRetryInterval
+    }
+    if settings.OutcomeInterval == 0 {
+        settings.OutcomeInterval = DefaultOutcomeInterval
+    }
+    settings.Clock = clock.OrReal(settings.Clock)
+    client := settings.Client
+    if client == nil {
+        proxy := http.ProxyFromEnvironment
+        if settings.Proxy != nil {
+            proxy = http.ProxyURL(settings.Proxy)
+        }
+        client = &http.Client{Timeout: settings.Timeout, Transport: &http.Transport{Proxy: proxy}}
+    }
+    return &Client{settings: settings, client: client}
+}
+
+// posts the given block height to the Pool Tool API.
+func (client *Client) PostTip(tip *big.Int) error {
+    u, err := url.Parse(client.settings.TipURL)
+    if err != nil {
+        return err
+    }
+    q := u.Query()
+    q.Set("poolid", client.settings.PoolID)
+    q.Set("userid", client.settings.UserID)
+    q.Set("genesispref", client.settings.GenesisHash)
+    q.Set("mytip", tip.String())
+    q.Set("platform", "thor")
+    u.RawQuery = q.Encode()
+    response, err := client.client.Get(u.String())
+    if err != nil {
+        return withoutQuery(err, client.settings.TipURL)
+    }
+    defer response.Body.Close()
+    if response.StatusCode != 200 {
+        return poolToolAPIException{URL: client.settings.TipURL, StatusCode: response.StatusCode,
+            Reason: response.Status}
+    }
+    return nil
+}
+
+// statistics of the network published by Pool Tool.
+type networkStats struct {
+    MajorityMax *big.Int `json:"majoritymax"`
+}
+
+// gets the tip of the network, i.e. the block height reported by the majority
+// of the pools to Pool Tool.
+func (client *Client) GetNetworkTip() (*big.Int, error) {
+    networkTipURL := client.settings.NetworkTipURL
+    response, err := client.client.Get(networkTipURL)
+    if err != nil {
+        return nil, err
+    }
+    defer response.Body.Close()
+    if response.StatusCode != 200 {
+        return nil, poolToolAPIException{URL: networkTipURL, StatusCode: response.StatusCode, Reason: response.Status}
+    }
+    var stats networkStats
+    err = json.NewDecoder(response.Body).Decode(&stats)
+    if err != nil {
+        return nil, poolToolAPIException{
+            URL:        networkTipURL,
+            StatusCode: response.StatusCode,
+            Reason:     fmt.Sprintf("Could not serialize the JSON body. %v", err.Error()),
+        }
+    }
+    if stats.MajorityMax == nil {
+        return nil, poolToolAPIException{URL: networkTipURL, StatusCode: response.StatusCode,
+            Reason: "The tip of the network is missing."}
+    }
+    return stats.MajorityMax, nil
+}
+
+// payload for sending a schedule update to Pool Tool.
+type postSchedulePayload struct {
+    CurrentEpoch     int64  `json:"currentepoch"`
+    PoolID           string `json:"poolid"`
+    UserID           string `json:"userid"`
+    GenesisPref      string `json:"genesispref"`
+    AssignedSlots    int    `json:"assigned_slots"`
+    EncryptedSlots   string `json:"encrypted_slots"`
+    PreviousEpochKey string `json:"previous_epoch_key,omitempty"`
+}
+
+// response payload from Pool Tool for the API method
+// accepting schedule update.
+type postScheduleResponse struct {
+    Success bool                       `json:"success"`
+    Message map[string]json.RawMessage `json:"message"`
+}
+
+// posts the schedule information to Pool Tool. It requires the epoch for which the schedule
+// shall be updated, the number of assigned slots in the epoch and the encrypted JSON serialization
+// of the schedule. If there has been an update for the previous epoch, then the key for encrypting
+// the data for the previous epoch must be specified. However, this field is optional.
+func (client *Client) PostSchedule(epoch *big.Int, slotsNumber int, encryptedSchedule string,
+    previousEpochKey string) error {
+    scheduleURL := client.settings.ScheduleURL
+    payload := &postSchedulePayload{
+        CurrentEpoch:     epoch.Int64(),
+        PoolID:           client.settings.PoolID,
+        UserID:           client.settings.UserID,
+        GenesisPref:      client.settings.GenesisHash,
+        AssignedSlots:    slotsNumber,
+        EncryptedSlots:   encryptedSchedule,
+        PreviousEpochKey: previousEpochKey,
+    }
+    payloadBytes, err := json.Marshal(payload)
+    if err != nil {
+        return err
+    }
+    response, err := client.client.Post(scheduleURL, "application/json", bytes.NewReader(payloadBytes))
+    if err != nil {
+        return err
+    }
+    defer response.Body.Close()
+    if response.StatusCode != 200 {
+        return poolToolAPIException{URL: scheduleURL, StatusCode: response.StatusCode, Reason: response.Status}
+    }
+    responseData, err := ioutil.ReadAll(response.Body)
+    if err != nil {
+        return poolToolAPIException{
+            URL:        scheduleURL,
+            StatusCode: response.StatusCode,
+            Reason:     fmt.Sprintf("Could not read the body of the response. %v", err.Error()),
+        }
+    }
+    var responseJSON postScheduleResponse
+    err = json.Unmarshal(responseData, &responseJSON)
+    if err != nil {
+        return poolToolAPIException{
+            URL:        scheduleURL,
+            StatusCode: response.StatusCode,
+            Reason:     fmt.Sprintf("Could not serialize the JSON body.%v", err.Error()),
+        }
+    }
+    if responseJSON.Success {
+        return nil
+    }
+    var reason string = ""
+    reasonRaw, found := responseJSON.Message["error"]
+    if found {
+        _ = json.Unmarshal(reasonRaw, &reason)
+    }
+    if reason == malformedPreviousScheduleReason && previousEpochKey != "" {
+        log.Warn("[POOLTOOL] Could not post the schedule to Pool Tool, because the information sent in the previous " +
+            "epoch was malformed. Trying without passing key phrase about previous epoch.")
+        return client.PostSchedule(epoch, slotsNumber, encryptedSchedule, "")
+    }
+    return poolToolAPIException{
+        URL:        scheduleURL,
+        StatusCode: response.StatusCode,
+        Reason:     fmt.Sprintf("Request was rejected, due to: %v", reason),
+    }
+}
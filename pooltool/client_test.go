@@ -0,0 +1,92 @@
+package pooltool
+
+import (
+    "encoding/json"
+    "github.com/stretchr/testify/assert"
+    "math/big"
+    "net/http"
+    "net/http/httptest"
+    "net/url"
+    "testing"
+    "time"
+)
+
+func getSettings(server *httptest.Server) Settings {
+    return Settings{
+        PoolID:      "pool",
+        UserID:      "user",
+        GenesisHash: "genesis",
+        TipURL:      server.URL + "/tip",
+        ScheduleURL: server.URL + "/schedule",
+        Client:      server.Client(),
+    }
+}
+
+func TestClient_PostTip_mustSendTipAsQuery(t *testing.T) {
+    var query url.Values
+    server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+        assert.Equal(t, "/tip", request.URL.Path)
+        query = request.URL.Query()
+    }))
+    defer server.Close()
+    err := NewClient(getSettings(server)).PostTip(big.NewInt(42))
+    if assert.Nil(t, err) {
+        assert.Equal(t, "42", query.Get("mytip"))
+        assert.Equal(t, "pool", query.Get("poolid"))
+        assert.Equal(t, "user", query.Get("userid"))
+        assert.Equal(t, "genesis", query.Get("genesispref"))
+    }
+}
+
+func TestClient_PostTip_mustFailOnTimeout(t *testing.T) {
+    done := make(chan bool)
+    server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+        <-done
+    }))
+    defer server.Close()
+    defer close(done)
+    settings := getSettings(server)
+    settings.Client = nil
+    settings.Timeout = 50 * time.Millisecond
+    err := NewClient(settings).PostTip(big.NewInt(42))
+    if assert.NotNil(t, err) {
+        // the user ID in the query must not be logged.
+        assert.NotContains(t, err.Error(), "userid")
+        assert.Contains(t, err.Error(), settings.TipURL)
+    }
+}
+
+func TestClient_PostSchedule_mustRetryWithoutMalformedPreviousKey(t *testing.T) {
+    payloads := make([]postSchedulePayload, 0)
+    server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+        var payload postSchedulePayload
+        _ = json.NewDecoder(request.Body).Decode(&payload)
+        payloads = append(payloads, payload)
+        if payload.PreviousEpochKey != "" {
+            _ = json.NewEncoder(writer).Encode(map[string]interface{}{"success": false,
+                "message": map[string]string{"error": malformedPreviousScheduleReason}})
+            return
+        }
+        _ = json.NewEncoder(writer).Encode(map[string]interface{}{"success": true})
+    }))
+    defer server.Close()
+    err := NewClient(getSettings(server)).PostSchedule(big.NewInt(10), 3, "encrypted", "key")
+    if assert.Nil(t, err) && assert.Len(t, payloads, 2) {
+        assert.Equal(t, "key", payloads[0].PreviousEpochKey)
+        assert.Equal(t, "", payloads[1].PreviousEpochKey)
+        assert.Equal(t, int64(10), payloads[1].CurrentEpoch)
+        assert.Equal(t, 3, payloads[1].AssignedSlots)
+    }
+}
+
+func TestClient_PostSchedule_mustReturnRejection(t *testing.T) {
+    server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+        _ = json.NewEncoder(writer).Encode(map[string]interface{}{"success": false,
+            "message": map[string]string{"error": "unknown pool"}})
+    }))
+    defer server.Close()
+    err := NewClient(getSettings(server)).PostSchedule(big.NewInt(10), 3, "encrypted", "")
+    if assert.NotNil(t, err) {
+        assert.Contains(t, err.Error(), "unknown pool")
+    }
+}
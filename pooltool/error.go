@@ -1,6 +1,9 @@
 package pooltool
 
-import "fmt"
+import (
+    "fmt"
+    "net/url"
+)
 
 type poolToolAPIException struct {
     URL        string
@@ -11,3 +14,12 @@ type poolToolAPIException struct {
 func (e poolToolAPIException) Error() string {
     return fmt.Sprintf("Pool Tool API method '%v' failed with status code %v. %v", e.URL, e.StatusCode, e.Reason)
 }
+
+// gets the given error of a request to the given endpoint without the URL of
+// the request, whose query might hold secrets such as the user ID.
+func withoutQuery(err error, endpoint string) error {
+    if urlErr, ok := err.(*url.Error); ok {
+        return fmt.Errorf("%v '%v' failed. %v", urlErr.Op, endpoint, urlErr.Err.Error())
+    }
+    return err
+}
@@ -1,45 +1,73 @@
 package pooltool
 
 import (
-    "github.com/boltdb/bolt"
     "github.com/sobitada/go-cardano"
     jor "github.com/sobitada/go-jormungandr/api"
+    "github.com/sobitada/thor/blocks"
+    "github.com/sobitada/thor/leader"
     "github.com/sobitada/thor/monitor"
+    "github.com/sobitada/thor/storage"
+    "sync"
 )
 
-// Pool Tool object, which specifies the user
-// id, pool id and the hash of the genesis
-// block.
+// sources from which the information sent to Pool Tool is taken. the tracker
+// and jury are optional.
+type Sources struct {
+    Monitor      *monitor.NodeMonitor
+    WatchDog     *monitor.ScheduleWatchDog
+    Tracker      *blocks.Tracker
+    Jury         *leader.Jury
+    TimeSettings *cardano.TimeSettings
+}
+
+// Pool Tool object, which sends the tip and the
+// schedule of the pool with its client.
 type PoolTool struct {
-    poolID         string
-    userID         string
-    genesisHash    string
+    client         *Client
+    sources        Sources
     tipUpdate      *tipUpdate
     scheduleUpdate *scheduleUpdate
+    outcomeUpdate  *outcomeUpdate
 }
 
-// constructs a new pool tool with the given configuration.
-func GetPoolTool(mon *monitor.NodeMonitor, watchDog *monitor.ScheduleWatchDog, timeSettings *cardano.TimeSettings, db *bolt.DB,
-    poolID string, userID string, genesisHash string) *PoolTool {
+// constructs a new pool tool with the given settings of the client. the outcomes
+// of the assignments are sent with the schedule, if a tracker is given.
+func GetPoolTool(sources Sources, store storage.Store, settings Settings) *PoolTool {
     // tip
     tipListener := make(chan map[string]jor.NodeStatistic)
-    mon.ListenerManager.RegisterNodeStatisticListener(tipListener)
+    sources.Monitor.ListenerManager.RegisterNodeStatisticListener(tipListener)
     // schedule
     scheduleListener := make(chan []jor.LeaderAssignment)
-    watchDog.RegisterListener(scheduleListener)
+    sources.WatchDog.RegisterListener(scheduleListener)
+    // outcomes
+    var outcomes *outcomeUpdate = nil
+    if sources.Tracker != nil {
+        outcomeListener := make(chan blocks.BlockOutcome)
+        sources.Tracker.RegisterListener(outcomeListener)
+        outcomes = &outcomeUpdate{
+            outcomes:      outcomeListener,
+            changedEpochs: make(map[uint64]bool),
+            changed:       make(chan bool, 1),
+            mutex:         &sync.Mutex{},
+        }
+    }
     return &PoolTool{
-        poolID:      poolID,
-        userID:      userID,
-        genesisHash: genesisHash,
+        client:  NewClient(settings),
+        sources: sources,
         tipUpdate: &tipUpdate{
             latestTip:        nil,
             latestTipChannel: tipListener,
+            mutex:            &sync.Mutex{},
         },
         scheduleUpdate: &scheduleUpdate{
-            db:             db,
-            timeSettings:   timeSettings,
+            store:          store,
+            timeSettings:   sources.TimeSettings,
+            watchDog:       sources.WatchDog,
+            tracker:        sources.Tracker,
             latestSchedule: scheduleListener,
+            mutex:          &sync.Mutex{},
         },
+        outcomeUpdate: outcomes,
     }
 }
 
@@ -47,4 +75,5 @@ func GetPoolTool(mon *monitor.NodeMonitor, watchDog *monitor.ScheduleWatchDog, t
 func (poolTool *PoolTool) Start() {
     go poolTool.startTipUpdating()
     go poolTool.startScheduleUpdating()
+    go poolTool.startOutcomeUpdating()
 }
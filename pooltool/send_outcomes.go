@@ -0,0 +1,184 @@
+package pooltool
+
+import (
+    log "github.com/sirupsen/logrus"
+    jor "github.com/sobitada/go-jormungandr/api"
+    "github.com/sobitada/thor/blocks"
+    "github.com/sobitada/thor/storage"
+    "math/big"
+    "sync"
+)
+
+type outcomeUpdate struct {
+    outcomes chan blocks.BlockOutcome
+    // epochs, whose outcomes changed since the last update.
+    changedEpochs map[uint64]bool
+    changed       chan bool
+    mutex         *sync.Mutex
+}
+
+// start the process of updating the schedule sent to Pool Tool with the
+// outcomes determined by the tracker.
+func (poolTool *PoolTool) startOutcomeUpdating() {
+    outcomeUpdate := poolTool.outcomeUpdate
+    if outcomeUpdate == nil {
+        log.Info("[POOLTOOL] No outcomes of the assignments will be sent, because no tracker is configured.")
+        return
+    }
+    go poolTool.updateChangedOutcomes()
+    for ; ; {
+        outcome := <-outcomeUpdate.outcomes
+        outcomeUpdate.mutex.Lock()
+        outcomeUpdate.changedEpochs[outcome.Epoch] = true
+        outcomeUpdate.mutex.Unlock()
+        select {
+        case outcomeUpdate.changed <- true:
+        default:
+        }
+    }
+}
+
+// a blocking call, which sends the schedules of the epochs, whose outcomes
+// changed. the schedules are sent at most once per outcome interval, and
+// the changes in between are sent together. the tracker is never blocked
+// by the requests to Pool Tool.
+func (poolTool *PoolTool) updateChangedOutcomes() {
+    outcomeUpdate := poolTool.outcomeUpdate
+    for ; ; {
+        <-outcomeUpdate.changed
+        outcomeUpdate.mutex.Lock()
+        epochs := outcomeUpdate.changedEpochs
+        outcomeUpdate.changedEpochs = make(map[uint64]bool)
+        outcomeUpdate.mutex.Unlock()
+        for epoch := range epochs {
+            poolTool.updateOutcomes(new(big.Int).SetUint64(epoch))
+        }
+        poolTool.client.settings.Clock.Sleep(poolTool.client.settings.OutcomeInterval)
+    }
+}
+
+// sends the schedule of the given epoch with the latest outcomes. the
+// schedule of the previous epoch is sent once more with its final outcomes,
+// after its key has been revealed.
+func (poolTool *PoolTool) updateOutcomes(epoch *big.Int) {
+    poolTool.scheduleUpdate.mutex.Lock()
+    defer poolTool.scheduleUpdate.mutex.Unlock()
+    currentEpoch := poolTool.currentEpoch()
+    if epoch.Cmp(currentEpoch) > 0 || new(big.Int).Sub(currentEpoch, epoch).Cmp(new(big.Int).SetInt64(1)) > 0 {
+        return
+    }
+    if epoch.Cmp(currentEpoch) < 0 && poolTool.scheduleUpdate.getKey(currentEpoch) != "" {
+        poolTool.sendFinalOutcomes(epoch)
+        return
+    }
+    poolTool.refreshOutcomes(epoch)
+}
+
+// sends the schedule of the given passed epoch with its final outcomes, once
+// the outcomes of all its assignments have settled. it is only sent once,
+// and only if the schedule has been sent before. it is posted like the other
+// updates of the epoch, i.e. for the passed epoch with the key of the epoch
+// before, which has already been revealed. a failed post is retried until
+// the end of the current epoch, and the epoch is only marked as sent, once
+// the post has been accepted.
+func (poolTool *PoolTool) sendFinalOutcomes(epoch *big.Int) {
+    scheduleUpdate := poolTool.scheduleUpdate
+    if scheduleUpdate.tracker == nil || scheduleUpdate.watchDog == nil {
+        return
+    }
+    key := scheduleUpdate.getKey(epoch)
+    if key == "" || scheduleUpdate.isFinalSent(epoch) {
+        return
+    }
+    p, found, err := scheduleUpdate.getPendingSchedule(epoch.Uint64())
+    if err != nil {
+        log.Errorf("[POOLTOOL] Could not load the pending schedule updates. %v", err.Error())
+        return
+    } else if found && p.Final {
+        log.Debugf("[POOLTOOL] The final outcomes of epoch '%v' are pending.", epoch.String())
+        return
+    }
+    schedule, found := scheduleUpdate.watchDog.GetStoredScheduleFor(epoch)
+    if !found {
+        return
+    }
+    schedule = jor.GetLeaderLogsInEpoch(epoch, schedule)
+    if len(schedule) == 0 {
+        return
+    }
+    outcomes := scheduleUpdate.getOutcomes(epoch)
+    for _, assignment := range schedule {
+        outcome, found := outcomes[assignment.ScheduleBlockDate.GetSlot().Uint64()]
+        if !found || !outcome.IsFinal() {
+            log.Debugf("[POOLTOOL] The outcomes of epoch '%v' have not yet settled.", epoch.String())
+            return
+        }
+    }
+    log.Infof("[POOLTOOL] Sending the final outcomes of epoch '%v'.", epoch.String())
+    poolTool.sendSchedule(epoch, schedule, key, true)
+}
+
+// checks whether the final outcomes of the given epoch have been sent.
+func (scheduleUpdate *scheduleUpdate) isFinalSent(epoch *big.Int) bool {
+    sent := false
+    _ = scheduleUpdate.store.View(func(tx storage.Tx) error {
+        data, err := tx.Get(storage.PoolToolOutcomesBucket, epoch.String())
+        sent = err == nil && data != nil
+        return err
+    })
+    return sent
+}
+
+// sends the schedule of the given epoch with the outcomes determined so far,
+// if the schedule has been sent before and its key has not yet been revealed
+// with the schedule of the next epoch. the key of the epoch is kept, such that
+// Pool Tool can verify the outcomes with the key later.
+func (poolTool *PoolTool) refreshOutcomes(epoch *big.Int) {
+    scheduleUpdate := poolTool.scheduleUpdate
+    if scheduleUpdate.tracker == nil || scheduleUpdate.watchDog == nil || epoch.Sign() < 0 {
+        return
+    }
+    nextEpoch := new(big.Int).Add(epoch, new(big.Int).SetInt64(1))
+    if scheduleUpdate.getKey(nextEpoch) != "" {
+        log.Debugf("[POOLTOOL] The key of epoch '%v' has already been revealed.", epoch.String())
+        return
+    }
+    key := scheduleUpdate.getKey(epoch)
+    if key == "" {
+        p, found, err := scheduleUpdate.getPendingSchedule(epoch.Uint64())
+        if err != nil {
+            log.Errorf("[POOLTOOL] Could not load the pending schedule updates. %v", err.Error())
+            return
+        } else if !found {
+            return
+        }
+        key = p.Key
+    }
+    schedule, found := scheduleUpdate.watchDog.GetStoredScheduleFor(epoch)
+    if !found {
+        return
+    }
+    schedule = jor.GetLeaderLogsInEpoch(epoch, schedule)
+    if len(schedule) == 0 {
+        return
+    }
+    poolTool.sendSchedule(epoch, schedule, key, false)
+}
+
+// gets the outcomes determined for the assignments of the given epoch keyed
+// by slot.
+func (scheduleUpdate *scheduleUpdate) getOutcomes(epoch *big.Int) map[uint64]blocks.BlockOutcome {
+    outcomeMap := make(map[uint64]blocks.BlockOutcome)
+    if scheduleUpdate.tracker == nil {
+        return outcomeMap
+    }
+    outcomes, err := scheduleUpdate.tracker.GetOutcomes(epoch.Uint64())
+    if err != nil {
+        log.Errorf("[POOLTOOL] Could not load the outcomes of epoch '%v'. %v", epoch.String(), err.Error())
+        return outcomeMap
+    }
+    for _, outcome := range outcomes {
+        outcomeMap[outcome.Slot] = outcome
+    }
+    return outcomeMap
+}
@@ -0,0 +1,150 @@
+package pooltool
+
+import (
+    "encoding/json"
+    "github.com/sobitada/go-cardano"
+    jor "github.com/sobitada/go-jormungandr/api"
+    "github.com/sobitada/thor/blocks"
+    "github.com/sobitada/thor/jortest"
+    "github.com/sobitada/thor/monitor"
+    "github.com/sobitada/thor/storage"
+    "github.com/stretchr/testify/assert"
+    "math/big"
+    "net/http"
+    "net/http/httptest"
+    "testing"
+    "time"
+)
+
+// gets a Pool Tool, whose schedule is sent to the given server, and whose
+// outcomes are tracked for the given fake leader candidate, which computed
+// the given schedule.
+func getOutcomePoolTool(t *testing.T, server *httptest.Server, node *jortest.Node,
+    schedule func(settings cardano.TimeSettings) []jor.LeaderAssignment) (*PoolTool, *jortest.Clock) {
+    poolTool, testClock := getSchedulePoolTool(server)
+    scheduleUpdate := poolTool.scheduleUpdate
+    node.SetSchedule(schedule(*scheduleUpdate.timeSettings))
+    nodes := []monitor.Node{{Name: "a", Type: monitor.LeaderCandidate, API: node.API(time.Second),
+        BlockAPI: node.BlockAPI(time.Second)}}
+    scheduleSettings := monitor.DefaultScheduleSettings()
+    scheduleSettings.Clock = testClock
+    scheduleUpdate.watchDog = monitor.NewScheduleWatchDog(nodes, scheduleUpdate.timeSettings, scheduleUpdate.store,
+        scheduleSettings)
+    go scheduleUpdate.watchDog.Watch()
+    assert.Eventually(t, func() bool {
+        _, found := scheduleUpdate.watchDog.GetScheduleFor(big.NewInt(5))
+        return found
+    }, 5*time.Second, 10*time.Millisecond)
+    var err error
+    scheduleUpdate.tracker, err = blocks.NewTracker(nodes, scheduleUpdate.watchDog, scheduleUpdate.store,
+        scheduleUpdate.timeSettings, blocks.TrackerSettings{Interval: time.Minute, SettleSlots: 5,
+            ConfirmationDepth: 10, Clock: testClock})
+    if err != nil {
+        t.Fatal(err)
+    }
+    return poolTool, testClock
+}
+
+func TestUpdateOutcomes_previousEpoch_mustSendFinalOutcomesOnce(t *testing.T) {
+    payloads := make([]postSchedulePayload, 0)
+    server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+        var payload postSchedulePayload
+        _ = json.NewDecoder(request.Body).Decode(&payload)
+        payloads = append(payloads, payload)
+        _ = json.NewEncoder(writer).Encode(map[string]interface{}{"success": true})
+    }))
+    defer server.Close()
+    node := jortest.NewNode()
+    defer node.Close()
+    poolTool, testClock := getOutcomePoolTool(t, server, node, func(settings cardano.TimeSettings) []jor.LeaderAssignment {
+        return []jor.LeaderAssignment{jortest.Assignment(5, 20, settings)}
+    })
+    timeSettings := *poolTool.scheduleUpdate.timeSettings
+    poolTool.updateSchedule([]jor.LeaderAssignment{jortest.Assignment(5, 20, timeSettings)})
+    assert.Len(t, payloads, 1)
+    node.SetTip(100, jortest.Hash(100), cardano.PlainSlotDateFromInt(5, 99))
+    node.SetBlockMinted(cardano.PlainSlotDateFromInt(5, 20), jortest.Hash(50), 50)
+    node.AddToMainChain(jortest.Hash(50))
+    // the key of epoch 5 is revealed with the schedule of epoch 6.
+    testClock.AdvanceToSlot(timeSettings, 6, 10)
+    _ = poolTool.scheduleUpdate.store.Update(func(tx storage.Tx) error {
+        return tx.Put(storage.ScheduleKeysBucket, "6", []byte("next"))
+    })
+    poolTool.updateOutcomes(big.NewInt(5))
+    assert.Len(t, payloads, 1, "the outcomes have not yet settled.")
+
+    go poolTool.scheduleUpdate.tracker.Track()
+    assert.Eventually(t, func() bool {
+        outcomes, err := poolTool.scheduleUpdate.tracker.GetOutcomes(5)
+        return err == nil && len(outcomes) == 1 && outcomes[0].IsFinal()
+    }, 5*time.Second, 10*time.Millisecond)
+    poolTool.updateOutcomes(big.NewInt(5))
+    poolTool.updateOutcomes(big.NewInt(5))
+    if assert.Len(t, payloads, 2) {
+        assert.Equal(t, int64(5), payloads[1].CurrentEpoch)
+    }
+}
+
+func TestUpdateOutcomes_failedFinalOutcomes_mustBeRetriedInNextEpoch(t *testing.T) {
+    payloads := make([]postSchedulePayload, 0)
+    failing := false
+    server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+        var payload postSchedulePayload
+        _ = json.NewDecoder(request.Body).Decode(&payload)
+        payloads = append(payloads, payload)
+        if failing {
+            writer.WriteHeader(http.StatusBadGateway)
+            return
+        }
+        _ = json.NewEncoder(writer).Encode(map[string]interface{}{"success": true})
+    }))
+    defer server.Close()
+    node := jortest.NewNode()
+    defer node.Close()
+    poolTool, testClock := getOutcomePoolTool(t, server, node, func(settings cardano.TimeSettings) []jor.LeaderAssignment {
+        return []jor.LeaderAssignment{jortest.Assignment(5, 20, settings)}
+    })
+    scheduleUpdate := poolTool.scheduleUpdate
+    timeSettings := *scheduleUpdate.timeSettings
+    _ = scheduleUpdate.store.Update(func(tx storage.Tx) error {
+        return tx.Put(storage.ScheduleKeysBucket, "4", []byte("previous"))
+    })
+    poolTool.updateSchedule([]jor.LeaderAssignment{jortest.Assignment(5, 20, timeSettings)})
+    key := scheduleUpdate.getKey(big.NewInt(5))
+    node.SetTip(100, jortest.Hash(100), cardano.PlainSlotDateFromInt(5, 99))
+    node.SetBlockMinted(cardano.PlainSlotDateFromInt(5, 20), jortest.Hash(50), 50)
+    node.AddToMainChain(jortest.Hash(50))
+    testClock.AdvanceToSlot(timeSettings, 6, 10)
+    _ = scheduleUpdate.store.Update(func(tx storage.Tx) error {
+        return tx.Put(storage.ScheduleKeysBucket, "6", []byte("next"))
+    })
+    go scheduleUpdate.tracker.Track()
+    assert.Eventually(t, func() bool {
+        outcomes, err := scheduleUpdate.tracker.GetOutcomes(5)
+        return err == nil && len(outcomes) == 1 && outcomes[0].IsFinal()
+    }, 5*time.Second, 10*time.Millisecond)
+
+    failing = true
+    poolTool.updateOutcomes(big.NewInt(5))
+    assert.Len(t, payloads, 2)
+    assert.False(t, scheduleUpdate.isFinalSent(big.NewInt(5)))
+    // the pending final outcomes are not sent again with further outcomes.
+    poolTool.updateOutcomes(big.NewInt(5))
+    assert.Len(t, payloads, 2)
+
+    failing = false
+    poolTool.retryPendingScheduleUpdates()
+    if assert.Len(t, payloads, 3) {
+        // the final outcomes are posted like the other updates of epoch 5.
+        assert.Equal(t, int64(5), payloads[2].CurrentEpoch)
+        assert.Equal(t, "previous", payloads[2].PreviousEpochKey)
+    }
+    assert.True(t, scheduleUpdate.isFinalSent(big.NewInt(5)))
+    assert.Equal(t, key, scheduleUpdate.getKey(big.NewInt(5)))
+    pending, err := scheduleUpdate.getPendingSchedules()
+    if assert.Nil(t, err) {
+        assert.Empty(t, pending)
+    }
+    poolTool.updateOutcomes(big.NewInt(5))
+    assert.Len(t, payloads, 3)
+}
@@ -2,35 +2,52 @@ package pooltool
 
 import (
     "bytes"
+    "crypto/rand"
     "encoding/base64"
     "encoding/json"
-    "fmt"
-    "github.com/boltdb/bolt"
     log "github.com/sirupsen/logrus"
     "github.com/sobitada/go-cardano"
     jor "github.com/sobitada/go-jormungandr/api"
+    "github.com/sobitada/thor/blocks"
+    "github.com/sobitada/thor/monitor"
+    "github.com/sobitada/thor/storage"
     "golang.org/x/crypto/openpgp"
     "golang.org/x/crypto/openpgp/armor"
-    "io/ioutil"
     "math/big"
-    "math/rand"
-    "net/http"
+    "strconv"
+    "sync"
     "time"
 )
 
-const poolToolScheduleURL string = "https://api.pooltool.io/v0/sendlogs"
-
 type scheduleUpdate struct {
-    db             *bolt.DB
+    store          storage.Store
     timeSettings   *cardano.TimeSettings
+    watchDog       *monitor.ScheduleWatchDog
+    tracker        *blocks.Tracker
     latestSchedule chan []jor.LeaderAssignment
+    mutex          *sync.Mutex
+}
+
+// an encrypted schedule, which has not yet been sent successfully. it is kept
+// in the store until it has been sent, or its epoch has passed. the schedule
+// with the final outcomes of the previous epoch is kept for one more epoch.
+type pendingSchedule struct {
+    Epoch          uint64 `json:"epoch"`
+    AssignedSlots  int    `json:"assignedSlots"`
+    EncryptedSlots string `json:"encryptedSlots"`
+    Key            string `json:"key"`
+    // whether the schedule holds the final outcomes of a passed epoch.
+    Final          bool   `json:"final,omitempty"`
+    Attempts       int    `json:"attempts"`
+    LastError      string `json:"lastError,omitempty"`
 }
 
 // start the process of updating the schedule in each experienced epoch.
 func (poolTool *PoolTool) startScheduleUpdating() {
     scheduleUpdate := poolTool.scheduleUpdate
-    if scheduleUpdate != nil && scheduleUpdate.db != nil && scheduleUpdate.latestSchedule != nil {
+    if scheduleUpdate != nil && scheduleUpdate.store != nil && scheduleUpdate.latestSchedule != nil {
         log.Info("[POOLTOOL] Start to update Pool Tool with our schedule.")
+        go poolTool.retryPendingSchedules()
         for ; ; {
             schedule := <-scheduleUpdate.latestSchedule
             poolTool.updateSchedule(schedule)
@@ -40,11 +57,14 @@ func (poolTool *PoolTool) startScheduleUpdating() {
     }
 }
 
-// generates a 32 character long key encoded in base64.
-func generateKey() string {
+// generates a random key of 32 bytes encoded in base64.
+func generateKey() (string, error) {
     var key = make([]byte, 32)
-    rand.Read(key)
-    return base64.StdEncoding.EncodeToString(key)
+    _, err := rand.Read(key)
+    if err != nil {
+        return "", err
+    }
+    return base64.StdEncoding.EncodeToString(key), nil
 }
 
 // transforms the given schedule into JSON and encrypts the JSOn string with the
@@ -52,222 +72,306 @@ func generateKey() string {
 // the encryption failed.
 func encryptSchedule(schedule []LeaderAssignment, key string) (string, error) {
     scheduleData, err := json.Marshal(schedule)
-    log.Infof(">>JSON>> %v", string(scheduleData))
-    if err == nil {
-        armoredScheduleOut := bytes.NewBuffer(nil)
-        w, err := armor.Encode(armoredScheduleOut, "PGP MESSAGE", nil)
-        defer w.Close()
-        if err == nil {
-            plainTextWriter, err := openpgp.SymmetricallyEncrypt(w, []byte(key), nil, nil)
-            if err == nil {
-                _, err := plainTextWriter.Write(scheduleData)
-                plainTextWriter.Close()
-                if err == nil {
-                    w.Close()
-                    return string(armoredScheduleOut.Bytes()), nil
-                } else {
-                    return "", err
-                }
-            } else {
-                return "", err
-            }
-        } else {
-            return "", err
-        }
-    } else {
+    if err != nil {
         return "", err
     }
+    armoredScheduleOut := bytes.NewBuffer(nil)
+    w, err := armor.Encode(armoredScheduleOut, "PGP MESSAGE", nil)
+    if err != nil {
+        return "", err
+    }
+    plainTextWriter, err := openpgp.SymmetricallyEncrypt(w, []byte(key), nil, nil)
+    if err != nil {
+        return "", err
+    }
+    _, err = plainTextWriter.Write(scheduleData)
+    plainTextWriter.Close()
+    if err != nil {
+        return "", err
+    }
+    err = w.Close()
+    if err != nil {
+        return "", err
+    }
+    return string(armoredScheduleOut.Bytes()), nil
+}
+
+// gets the current epoch.
+func (poolTool *PoolTool) currentEpoch() *big.Int {
+    currentSlotDate, _ := poolTool.scheduleUpdate.timeSettings.GetSlotDateFor(poolTool.client.settings.Clock.Now())
+    return currentSlotDate.GetEpoch()
 }
 
 // analysis the given schedule, and checks whether an update is necessary. if so, the
-// update will be issued.
+// update will be issued. the schedule is only sent once per epoch, i.e. if no key
+// has been stored for the current epoch and no update is pending.
 func (poolTool *PoolTool) updateSchedule(schedule []jor.LeaderAssignment) {
-    currentSlotDate, _ := poolTool.scheduleUpdate.timeSettings.GetSlotDateFor(time.Now())
-    currentEpoch := currentSlotDate.GetEpoch()
-    currentKeyPhrase := poolTool.scheduleUpdate.getKey(currentEpoch)
-    if currentKeyPhrase == "" || true {
-        // issue the update
-        schedule = jor.GetLeaderLogsInEpoch(currentEpoch, schedule)
-        if len(schedule) > 0 {
-            key := generateKey()
-            data, err := encryptSchedule(transformAssignments(schedule), key)
-            log.Infof(">>Key>> %v", key)
-            log.Infof(">>Encrypted>> %v", data)
-            if err == nil {
-                previousEpoch := new(big.Int).Sub(currentEpoch, new(big.Int).SetInt64(1))
-                previousEpochKey := poolTool.scheduleUpdate.getKey(previousEpoch)
-                err := poolTool.postSchedule(currentEpoch, len(schedule), data, previousEpochKey)
-                if err == nil {
-                    err := poolTool.scheduleUpdate.storeKey(currentEpoch, key)
-                    if err != nil {
-                        log.Errorf("[POOLTOOL] Could not persist the key for epoch '%v'. %v",
-                            currentEpoch.String(), err.Error())
-                    }
-                } else {
-                    log.Errorf("[POOLTOOL] Could not send schedule update to Pool Tool. %v",
-                        err.Error())
-                }
-            } else {
-                log.Errorf("[POOLTOOL] Could not encrypt the schedule for epoch '%v'. %v",
-                    currentEpoch.String(), err.Error())
+    scheduleUpdate := poolTool.scheduleUpdate
+    scheduleUpdate.mutex.Lock()
+    defer scheduleUpdate.mutex.Unlock()
+    currentEpoch := poolTool.currentEpoch()
+    if scheduleUpdate.getKey(currentEpoch) != "" {
+        log.Debugf("[POOLTOOL] Schedule has already been sent for epoch '%v'.", currentEpoch.String())
+        return
+    }
+    _, found, err := scheduleUpdate.getPendingSchedule(currentEpoch.Uint64())
+    if err != nil {
+        log.Errorf("[POOLTOOL] Could not load the pending schedule updates. %v", err.Error())
+        return
+    } else if found {
+        log.Debugf("[POOLTOOL] Schedule update for epoch '%v' is pending.", currentEpoch.String())
+        return
+    }
+    schedule = jor.GetLeaderLogsInEpoch(currentEpoch, schedule)
+    if len(schedule) == 0 {
+        return
+    }
+    key, err := generateKey()
+    if err != nil {
+        log.Errorf("[POOLTOOL] Could not generate a key for epoch '%v'. %v", currentEpoch.String(), err.Error())
+        return
+    }
+    // the key of the previous epoch is revealed with this update, such that
+    // the outcomes known so far are sent for the previous epoch beforehand.
+    poolTool.refreshOutcomes(new(big.Int).Sub(currentEpoch, new(big.Int).SetInt64(1)))
+    poolTool.sendSchedule(currentEpoch, schedule, key, false)
+}
+
+// encrypts the given schedule of the given epoch together with the outcomes
+// determined so far with the given key, and sends it to Pool Tool. the
+// schedule is kept as pending schedule until it has been sent successfully.
+// final indicates, whether the outcomes of the passed epoch are final.
+func (poolTool *PoolTool) sendSchedule(epoch *big.Int, schedule []jor.LeaderAssignment, key string, final bool) {
+    scheduleUpdate := poolTool.scheduleUpdate
+    data, err := encryptSchedule(transformAssignments(schedule, scheduleUpdate.getOutcomes(epoch)), key)
+    if err != nil {
+        log.Errorf("[POOLTOOL] Could not encrypt the schedule for epoch '%v'. %v", epoch.String(), err.Error())
+        return
+    }
+    p := pendingSchedule{Epoch: epoch.Uint64(), AssignedSlots: len(schedule), EncryptedSlots: data, Key: key,
+        Final: final}
+    err = scheduleUpdate.storePendingSchedule(p)
+    if err != nil {
+        log.Errorf("[POOLTOOL] Could not persist the schedule update for epoch '%v'. %v", epoch.String(),
+            err.Error())
+        return
+    }
+    poolTool.sendPendingSchedule(p)
+}
+
+// sends the given pending schedule, and stores its key once it has been sent
+// successfully. the pending schedule is otherwise kept for another attempt.
+func (poolTool *PoolTool) sendPendingSchedule(p pendingSchedule) {
+    scheduleUpdate := poolTool.scheduleUpdate
+    epoch := new(big.Int).SetUint64(p.Epoch)
+    previousEpoch := new(big.Int).Sub(epoch, new(big.Int).SetInt64(1))
+    previousEpochKey := scheduleUpdate.getKey(previousEpoch)
+    err := poolTool.client.PostSchedule(epoch, p.AssignedSlots, p.EncryptedSlots, previousEpochKey)
+    if err == nil {
+        log.Infof("[POOLTOOL] Sent the schedule with %v assigned slots for epoch '%v'.", p.AssignedSlots, p.Epoch)
+        err = scheduleUpdate.completePendingSchedule(p)
+        if err != nil {
+            log.Errorf("[POOLTOOL] Could not persist the key for epoch '%v'. %v", p.Epoch, err.Error())
+        }
+        return
+    }
+    p.Attempts++
+    p.LastError = err.Error()
+    log.Errorf("[POOLTOOL] Could not send schedule update for epoch '%v' to Pool Tool (attempt %v). %v", p.Epoch,
+        p.Attempts, err.Error())
+    err = scheduleUpdate.storePendingSchedule(p)
+    if err != nil {
+        log.Errorf("[POOLTOOL] Could not persist the schedule update for epoch '%v'. %v", p.Epoch, err.Error())
+    }
+}
+
+// retries to send the pending schedules of the current epoch and the final
+// outcomes of the previous epoch, and discards the ones of passed epochs.
+func (poolTool *PoolTool) retryPendingScheduleUpdates() {
+    scheduleUpdate := poolTool.scheduleUpdate
+    scheduleUpdate.mutex.Lock()
+    defer scheduleUpdate.mutex.Unlock()
+    pending, err := scheduleUpdate.getPendingSchedules()
+    if err != nil {
+        log.Errorf("[POOLTOOL] Could not load the pending schedule updates. %v", err.Error())
+        return
+    }
+    currentEpoch := poolTool.currentEpoch().Uint64()
+    for _, p := range pending {
+        if p.Epoch < currentEpoch && !(p.Final && p.Epoch+1 == currentEpoch) {
+            log.Warnf("[POOLTOOL] Discarding the schedule update for epoch '%v' after %v failed attempts.", p.Epoch,
+                p.Attempts)
+            err := scheduleUpdate.deletePendingSchedule(p.Epoch)
+            if err != nil {
+                log.Errorf("[POOLTOOL] Could not discard the schedule update for epoch '%v'. %v", p.Epoch,
+                    err.Error())
             }
+            continue
         }
-    } else {
-        log.Warnf("[POOLTOOL] Schedule has already been updated for epoch '%v'.",
-            currentEpoch.String())
+        poolTool.sendPendingSchedule(p)
     }
 }
 
-// payload for sending a schedule update to Pool Tool.
-type postSchedulePayload struct {
-    CurrentEpoch     int64  `json:"currentepoch"`
-    PoolID           string `json:"poolid"`
-    UserID           string `json:"userid"`
-    GenesisPref      string `json:"genesispref"`
-    AssignedSlots    int    `json:"assigned_slots"`
-    EncryptedSlots   string `json:"encrypted_slots"`
-    PreviousEpochKey string `json:"previous_epoch_key,omitempty"`
+// a blocking call, which retries to send the pending schedules in the
+// configured interval.
+func (poolTool *PoolTool) retryPendingSchedules() {
+    for ; ; {
+        poolTool.client.settings.Clock.Sleep(poolTool.client.settings.RetryInterval)
+        poolTool.retryPendingScheduleUpdates()
+    }
 }
 
-// a leader assignment as expected by Pool Tool.
+// a leader assignment as expected by Pool Tool. the status is either "Pending",
+// or describes the produced block or the rejection like the leader logs of
+// Jormungandr.
 type LeaderAssignment struct {
-    CreatedAtTime   string  `json:"created_at_time"`
-    ScheduledAtTime string  `json:"scheduled_at_time"`
-    ScheduledAtDate string  `json:"scheduled_at_date"`
-    WakeAtTime      *string `json:"wake_at_time"`
-    FinishedAtTime  *string `json:"finished_at_time"`
-    Status          string  `json:"status"`
-    EnclaveLeaderID int     `json:"enclave_leader_id"`
+    CreatedAtTime   string      `json:"created_at_time"`
+    ScheduledAtTime string      `json:"scheduled_at_time"`
+    ScheduledAtDate string      `json:"scheduled_at_date"`
+    WakeAtTime      *string     `json:"wake_at_time"`
+    FinishedAtTime  *string     `json:"finished_at_time"`
+    Status          interface{} `json:"status"`
+    EnclaveLeaderID int         `json:"enclave_leader_id"`
+}
+
+// status of a leader assignment, for which a block has been produced.
+type blockStatus struct {
+    Block struct {
+        Block       string `json:"block"`
+        ChainLength uint64 `json:"chain_length"`
+    } `json:"Block"`
+}
+
+// status of a leader assignment, for which no block has been produced.
+type rejectedStatus struct {
+    Rejected struct {
+        Reason string `json:"reason"`
+    } `json:"Rejected"`
+}
+
+const timeLayout string = "2006-01-02T15:04:05.999999999-07:00"
+
+// formats the given optional time in the format expected by Pool Tool.
+func formatTime(t *time.Time) *string {
+    if t == nil {
+        return nil
+    }
+    formatted := t.Format(timeLayout)
+    return &formatted
 }
 
 // transforms the given leader assignments passed by the Jormungandr wrapper into
-// a format that is expected by Pool Tool.
-func transformAssignments(assignments []jor.LeaderAssignment) []LeaderAssignment {
+// a format that is expected by Pool Tool. the status and times are taken from
+// the given outcomes keyed by slot, and assignments without outcome are pending.
+func transformAssignments(assignments []jor.LeaderAssignment,
+    outcomes map[uint64]blocks.BlockOutcome) []LeaderAssignment {
     transformedAssignments := make([]LeaderAssignment, len(assignments))
     for i, entry := range assignments {
         transformedAssignment := LeaderAssignment{
-            CreatedAtTime:   entry.CreationTime.Format("2006-01-02T15:04:05.999999999-07:00"),
-            ScheduledAtTime: entry.ScheduleTime.Format("2006-01-02T15:04:05.999999999-07:00"),
+            CreatedAtTime:   entry.CreationTime.Format(timeLayout),
+            ScheduledAtTime: entry.ScheduleTime.Format(timeLayout),
             ScheduledAtDate: entry.ScheduleBlockDate.String(),
             Status:          "Pending",
             EnclaveLeaderID: 1,
         }
+        if outcome, found := outcomes[entry.ScheduleBlockDate.GetSlot().Uint64()]; found {
+            transformedAssignment.WakeAtTime = formatTime(outcome.WakeTime)
+            transformedAssignment.FinishedAtTime = formatTime(outcome.FinishingTime)
+            if outcome.Outcome == blocks.Missed {
+                var status rejectedStatus
+                status.Rejected.Reason = outcome.Reason
+                transformedAssignment.Status = status
+            } else {
+                var status blockStatus
+                status.Block.Block = outcome.BlockHash
+                status.Block.ChainLength = outcome.ChainLength
+                transformedAssignment.Status = status
+            }
+        }
         transformedAssignments[i] = transformedAssignment
     }
     return transformedAssignments
 }
 
-// response payload from Pool Tool for the API method
-// accepting schedule update.
-type postScheduleResponse struct {
-    Success bool                       `json:"success"`
-    Message map[string]json.RawMessage `json:"message"`
+// gets the key for the given epoch, if it can be found, or an empty string otherwise.
+func (scheduleUpdate *scheduleUpdate) getKey(epoch *big.Int) string {
+    initialKey := ""
+    keyPtr := &initialKey
+    _ = scheduleUpdate.store.View(func(tx storage.Tx) error {
+        keyData, err := tx.Get(storage.ScheduleKeysBucket, epoch.String())
+        if err == nil && keyData != nil {
+            keyStr := string(keyData)
+            keyPtr = &keyStr
+        }
+        return err
+    })
+    return *keyPtr
 }
 
-// posts the schedule information to Pool Tool. It requires the epoch for which the schedule
-// shall be updated, the number of assigned slots in the epoch and the encrypted JSON serialization
-// of the schedule. If there has been an update for the previous epoch, then the key for encrypting
-// the data for the previous epoch must be specified. However, this field is optional.
-func (poolTool *PoolTool) postSchedule(epoch *big.Int, slotsNumber int, encryptedSchedule string,
-    previousEpochKey string) error {
-    payload := &postSchedulePayload{
-        CurrentEpoch:     epoch.Int64(),
-        PoolID:           poolTool.poolID,
-        UserID:           poolTool.userID,
-        GenesisPref:      poolTool.genesisHash,
-        AssignedSlots:    slotsNumber,
-        EncryptedSlots:   encryptedSchedule,
-        PreviousEpochKey: previousEpochKey,
-    }
-    payloadBytes, err := json.Marshal(payload)
-    if err == nil {
-        reader := bytes.NewReader(payloadBytes)
-        response, err := http.Post(poolToolScheduleURL, "application/json", reader)
-        if err == nil {
-            if response.StatusCode == 200 {
-                responseData, err := ioutil.ReadAll(response.Body)
-                if err == nil {
-                    if responseData != nil {
-                        var responseJSON postScheduleResponse
-                        err := json.Unmarshal(responseData, &responseJSON)
-                        if err == nil {
-                            if responseJSON.Success {
-                                return nil
-                            } else {
-                                var reason string = ""
-                                reasonRaw, found := responseJSON.Message["error"]
-                                if found {
-                                    _ = json.Unmarshal(reasonRaw, &reason)
-                                }
-                                if reason == "We were unable to parse the decrypted json data.  That either means we were unable to decrypt it, or the json is not valid" {
-                                    log.Warn("Could not post the schedule to Pool Tool, because the information sent in the previous epoch was malformed. Trying without passing key phrase about previous epoch.")
-                                    return poolTool.postSchedule(epoch, slotsNumber, encryptedSchedule, "")
-                                }
-                                return poolToolAPIException{
-                                    URL:        poolToolScheduleURL,
-                                    StatusCode: response.StatusCode,
-                                    Reason:     fmt.Sprintf("Request was rejected, due to: %v", reason),
-                                }
-                            }
-                        } else {
-                            return poolToolAPIException{
-                                URL:        poolToolScheduleURL,
-                                StatusCode: response.StatusCode,
-                                Reason:     fmt.Sprintf("Could not serialize the JSON body.%v", err.Error()),
-                            }
-                        }
-                    } else {
-                        return poolToolAPIException{
-                            URL:        poolToolScheduleURL,
-                            StatusCode: response.StatusCode,
-                            Reason:     "Could not read the body of the response.",
-                        }
-                    }
-                } else {
-                    return poolToolAPIException{
-                        URL:        poolToolScheduleURL,
-                        StatusCode: response.StatusCode,
-                        Reason:     fmt.Sprintf("Could not read the body of the response. %v", err.Error()),
-                    }
-                }
-            } else {
-                return poolToolAPIException{
-                    URL:        poolToolScheduleURL,
-                    StatusCode: response.StatusCode,
-                    Reason:     response.Status,
-                }
-            }
-        }
+// stores the given pending schedule, replacing the one of the same epoch.
+func (scheduleUpdate *scheduleUpdate) storePendingSchedule(p pendingSchedule) error {
+    data, err := json.Marshal(p)
+    if err != nil {
         return err
     }
-    return err
+    return scheduleUpdate.store.Update(func(tx storage.Tx) error {
+        return tx.Put(storage.PoolToolQueueBucket, strconv.FormatUint(p.Epoch, 10), data)
+    })
 }
 
-// stores the given key phrase under the given epoch into the key,value db.
-func (scheduleUpdate *scheduleUpdate) storeKey(epoch *big.Int, key string) error {
-    return scheduleUpdate.db.Update(func(tx *bolt.Tx) error {
-        b, err := tx.CreateBucketIfNotExists([]byte("schedule-epoch-keys"))
-        if err == nil && b != nil {
-            return b.Put([]byte(epoch.String()), []byte(key))
-        }
-        return err
+// removes the pending schedule of the given epoch.
+func (scheduleUpdate *scheduleUpdate) deletePendingSchedule(epoch uint64) error {
+    return scheduleUpdate.store.Update(func(tx storage.Tx) error {
+        return tx.Delete(storage.PoolToolQueueBucket, strconv.FormatUint(epoch, 10))
     })
 }
 
-// gets the key for the given epoch, if it can be found, or an empty string otherwise.
-func (scheduleUpdate *scheduleUpdate) getKey(epoch *big.Int) string {
-    initialKey := ""
-    keyPtr := &initialKey
-    _ = scheduleUpdate.db.View(func(tx *bolt.Tx) error {
-        b := tx.Bucket([]byte("schedule-epoch-keys"))
-        if b != nil {
-            keyData := b.Get([]byte(epoch.String()))
-            if keyData != nil {
-                keyStr := string(keyData)
-                keyPtr = &keyStr
+// stores the key of the given schedule, which has been sent, and removes it
+// from the pending schedules in a single transaction.
+func (scheduleUpdate *scheduleUpdate) completePendingSchedule(p pendingSchedule) error {
+    return scheduleUpdate.store.Update(func(tx storage.Tx) error {
+        epoch := strconv.FormatUint(p.Epoch, 10)
+        err := tx.Put(storage.ScheduleKeysBucket, epoch, []byte(p.Key))
+        if err != nil {
+            return err
+        }
+        if p.Final {
+            err = tx.Put(storage.PoolToolOutcomesBucket, epoch, []byte(p.Key))
+            if err != nil {
+                return err
             }
         }
-        return nil
+        return tx.Delete(storage.PoolToolQueueBucket, epoch)
     })
-    return *keyPtr
+}
+
+// gets the pending schedule of the given epoch, the boolean value indicates
+// whether it has been found.
+func (scheduleUpdate *scheduleUpdate) getPendingSchedule(epoch uint64) (pendingSchedule, bool, error) {
+    pending, err := scheduleUpdate.getPendingSchedules()
+    if err != nil {
+        return pendingSchedule{}, false, err
+    }
+    for _, p := range pending {
+        if p.Epoch == epoch {
+            return p, true, nil
+        }
+    }
+    return pendingSchedule{}, false, nil
+}
+
+// gets all the pending schedules.
+func (scheduleUpdate *scheduleUpdate) getPendingSchedules() ([]pendingSchedule, error) {
+    pending := make([]pendingSchedule, 0)
+    err := scheduleUpdate.store.View(func(tx storage.Tx) error {
+        return tx.ForEach(storage.PoolToolQueueBucket, func(key string, value []byte) error {
+            var p pendingSchedule
+            err := json.Unmarshal(value, &p)
+            if err == nil {
+                pending = append(pending, p)
+            }
+            return err
+        })
+    })
+    return pending, err
 }
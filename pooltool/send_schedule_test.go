@@ -0,0 +1,147 @@
+package pooltool
+
+import (
+    "encoding/json"
+    jor "github.com/sobitada/go-jormungandr/api"
+    "github.com/sobitada/thor/blocks"
+    "github.com/sobitada/thor/jortest"
+    "github.com/sobitada/thor/storage"
+    "github.com/stretchr/testify/assert"
+    "math/big"
+    "net/http"
+    "net/http/httptest"
+    "sync"
+    "testing"
+    "time"
+)
+
+// gets a Pool Tool, whose schedule is sent to the given server, and which
+// is in the slot 10 of epoch 5 of the returned clock.
+func getSchedulePoolTool(server *httptest.Server) (*PoolTool, *jortest.Clock) {
+    testClock := jortest.NewClock(time.Date(2020, 6, 1, 12, 0, 0, 0, time.UTC))
+    timeSettings := jortest.TimeSettingsAt(testClock.Now(), 5, 10, time.Second, 100)
+    settings := getSettings(server)
+    settings.Clock = testClock
+    return &PoolTool{
+        client: NewClient(settings),
+        scheduleUpdate: &scheduleUpdate{
+            store:        storage.NewMemory(),
+            timeSettings: timeSettings,
+            mutex:        &sync.Mutex{},
+        },
+    }, testClock
+}
+
+// gets a schedule with the given number of assignments in epoch 5.
+func getSchedule(poolTool *PoolTool, n int) []jor.LeaderAssignment {
+    schedule := make([]jor.LeaderAssignment, n)
+    for i := 0; i < n; i++ {
+        schedule[i] = jortest.Assignment(5, uint64(20+i), *poolTool.scheduleUpdate.timeSettings)
+    }
+    return schedule
+}
+
+func TestUpdateSchedule_mustBeSentOncePerEpoch(t *testing.T) {
+    payloads := make([]postSchedulePayload, 0)
+    server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+        var payload postSchedulePayload
+        _ = json.NewDecoder(request.Body).Decode(&payload)
+        payloads = append(payloads, payload)
+        _ = json.NewEncoder(writer).Encode(map[string]interface{}{"success": true})
+    }))
+    defer server.Close()
+    poolTool, _ := getSchedulePoolTool(server)
+    _ = poolTool.scheduleUpdate.store.Update(func(tx storage.Tx) error {
+        return tx.Put(storage.ScheduleKeysBucket, "4", []byte("previous"))
+    })
+    poolTool.updateSchedule(getSchedule(poolTool, 3))
+    poolTool.updateSchedule(getSchedule(poolTool, 3))
+    if assert.Len(t, payloads, 1) {
+        assert.Equal(t, int64(5), payloads[0].CurrentEpoch)
+        assert.Equal(t, 3, payloads[0].AssignedSlots)
+        assert.Equal(t, "previous", payloads[0].PreviousEpochKey)
+    }
+    assert.NotEmpty(t, poolTool.scheduleUpdate.getKey(big.NewInt(5)))
+}
+
+func TestUpdateSchedule_failedUpdate_mustBeRetried(t *testing.T) {
+    failing := true
+    requests := 0
+    server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+        requests++
+        if failing {
+            writer.WriteHeader(http.StatusBadGateway)
+            return
+        }
+        _ = json.NewEncoder(writer).Encode(map[string]interface{}{"success": true})
+    }))
+    defer server.Close()
+    poolTool, _ := getSchedulePoolTool(server)
+    poolTool.updateSchedule(getSchedule(poolTool, 2))
+    poolTool.updateSchedule(getSchedule(poolTool, 2))
+    assert.Equal(t, 1, requests)
+    pending, err := poolTool.scheduleUpdate.getPendingSchedules()
+    if assert.Nil(t, err) && assert.Len(t, pending, 1) {
+        assert.Equal(t, uint64(5), pending[0].Epoch)
+        assert.Equal(t, 1, pending[0].Attempts)
+    }
+    assert.Empty(t, poolTool.scheduleUpdate.getKey(big.NewInt(5)))
+    failing = false
+    poolTool.retryPendingScheduleUpdates()
+    assert.Equal(t, 2, requests)
+    pending, err = poolTool.scheduleUpdate.getPendingSchedules()
+    if assert.Nil(t, err) {
+        assert.Empty(t, pending)
+    }
+    assert.NotEmpty(t, poolTool.scheduleUpdate.getKey(big.NewInt(5)))
+}
+
+func TestUpdateSchedule_failedUpdateOfPassedEpoch_mustBeDiscarded(t *testing.T) {
+    requests := 0
+    server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+        requests++
+        writer.WriteHeader(http.StatusBadGateway)
+    }))
+    defer server.Close()
+    poolTool, testClock := getSchedulePoolTool(server)
+    poolTool.updateSchedule(getSchedule(poolTool, 2))
+    testClock.AdvanceToSlot(*poolTool.scheduleUpdate.timeSettings, 6, 0)
+    poolTool.retryPendingScheduleUpdates()
+    assert.Equal(t, 1, requests)
+    pending, err := poolTool.scheduleUpdate.getPendingSchedules()
+    if assert.Nil(t, err) {
+        assert.Empty(t, pending)
+    }
+}
+
+func TestTransformAssignments_mustTakeStatusFromOutcomes(t *testing.T) {
+    start := time.Date(2020, 6, 1, 12, 0, 0, 0, time.UTC)
+    timeSettings := jortest.TimeSettingsAt(start, 5, 10, time.Second, 100)
+    wakeTime := start.Add(10 * time.Second)
+    schedule := []jor.LeaderAssignment{
+        jortest.Assignment(5, 10, *timeSettings),
+        jortest.Assignment(5, 20, *timeSettings),
+        jortest.Assignment(5, 30, *timeSettings),
+    }
+    outcomes := map[uint64]blocks.BlockOutcome{
+        10: {Epoch: 5, Slot: 10, Outcome: blocks.Adopted, BlockHash: "abc", ChainLength: 42, WakeTime: &wakeTime,
+            FinishingTime: &wakeTime},
+        20: {Epoch: 5, Slot: 20, Outcome: blocks.Missed, Reason: "No leader candidate woke up for the assignment."},
+    }
+    data, err := json.Marshal(transformAssignments(schedule, outcomes))
+    if !assert.Nil(t, err) {
+        return
+    }
+    var assignments []map[string]interface{}
+    _ = json.Unmarshal(data, &assignments)
+    if assert.Len(t, assignments, 3) {
+        assert.Equal(t, map[string]interface{}{"Block": map[string]interface{}{"block": "abc",
+            "chain_length": float64(42)}}, assignments[0]["status"])
+        assert.Equal(t, "2020-06-01T12:00:10+00:00", assignments[0]["wake_at_time"])
+        assert.Equal(t, map[string]interface{}{"Rejected": map[string]interface{}{
+            "reason": "No leader candidate woke up for the assignment."}}, assignments[1]["status"])
+        assert.Nil(t, assignments[1]["wake_at_time"])
+        assert.Equal(t, "Pending", assignments[2]["status"])
+        assert.Nil(t, assignments[2]["finished_at_time"])
+    }
+}
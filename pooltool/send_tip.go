@@ -5,73 +5,138 @@ import (
     jor "github.com/sobitada/go-jormungandr/api"
     "github.com/sobitada/thor/utils"
     "math/big"
-    "net/http"
-    "net/url"
+    "sync"
     "time"
 )
 
-const poolToolTipURL string = "https://tamoq3vkbl.execute-api.us-west-2.amazonaws.com/prod/sharemytip"
+// which tip is reported to Pool Tool.
+type TipSource string
 
-// team of Pool Tool asked to keep rate low.
-const tipPostLimit time.Duration = 30 * time.Second
+const (
+    // the maximum block height among the monitored nodes.
+    MaxTip TipSource = "max"
+    // the block height of the current leader, the maximum block
+    // height is reported, if there is no leader.
+    LeaderTip TipSource = "leader"
+)
+
+// what is done with a tip that has not advanced for a while.
+type StaleTipAction string
+
+const (
+    // the stale tip is reported, but a warning is logged.
+    FlagStaleTip StaleTipAction = "flag"
+    // the stale tip is not reported.
+    SuppressStaleTip StaleTipAction = "suppress"
+)
+
+// name of the reference height passed to the monitor.
+const networkTipReference string = "pooltool"
 
 type tipUpdate struct {
     latestTip        *big.Int
+    advancedAt       time.Time
     latestTipChannel chan map[string]jor.NodeStatistic
+    mutex            *sync.Mutex
 }
 
 // informs pool tool about the latest block height.
 func (poolTool *PoolTool) PushLatestTip(tip *big.Int) {
-    poolTool.tipUpdate.latestTip = tip
+    tipUpdate := poolTool.tipUpdate
+    tipUpdate.mutex.Lock()
+    defer tipUpdate.mutex.Unlock()
+    if tipUpdate.latestTip == nil || tip.Cmp(tipUpdate.latestTip) > 0 {
+        tipUpdate.advancedAt = poolTool.client.settings.Clock.Now()
+    }
+    tipUpdate.latestTip = tip
+}
+
+// gets the latest tip and the time at which it advanced the last time.
+func (poolTool *PoolTool) getLatestTip() (*big.Int, time.Time) {
+    poolTool.tipUpdate.mutex.Lock()
+    defer poolTool.tipUpdate.mutex.Unlock()
+    return poolTool.tipUpdate.latestTip, poolTool.tipUpdate.advancedAt
 }
 
 func (poolTool *PoolTool) startTipUpdating() {
     go poolTool.updateTip()
     for ; ; {
-        if poolTool.tipUpdate.latestTip != nil && poolTool.tipUpdate.latestTip.Cmp(new(big.Int).SetUint64(0)) > 0 {
-            err := poolTool.postLatestTip(poolTool.tipUpdate.latestTip)
-            if err != nil {
-                log.Warnf("Could not post to pool tool. %v", err.Error())
+        poolTool.reportTip()
+        poolTool.client.settings.Clock.Sleep(poolTool.client.settings.TipInterval)
+    }
+}
+
+// reports the latest tip to Pool Tool, unless it is stale and stale tips
+// shall be suppressed. the tip of the network is fetched beforehand, if
+// it has been configured.
+func (poolTool *PoolTool) reportTip() {
+    settings := poolTool.client.settings
+    tip, advancedAt := poolTool.getLatestTip()
+    if tip == nil || tip.Cmp(new(big.Int).SetUint64(0)) <= 0 {
+        return
+    }
+    if settings.NetworkTipURL != "" {
+        poolTool.updateNetworkTip(tip)
+    }
+    if settings.StaleTipAfter > 0 {
+        stale := settings.Clock.Now().Sub(advancedAt)
+        if stale >= settings.StaleTipAfter {
+            if settings.StaleTipAction == SuppressStaleTip {
+                log.Warnf("[POOLTOOL] The tip <%v> has not advanced for %v, and is not reported.", tip.String(),
+                    stale.String())
+                return
             }
+            log.Warnf("[POOLTOOL] The tip <%v> has not advanced for %v.", tip.String(), stale.String())
         }
-        time.Sleep(tipPostLimit)
+    }
+    err := poolTool.client.PostTip(tip)
+    if err != nil {
+        log.Warnf("[POOLTOOL] Could not post the tip to Pool Tool. %v", err.Error())
+    }
+}
+
+// fetches the tip of the network, logs the lag of the given tip behind it,
+// and passes it to the monitor as reference height.
+func (poolTool *PoolTool) updateNetworkTip(tip *big.Int) {
+    networkTip, err := poolTool.client.GetNetworkTip()
+    if err != nil {
+        log.Warnf("[POOLTOOL] Could not fetch the tip of the network. %v", err.Error())
+        return
+    }
+    log.Infof("[POOLTOOL] Network Tip: <%v>, Lag: <%v>", networkTip.String(),
+        new(big.Int).Sub(networkTip, tip).String())
+    if poolTool.sources.Monitor != nil {
+        poolTool.sources.Monitor.SetReferenceHeight(networkTipReference, networkTip,
+            3*poolTool.client.settings.TipInterval)
     }
 }
 
 func (poolTool *PoolTool) updateTip() {
     for ; ; {
         latestBlockStats := <-poolTool.tipUpdate.latestTipChannel
-        // compute max
-        blockHeightMap := make(map[string]*big.Int)
-        for name, nodeStats := range latestBlockStats {
-            blockHeightMap[name] = nodeStats.LastBlockHeight
+        tip := poolTool.selectTip(latestBlockStats)
+        if tip != nil {
+            poolTool.PushLatestTip(tip)
         }
-        maxHeight, _ := utils.MaxInt(blockHeightMap)
-        // update
-        poolTool.tipUpdate.latestTip = maxHeight
     }
 }
 
-// posts the given block height to the pool tool API.
-func (poolTool *PoolTool) postLatestTip(tip *big.Int) error {
-    u, err := url.Parse(poolToolTipURL)
-    if err == nil {
-        q := u.Query()
-        q.Set("poolid", poolTool.poolID)
-        q.Set("userid", poolTool.userID)
-        q.Set("genesispref", poolTool.genesisHash)
-        q.Set("mytip", tip.String())
-        q.Set("platform", "thor")
-        u.RawQuery = q.Encode()
-        response, err := http.Get(u.String())
-        if err == nil {
-            if response.StatusCode == 200 {
-                return nil
-            } else {
-                return poolToolAPIException{URL: poolToolTipURL, StatusCode: response.StatusCode, Reason: response.Status}
+// selects the tip, which shall be reported, from the given statistics.
+func (poolTool *PoolTool) selectTip(latestBlockStats map[string]jor.NodeStatistic) *big.Int {
+    if poolTool.client.settings.TipSource == LeaderTip && poolTool.sources.Jury != nil {
+        leaderName, found := poolTool.sources.Jury.GetLeader()
+        if found {
+            leaderStats, found := latestBlockStats[leaderName]
+            if found && leaderStats.LastBlockHeight != nil {
+                return leaderStats.LastBlockHeight
             }
         }
-        return err
     }
-    return err
+    // compute max
+    blockHeightMap := make(map[string]*big.Int)
+    for name, nodeStats := range latestBlockStats {
+        blockHeightMap[name] = nodeStats.LastBlockHeight
+    }
+    maxHeight, _ := utils.MaxInt(blockHeightMap)
+    return maxHeight
 }
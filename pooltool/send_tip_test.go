@@ -0,0 +1,95 @@
+package pooltool
+
+import (
+    "encoding/json"
+    jor "github.com/sobitada/go-jormungandr/api"
+    "github.com/sobitada/thor/jortest"
+    "github.com/sobitada/thor/monitor"
+    "github.com/stretchr/testify/assert"
+    "math/big"
+    "net/http"
+    "net/http/httptest"
+    "sync"
+    "testing"
+    "time"
+)
+
+// gets a Pool Tool with the given settings, which uses the returned clock.
+func getTipPoolTool(settings Settings) (*PoolTool, *jortest.Clock) {
+    testClock := jortest.NewClock(time.Date(2020, 6, 1, 12, 0, 0, 0, time.UTC))
+    settings.Clock = testClock
+    return &PoolTool{
+        client:    NewClient(settings),
+        tipUpdate: &tipUpdate{mutex: &sync.Mutex{}},
+    }, testClock
+}
+
+func TestSelectTip_mustBeMaximumWithoutJury(t *testing.T) {
+    server := httptest.NewServer(http.NotFoundHandler())
+    defer server.Close()
+    settings := getSettings(server)
+    settings.TipSource = LeaderTip
+    poolTool, _ := getTipPoolTool(settings)
+    tip := poolTool.selectTip(map[string]jor.NodeStatistic{
+        "a": {LastBlockHeight: big.NewInt(10)},
+        "b": {LastBlockHeight: big.NewInt(12)},
+    })
+    assert.Equal(t, big.NewInt(12), tip)
+}
+
+func TestReportTip_staleTip_mustBeSuppressed(t *testing.T) {
+    requests := 0
+    server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+        requests++
+    }))
+    defer server.Close()
+    settings := getSettings(server)
+    settings.StaleTipAfter = time.Minute
+    settings.StaleTipAction = SuppressStaleTip
+    poolTool, testClock := getTipPoolTool(settings)
+    poolTool.PushLatestTip(big.NewInt(100))
+    poolTool.reportTip()
+    assert.Equal(t, 1, requests)
+    testClock.Advance(2 * time.Minute)
+    poolTool.PushLatestTip(big.NewInt(100))
+    poolTool.reportTip()
+    assert.Equal(t, 1, requests)
+    poolTool.PushLatestTip(big.NewInt(101))
+    poolTool.reportTip()
+    assert.Equal(t, 2, requests)
+}
+
+func TestReportTip_staleTip_mustBeReportedIfFlagged(t *testing.T) {
+    requests := 0
+    server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+        requests++
+    }))
+    defer server.Close()
+    settings := getSettings(server)
+    settings.StaleTipAfter = time.Minute
+    poolTool, testClock := getTipPoolTool(settings)
+    poolTool.PushLatestTip(big.NewInt(100))
+    testClock.Advance(2 * time.Minute)
+    poolTool.reportTip()
+    assert.Equal(t, 1, requests)
+}
+
+func TestReportTip_networkTip_mustBePassedToMonitor(t *testing.T) {
+    server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+        if request.URL.Path == "/stats" {
+            _ = json.NewEncoder(writer).Encode(map[string]interface{}{"majoritymax": 120})
+        }
+    }))
+    defer server.Close()
+    settings := getSettings(server)
+    settings.NetworkTipURL = server.URL + "/stats"
+    poolTool, testClock := getTipPoolTool(settings)
+    poolTool.sources.Monitor = monitor.GetNodeMonitor(nil, monitor.NodeMonitorBehaviour{Clock: testClock}, nil, nil,
+        nil, nil)
+    poolTool.PushLatestTip(big.NewInt(100))
+    poolTool.reportTip()
+    references := poolTool.sources.Monitor.GetReferenceHeights()
+    if assert.Len(t, references, 1) {
+        assert.Equal(t, big.NewInt(120), references[networkTipReference])
+    }
+}
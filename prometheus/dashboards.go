@@ -0,0 +1,331 @@
+package prometheus
+
+import (
+    "fmt"
+    "time"
+)
+
+// settings of the generated alerting rules.
+type AlertSettings struct {
+    // number of blocks a node can lag behind before an alert fires.
+    MaxBlockLag uint64
+    // time since the last block of a node before it is considered stuck.
+    MaxTimeSinceLastBlock time.Duration
+    // number of leader changes per hour before an alert fires.
+    MaxLeaderChanges int
+}
+
+// gets the default settings of the alerting rules, which match the default
+// settings of the monitor.
+func DefaultAlertSettings() AlertSettings {
+    return AlertSettings{
+        MaxBlockLag:           10,
+        MaxTimeSinceLastBlock: 10 * time.Minute,
+        MaxLeaderChanges:      3,
+    }
+}
+
+// a Grafana dashboard.
+type Dashboard struct {
+    UID           string     `json:"uid"`
+    Title         string     `json:"title"`
+    Tags          []string   `json:"tags"`
+    Timezone      string     `json:"timezone"`
+    SchemaVersion int        `json:"schemaVersion"`
+    Refresh       string     `json:"refresh"`
+    Time          TimeRange  `json:"time"`
+    Templating    Templating `json:"templating"`
+    Panels        []Panel    `json:"panels"`
+}
+
+type TimeRange struct {
+    From string `json:"from"`
+    To   string `json:"to"`
+}
+
+type Templating struct {
+    List []Variable `json:"list"`
+}
+
+// a variable of a dashboard.
+type Variable struct {
+    Name       string `json:"name"`
+    Label      string `json:"label"`
+    Type       string `json:"type"`
+    Query      string `json:"query"`
+    Datasource string `json:"datasource,omitempty"`
+    Multi      bool   `json:"multi"`
+    IncludeAll bool   `json:"includeAll"`
+    Refresh    int    `json:"refresh"`
+}
+
+// a panel of a dashboard.
+type Panel struct {
+    ID          int         `json:"id"`
+    Title       string      `json:"title"`
+    Description string      `json:"description,omitempty"`
+    Type        string      `json:"type"`
+    Datasource  string      `json:"datasource"`
+    GridPos     GridPos     `json:"gridPos"`
+    Targets     []Target    `json:"targets"`
+    FieldConfig FieldConfig `json:"fieldConfig"`
+}
+
+type GridPos struct {
+    H int `json:"h"`
+    W int `json:"w"`
+    X int `json:"x"`
+    Y int `json:"y"`
+}
+
+// a query of a panel.
+type Target struct {
+    RefID        string `json:"refId"`
+    Expr         string `json:"expr"`
+    LegendFormat string `json:"legendFormat,omitempty"`
+}
+
+type FieldConfig struct {
+    Defaults  FieldDefaults `json:"defaults"`
+    Overrides []interface{} `json:"overrides"`
+}
+
+type FieldDefaults struct {
+    Unit       string      `json:"unit,omitempty"`
+    Thresholds *Thresholds `json:"thresholds,omitempty"`
+}
+
+type Thresholds struct {
+    Mode  string          `json:"mode"`
+    Steps []ThresholdStep `json:"steps"`
+}
+
+// a step of the thresholds, the base step has no value.
+type ThresholdStep struct {
+    Color string   `json:"color"`
+    Value *float64 `json:"value"`
+}
+
+// a file with alerting rules for Prometheus.
+type RuleFile struct {
+    Groups []RuleGroup `yaml:"groups"`
+}
+
+type RuleGroup struct {
+    Name  string `yaml:"name"`
+    Rules []Rule `yaml:"rules"`
+}
+
+// an alerting rule.
+type Rule struct {
+    Alert       string            `yaml:"alert"`
+    Expr        string            `yaml:"expr"`
+    For         string            `yaml:"for,omitempty"`
+    Labels      map[string]string `yaml:"labels,omitempty"`
+    Annotations map[string]string `yaml:"annotations,omitempty"`
+}
+
+// selects the series of the given metric of the chosen thor instances.
+func selectInstances(metric string, matchers string) string {
+    if matchers != "" {
+        matchers = "," + matchers
+    }
+    return fmt.Sprintf(`%v{instance=~"$instance"%v}`, metric, matchers)
+}
+
+// gets the thresholds with a green base and the given steps.
+func getThresholds(steps ...ThresholdStep) *Thresholds {
+    return &Thresholds{Mode: "absolute", Steps: append([]ThresholdStep{{Color: "green"}}, steps...)}
+}
+
+func step(color string, value float64) ThresholdStep {
+    return ThresholdStep{Color: color, Value: &value}
+}
+
+// gets the Grafana dashboard for the metrics of this package. the panels
+// show the chosen thor instances of the chosen Prometheus data source.
+func GetDashboard(settings AlertSettings) Dashboard {
+    panels := []Panel{
+        {Title: "Block Height", Type: "timeseries", Targets: []Target{
+            {Expr: selectInstances(LastBlockHeightMetric, ""), LegendFormat: "{{name}} ({{version}})"},
+        }},
+        {Title: "Block Lag", Type: "timeseries",
+            Description: "Number of blocks a node lags behind the maximum block height of all nodes.",
+            Targets: []Target{
+                {Expr: selectInstances(BlockLagMetric, ""), LegendFormat: "{{name}}"},
+            }, FieldConfig: FieldConfig{Defaults: FieldDefaults{
+                Thresholds: getThresholds(step("red", float64(settings.MaxBlockLag)))}}},
+        {Title: "Seconds Since Last Block", Type: "timeseries",
+            Description: "A node is stuck, if it has not received a block for a long time.",
+            Targets: []Target{
+                {Expr: selectInstances(SecondsSinceLastBlockMetric, ""), LegendFormat: "{{name}}"},
+            }, FieldConfig: FieldConfig{Defaults: FieldDefaults{Unit: "s",
+                Thresholds: getThresholds(step("red", settings.MaxTimeSinceLastBlock.Seconds()))}}},
+        {Title: "Current Leader", Type: "stat", Targets: []Target{
+            {Expr: selectInstances(CurrentLeaderMetric, "") + " == 1", LegendFormat: "{{name}}"},
+        }},
+        {Title: "Leader Changes", Type: "timeseries", Targets: []Target{
+            {Expr: fmt.Sprintf("increase(%v[1h])", selectInstances(LeaderChangesMetric, "")),
+                LegendFormat: "{{instance}}"},
+        }, FieldConfig: FieldConfig{Defaults: FieldDefaults{
+            Thresholds: getThresholds(step("red", float64(settings.MaxLeaderChanges)))}}},
+        {Title: "Health Score", Type: "timeseries",
+            Description: "Health score assessed by the leader jury, the lower the healthier.",
+            Targets: []Target{
+                {Expr: selectInstances(HealthScoreMetric, ""), LegendFormat: "{{name}}"},
+            }},
+        {Title: "Blocks of the Current Epoch", Type: "stat", Targets: []Target{
+            {Expr: selectInstances(EpochBlocksMetric, ""), LegendFormat: "{{outcome}}"},
+        }},
+        {Title: "Lost and Missed Blocks", Type: "timeseries", Targets: []Target{
+            {Expr: fmt.Sprintf("increase(%v[1h])", selectInstances(BlockOutcomesMetric, `outcome=~"lost|missed"`)),
+                LegendFormat: "{{outcome}}"},
+        }, FieldConfig: FieldConfig{Defaults: FieldDefaults{Thresholds: getThresholds(step("red", 1))}}},
+        {Title: "Seconds Until Next Block", Type: "stat", Targets: []Target{
+            {Expr: selectInstances(SecondsUntilNextBlockMetric, "")},
+        }, FieldConfig: FieldConfig{Defaults: FieldDefaults{Unit: "s"}}},
+        {Title: "Shutdowns", Type: "timeseries", Targets: []Target{
+            {Expr: fmt.Sprintf("increase(%v[1h])", selectInstances(ShutdownsMetric, "")),
+                LegendFormat: "{{name}} ({{cause}}, {{outcome}})"},
+        }},
+        {Title: "Peers", Type: "timeseries", Targets: []Target{
+            {Expr: selectInstances(PeerAvailableCountMetric, ""), LegendFormat: "{{name}} available"},
+            {Expr: selectInstances(PeerQuarantinedCountMetric, ""), LegendFormat: "{{name}} quarantined"},
+            {Expr: selectInstances(PeerUnreachableCountMetric, ""), LegendFormat: "{{name}} unreachable"},
+        }},
+        {Title: "API Latency (p95)", Type: "timeseries", Targets: []Target{
+            {Expr: fmt.Sprintf("histogram_quantile(0.95, sum by (name, le) (rate(%v[5m])))",
+                selectInstances(APILatencyMetric+"_bucket", "")), LegendFormat: "{{name}}"},
+        }, FieldConfig: FieldConfig{Defaults: FieldDefaults{Unit: "s"}}},
+    }
+    for i := range panels {
+        panels[i].ID = i + 1
+        panels[i].Datasource = "${datasource}"
+        panels[i].GridPos = GridPos{H: 8, W: 12, X: (i % 2) * 12, Y: (i / 2) * 8}
+        if panels[i].FieldConfig.Overrides == nil {
+            panels[i].FieldConfig.Overrides = []interface{}{}
+        }
+        for j := range panels[i].Targets {
+            panels[i].Targets[j].RefID = string(rune('A' + j))
+        }
+    }
+    return Dashboard{
+        UID:           "thor",
+        Title:         "Thor",
+        Tags:          []string{"thor", "jormungandr"},
+        Timezone:      "browser",
+        SchemaVersion: 27,
+        Refresh:       "30s",
+        Time:          TimeRange{From: "now-6h", To: "now"},
+        Templating: Templating{List: []Variable{
+            {Name: "datasource", Label: "Data Source", Type: "datasource", Query: "prometheus"},
+            {Name: "instance", Label: "Instance", Type: "query", Datasource: "${datasource}",
+                Query: fmt.Sprintf("label_values(%v, instance)", LeaderChangesMetric), Multi: true, IncludeAll: true,
+                Refresh: 2},
+        }},
+        Panels: panels,
+    }
+}
+
+// gets the alerting rules for the metrics of this package.
+func GetAlertRules(settings AlertSettings) RuleFile {
+    stuckAfter := int64(settings.MaxTimeSinceLastBlock.Seconds())
+    return RuleFile{Groups: []RuleGroup{{
+        Name: "thor",
+        Rules: []Rule{
+            {
+                Alert:  "ThorNodeLagging",
+                Expr:   fmt.Sprintf("%v > %v", BlockLagMetric, settings.MaxBlockLag),
+                For:    "5m",
+                Labels: map[string]string{"severity": "warning"},
+                Annotations: map[string]string{
+                    "summary":     "Node {{ $labels.name }} lags behind.",
+                    "description": "Node {{ $labels.name }} lags {{ $value }} blocks behind the maximum block height.",
+                },
+            },
+            {
+                Alert:  "ThorSwarmLagging",
+                Expr:   fmt.Sprintf("%v > %v", ReferenceLagMetric, settings.MaxBlockLag),
+                For:    "5m",
+                Labels: map[string]string{"severity": "warning"},
+                Annotations: map[string]string{
+                    "summary": "The nodes of {{ $labels.instance }} lag behind.",
+                    "description": "The maximum block height lags {{ $value }} blocks behind the reference of " +
+                        "{{ $labels.source }}.",
+                },
+            },
+            {
+                Alert:  "ThorNodeStuck",
+                Expr:   fmt.Sprintf("%v > %v", SecondsSinceLastBlockMetric, stuckAfter),
+                For:    "2m",
+                Labels: map[string]string{"severity": "critical"},
+                Annotations: map[string]string{
+                    "summary":     "Node {{ $labels.name }} is stuck.",
+                    "description": "Node {{ $labels.name }} has not received a block for {{ $value }} seconds.",
+                },
+            },
+            {
+                Alert:  "ThorNodeUnreachable",
+                Expr:   fmt.Sprintf("increase(%v[5m]) > 3", APIErrorsMetric),
+                Labels: map[string]string{"severity": "warning"},
+                Annotations: map[string]string{
+                    "summary":     "Node {{ $labels.name }} is unreachable.",
+                    "description": "The statistics of node {{ $labels.name }} could not be fetched repeatedly.",
+                },
+            },
+            {
+                Alert:  "ThorNoLeader",
+                Expr:   fmt.Sprintf("sum by (instance) (%v) == 0", CurrentLeaderMetric),
+                For:    "5m",
+                Labels: map[string]string{"severity": "critical"},
+                Annotations: map[string]string{
+                    "summary": "The leader jury of {{ $labels.instance }} has no leader.",
+                },
+            },
+            {
+                Alert:  "ThorFrequentLeaderChanges",
+                Expr:   fmt.Sprintf("increase(%v[1h]) > %v", LeaderChangesMetric, settings.MaxLeaderChanges),
+                Labels: map[string]string{"severity": "warning"},
+                Annotations: map[string]string{
+                    "summary":     "The leader changes frequently.",
+                    "description": "The leader has changed {{ $value }} times in the last hour.",
+                },
+            },
+            {
+                Alert:  "ThorNodeShutDown",
+                Expr:   fmt.Sprintf(`increase(%v{outcome="success"}[15m]) > 0`, ShutdownsMetric),
+                Labels: map[string]string{"severity": "warning"},
+                Annotations: map[string]string{
+                    "summary": "Node {{ $labels.name }} has been shut down due to {{ $labels.cause }}.",
+                },
+            },
+            {
+                Alert:  "ThorBlockMissed",
+                Expr:   fmt.Sprintf(`increase(%v{outcome="missed"}[1h]) > 0`, BlockOutcomesMetric),
+                Labels: map[string]string{"severity": "critical"},
+                Annotations: map[string]string{
+                    "summary":     "A scheduled block has been missed.",
+                    "description": "{{ $value }} scheduled blocks have been missed in the last hour.",
+                },
+            },
+            {
+                Alert:  "ThorBlockLost",
+                Expr:   fmt.Sprintf(`increase(%v{outcome="lost"}[1h]) > 0`, BlockOutcomesMetric),
+                Labels: map[string]string{"severity": "warning"},
+                Annotations: map[string]string{
+                    "summary":     "A minted block has not been adopted.",
+                    "description": "{{ $value }} minted blocks have been lost in the last hour.",
+                },
+            },
+            {
+                Alert:  "ThorScheduleNotViable",
+                Expr:   fmt.Sprintf("%v == 0", ViableMetric),
+                For:    "10m",
+                Labels: map[string]string{"severity": "warning"},
+                Annotations: map[string]string{
+                    "summary": "Leader candidate {{ $labels.name }} does not report the expected leader schedule.",
+                },
+            },
+        },
+    }}}
+}
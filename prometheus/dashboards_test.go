@@ -0,0 +1,122 @@
+package prometheus
+
+import (
+    "encoding/json"
+    "fmt"
+    "github.com/prometheus/client_golang/prometheus"
+    "github.com/sobitada/thor/monitor"
+    "github.com/stretchr/testify/assert"
+    "regexp"
+    "strings"
+    "testing"
+)
+
+var metricNamePattern = regexp.MustCompile(`thor_[a-z_]+`)
+var descriptionNamePattern = regexp.MustCompile(`fqName: "([a-z_]+)"`)
+
+// gets the names of all metrics registered by a client.
+func getRegisteredNames() map[string]bool {
+    m := newMetrics()
+    collectors := []prometheus.Collector{m.lastBlockHeight, m.transactionReceivedCount, m.peerAvailableCount,
+        m.peerQuarantinedCount, m.peerUnreachableCount, m.upTime, m.epochBlocks, m.blockOutcomes, m.blockLag,
+        m.secondsSinceLastBlock, m.apiLatency, m.apiErrors, m.viable, m.healthScore, m.currentLeader,
+        m.leaderChanges, m.shutdowns, m.secondsUntilNextBlock, m.referenceLag}
+    names := map[string]bool{}
+    descriptions := make(chan *prometheus.Desc, len(collectors))
+    for _, collector := range collectors {
+        collector.Describe(descriptions)
+        match := descriptionNamePattern.FindStringSubmatch((<-descriptions).String())
+        names[match[1]] = true
+    }
+    return names
+}
+
+// asserts that all the metrics referenced in the given expression are
+// exposed by a client.
+func assertKnownMetrics(t *testing.T, names map[string]bool, expr string) {
+    for _, name := range metricNamePattern.FindAllString(expr, -1) {
+        name = strings.TrimSuffix(name, "_bucket")
+        assert.True(t, names[name], "the metric %v in '%v' is not exposed.", name, expr)
+    }
+}
+
+func TestGetDashboard_mustOnlyReferenceExposedMetrics(t *testing.T) {
+    names := getRegisteredNames()
+    dashboard := GetDashboard(DefaultAlertSettings())
+    ids := map[int]bool{}
+    for _, panel := range dashboard.Panels {
+        assert.False(t, ids[panel.ID])
+        ids[panel.ID] = true
+        for _, target := range panel.Targets {
+            assertKnownMetrics(t, names, target.Expr)
+        }
+    }
+    for _, variable := range dashboard.Templating.List {
+        assertKnownMetrics(t, names, variable.Query)
+    }
+    _, err := json.Marshal(dashboard)
+    assert.Nil(t, err)
+}
+
+func TestGetAlertRules_mustOnlyReferenceExposedMetrics(t *testing.T) {
+    names := getRegisteredNames()
+    alerts := map[string]bool{}
+    for _, group := range GetAlertRules(DefaultAlertSettings()).Groups {
+        for _, rule := range group.Rules {
+            assertKnownMetrics(t, names, rule.Expr)
+            alerts[rule.Alert] = true
+        }
+    }
+    for _, alert := range []string{"ThorNodeLagging", "ThorNodeStuck", "ThorFrequentLeaderChanges",
+        "ThorBlockMissed"} {
+        assert.True(t, alerts[alert], "the alert %v is missing.", alert)
+    }
+}
+
+var sumRulePattern = regexp.MustCompile(`^sum by \(instance\) \((thor_[a-z_]+)\) == ([0-9]+)$`)
+
+// evaluates the given rule of the form 'sum by (instance) (metric) == value'
+// against the metrics gathered from the registry of the given client.
+func evaluateSumRule(t *testing.T, client *Client, expr string) bool {
+    match := sumRulePattern.FindStringSubmatch(expr)
+    if !assert.NotNil(t, match, "the rule '%v' cannot be evaluated.", expr) {
+        return false
+    }
+    families, err := client.registry.Gather()
+    if !assert.Nil(t, err) {
+        return false
+    }
+    for _, family := range families {
+        if family.GetName() != match[1] || len(family.Metric) == 0 {
+            continue
+        }
+        sum := 0.0
+        for _, metric := range family.Metric {
+            sum += metric.GetGauge().GetValue()
+        }
+        return fmt.Sprintf("%v", sum) == match[2]
+    }
+    // the sum of no series is no series, the rule does not fire.
+    return false
+}
+
+func TestGetAlertRules_noLeader_mustFire(t *testing.T) {
+    var expr string
+    for _, group := range GetAlertRules(DefaultAlertSettings()).Groups {
+        for _, rule := range group.Rules {
+            if rule.Alert == "ThorNoLeader" {
+                expr = rule.Expr
+            }
+        }
+    }
+    client := newClient(Settings{}, Sources{})
+    check := monitor.Check{Nodes: []monitor.NodeCheck{
+        {Name: "a", Type: monitor.LeaderCandidate},
+        {Name: "b", Type: monitor.LeaderCandidate},
+        {Name: "p", Type: monitor.Passive},
+    }}
+    client.updateLeader(check, "a", true)
+    assert.False(t, evaluateSumRule(t, client, expr))
+    client.updateLeader(check, "", false)
+    assert.True(t, evaluateSumRule(t, client, expr))
+}
@@ -0,0 +1,204 @@
+package prometheus
+
+import (
+    "github.com/prometheus/client_golang/prometheus"
+)
+
+// names of the exposed metrics, which are referenced by the generated
+// dashboards and alerting rules.
+const (
+    LastBlockHeightMetric          = "thor_jormungandr_last_block_height"
+    TransactionReceivedCountMetric = "thor_jormungandr_tx_received_count"
+    PeerAvailableCountMetric       = "thor_jormungandr_peer_available_count"
+    PeerQuarantinedCountMetric     = "thor_jormungandr_peer_quarantined_count"
+    PeerUnreachableCountMetric     = "thor_jormungandr_peer_unreachable_count"
+    UpTimeMetric                   = "thor_jormungandr_uptime"
+    EpochBlocksMetric              = "thor_epoch_blocks"
+    BlockOutcomesMetric            = "thor_block_outcomes_total"
+    BlockLagMetric                 = "thor_node_block_lag"
+    SecondsSinceLastBlockMetric    = "thor_node_seconds_since_last_block"
+    APILatencyMetric               = "thor_node_api_request_duration_seconds"
+    APIErrorsMetric                = "thor_node_api_request_errors_total"
+    ViableMetric                   = "thor_node_viable"
+    HealthScoreMetric              = "thor_jury_health_score"
+    CurrentLeaderMetric            = "thor_leader_info"
+    LeaderChangesMetric            = "thor_leader_changes_total"
+    ShutdownsMetric                = "thor_node_shutdowns_total"
+    SecondsUntilNextBlockMetric    = "thor_seconds_until_next_block"
+    ReferenceLagMetric             = "thor_reference_lag"
+)
+
+// metrics exposed by a client, which are registered in the registry
+// of the client.
+type metrics struct {
+    // statistics of the nodes.
+    lastBlockHeight          *prometheus.GaugeVec
+    transactionReceivedCount *prometheus.GaugeVec
+    peerAvailableCount       *prometheus.GaugeVec
+    peerQuarantinedCount     *prometheus.GaugeVec
+    peerUnreachableCount     *prometheus.GaugeVec
+    upTime                   *prometheus.GaugeVec
+    // outcomes of the leader assignments.
+    epochBlocks   *prometheus.GaugeVec
+    blockOutcomes *prometheus.CounterVec
+    // state of this tool.
+    blockLag              *prometheus.GaugeVec
+    secondsSinceLastBlock *prometheus.GaugeVec
+    apiLatency            *prometheus.HistogramVec
+    apiErrors             *prometheus.CounterVec
+    viable                *prometheus.GaugeVec
+    healthScore           *prometheus.GaugeVec
+    currentLeader         *prometheus.GaugeVec
+    leaderChanges         prometheus.Counter
+    shutdowns             *prometheus.CounterVec
+    secondsUntilNextBlock *prometheus.GaugeVec
+    referenceLag          *prometheus.GaugeVec
+}
+
+// gets a gauge for a statistic of a node, which is labeled with the name
+// and version of the node.
+func newNodeGauge(name string, help string) *prometheus.GaugeVec {
+    return prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: name, Help: help}, []string{"name", "version"})
+}
+
+func newMetrics() *metrics {
+    return &metrics{
+        lastBlockHeight: newNodeGauge(LastBlockHeightMetric,
+            "The latest block height reported by this Jormungandr node."),
+        transactionReceivedCount: newNodeGauge(TransactionReceivedCountMetric,
+            "The number of transaction received by this Jormungandr node."),
+        peerAvailableCount: newNodeGauge(PeerAvailableCountMetric,
+            "The number of peers available to this Jormungandr node."),
+        peerQuarantinedCount: newNodeGauge(PeerQuarantinedCountMetric,
+            "The number of peers quarantined to this Jormungandr node."),
+        peerUnreachableCount: newNodeGauge(PeerUnreachableCountMetric,
+            "The number of peers unreachable to this Jormungandr node."),
+        upTime: newNodeGauge(UpTimeMetric,
+            "The uptime reported by this jormungandr node."),
+        epochBlocks: prometheus.NewGaugeVec(
+            prometheus.GaugeOpts{
+                Name: EpochBlocksMetric,
+                Help: "The number of leader assignments in the current epoch by their outcome.",
+            }, []string{
+                "outcome",
+            }),
+        blockOutcomes: prometheus.NewCounterVec(
+            prometheus.CounterOpts{
+                Name: BlockOutcomesMetric,
+                Help: "The number of leader assignments with a final outcome determined by this thor instance.",
+            }, []string{
+                "outcome",
+            }),
+        blockLag: prometheus.NewGaugeVec(
+            prometheus.GaugeOpts{
+                Name: BlockLagMetric,
+                Help: "The number of blocks this node lags behind the maximum block height of all nodes.",
+            }, []string{
+                "name",
+            }),
+        secondsSinceLastBlock: prometheus.NewGaugeVec(
+            prometheus.GaugeOpts{
+                Name: SecondsSinceLastBlockMetric,
+                Help: "The number of seconds since the slot of the most recent block received by this node.",
+            }, []string{
+                "name",
+            }),
+        apiLatency: prometheus.NewHistogramVec(
+            prometheus.HistogramOpts{
+                Name:    APILatencyMetric,
+                Help:    "The duration of the requests for the statistics of this node.",
+                Buckets: []float64{.01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10},
+            }, []string{
+                "name",
+            }),
+        apiErrors: prometheus.NewCounterVec(
+            prometheus.CounterOpts{
+                Name: APIErrorsMetric,
+                Help: "The number of failed requests for the statistics of this node.",
+            }, []string{
+                "name",
+            }),
+        viable: prometheus.NewGaugeVec(
+            prometheus.GaugeOpts{
+                Name: ViableMetric,
+                Help: "Whether this leader candidate reports the expected leader schedule (1) or not (0).",
+            }, []string{
+                "name",
+            }),
+        healthScore: prometheus.NewGaugeVec(
+            prometheus.GaugeOpts{
+                Name: HealthScoreMetric,
+                Help: "The health score of this leader candidate assessed by the leader jury, the lower the healthier.",
+            }, []string{
+                "name",
+            }),
+        currentLeader: prometheus.NewGaugeVec(
+            prometheus.GaugeOpts{
+                Name: CurrentLeaderMetric,
+                Help: "Whether this leader candidate is currently leader according to the leader jury (1) or not (0).",
+            }, []string{
+                "name",
+            }),
+        leaderChanges: prometheus.NewCounter(
+            prometheus.CounterOpts{
+                Name: LeaderChangesMetric,
+                Help: "The number of successful leader promotions, including the ones at the epoch turn over.",
+            }),
+        shutdowns: prometheus.NewCounterVec(
+            prometheus.CounterOpts{
+                Name: ShutdownsMetric,
+                Help: "The number of shutdowns of this node issued by this thor instance by cause and outcome.",
+            }, []string{
+                "name",
+                "cause",
+                "outcome",
+            }),
+        secondsUntilNextBlock: prometheus.NewGaugeVec(
+            prometheus.GaugeOpts{
+                Name: SecondsUntilNextBlockMetric,
+                Help: "The number of seconds until the next scheduled block in the current epoch.",
+            }, []string{}),
+        referenceLag: prometheus.NewGaugeVec(
+            prometheus.GaugeOpts{
+                Name: ReferenceLagMetric,
+                Help: "The number of blocks the maximum block height of all nodes lags behind this reference height.",
+            }, []string{
+                "source",
+            }),
+    }
+}
+
+// registers the metrics in the given registry. the outcomes of leader
+// assignments are only registered, if they are tracked.
+func (m *metrics) register(registry *prometheus.Registry, withOutcomes bool) {
+    collectors := []prometheus.Collector{m.lastBlockHeight, m.transactionReceivedCount, m.peerAvailableCount,
+        m.peerQuarantinedCount, m.peerUnreachableCount, m.upTime, m.blockLag, m.secondsSinceLastBlock, m.apiLatency,
+        m.apiErrors, m.viable, m.healthScore, m.currentLeader, m.leaderChanges, m.shutdowns, m.secondsUntilNextBlock,
+        m.referenceLag}
+    if withOutcomes {
+        collectors = append(collectors, m.epochBlocks, m.blockOutcomes)
+    }
+    for _, collector := range collectors {
+        registry.MustRegister(collector)
+    }
+}
+
+// removes the series of the node with the given name, which are labeled
+// with the given version.
+func (m *metrics) deleteVersion(name string, version string) {
+    for _, gauge := range []*prometheus.GaugeVec{m.lastBlockHeight, m.transactionReceivedCount, m.peerAvailableCount,
+        m.peerQuarantinedCount, m.peerUnreachableCount, m.upTime} {
+        gauge.DeleteLabelValues(name, version)
+    }
+}
+
+// removes all the series of the node with the given name, which has been
+// reported with the given version.
+func (m *metrics) deleteNode(name string, version string) {
+    m.deleteVersion(name, version)
+    for _, gauge := range []*prometheus.GaugeVec{m.blockLag, m.secondsSinceLastBlock, m.viable, m.healthScore} {
+        gauge.DeleteLabelValues(name)
+    }
+    m.apiLatency.DeleteLabelValues(name)
+    m.apiErrors.DeleteLabelValues(name)
+}
@@ -1,111 +1,292 @@
 package prometheus
 
 import (
+    "crypto/subtle"
     "fmt"
     "github.com/prometheus/client_golang/prometheus"
     "github.com/prometheus/client_golang/prometheus/promhttp"
     log "github.com/sirupsen/logrus"
+    "github.com/sobitada/go-cardano"
     jor "github.com/sobitada/go-jormungandr/api"
+    "github.com/sobitada/thor/audit"
+    "github.com/sobitada/thor/blocks"
+    "github.com/sobitada/thor/clock"
+    "github.com/sobitada/thor/leader"
     "github.com/sobitada/thor/monitor"
     "math/big"
     "net/http"
+    "sync"
+    "time"
 )
 
+// sources of the exposed metrics. all of them except the monitor are
+// optional and can be nil, the corresponding metrics are then missing.
+type Sources struct {
+    Monitor      *monitor.NodeMonitor
+    Tracker      *blocks.Tracker
+    WatchDog     *monitor.ScheduleWatchDog
+    Jury         *leader.Jury
+    AuditLog     *audit.Log
+    TimeSettings *cardano.TimeSettings
+}
+
+// settings of the server of a client.
+type Settings struct {
+    // hostname and port on which the metrics are served. the metrics
+    // are not served, if the port is empty.
+    Hostname string
+    Port     string
+    // certificate and key file for serving the metrics over TLS. plain
+    // HTTP is used, if they are empty.
+    CertFile string
+    KeyFile  string
+    // credentials required for fetching the metrics with basic auth. the
+    // metrics are not protected, if the username is empty.
+    Username string
+    Password string
+    // time after which the series of a node are removed, if no statistics
+    // have been fetched for it. this client is moreover not ready, if no
+    // check of the monitor has been processed in this time.
+    StaleAfter time.Duration
+    // settings for pushing the metrics periodically, the metrics are
+    // not pushed if it is nil.
+    Push *PushSettings
+    // clock used for timing, the clock of the system is used if
+    // it is nil.
+    Clock clock.Clock
+}
+
+// a node, for which statistics have been exposed.
+type exposedNode struct {
+    version  string
+    lastSeen time.Time
+}
+
 type Client struct {
-    host                 string
-    port                 string
-    nodeStatisticChannel chan map[string]jor.NodeStatistic
-}
-
-func GetClient(host string, port string, mon *monitor.NodeMonitor) *Client {
-    nodeStatisticChannel := make(chan map[string]jor.NodeStatistic)
-    mon.ListenerManager.RegisterNodeStatisticListener(nodeStatisticChannel)
-    return &Client{host: host, port: port, nodeStatisticChannel: nodeStatisticChannel}
-}
-
-var (
-    lastBlockHeight = prometheus.NewGaugeVec(
-        prometheus.GaugeOpts{
-            Name: "thor_jormungandr_last_block_height",
-            Help: "The latest block height reported by this Jormungandr node.",
-        }, []string{
-            "name",
-            "version",
-        })
-    transactionReceivedCount = prometheus.NewGaugeVec(
-        prometheus.GaugeOpts{
-            Name: "thor_jormungandr_tx_received_count",
-            Help: "The number of transaction received by this Jormungandr node.",
-        }, []string{
-            "name",
-            "version",
-        })
-    peerAvailableCount = prometheus.NewGaugeVec(
-        prometheus.GaugeOpts{
-            Name: "thor_jormungandr_peer_available_count",
-            Help: "The number of peers available to this Jormungandr node.",
-        }, []string{
-            "name",
-            "version",
-        })
-    peerQuarantinedCount = prometheus.NewGaugeVec(
-        prometheus.GaugeOpts{
-            Name: "thor_jormungandr_peer_quarantined_count",
-            Help: "The number of peers quarantined to this Jormungandr node.",
-        }, []string{
-            "name",
-            "version",
-        })
-    peerUnreachableCount = prometheus.NewGaugeVec(
-        prometheus.GaugeOpts{
-            Name: "thor_jormungandr_peer_unreachable_count",
-            Help: "The number of peers unreachable to this Jormungandr node.",
-        }, []string{
-            "name",
-            "version",
-        })
-    upTime = prometheus.NewGaugeVec(
-        prometheus.GaugeOpts{
-            Name: "thor_jormungandr_uptime",
-            Help: "The uptime reported by this jormungandr node.",
-        }, []string{
-            "name",
-            "version",
-        })
-)
+    settings            Settings
+    sources             Sources
+    metrics             *metrics
+    registry            *prometheus.Registry
+    mux                 *http.ServeMux
+    checkChannel        chan monitor.Check
+    blockOutcomeChannel chan blocks.BlockOutcome
+    auditChannel        chan audit.Entry
+    pusher              *Pusher
+    nodes               map[string]*exposedNode
+    lastCheck           time.Time
+    mutex               *sync.RWMutex
+}
+
+// gets a new client, which exposes the statistics of the nodes and the state
+// of this tool taken from the given sources. the metrics are registered in a
+// registry of this client, and not in the global one.
+func GetClient(settings Settings, sources Sources) *Client {
+    client := newClient(settings, sources)
+    client.checkChannel = make(chan monitor.Check)
+    sources.Monitor.ListenerManager.RegisterCheckListener(client.checkChannel)
+    if sources.Tracker != nil {
+        client.blockOutcomeChannel = make(chan blocks.BlockOutcome)
+        sources.Tracker.RegisterListener(client.blockOutcomeChannel)
+    }
+    if sources.AuditLog != nil {
+        client.auditChannel = make(chan audit.Entry, audit.ListenerCapacity)
+        sources.AuditLog.RegisterListener(client.auditChannel)
+    }
+    return client
+}
+
+// creates a client with its registry and handlers, which is not yet
+// listening to any of the sources.
+func newClient(settings Settings, sources Sources) *Client {
+    settings.Clock = clock.OrReal(settings.Clock)
+    client := &Client{
+        settings: settings,
+        sources:  sources,
+        metrics:  newMetrics(),
+        registry: prometheus.NewRegistry(),
+        mux:      http.NewServeMux(),
+        nodes:    map[string]*exposedNode{},
+        mutex:    &sync.RWMutex{},
+    }
+    client.metrics.register(client.registry, sources.Tracker != nil)
+    // the series of the runtime and the process, which are exposed by the
+    // default registry.
+    client.registry.MustRegister(prometheus.NewGoCollector(),
+        prometheus.NewProcessCollector(prometheus.ProcessCollectorOpts{}))
+    client.mux.Handle("/metrics", client.withBasicAuth(promhttp.HandlerFor(client.registry, promhttp.HandlerOpts{})))
+    client.mux.HandleFunc("/healthz", client.serveLiveness)
+    client.mux.HandleFunc("/readyz", client.serveReadiness)
+    if settings.Push != nil {
+        push := *settings.Push
+        if push.Clock == nil {
+            push.Clock = settings.Clock
+        }
+        client.pusher = newPusher(client, push)
+    }
+    return client
+}
+
+// gets the handler serving the metrics at '/metrics', as well as the liveness
+// at '/healthz' and the readiness at '/readyz'.
+func (client *Client) Handler() http.Handler {
+    return client.mux
+}
+
+// requires the configured credentials for the given handler, if a username
+// has been configured.
+func (client *Client) withBasicAuth(handler http.Handler) http.Handler {
+    if client.settings.Username == "" {
+        return handler
+    }
+    return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+        username, password, ok := request.BasicAuth()
+        if !ok || subtle.ConstantTimeCompare([]byte(username), []byte(client.settings.Username)) != 1 ||
+            subtle.ConstantTimeCompare([]byte(password), []byte(client.settings.Password)) != 1 {
+            writer.Header().Set("WWW-Authenticate", `Basic realm="thor"`)
+            http.Error(writer, "Unauthorized", http.StatusUnauthorized)
+            return
+        }
+        handler.ServeHTTP(writer, request)
+    })
+}
+
+// serves whether this client is alive, which is always the case.
+func (client *Client) serveLiveness(writer http.ResponseWriter, request *http.Request) {
+    _, _ = fmt.Fprintln(writer, "OK")
+}
+
+// serves whether this client is ready, i.e. a check of the monitor has
+// been processed recently.
+func (client *Client) serveReadiness(writer http.ResponseWriter, request *http.Request) {
+    client.mutex.RLock()
+    lastCheck := client.lastCheck
+    client.mutex.RUnlock()
+    if lastCheck.IsZero() {
+        http.Error(writer, "No check of the monitor has been processed yet.", http.StatusServiceUnavailable)
+        return
+    }
+    if age := client.settings.Clock.Now().Sub(lastCheck); age > client.settings.StaleAfter {
+        http.Error(writer, fmt.Sprintf("The most recent check of the monitor is %v old.", age),
+            http.StatusServiceUnavailable)
+        return
+    }
+    _, _ = fmt.Fprintln(writer, "OK")
+}
+
+// updates the number of leader assignments in the current epoch by outcome.
+func (client *Client) updateEpochBlocks() {
+    tracker := client.sources.Tracker
+    if tracker == nil {
+        return
+    }
+    epochOutcomes, err := tracker.GetEpochOutcomes(tracker.CurrentEpoch())
+    if err == nil {
+        summary := epochOutcomes.Summary
+        epochBlocks := client.metrics.epochBlocks
+        epochBlocks.WithLabelValues("scheduled").Set(float64(summary.Scheduled))
+        epochBlocks.WithLabelValues("pending").Set(float64(summary.Pending))
+        epochBlocks.WithLabelValues(string(blocks.Minted)).Set(float64(summary.Minted))
+        epochBlocks.WithLabelValues(string(blocks.Adopted)).Set(float64(summary.Adopted))
+        epochBlocks.WithLabelValues(string(blocks.Lost)).Set(float64(summary.Lost))
+        epochBlocks.WithLabelValues(string(blocks.Missed)).Set(float64(summary.Missed))
+    }
+}
+
+// updates the metrics mirroring the given statistics of a node. the series
+// of a previously reported version of the node are removed.
+func (client *Client) updateNodeStatistic(name string, value jor.NodeStatistic, now time.Time) {
+    m := client.metrics
+    node, found := client.nodes[name]
+    if !found {
+        node = &exposedNode{version: value.JormungandrVersion}
+        client.nodes[name] = node
+    } else if node.version != value.JormungandrVersion {
+        m.deleteVersion(name, node.version)
+        node.version = value.JormungandrVersion
+    }
+    node.lastSeen = now
+    if value.LastBlockHeight != nil {
+        height, _ := new(big.Float).SetInt(value.LastBlockHeight).Float64()
+        m.lastBlockHeight.WithLabelValues(name, value.JormungandrVersion).Set(height)
+    }
+    if value.ReceivedTransactions != nil {
+        count, _ := new(big.Float).SetInt(value.ReceivedTransactions).Float64()
+        m.transactionReceivedCount.WithLabelValues(name, value.JormungandrVersion).Set(count)
+    }
+    if value.PeerAvailableCount != nil {
+        m.peerAvailableCount.WithLabelValues(name, value.JormungandrVersion).Set(float64(*value.PeerAvailableCount))
+    }
+    if value.PeerQuarantinedCount != nil {
+        m.peerQuarantinedCount.WithLabelValues(name, value.JormungandrVersion).Set(float64(*value.PeerQuarantinedCount))
+    }
+    if value.PeerUnreachableCnt != nil {
+        m.peerUnreachableCount.WithLabelValues(name, value.JormungandrVersion).Set(float64(*value.PeerUnreachableCnt))
+    }
+    m.upTime.WithLabelValues(name, value.JormungandrVersion).Set(value.UpTime.Seconds())
+}
+
+// removes the series of the nodes, for which no statistics have been fetched
+// for longer than the configured time.
+func (client *Client) removeStaleNodes(now time.Time) {
+    for name, node := range client.nodes {
+        if now.Sub(node.lastSeen) > client.settings.StaleAfter {
+            log.Infof("[PROMETHEUS] Removing the series of node %v, no statistics since %v.", name,
+                node.lastSeen.Format(time.RFC3339))
+            client.metrics.deleteNode(name, node.version)
+            delete(client.nodes, name)
+        }
+    }
+}
+
+// processes the given check of the monitor.
+func (client *Client) processCheck(check monitor.Check) {
+    for _, nodeCheck := range check.Nodes {
+        if nodeCheck.Statistic != nil {
+            client.updateNodeStatistic(nodeCheck.Name, *nodeCheck.Statistic, check.Time)
+        }
+    }
+    client.updateCheck(check)
+    client.removeStaleNodes(check.Time)
+    client.updateEpochBlocks()
+    client.mutex.Lock()
+    client.lastCheck = check.Time
+    client.mutex.Unlock()
+}
 
 func (client *Client) update() {
     for ; ; {
-        nodeStatisticMap := <-client.nodeStatisticChannel
-        for name, value := range nodeStatisticMap {
-            if value.LastBlockHeight != nil {
-                height, _ := new(big.Float).SetInt(value.LastBlockHeight).Float64()
-                lastBlockHeight.WithLabelValues(name, value.JormungandrVersion).Set(height)
+        select {
+        case check := <-client.checkChannel:
+            client.processCheck(check)
+        case outcome := <-client.blockOutcomeChannel:
+            if outcome.IsFinal() {
+                client.metrics.blockOutcomes.WithLabelValues(string(outcome.Outcome)).Inc()
             }
-            if value.PeerAvailableCount != nil {
-                peerAvailableCount.WithLabelValues(name, value.JormungandrVersion).Set(float64(*value.PeerAvailableCount))
-            }
-            if value.PeerQuarantinedCount != nil {
-                peerQuarantinedCount.WithLabelValues(name, value.JormungandrVersion).Set(float64(*value.PeerQuarantinedCount))
-            }
-            if value.PeerUnreachableCnt != nil {
-                peerUnreachableCount.WithLabelValues(name, value.JormungandrVersion).Set(float64(*value.PeerUnreachableCnt))
-            }
-            upTime.WithLabelValues(name, value.JormungandrVersion).Set(value.UpTime.Seconds())
+            client.updateEpochBlocks()
+        case entry := <-client.auditChannel:
+            client.updateAuditEntry(entry)
         }
     }
 }
 
+// starts the server and the pusher of this client, this is a blocking call.
 func (client *Client) Run() {
-    prometheus.MustRegister(lastBlockHeight)
-    prometheus.MustRegister(transactionReceivedCount)
-    prometheus.MustRegister(peerAvailableCount)
-    prometheus.MustRegister(peerQuarantinedCount)
-    prometheus.MustRegister(peerUnreachableCount)
-    prometheus.MustRegister(upTime)
-    http.Handle("/metrics", promhttp.Handler())
     go client.update()
-    err := http.ListenAndServe(fmt.Sprintf("%v:%v", client.host, client.port), nil)
+    if client.pusher != nil {
+        if client.settings.Port == "" {
+            client.pusher.Run()
+            return
+        }
+        go client.pusher.Run()
+    }
+    address := fmt.Sprintf("%v:%v", client.settings.Hostname, client.settings.Port)
+    log.Infof("[PROMETHEUS] Starting the Prometheus client on %v.", address)
+    var err error
+    if client.settings.CertFile != "" {
+        err = http.ListenAndServeTLS(address, client.settings.CertFile, client.settings.KeyFile, client.mux)
+    } else {
+        err = http.ListenAndServe(address, client.mux)
+    }
     if err != nil {
         log.Errorf("Prometheus client could not be started. %v", err.Error())
     }
@@ -0,0 +1,105 @@
+package prometheus
+
+import (
+    "github.com/prometheus/client_golang/prometheus/testutil"
+    jor "github.com/sobitada/go-jormungandr/api"
+    "github.com/sobitada/thor/jortest"
+    "github.com/sobitada/thor/monitor"
+    "github.com/stretchr/testify/assert"
+    "math/big"
+    "net/http"
+    "net/http/httptest"
+    "runtime"
+    "testing"
+    "time"
+)
+
+var start = time.Date(2020, 6, 1, 12, 0, 0, 0, time.UTC)
+
+func getCheck(now time.Time, nodes map[string]string) monitor.Check {
+    check := monitor.Check{Time: now, MaximumBlockHeight: big.NewInt(100)}
+    for name, version := range nodes {
+        check.Nodes = append(check.Nodes, monitor.NodeCheck{Name: name, Statistic: &jor.NodeStatistic{
+            JormungandrVersion: version, LastBlockHeight: big.NewInt(100)}})
+    }
+    return check
+}
+
+func serve(client *Client, path string, username string, password string) *httptest.ResponseRecorder {
+    request := httptest.NewRequest(http.MethodGet, path, nil)
+    if username != "" {
+        request.SetBasicAuth(username, password)
+    }
+    recorder := httptest.NewRecorder()
+    client.Handler().ServeHTTP(recorder, request)
+    return recorder
+}
+
+func TestClient_VersionChange_mustRemoveSeriesOfOldVersion(t *testing.T) {
+    client := newClient(Settings{StaleAfter: time.Minute}, Sources{})
+    client.processCheck(getCheck(start, map[string]string{"a": "0.8.18"}))
+    client.processCheck(getCheck(start.Add(time.Second), map[string]string{"a": "0.8.19"}))
+    assert.Equal(t, 1, testutil.CollectAndCount(client.metrics.lastBlockHeight))
+    assert.Equal(t, 100.0, testutil.ToFloat64(client.metrics.lastBlockHeight.WithLabelValues("a", "0.8.19")))
+}
+
+func TestClient_NodeWithoutStatistics_mustBeRemovedWhenStale(t *testing.T) {
+    client := newClient(Settings{StaleAfter: time.Minute}, Sources{})
+    client.processCheck(getCheck(start, map[string]string{"a": "0.8.19", "b": "0.8.19"}))
+    client.processCheck(getCheck(start.Add(30*time.Second), map[string]string{"a": "0.8.19"}))
+    assert.Equal(t, 2, testutil.CollectAndCount(client.metrics.upTime))
+    client.processCheck(getCheck(start.Add(2*time.Minute), map[string]string{"a": "0.8.19"}))
+    assert.Equal(t, 1, testutil.CollectAndCount(client.metrics.upTime))
+    assert.Equal(t, 1, testutil.CollectAndCount(client.metrics.blockLag))
+}
+
+func TestClient_RemovedNode_mustRemoveAllItsSeries(t *testing.T) {
+    client := newClient(Settings{StaleAfter: time.Minute}, Sources{})
+    client.processCheck(getCheck(start, map[string]string{"a": "0.8.19", "b": "0.8.19"}))
+    client.metrics.healthScore.WithLabelValues("b").Set(2)
+    client.processCheck(getCheck(start.Add(2*time.Minute), map[string]string{"a": "0.8.19"}))
+    assert.Equal(t, 1, testutil.CollectAndCount(client.metrics.apiLatency))
+    assert.Equal(t, 1, testutil.CollectAndCount(client.metrics.apiErrors))
+    assert.Equal(t, 0, testutil.CollectAndCount(client.metrics.healthScore))
+}
+
+func TestClient_Registry_mustExposeRuntimeSeries(t *testing.T) {
+    client := newClient(Settings{}, Sources{})
+    families, err := client.registry.Gather()
+    if assert.Nil(t, err) {
+        names := map[string]bool{}
+        for _, family := range families {
+            names[family.GetName()] = true
+        }
+        assert.True(t, names["go_goroutines"])
+        if runtime.GOOS == "linux" {
+            assert.True(t, names["process_start_time_seconds"])
+        }
+    }
+}
+
+func TestClient_TwoClients_mustNotShareRegistry(t *testing.T) {
+    a := newClient(Settings{}, Sources{})
+    b := newClient(Settings{}, Sources{})
+    a.metrics.leaderChanges.Inc()
+    assert.Equal(t, 1.0, testutil.ToFloat64(a.metrics.leaderChanges))
+    assert.Equal(t, 0.0, testutil.ToFloat64(b.metrics.leaderChanges))
+}
+
+func TestClient_BasicAuth_mustOnlyProtectMetrics(t *testing.T) {
+    client := newClient(Settings{Username: "prometheus", Password: "secret"}, Sources{})
+    assert.Equal(t, http.StatusUnauthorized, serve(client, "/metrics", "", "").Code)
+    assert.Equal(t, http.StatusUnauthorized, serve(client, "/metrics", "prometheus", "wrong").Code)
+    assert.Equal(t, http.StatusOK, serve(client, "/metrics", "prometheus", "secret").Code)
+    assert.Equal(t, http.StatusOK, serve(client, "/healthz", "", "").Code)
+}
+
+func TestClient_Readiness_mustRequireRecentCheck(t *testing.T) {
+    testClock := jortest.NewClock(start)
+    client := newClient(Settings{StaleAfter: time.Minute, Clock: testClock}, Sources{})
+    assert.Equal(t, http.StatusServiceUnavailable, serve(client, "/readyz", "", "").Code)
+    client.processCheck(getCheck(start, map[string]string{"a": "0.8.19"}))
+    assert.Equal(t, http.StatusOK, serve(client, "/readyz", "", "").Code)
+    testClock.Advance(2 * time.Minute)
+    assert.Equal(t, http.StatusServiceUnavailable, serve(client, "/readyz", "", "").Code)
+}
@@ -0,0 +1,133 @@
+package prometheus
+
+import (
+    "fmt"
+    "github.com/prometheus/client_golang/prometheus/push"
+    log "github.com/sirupsen/logrus"
+    "github.com/sobitada/thor/clock"
+    "net/http"
+    "time"
+)
+
+// endpoint to which the metrics are pushed.
+type PushMode string
+
+const (
+    // a Prometheus Pushgateway.
+    Pushgateway PushMode = "pushgateway"
+    // an endpoint accepting the Prometheus remote-write protocol.
+    RemoteWrite PushMode = "remote-write"
+)
+
+// settings for pushing the metrics periodically.
+type PushSettings struct {
+    Mode PushMode
+    // URL of the Pushgateway or the remote-write endpoint.
+    URL string
+    // labels identifying this thor instance.
+    Job      string
+    Instance string
+    // credentials for basic auth, none is used if the username is empty.
+    Username string
+    Password string
+    // interval in which the metrics are pushed.
+    Interval time.Duration
+    // number of retries of a failed push, and the time to wait before
+    // the first retry, which is doubled for each further retry.
+    Retries       int
+    RetryInterval time.Duration
+    // client used for the requests.
+    Client *http.Client
+    // clock used for timing, the clock of the system is used if
+    // it is nil.
+    Clock clock.Clock
+}
+
+// a failed push, which must not be retried.
+type permanentError struct {
+    reason string
+}
+
+func (err permanentError) Error() string {
+    return err.reason
+}
+
+// pusher sending the metrics of a client periodically to an endpoint.
+type Pusher struct {
+    client   *Client
+    settings PushSettings
+}
+
+// creates a pusher for the metrics of the given client.
+func newPusher(client *Client, settings PushSettings) *Pusher {
+    settings.Clock = clock.OrReal(settings.Clock)
+    if settings.Client == nil {
+        settings.Client = &http.Client{Timeout: 10 * time.Second}
+    }
+    return &Pusher{client: client, settings: settings}
+}
+
+// pushes the current metrics once without retrying.
+func (pusher *Pusher) pushOnce() error {
+    settings := pusher.settings
+    switch settings.Mode {
+    case Pushgateway:
+        p := push.New(settings.URL, settings.Job).Gatherer(pusher.client.registry).Client(settings.Client)
+        if settings.Instance != "" {
+            p = p.Grouping("instance", settings.Instance)
+        }
+        if settings.Username != "" {
+            p = p.BasicAuth(settings.Username, settings.Password)
+        }
+        return p.Push()
+    case RemoteWrite:
+        families, err := pusher.client.registry.Gather()
+        if err != nil {
+            return err
+        }
+        labels := map[string]string{"job": settings.Job}
+        if settings.Instance != "" {
+            labels["instance"] = settings.Instance
+        }
+        request := encodeWriteRequest(families, labels, settings.Clock.Now())
+        return sendWriteRequest(settings.Client, settings.URL, settings.Username, settings.Password, request)
+    default:
+        return permanentError{reason: fmt.Sprintf("the push mode '%v' is unknown", settings.Mode)}
+    }
+}
+
+// pushes the current metrics, and retries a failed push with an exponential
+// back off for the configured number of times.
+func (pusher *Pusher) Push() error {
+    wait := pusher.settings.RetryInterval
+    var err error
+    for attempt := 0; ; attempt++ {
+        err = pusher.pushOnce()
+        if err == nil {
+            return nil
+        }
+        if _, permanent := err.(permanentError); permanent || attempt >= pusher.settings.Retries {
+            return err
+        }
+        log.Warnf("[PROMETHEUS] Could not push the metrics to %v, retrying in %v. %v", pusher.settings.URL, wait,
+            err.Error())
+        pusher.settings.Clock.Sleep(wait)
+        wait *= 2
+    }
+}
+
+// a blocking call, which pushes the metrics in the configured interval.
+func (pusher *Pusher) Run() {
+    log.Infof("[PROMETHEUS] Pushing the metrics to %v every %v.", pusher.settings.URL, pusher.settings.Interval)
+    for ; ; {
+        start := pusher.settings.Clock.Now()
+        err := pusher.Push()
+        if err != nil {
+            log.Errorf("[PROMETHEUS] Could not push the metrics to %v. %v", pusher.settings.URL, err.Error())
+        }
+        diff := start.Add(pusher.settings.Interval).Sub(pusher.settings.Clock.Now())
+        if diff > 0 {
+            pusher.settings.Clock.Sleep(diff)
+        }
+    }
+}
@@ -0,0 +1,86 @@
+package prometheus
+
+import (
+    "github.com/golang/snappy"
+    "github.com/sobitada/thor/jortest"
+    "github.com/stretchr/testify/assert"
+    "io/ioutil"
+    "net/http"
+    "net/http/httptest"
+    "strings"
+    "sync/atomic"
+    "testing"
+    "time"
+)
+
+func TestPusher_Pushgateway_mustPutMetricsWithJobAndInstance(t *testing.T) {
+    var path, method string
+    server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+        path, method = request.URL.Path, request.Method
+        writer.WriteHeader(http.StatusOK)
+    }))
+    defer server.Close()
+    client := newClient(Settings{Push: &PushSettings{Mode: Pushgateway, URL: server.URL, Job: "thor",
+        Instance: "pool-1"}}, Sources{})
+    client.metrics.leaderChanges.Inc()
+    assert.Nil(t, client.pusher.Push())
+    assert.Equal(t, http.MethodPut, method)
+    assert.Equal(t, "/metrics/job/thor/instance/pool-1", path)
+}
+
+func TestPusher_RemoteWrite_mustSendCompressedWriteRequest(t *testing.T) {
+    var header http.Header
+    var body []byte
+    server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+        header = request.Header
+        compressed, _ := ioutil.ReadAll(request.Body)
+        body, _ = snappy.Decode(nil, compressed)
+        writer.WriteHeader(http.StatusNoContent)
+    }))
+    defer server.Close()
+    client := newClient(Settings{Push: &PushSettings{Mode: RemoteWrite, URL: server.URL, Job: "thor",
+        Instance: "pool-1", Username: "thor", Password: "secret"}}, Sources{})
+    client.metrics.leaderChanges.Inc()
+    assert.Nil(t, client.pusher.Push())
+    assert.Equal(t, "snappy", header.Get("Content-Encoding"))
+    assert.Equal(t, "application/x-protobuf", header.Get("Content-Type"))
+    assert.True(t, strings.HasPrefix(header.Get("Authorization"), "Basic "))
+    assert.Contains(t, string(body), "thor_leader_changes_total")
+    assert.Contains(t, string(body), "pool-1")
+}
+
+func TestPusher_ServerError_mustBeRetried(t *testing.T) {
+    var requests int32
+    server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+        if atomic.AddInt32(&requests, 1) == 1 {
+            writer.WriteHeader(http.StatusInternalServerError)
+            return
+        }
+        writer.WriteHeader(http.StatusNoContent)
+    }))
+    defer server.Close()
+    testClock := jortest.NewClock(start)
+    client := newClient(Settings{Clock: testClock, Push: &PushSettings{Mode: RemoteWrite, URL: server.URL,
+        Job: "thor", Retries: 3, RetryInterval: time.Second}}, Sources{})
+    result := make(chan error)
+    go func() {
+        result <- client.pusher.Push()
+    }()
+    assert.True(t, testClock.WaitForTimers(1, time.Second))
+    testClock.Advance(time.Second)
+    assert.Nil(t, <-result)
+    assert.Equal(t, int32(2), atomic.LoadInt32(&requests))
+}
+
+func TestPusher_ClientError_mustNotBeRetried(t *testing.T) {
+    var requests int32
+    server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+        atomic.AddInt32(&requests, 1)
+        writer.WriteHeader(http.StatusBadRequest)
+    }))
+    defer server.Close()
+    client := newClient(Settings{Push: &PushSettings{Mode: RemoteWrite, URL: server.URL, Job: "thor",
+        Retries: 3, RetryInterval: time.Second}}, Sources{})
+    assert.NotNil(t, client.pusher.Push())
+    assert.Equal(t, int32(1), atomic.LoadInt32(&requests))
+}
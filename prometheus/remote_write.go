@@ -0,0 +1,151 @@
+package prometheus
+
+import (
+    "bytes"
+    "encoding/binary"
+    "errors"
+    "fmt"
+    "github.com/golang/snappy"
+    dto "github.com/prometheus/client_model/go"
+    "io/ioutil"
+    "math"
+    "net/http"
+    "sort"
+    "strconv"
+    "time"
+)
+
+// a label of a time series.
+type seriesLabel struct {
+    name  string
+    value string
+}
+
+// a time series of the remote-write protocol with a single sample.
+type series struct {
+    labels    []seriesLabel
+    value     float64
+    timestamp int64
+}
+
+// converts the given metric families into time series with a sample at the
+// given time. the given labels are added to all series.
+func toSeries(families []*dto.MetricFamily, extraLabels map[string]string, now time.Time) []series {
+    timestamp := now.UnixNano() / int64(time.Millisecond)
+    result := make([]series, 0)
+    for _, family := range families {
+        for _, metric := range family.GetMetric() {
+            add := func(suffix string, value float64, labels ...seriesLabel) {
+                s := series{value: value, timestamp: timestamp}
+                s.labels = append(s.labels, seriesLabel{name: "__name__", value: family.GetName() + suffix})
+                for _, pair := range metric.GetLabel() {
+                    s.labels = append(s.labels, seriesLabel{name: pair.GetName(), value: pair.GetValue()})
+                }
+                for name, value := range extraLabels {
+                    s.labels = append(s.labels, seriesLabel{name: name, value: value})
+                }
+                s.labels = append(s.labels, labels...)
+                sort.Slice(s.labels, func(i, j int) bool {
+                    return s.labels[i].name < s.labels[j].name
+                })
+                result = append(result, s)
+            }
+            switch family.GetType() {
+            case dto.MetricType_COUNTER:
+                add("", metric.GetCounter().GetValue())
+            case dto.MetricType_GAUGE:
+                add("", metric.GetGauge().GetValue())
+            case dto.MetricType_UNTYPED:
+                add("", metric.GetUntyped().GetValue())
+            case dto.MetricType_HISTOGRAM:
+                histogram := metric.GetHistogram()
+                for _, bucket := range histogram.GetBucket() {
+                    add("_bucket", float64(bucket.GetCumulativeCount()), seriesLabel{name: "le",
+                        value: strconv.FormatFloat(bucket.GetUpperBound(), 'g', -1, 64)})
+                }
+                add("_bucket", float64(histogram.GetSampleCount()), seriesLabel{name: "le", value: "+Inf"})
+                add("_sum", histogram.GetSampleSum())
+                add("_count", float64(histogram.GetSampleCount()))
+            case dto.MetricType_SUMMARY:
+                summary := metric.GetSummary()
+                for _, quantile := range summary.GetQuantile() {
+                    add("", quantile.GetValue(), seriesLabel{name: "quantile",
+                        value: strconv.FormatFloat(quantile.GetQuantile(), 'g', -1, 64)})
+                }
+                add("_sum", summary.GetSampleSum())
+                add("_count", float64(summary.GetSampleCount()))
+            }
+        }
+    }
+    return result
+}
+
+// appends the given field key of the protobuf encoding.
+func appendKey(data []byte, field int, wireType int) []byte {
+    return appendVarint(data, uint64(field<<3|wireType))
+}
+
+func appendVarint(data []byte, value uint64) []byte {
+    buffer := make([]byte, binary.MaxVarintLen64)
+    n := binary.PutUvarint(buffer, value)
+    return append(data, buffer[:n]...)
+}
+
+// appends the given bytes as length-delimited field.
+func appendBytes(data []byte, field int, value []byte) []byte {
+    data = appendKey(data, field, 2)
+    data = appendVarint(data, uint64(len(value)))
+    return append(data, value...)
+}
+
+// encodes the metric families as protobuf WriteRequest of the remote-write
+// protocol, which is compressed with snappy.
+func encodeWriteRequest(families []*dto.MetricFamily, labels map[string]string, now time.Time) []byte {
+    request := make([]byte, 0)
+    for _, s := range toSeries(families, labels, now) {
+        timeSeries := make([]byte, 0)
+        for _, label := range s.labels {
+            encodedLabel := appendBytes(nil, 1, []byte(label.name))
+            encodedLabel = appendBytes(encodedLabel, 2, []byte(label.value))
+            timeSeries = appendBytes(timeSeries, 1, encodedLabel)
+        }
+        sample := appendKey(nil, 1, 1)
+        value := make([]byte, 8)
+        binary.LittleEndian.PutUint64(value, math.Float64bits(s.value))
+        sample = append(sample, value...)
+        sample = appendKey(sample, 2, 0)
+        sample = appendVarint(sample, uint64(s.timestamp))
+        timeSeries = appendBytes(timeSeries, 2, sample)
+        request = appendBytes(request, 1, timeSeries)
+    }
+    return snappy.Encode(nil, request)
+}
+
+// sends the given encoded write request to the given remote-write endpoint.
+// client errors are permanent, and must not be retried.
+func sendWriteRequest(client *http.Client, url string, username string, password string, request []byte) error {
+    httpRequest, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(request))
+    if err != nil {
+        return permanentError{reason: err.Error()}
+    }
+    httpRequest.Header.Set("Content-Encoding", "snappy")
+    httpRequest.Header.Set("Content-Type", "application/x-protobuf")
+    httpRequest.Header.Set("X-Prometheus-Remote-Write-Version", "0.1.0")
+    if username != "" {
+        httpRequest.SetBasicAuth(username, password)
+    }
+    response, err := client.Do(httpRequest)
+    if err != nil {
+        return err
+    }
+    defer response.Body.Close()
+    if response.StatusCode/100 == 2 {
+        return nil
+    }
+    body, _ := ioutil.ReadAll(response.Body)
+    reason := fmt.Sprintf("the remote-write request failed with status code %v. %s", response.StatusCode, body)
+    if response.StatusCode/100 == 4 && response.StatusCode != http.StatusTooManyRequests {
+        return permanentError{reason: reason}
+    }
+    return errors.New(reason)
+}
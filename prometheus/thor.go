@@ -0,0 +1,109 @@
+package prometheus
+
+import (
+    "github.com/sobitada/go-cardano"
+    "github.com/sobitada/thor/audit"
+    "github.com/sobitada/thor/monitor"
+    "math/big"
+    "time"
+)
+
+// updates the metrics about the state of this tool with the given check
+// of the monitor, and the state of the other sources at this time.
+func (client *Client) updateCheck(check monitor.Check) {
+    sources, m := client.sources, client.metrics
+    var viableNodes map[string]bool = nil
+    if sources.WatchDog != nil {
+        viableNodes = map[string]bool{}
+        for _, name := range sources.WatchDog.GetViableLeaderNodes() {
+            viableNodes[name] = true
+        }
+    }
+    for _, nodeCheck := range check.Nodes {
+        m.apiLatency.WithLabelValues(nodeCheck.Name).Observe(nodeCheck.Latency.Seconds())
+        errors := m.apiErrors.WithLabelValues(nodeCheck.Name)
+        if nodeCheck.Error != nil {
+            errors.Inc()
+        }
+        if viableNodes != nil && nodeCheck.Type == monitor.LeaderCandidate {
+            if viableNodes[nodeCheck.Name] {
+                m.viable.WithLabelValues(nodeCheck.Name).Set(1)
+            } else {
+                m.viable.WithLabelValues(nodeCheck.Name).Set(0)
+            }
+        }
+        stats := nodeCheck.Statistic
+        if stats == nil {
+            continue
+        }
+        if stats.LastBlockHeight != nil && check.MaximumBlockHeight != nil {
+            lag, _ := new(big.Float).SetInt(new(big.Int).Sub(check.MaximumBlockHeight, stats.LastBlockHeight)).Float64()
+            m.blockLag.WithLabelValues(nodeCheck.Name).Set(lag)
+        }
+        if sources.TimeSettings != nil && stats.LastBlockDate != nil {
+            lastBlock := cardano.MakeFullSlotDate(stats.LastBlockDate, *sources.TimeSettings).GetEndDateTime()
+            m.secondsSinceLastBlock.WithLabelValues(nodeCheck.Name).Set(check.Time.Sub(lastBlock).Seconds())
+        } else if !stats.LastBlockTime.IsZero() {
+            m.secondsSinceLastBlock.WithLabelValues(nodeCheck.Name).Set(check.Time.Sub(stats.LastBlockTime).Seconds())
+        }
+    }
+    client.updateReferenceLag(check)
+    if sources.Jury != nil {
+        m.healthScore.Reset()
+        for name, score := range sources.Jury.GetHealthScores() {
+            m.healthScore.WithLabelValues(name).Set(score)
+        }
+        leaderName, found := sources.Jury.GetLeader()
+        client.updateLeader(check, leaderName, found)
+    }
+    next, found := time.Time{}, false
+    if sources.WatchDog != nil {
+        next, found = sources.WatchDog.GetNextScheduledBlock(check.Time)
+    }
+    if found {
+        m.secondsUntilNextBlock.WithLabelValues().Set(next.Sub(check.Time).Seconds())
+    } else {
+        m.secondsUntilNextBlock.Reset()
+    }
+}
+
+// exports the lag of the maximum block height of the given check behind each
+// of its reference heights. the series of expired references are removed.
+func (client *Client) updateReferenceLag(check monitor.Check) {
+    m := client.metrics
+    m.referenceLag.Reset()
+    if check.MaximumBlockHeight == nil {
+        return
+    }
+    for source, height := range check.ReferenceHeights {
+        lag, _ := new(big.Float).SetInt(new(big.Int).Sub(height, check.MaximumBlockHeight)).Float64()
+        m.referenceLag.WithLabelValues(source).Set(lag)
+    }
+}
+
+// exports a series for each leader candidate in the given check, which is 1
+// for the given leader and 0 for the others. hence, the sum of the series is
+// 0, if there is no leader.
+func (client *Client) updateLeader(check monitor.Check, leaderName string, found bool) {
+    m := client.metrics
+    m.currentLeader.Reset()
+    for _, nodeCheck := range check.Nodes {
+        if nodeCheck.Type == monitor.LeaderCandidate {
+            m.currentLeader.WithLabelValues(nodeCheck.Name).Set(0)
+        }
+    }
+    if found {
+        m.currentLeader.WithLabelValues(leaderName).Set(1)
+    }
+}
+
+// updates the counters of leader changes and shutdowns with the given
+// entry of the audit log. decisions in shadow mode are ignored.
+func (client *Client) updateAuditEntry(entry audit.Entry) {
+    m := client.metrics
+    if entry.IsLeaderChange() {
+        m.leaderChanges.Inc()
+    } else if cause, outcome, ok := entry.GetShutdownLabels(); ok {
+        m.shutdowns.WithLabelValues(entry.Node, cause, outcome).Inc()
+    }
+}
@@ -0,0 +1,72 @@
+package prometheus
+
+import (
+    "errors"
+    "github.com/prometheus/client_golang/prometheus/testutil"
+    jor "github.com/sobitada/go-jormungandr/api"
+    "github.com/sobitada/thor/audit"
+    "github.com/sobitada/thor/monitor"
+    "github.com/stretchr/testify/assert"
+    "math/big"
+    "testing"
+    "time"
+)
+
+func TestClient_UpdateCheck_mustSetLagAndCountErrors(t *testing.T) {
+    now := time.Date(2020, 6, 1, 12, 0, 0, 0, time.UTC)
+    client := newClient(Settings{StaleAfter: time.Minute}, Sources{})
+    client.processCheck(monitor.Check{
+        Time: now,
+        Nodes: []monitor.NodeCheck{
+            {Name: "a", Statistic: &jor.NodeStatistic{LastBlockHeight: big.NewInt(100),
+                ReceivedTransactions: big.NewInt(42), LastBlockTime: now.Add(-30 * time.Second)}},
+            {Name: "b", Statistic: &jor.NodeStatistic{LastBlockHeight: big.NewInt(97)}},
+            {Name: "c", Error: errors.New("timeout"), Latency: 3 * time.Second},
+        },
+        MaximumBlockHeight: big.NewInt(100),
+    })
+    m := client.metrics
+    assert.Equal(t, 0.0, testutil.ToFloat64(m.blockLag.WithLabelValues("a")))
+    assert.Equal(t, 3.0, testutil.ToFloat64(m.blockLag.WithLabelValues("b")))
+    assert.Equal(t, 30.0, testutil.ToFloat64(m.secondsSinceLastBlock.WithLabelValues("a")))
+    assert.Equal(t, 42.0, testutil.ToFloat64(m.transactionReceivedCount.WithLabelValues("a", "")))
+    assert.Equal(t, 0.0, testutil.ToFloat64(m.apiErrors.WithLabelValues("a")))
+    assert.Equal(t, 1.0, testutil.ToFloat64(m.apiErrors.WithLabelValues("c")))
+}
+
+func TestClient_UpdateCheck_mustExportLagBehindReferenceHeights(t *testing.T) {
+    now := time.Date(2020, 6, 1, 12, 0, 0, 0, time.UTC)
+    client := newClient(Settings{StaleAfter: time.Minute}, Sources{})
+    client.processCheck(monitor.Check{
+        Time:               now,
+        Nodes:              []monitor.NodeCheck{{Name: "a", Statistic: &jor.NodeStatistic{LastBlockHeight: big.NewInt(100)}}},
+        MaximumBlockHeight: big.NewInt(100),
+        ReferenceHeights:   map[string]*big.Int{"pooltool": big.NewInt(112)},
+    })
+    m := client.metrics
+    assert.Equal(t, 12.0, testutil.ToFloat64(m.referenceLag.WithLabelValues("pooltool")))
+    // the reference has expired.
+    client.processCheck(monitor.Check{
+        Time:               now.Add(time.Minute),
+        Nodes:              []monitor.NodeCheck{{Name: "a", Statistic: &jor.NodeStatistic{LastBlockHeight: big.NewInt(101)}}},
+        MaximumBlockHeight: big.NewInt(101),
+    })
+    assert.Equal(t, 0, testutil.CollectAndCount(m.referenceLag))
+}
+
+func TestClient_UpdateAuditEntry_mustCountLeaderChangesAndShutdownsByCause(t *testing.T) {
+    client := newClient(Settings{}, Sources{})
+    client.updateAuditEntry(audit.Entry{Node: "a", Action: audit.Promotion, Outcome: audit.Success})
+    client.updateAuditEntry(audit.Entry{Node: "a", Action: audit.Promotion, Outcome: audit.RolledBack})
+    client.updateAuditEntry(audit.Entry{Node: "b", Action: audit.Shutdown, Cause: monitor.LagCause,
+        Outcome: audit.Success})
+    client.updateAuditEntry(audit.Entry{Node: "b", Action: audit.Shutdown, Cause: monitor.LagCause,
+        Outcome: audit.Failure + ": connection refused"})
+    client.updateAuditEntry(audit.Entry{Node: "b", Action: audit.Shutdown, Cause: monitor.StuckCause,
+        Outcome: audit.Shadow})
+    m := client.metrics
+    assert.Equal(t, 1.0, testutil.ToFloat64(m.leaderChanges))
+    assert.Equal(t, 1.0, testutil.ToFloat64(m.shutdowns.WithLabelValues("b", monitor.LagCause, audit.Success)))
+    assert.Equal(t, 1.0, testutil.ToFloat64(m.shutdowns.WithLabelValues("b", monitor.LagCause, audit.Failure)))
+    assert.Equal(t, 0.0, testutil.ToFloat64(m.shutdowns.WithLabelValues("b", monitor.StuckCause, audit.Success)))
+}
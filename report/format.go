@@ -0,0 +1,157 @@
+package report
+
+import (
+    "encoding/csv"
+    "encoding/json"
+    "fmt"
+    "io"
+    "strconv"
+    "strings"
+    "time"
+)
+
+// format in which a report can be exported.
+type Format string
+
+const (
+    JSON     Format = "json"
+    CSV      Format = "csv"
+    Markdown Format = "markdown"
+)
+
+// parses the given name of a format, an empty name is parsed as JSON.
+func ParseFormat(name string) (Format, error) {
+    switch Format(name) {
+    case "", JSON:
+        return JSON, nil
+    case CSV, Markdown:
+        return Format(name), nil
+    }
+    return "", fmt.Errorf("unknown format '%v', it must be one of json, csv or markdown", name)
+}
+
+// gets the MIME type of the given format.
+func (format Format) ContentType() string {
+    switch format {
+    case CSV:
+        return "text/csv"
+    case Markdown:
+        return "text/markdown"
+    }
+    return "application/json"
+}
+
+// writes the given report in the given format to the given writer.
+func Write(writer io.Writer, report EpochReport, format Format) error {
+    switch format {
+    case CSV:
+        return writeCSV(writer, report)
+    case Markdown:
+        return writeMarkdown(writer, report)
+    }
+    encoder := json.NewEncoder(writer)
+    encoder.SetIndent("", "  ")
+    return encoder.Encode(report)
+}
+
+// formats the leaders at the start of the epoch of the given report joined
+// with the given separator, which are either unknown or none at all.
+func formatLeadersAtStart(report EpochReport, separator string) string {
+    if report.LeaderUnknownAtStart {
+        return "unknown"
+    } else if len(report.LeadersAtStart) == 0 {
+        return "none"
+    }
+    return strings.Join(report.LeadersAtStart, separator)
+}
+
+// writes the report as CSV with the columns metric, node and value. the
+// metrics of the epoch have an empty node.
+func writeCSV(writer io.Writer, report EpochReport) error {
+    csvWriter := csv.NewWriter(writer)
+    records := [][]string{
+        {"metric", "node", "value"},
+        {"epoch", "", strconv.FormatUint(report.Epoch, 10)},
+        {"start", "", report.Start.Format(time.RFC3339)},
+        {"end", "", report.End.Format(time.RFC3339)},
+        {"generated_at", "", report.GeneratedAt.Format(time.RFC3339)},
+        {"assigned", "", strconv.Itoa(report.Assigned)},
+        {"pending", "", strconv.Itoa(report.Pending)},
+        {"minted", "", strconv.Itoa(report.Minted)},
+        {"adopted", "", strconv.Itoa(report.Adopted)},
+        {"lost", "", strconv.Itoa(report.Lost)},
+        {"missed", "", strconv.Itoa(report.Missed)},
+        {"leader_changes", "", strconv.Itoa(report.LeaderChanges)},
+        {"restarts", "", strconv.Itoa(report.Restarts)},
+        {"leaders_at_start", "", formatLeadersAtStart(report, ";")},
+        {"monitor_uptime_seconds", "", strconv.FormatInt(report.MonitorUptimeSeconds, 10)},
+        {"monitor_uptime_ratio", "", strconv.FormatFloat(report.MonitorUptimeRatio, 'f', 4, 64)},
+    }
+    for _, node := range report.Nodes {
+        records = append(records,
+            []string{"promotions", node.Name, strconv.Itoa(node.Promotions)},
+            []string{"demotions", node.Name, strconv.Itoa(node.Demotions)},
+            []string{"restarts", node.Name, strconv.Itoa(node.Restarts)},
+            []string{"leader_time_seconds", node.Name, strconv.FormatInt(node.LeaderTimeSeconds, 10)})
+    }
+    err := csvWriter.WriteAll(records)
+    if err != nil {
+        return err
+    }
+    csvWriter.Flush()
+    return csvWriter.Error()
+}
+
+// writes the report as Markdown document with a summary, the leader time of
+// the nodes and the outcomes of the assignments.
+func writeMarkdown(writer io.Writer, report EpochReport) error {
+    _, err := fmt.Fprintf(writer, `# Epoch %v
+
+%v - %v
+
+| | |
+|---|---|
+| Assigned | %v |
+| Pending | %v |
+| Minted | %v |
+| Adopted | %v |
+| Lost | %v |
+| Missed | %v |
+| Leader changes | %v |
+| Restarts | %v |
+| Leaders at start | %v |
+| Monitor uptime | %v (%.2f%%) |
+`, report.Epoch, report.Start.Format(time.RFC3339), report.End.Format(time.RFC3339), report.Assigned,
+        report.Pending, report.Minted, report.Adopted, report.Lost, report.Missed, report.LeaderChanges,
+        report.Restarts, formatLeadersAtStart(report, ", "), time.Duration(report.MonitorUptimeSeconds)*time.Second, report.MonitorUptimeRatio*100)
+    if err != nil {
+        return err
+    }
+    if len(report.Nodes) > 0 {
+        _, err = fmt.Fprint(writer, "\n## Nodes\n\n| Node | Leader time | Promotions | Demotions | Restarts |\n|---|---|---|---|---|\n")
+        if err != nil {
+            return err
+        }
+        for _, node := range report.Nodes {
+            _, err = fmt.Fprintf(writer, "| %v | %v | %v | %v | %v |\n", node.Name,
+                time.Duration(node.LeaderTimeSeconds)*time.Second, node.Promotions, node.Demotions, node.Restarts)
+            if err != nil {
+                return err
+            }
+        }
+    }
+    if len(report.Outcomes) > 0 {
+        _, err = fmt.Fprint(writer, "\n## Assignments\n\n| Slot | Time | Outcome | Node | Reason |\n|---|---|---|---|---|\n")
+        if err != nil {
+            return err
+        }
+        for _, outcome := range report.Outcomes {
+            _, err = fmt.Fprintf(writer, "| %v.%v | %v | %v | %v | %v |\n", outcome.Epoch, outcome.Slot,
+                outcome.ScheduleTime.Format(time.RFC3339), outcome.Outcome, outcome.Node, outcome.Reason)
+            if err != nil {
+                return err
+            }
+        }
+    }
+    return nil
+}
@@ -0,0 +1,33 @@
+package report
+
+import (
+    "net/http"
+    "strconv"
+)
+
+// serves the report of the epoch specified by the query parameter 'epoch' (by
+// default the current epoch) in the format specified by the query parameter
+// 'format' (json, csv or markdown, by default json).
+func (reporter *Reporter) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+    query := request.URL.Query()
+    format, err := ParseFormat(query.Get("format"))
+    if err != nil {
+        http.Error(writer, err.Error(), http.StatusBadRequest)
+        return
+    }
+    epoch := reporter.CurrentEpoch()
+    if query.Get("epoch") != "" {
+        epoch, err = strconv.ParseUint(query.Get("epoch"), 10, 64)
+        if err != nil {
+            http.Error(writer, "The epoch must be a non-negative number.", http.StatusBadRequest)
+            return
+        }
+    }
+    report, err := reporter.Generate(epoch)
+    if err != nil {
+        http.Error(writer, err.Error(), http.StatusInternalServerError)
+        return
+    }
+    writer.Header().Set("Content-Type", format.ContentType())
+    _ = Write(writer, report, format)
+}
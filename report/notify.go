@@ -0,0 +1,39 @@
+package report
+
+import (
+    "bytes"
+    "fmt"
+    "github.com/sobitada/thor/notify"
+)
+
+// receiver of the epoch reports generated after each turn over.
+type Notifier interface {
+    // sends the given report, and returns an error, if
+    // it could not be sent.
+    Notify(report EpochReport) error
+}
+
+// notifier posting the report as JSON to a webhook.
+type WebhookNotifier notify.Webhook
+
+func (notifier WebhookNotifier) Notify(report EpochReport) error {
+    var body bytes.Buffer
+    err := Write(&body, report, JSON)
+    if err != nil {
+        return err
+    }
+    return notify.Webhook(notifier).Post(JSON.ContentType(), &body)
+}
+
+// notifier sending the report as Markdown in a mail over SMTP.
+type MailNotifier notify.Mail
+
+func (notifier MailNotifier) Notify(report EpochReport) error {
+    var body bytes.Buffer
+    err := Write(&body, report, Markdown)
+    if err != nil {
+        return err
+    }
+    return notify.Mail(notifier).Send(fmt.Sprintf("Report for epoch %v", report.Epoch), Markdown.ContentType(),
+        body.Bytes())
+}
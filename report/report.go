@@ -0,0 +1,340 @@
+package report
+
+import (
+    log "github.com/sirupsen/logrus"
+    "github.com/sobitada/go-cardano"
+    "github.com/sobitada/thor/audit"
+    "github.com/sobitada/thor/blocks"
+    "github.com/sobitada/thor/clock"
+    "github.com/sobitada/thor/monitor"
+    "github.com/sobitada/thor/storage"
+    "math/big"
+    "sort"
+    "time"
+)
+
+// report about a node in an epoch.
+type NodeReport struct {
+    Name string `json:"name"`
+    // number of successful promotions and demotions.
+    Promotions int `json:"promotions"`
+    Demotions  int `json:"demotions"`
+    // number of restarts, i.e. successful shutdowns issued by
+    // this tool and other restarts observed by the monitor.
+    Restarts int `json:"restarts"`
+    // number of seconds the node has been leader.
+    LeaderTimeSeconds int64 `json:"leaderTimeSeconds"`
+}
+
+// summary of an epoch comparing the expected with the produced blocks, and
+// listing the actions taken on the nodes.
+type EpochReport struct {
+    Epoch       uint64    `json:"epoch"`
+    Start       time.Time `json:"start"`
+    End         time.Time `json:"end"`
+    GeneratedAt time.Time `json:"generatedAt"`
+    // number of assigned slots, and their outcomes.
+    Assigned int `json:"assigned"`
+    Pending  int `json:"pending"`
+    Minted   int `json:"minted"`
+    Adopted  int `json:"adopted"`
+    Lost     int `json:"lost"`
+    Missed   int `json:"missed"`
+    // number of successful promotions in the epoch.
+    LeaderChanges int `json:"leaderChanges"`
+    // number of restarts of the nodes.
+    Restarts int `json:"restarts"`
+    // leaders at the start of the epoch according to the audit log,
+    // which are unknown, if the audit log has no entry about them in
+    // the lookback before the start.
+    LeadersAtStart       []string `json:"leadersAtStart"`
+    LeaderUnknownAtStart bool     `json:"leaderUnknownAtStart"`
+    // number of seconds this tool has been running in the epoch,
+    // and the ratio to the elapsed time of the epoch.
+    MonitorUptimeSeconds int64   `json:"monitorUptimeSeconds"`
+    MonitorUptimeRatio   float64 `json:"monitorUptimeRatio"`
+    // reports about the nodes that have been leader or on which
+    // actions have been taken.
+    Nodes []NodeReport `json:"nodes"`
+    // outcomes of the assigned slots.
+    Outcomes []blocks.BlockOutcome `json:"outcomes"`
+}
+
+// settings for the generation of epoch reports.
+type Settings struct {
+    // number of slots after the epoch turn over, after which the
+    // report of the previous epoch is generated and sent.
+    DelaySlots uint64
+    // interval in which the uptime of this tool is recorded.
+    HeartbeatInterval time.Duration
+    // time before the start of an epoch, in which the audit log is
+    // searched for the leaders at the start. the duration of one
+    // epoch is used, if it is zero.
+    Lookback time.Duration
+    // notifiers to which the report is sent after each turn over.
+    Notifiers []Notifier
+    // clock used for timing, the clock of the system is used if
+    // it is nil.
+    Clock clock.Clock
+}
+
+// generator of epoch reports, which also records the uptime of this tool
+// and sends the report of the previous epoch after each turn over.
+type Reporter struct {
+    store        storage.Store
+    auditLog     *audit.Log
+    watchDog     *monitor.ScheduleWatchDog
+    tracker      *blocks.Tracker
+    timeSettings *cardano.TimeSettings
+    settings     Settings
+    startTime    time.Time
+}
+
+// creates a new reporter. the watchdog and the tracker of block outcomes
+// are optional, and can be nil.
+func NewReporter(store storage.Store, auditLog *audit.Log, watchDog *monitor.ScheduleWatchDog, tracker *blocks.Tracker,
+    timeSettings *cardano.TimeSettings, settings Settings) (*Reporter, error) {
+    err := createUptimeBucket(store)
+    if err != nil {
+        return nil, err
+    }
+    settings.Clock = clock.OrReal(settings.Clock)
+    if settings.Lookback == 0 {
+        settings.Lookback = time.Duration(timeSettings.SlotsPerEpoch.Int64()) * timeSettings.SlotDuration
+    }
+    return &Reporter{
+        store:        store,
+        auditLog:     auditLog,
+        watchDog:     watchDog,
+        tracker:      tracker,
+        timeSettings: timeSettings,
+        settings:     settings,
+        startTime:    settings.Clock.Now(),
+    }, nil
+}
+
+// gets the start and end time of the given epoch.
+func (reporter *Reporter) getEpochRange(epoch uint64) (time.Time, time.Time) {
+    start := cardano.FullSlotDateFromInt(epoch, 0, *reporter.timeSettings).GetStartDateTime()
+    end := cardano.FullSlotDateFromInt(epoch+1, 0, *reporter.timeSettings).GetStartDateTime()
+    return start, end
+}
+
+// gets the current epoch.
+func (reporter *Reporter) CurrentEpoch() uint64 {
+    currentSlotDate, _ := reporter.timeSettings.GetSlotDateFor(reporter.settings.Clock.Now())
+    return currentSlotDate.GetEpoch().Uint64()
+}
+
+// generates the report for the given epoch. the report of the current
+// epoch covers the epoch up to now.
+func (reporter *Reporter) Generate(epoch uint64) (EpochReport, error) {
+    now := reporter.settings.Clock.Now()
+    start, end := reporter.getEpochRange(epoch)
+    report := EpochReport{Epoch: epoch, Start: start, End: end, GeneratedAt: now, LeadersAtStart: []string{},
+        Nodes: []NodeReport{}, Outcomes: []blocks.BlockOutcome{}}
+    if reporter.tracker != nil {
+        epochOutcomes, err := reporter.tracker.GetEpochOutcomes(epoch)
+        if err != nil {
+            return report, err
+        }
+        summary := epochOutcomes.Summary
+        report.Assigned = summary.Scheduled
+        report.Pending = summary.Pending
+        report.Minted = summary.Minted
+        report.Adopted = summary.Adopted
+        report.Lost = summary.Lost
+        report.Missed = summary.Missed
+        report.Outcomes = epochOutcomes.Outcomes
+    } else if reporter.watchDog != nil {
+        schedule, _ := reporter.watchDog.GetStoredScheduleFor(new(big.Int).SetUint64(epoch))
+        report.Assigned = len(schedule)
+        report.Pending = len(schedule)
+    }
+    entries, err := reporter.auditLog.Query(audit.Filter{From: start.Add(-reporter.settings.Lookback), To: end})
+    if err != nil {
+        return report, err
+    }
+    report.LeadersAtStart, report.LeaderUnknownAtStart = getLeadersAtStart(entries, start)
+    if report.LeaderUnknownAtStart {
+        log.Warnf("[REPORT] The leader at the start of epoch %v is unknown, its leader time before the first "+
+            "promotion is missing.", epoch)
+    }
+    report.Nodes = getNodeReports(entries, start, end, now)
+    for _, node := range report.Nodes {
+        report.LeaderChanges += node.Promotions
+        report.Restarts += node.Restarts
+    }
+    periods, err := getUptimePeriods(reporter.store)
+    if err != nil {
+        return report, err
+    }
+    uptime := getUptime(periods, start, end)
+    report.MonitorUptimeSeconds = int64(uptime.Seconds())
+    elapsed := minTime(end, now).Sub(start)
+    if elapsed > 0 {
+        report.MonitorUptimeRatio = uptime.Seconds() / elapsed.Seconds()
+    }
+    return report, nil
+}
+
+// gets the leaders at the given start according to the given audit log
+// entries. the boolean value is true, if the leaders are unknown, because
+// there is no entry about a leader change before the start.
+func getLeadersAtStart(entries []audit.Entry, start time.Time) ([]string, bool) {
+    leaders := make(map[string]bool)
+    known := false
+    for _, entry := range entries {
+        if !entry.Time.Before(start) {
+            break
+        }
+        if entry.Outcome != audit.Success {
+            continue
+        }
+        switch entry.Action {
+        case audit.Promotion:
+            leaders[entry.Node] = true
+        case audit.Demotion, audit.Shutdown, audit.Restart:
+            delete(leaders, entry.Node)
+        default:
+            continue
+        }
+        known = true
+    }
+    names := make([]string, 0, len(leaders))
+    for name := range leaders {
+        names = append(names, name)
+    }
+    sort.Strings(names)
+    return names, !known
+}
+
+// gets the reports of all the nodes mentioned in the given audit log entries
+// for the epoch in the given range. entries before the start are needed to
+// know the leaders at the start of the epoch. a restart observed after a
+// shutdown issued by this tool is not counted again.
+func getNodeReports(entries []audit.Entry, start time.Time, end time.Time, now time.Time) []NodeReport {
+    nodeMap := make(map[string]*NodeReport)
+    leaderSince := make(map[string]time.Time)
+    shutDown := make(map[string]bool)
+    end = minTime(end, now)
+    for _, entry := range entries {
+        if entry.Outcome != audit.Success {
+            continue
+        }
+        node, found := nodeMap[entry.Node]
+        if !found {
+            node = &NodeReport{Name: entry.Node}
+            nodeMap[entry.Node] = node
+        }
+        inEpoch := !entry.Time.Before(start) && entry.Time.Before(end)
+        switch entry.Action {
+        case audit.Promotion:
+            if inEpoch {
+                node.Promotions++
+            }
+            if _, isLeader := leaderSince[entry.Node]; !isLeader {
+                leaderSince[entry.Node] = entry.Time
+            }
+        case audit.Demotion, audit.Shutdown, audit.Restart:
+            switch {
+            case entry.Action == audit.Demotion:
+                if inEpoch {
+                    node.Demotions++
+                }
+            case entry.Action == audit.Shutdown:
+                if inEpoch {
+                    node.Restarts++
+                }
+                shutDown[entry.Node] = true
+            case shutDown[entry.Node]:
+                delete(shutDown, entry.Node)
+            case inEpoch:
+                node.Restarts++
+            }
+            since, isLeader := leaderSince[entry.Node]
+            if isLeader {
+                node.LeaderTimeSeconds += int64(getOverlap(since, entry.Time, start, end).Seconds())
+                delete(leaderSince, entry.Node)
+            }
+        }
+    }
+    for name, since := range leaderSince {
+        nodeMap[name].LeaderTimeSeconds += int64(getOverlap(since, end, start, end).Seconds())
+    }
+    nodes := make([]NodeReport, 0, len(nodeMap))
+    for _, node := range nodeMap {
+        if node.Promotions > 0 || node.Demotions > 0 || node.Restarts > 0 || node.LeaderTimeSeconds > 0 {
+            nodes = append(nodes, *node)
+        }
+    }
+    sort.Slice(nodes, func(i, j int) bool { return nodes[i].Name < nodes[j].Name })
+    return nodes
+}
+
+// gets the duration in which the period [from, to) overlaps with [start, end).
+func getOverlap(from time.Time, to time.Time, start time.Time, end time.Time) time.Duration {
+    if from.Before(start) {
+        from = start
+    }
+    to = minTime(to, end)
+    if to.After(from) {
+        return to.Sub(from)
+    }
+    return 0
+}
+
+func minTime(a time.Time, b time.Time) time.Time {
+    if a.Before(b) {
+        return a
+    }
+    return b
+}
+
+// a blocking call, which records the uptime of this tool and sends the
+// report of the previous epoch to the notifiers after each turn over.
+func (reporter *Reporter) Run() {
+    log.Info("[REPORT] Starting to record the uptime and to report epochs.")
+    delay := time.Duration(reporter.settings.DelaySlots) * reporter.timeSettings.SlotDuration
+    currentEpoch := reporter.CurrentEpoch()
+    nextEpochStart, _ := reporter.getEpochRange(currentEpoch + 1)
+    nextReport := nextEpochStart.Add(delay)
+    for ; ; {
+        now := reporter.settings.Clock.Now()
+        err := recordUptime(reporter.store, reporter.startTime, now)
+        if err != nil {
+            log.Errorf("[REPORT] Could not record the uptime. %v", err.Error())
+        }
+        if !now.Before(nextReport) {
+            reporter.send(currentEpoch)
+            currentEpoch++
+            nextEpochStart, _ = reporter.getEpochRange(currentEpoch + 1)
+            nextReport = nextEpochStart.Add(delay)
+        }
+        wait := reporter.settings.HeartbeatInterval
+        if untilReport := nextReport.Sub(now); untilReport < wait {
+            wait = untilReport
+        }
+        reporter.settings.Clock.Sleep(wait)
+    }
+}
+
+// generates the report for the given epoch and sends it to all notifiers.
+func (reporter *Reporter) send(epoch uint64) {
+    if len(reporter.settings.Notifiers) == 0 {
+        return
+    }
+    report, err := reporter.Generate(epoch)
+    if err != nil {
+        log.Errorf("[REPORT] Could not generate the report for epoch %v. %v", epoch, err.Error())
+        return
+    }
+    for _, notifier := range reporter.settings.Notifiers {
+        err := notifier.Notify(report)
+        if err != nil {
+            log.Errorf("[REPORT] Could not send the report for epoch %v. %v", epoch, err.Error())
+        } else {
+            log.Infof("[REPORT] Sent the report for epoch %v.", epoch)
+        }
+    }
+}
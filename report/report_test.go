@@ -0,0 +1,147 @@
+package report
+
+import (
+    "bytes"
+    "encoding/json"
+    "github.com/sobitada/thor/audit"
+    "github.com/sobitada/thor/jortest"
+    "github.com/sobitada/thor/storage"
+    "github.com/stretchr/testify/assert"
+    "net/http"
+    "net/http/httptest"
+    "strings"
+    "testing"
+    "time"
+)
+
+func TestGetNodeReports_LeaderBeforeEpoch_mustCountLeaderTimeWithinEpoch(t *testing.T) {
+    start := time.Date(2020, 5, 1, 0, 0, 0, 0, time.UTC)
+    end := start.Add(24 * time.Hour)
+    entries := []audit.Entry{
+        {Time: start.Add(-time.Hour), Node: "a", Action: audit.Promotion, Outcome: audit.Success},
+        {Time: start.Add(2 * time.Hour), Node: "b", Action: audit.Promotion, Outcome: audit.Success},
+        {Time: start.Add(2 * time.Hour), Node: "a", Action: audit.Demotion, Outcome: audit.Success},
+        {Time: start.Add(3 * time.Hour), Node: "c", Action: audit.Promotion, Outcome: audit.RolledBack},
+        {Time: start.Add(4 * time.Hour), Node: "c", Action: audit.Shutdown, Outcome: audit.Success},
+        {Time: start.Add(5 * time.Hour), Node: "c", Action: audit.Promotion, Outcome: audit.Shadow},
+    }
+    nodes := getNodeReports(entries, start, end, end.Add(time.Hour))
+    if assert.Len(t, nodes, 3) {
+        assert.Equal(t, NodeReport{Name: "a", Demotions: 1, LeaderTimeSeconds: 2 * 3600}, nodes[0])
+        assert.Equal(t, NodeReport{Name: "b", Promotions: 1, LeaderTimeSeconds: 22 * 3600}, nodes[1])
+        assert.Equal(t, NodeReport{Name: "c", Restarts: 1}, nodes[2])
+    }
+}
+
+func TestGetNodeReports_ObservedRestarts_mustBeCountedOnce(t *testing.T) {
+    start := time.Date(2020, 5, 1, 0, 0, 0, 0, time.UTC)
+    end := start.Add(24 * time.Hour)
+    entries := []audit.Entry{
+        {Time: start.Add(-time.Hour), Node: "a", Action: audit.Promotion, Outcome: audit.Success},
+        {Time: start.Add(-time.Minute), Node: "b", Action: audit.Shutdown, Outcome: audit.Success},
+        {Time: start.Add(time.Minute), Node: "b", Action: audit.Restart, Outcome: audit.Success},
+        {Time: start.Add(time.Hour), Node: "a", Action: audit.Restart, Outcome: audit.Success},
+        {Time: start.Add(2 * time.Hour), Node: "c", Action: audit.Shutdown, Outcome: audit.Success},
+        {Time: start.Add(2*time.Hour + time.Minute), Node: "c", Action: audit.Restart, Outcome: audit.Success},
+        {Time: start.Add(3 * time.Hour), Node: "c", Action: audit.Restart, Outcome: audit.Success},
+    }
+    nodes := getNodeReports(entries, start, end, end)
+    if assert.Len(t, nodes, 2) {
+        assert.Equal(t, NodeReport{Name: "a", Restarts: 1, LeaderTimeSeconds: 3600}, nodes[0])
+        assert.Equal(t, NodeReport{Name: "c", Restarts: 2}, nodes[1])
+    }
+}
+
+func TestGetLeadersAtStart_mustBeUnknownWithoutEntriesBeforeStart(t *testing.T) {
+    start := time.Date(2020, 5, 1, 0, 0, 0, 0, time.UTC)
+    entries := []audit.Entry{
+        {Time: start.Add(-2 * time.Hour), Node: "a", Action: audit.Promotion, Outcome: audit.Success},
+        {Time: start.Add(-time.Hour), Node: "b", Action: audit.Promotion, Outcome: audit.Success},
+        {Time: start.Add(-time.Hour), Node: "a", Action: audit.Demotion, Outcome: audit.Success},
+        {Time: start.Add(time.Hour), Node: "c", Action: audit.Promotion, Outcome: audit.Success},
+    }
+    leaders, unknown := getLeadersAtStart(entries, start)
+    assert.False(t, unknown)
+    assert.Equal(t, []string{"b"}, leaders)
+    leaders, unknown = getLeadersAtStart(entries[3:], start)
+    assert.True(t, unknown)
+    assert.Empty(t, leaders)
+}
+
+func TestGetUptime_PeriodsAroundEpoch_mustOnlyCountOverlap(t *testing.T) {
+    start := time.Date(2020, 5, 1, 0, 0, 0, 0, time.UTC)
+    end := start.Add(24 * time.Hour)
+    periods := []uptimePeriod{
+        {Start: start.Add(-2 * time.Hour), LastSeen: start.Add(time.Hour)},
+        {Start: start.Add(5 * time.Hour), LastSeen: start.Add(6 * time.Hour)},
+        {Start: end.Add(-time.Hour), LastSeen: end.Add(time.Hour)},
+        {Start: end.Add(2 * time.Hour), LastSeen: end.Add(3 * time.Hour)},
+    }
+    assert.Equal(t, 3*time.Hour, getUptime(periods, start, end))
+}
+
+func TestReporter_Run_mustSendReportOfPreviousEpochAfterTurnOver(t *testing.T) {
+    now := time.Now()
+    clock := jortest.NewClock(now)
+    settings := jortest.TimeSettingsAt(now, 5, 990, time.Second, 1000)
+    db := storage.NewMemory()
+    defer db.Close()
+    auditLog, err := audit.NewLog(db, settings, clock)
+    if err != nil {
+        t.Fatal(err)
+    }
+    auditLog.Record("a", audit.Promotion, "healthiest node", audit.Success, nil)
+    reports := make(chan EpochReport, 1)
+    server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+        var report EpochReport
+        if json.NewDecoder(request.Body).Decode(&report) == nil {
+            reports <- report
+        }
+    }))
+    defer server.Close()
+    reporter, err := NewReporter(db, auditLog, nil, nil, settings, Settings{
+        DelaySlots:        5,
+        HeartbeatInterval: time.Minute,
+        Notifiers:         []Notifier{WebhookNotifier{URL: server.URL}},
+        Clock:             clock,
+    })
+    if err != nil {
+        t.Fatal(err)
+    }
+    go reporter.Run()
+    assert.True(t, clock.WaitForTimers(1, time.Second))
+    clock.AdvanceToSlot(*settings, 6, 5)
+    select {
+    case report := <-reports:
+        assert.Equal(t, uint64(5), report.Epoch)
+        assert.Equal(t, 1, report.LeaderChanges)
+        assert.Equal(t, int64(10), report.MonitorUptimeSeconds)
+        if assert.Len(t, report.Nodes, 1) {
+            assert.Equal(t, "a", report.Nodes[0].Name)
+            // the audit log records the real time, which is slightly after the start of the clock.
+            assert.InDelta(t, 10, report.Nodes[0].LeaderTimeSeconds, 1)
+        }
+    case <-time.After(5 * time.Second):
+        t.Fatal("the report has not been sent after the turn over")
+    }
+}
+
+func TestWrite_CSVAndMarkdown_mustContainSummaryAndNodes(t *testing.T) {
+    report := EpochReport{Epoch: 42, Assigned: 3, Adopted: 2, Missed: 1, LeaderChanges: 1,
+        Nodes: []NodeReport{{Name: "a", Promotions: 1, LeaderTimeSeconds: 3600}}}
+    var csvOutput bytes.Buffer
+    if assert.NoError(t, Write(&csvOutput, report, CSV)) {
+        lines := strings.Split(strings.TrimSpace(csvOutput.String()), "\n")
+        assert.Equal(t, "metric,node,value", lines[0])
+        assert.Contains(t, lines, "assigned,,3")
+        assert.Contains(t, lines, "leader_time_seconds,a,3600")
+    }
+    var markdownOutput bytes.Buffer
+    if assert.NoError(t, Write(&markdownOutput, report, Markdown)) {
+        assert.Contains(t, markdownOutput.String(), "# Epoch 42")
+        assert.Contains(t, markdownOutput.String(), "| Adopted | 2 |")
+        assert.Contains(t, markdownOutput.String(), "| a | 1h0m0s | 1 | 0 | 0 |")
+    }
+    _, err := ParseFormat("xml")
+    assert.Error(t, err)
+}
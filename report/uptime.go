@@ -0,0 +1,57 @@
+package report
+
+import (
+    "encoding/json"
+    "github.com/sobitada/thor/storage"
+    "time"
+)
+
+// period in which this tool has been running.
+type uptimePeriod struct {
+    Start    time.Time `json:"start"`
+    LastSeen time.Time `json:"lastSeen"`
+}
+
+// creates the bucket for the uptime periods, if it does not exist yet.
+func createUptimeBucket(store storage.Store) error {
+    return store.Update(func(tx storage.Tx) error {
+        return tx.CreateBucket(storage.UptimeBucket)
+    })
+}
+
+// records that this tool, which has been started at the given start time,
+// is still running at the given time.
+func recordUptime(store storage.Store, start time.Time, now time.Time) error {
+    return store.Update(func(tx storage.Tx) error {
+        data, err := json.Marshal(uptimePeriod{Start: start, LastSeen: now})
+        if err != nil {
+            return err
+        }
+        return tx.Put(storage.UptimeBucket, start.UTC().Format(time.RFC3339Nano), data)
+    })
+}
+
+// gets all the recorded periods in which this tool has been running.
+func getUptimePeriods(store storage.Store) ([]uptimePeriod, error) {
+    periods := make([]uptimePeriod, 0)
+    err := store.View(func(tx storage.Tx) error {
+        return tx.ForEach(storage.UptimeBucket, func(key string, value []byte) error {
+            var period uptimePeriod
+            err := json.Unmarshal(value, &period)
+            if err == nil {
+                periods = append(periods, period)
+            }
+            return err
+        })
+    })
+    return periods, err
+}
+
+// gets the time this tool has been running within [start, end).
+func getUptime(periods []uptimePeriod, start time.Time, end time.Time) time.Duration {
+    var uptime time.Duration = 0
+    for _, period := range periods {
+        uptime += getOverlap(period.Start, period.LastSeen, start, end)
+    }
+    return uptime
+}
@@ -0,0 +1,94 @@
+package status
+
+import (
+    "encoding/json"
+    "fmt"
+    log "github.com/sirupsen/logrus"
+    "io/ioutil"
+    "net/http"
+    "net/url"
+    "time"
+)
+
+// status API exposing the state of this program
+// over HTTP.
+type Server struct {
+    host string
+    port string
+    mux  *http.ServeMux
+}
+
+// gets a new status API server listening on the
+// given host and port.
+func GetServer(host string, port string) *Server {
+    return &Server{host: host, port: port, mux: http.NewServeMux()}
+}
+
+// registers the handler for the given path.
+func (server *Server) Handle(path string, handler http.Handler) {
+    server.mux.Handle(path, handler)
+}
+
+// starts the status API server, this is a blocking call.
+func (server *Server) Run() {
+    log.Infof("[STATUS] Starting the status API on %v:%v.", server.host, server.port)
+    err := http.ListenAndServe(fmt.Sprintf("%v:%v", server.host, server.port), server.mux)
+    if err != nil {
+        log.Errorf("[STATUS] Status API could not be started. %v", err.Error())
+    }
+}
+
+// client for querying the status API of a running instance
+// of this program.
+type Client struct {
+    baseURL *url.URL
+    client  *http.Client
+}
+
+// gets a client for the status API on the given host and port. a
+// wildcard host is replaced by the loopback address.
+func GetClient(host string, port string) (*Client, error) {
+    if host == "" || host == "0.0.0.0" || host == "::" {
+        host = "127.0.0.1"
+    }
+    baseURL, err := url.Parse(fmt.Sprintf("http://%v:%v/", host, port))
+    if err != nil {
+        return nil, err
+    }
+    return &Client{baseURL: baseURL, client: &http.Client{Timeout: 10 * time.Second}}, nil
+}
+
+// sends a GET request for the given path with the given query and
+// decodes the JSON response into the given value.
+func (client *Client) Get(path string, query url.Values, value interface{}) error {
+    data, err := client.GetRaw(path, query)
+    if err == nil {
+        return json.Unmarshal(data, value)
+    }
+    return err
+}
+
+// sends a GET request for the given path with the given query and
+// returns the body of the response.
+func (client *Client) GetRaw(path string, query url.Values) ([]byte, error) {
+    ref, err := url.Parse(path)
+    if err != nil {
+        return nil, err
+    }
+    u := client.baseURL.ResolveReference(ref)
+    u.RawQuery = query.Encode()
+    response, err := client.client.Get(u.String())
+    if err != nil {
+        return nil, err
+    }
+    defer response.Body.Close()
+    data, err := ioutil.ReadAll(response.Body)
+    if err != nil {
+        return nil, err
+    }
+    if response.StatusCode != http.StatusOK {
+        return nil, fmt.Errorf("status API request '%v' failed with status code %v. %s", path,
+            response.StatusCode, data)
+    }
+    return data, nil
+}
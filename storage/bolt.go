@@ -0,0 +1,187 @@
+package storage
+
+import (
+    "fmt"
+    "github.com/boltdb/bolt"
+    "time"
+)
+
+// store backed by a bolt file, in which the buckets of a path are
+// nested bolt buckets.
+type boltStore struct {
+    db *bolt.DB
+}
+
+type boltTx struct {
+    tx *bolt.Tx
+}
+
+// opens the bolt file at the given path, which is created if it does not
+// exist. the file can only be opened by one process at a time.
+func OpenBolt(dbPath string) (Store, error) {
+    db, err := bolt.Open(dbPath, 0600, &bolt.Options{Timeout: 2 * time.Second})
+    if err == bolt.ErrTimeout {
+        return nil, fmt.Errorf("the db '%v' is locked by another process, e.g. a running thor instance", dbPath)
+    } else if err != nil {
+        return nil, err
+    }
+    return &boltStore{db: db}, nil
+}
+
+func (store *boltStore) View(fn func(tx Tx) error) error {
+    return store.db.View(func(tx *bolt.Tx) error {
+        return fn(boltTx{tx: tx})
+    })
+}
+
+func (store *boltStore) Update(fn func(tx Tx) error) error {
+    return store.db.Update(func(tx *bolt.Tx) error {
+        return fn(boltTx{tx: tx})
+    })
+}
+
+func (store *boltStore) Close() error {
+    return store.db.Close()
+}
+
+// gets the bolt bucket for the given path, which is created if it does not
+// exist and create is true. otherwise nil is returned for a missing bucket.
+func (tx boltTx) getBucket(bucket string, create bool) (*bolt.Bucket, error) {
+    var b *bolt.Bucket = nil
+    for i, name := range splitBucketPath(bucket) {
+        var next *bolt.Bucket
+        if create && i == 0 {
+            var err error
+            next, err = tx.tx.CreateBucketIfNotExists([]byte(name))
+            if err != nil {
+                return nil, err
+            }
+        } else if create {
+            var err error
+            next, err = b.CreateBucketIfNotExists([]byte(name))
+            if err != nil {
+                return nil, err
+            }
+        } else if i == 0 {
+            next = tx.tx.Bucket([]byte(name))
+        } else {
+            next = b.Bucket([]byte(name))
+        }
+        if next == nil {
+            return nil, nil
+        }
+        b = next
+    }
+    return b, nil
+}
+
+func (tx boltTx) Get(bucket string, key string) ([]byte, error) {
+    b, err := tx.getBucket(bucket, false)
+    if err != nil || b == nil {
+        return nil, err
+    }
+    value := b.Get([]byte(key))
+    if value == nil {
+        return nil, nil
+    }
+    return append([]byte{}, value...), nil
+}
+
+func (tx boltTx) Put(bucket string, key string, value []byte) error {
+    b, err := tx.getBucket(bucket, true)
+    if err != nil {
+        return err
+    }
+    return b.Put([]byte(key), value)
+}
+
+func (tx boltTx) Delete(bucket string, key string) error {
+    b, err := tx.getBucket(bucket, false)
+    if err != nil || b == nil {
+        return err
+    }
+    return b.Delete([]byte(key))
+}
+
+func (tx boltTx) ForEach(bucket string, fn func(key string, value []byte) error) error {
+    b, err := tx.getBucket(bucket, false)
+    if err != nil || b == nil {
+        return err
+    }
+    return b.ForEach(func(key []byte, value []byte) error {
+        if value == nil {
+            return nil
+        }
+        return fn(string(key), value)
+    })
+}
+
+func (tx boltTx) Buckets(bucket string) ([]string, error) {
+    names := make([]string, 0)
+    if bucket == "" {
+        err := tx.tx.ForEach(func(name []byte, b *bolt.Bucket) error {
+            names = append(names, string(name))
+            return nil
+        })
+        return names, err
+    }
+    b, err := tx.getBucket(bucket, false)
+    if err != nil || b == nil {
+        return names, err
+    }
+    err = b.ForEach(func(key []byte, value []byte) error {
+        if value == nil {
+            names = append(names, string(key))
+        }
+        return nil
+    })
+    return names, err
+}
+
+func (tx boltTx) CreateBucket(bucket string) error {
+    _, err := tx.getBucket(bucket, true)
+    return err
+}
+
+func (tx boltTx) DeleteBucket(bucket string) error {
+    names := splitBucketPath(bucket)
+    var err error
+    if len(names) == 1 {
+        err = tx.tx.DeleteBucket([]byte(bucket))
+    } else {
+        var parent *bolt.Bucket
+        parent, err = tx.getBucket(bucket[:len(bucket)-len(names[len(names)-1])-1], false)
+        if err != nil || parent == nil {
+            return err
+        }
+        err = parent.DeleteBucket([]byte(names[len(names)-1]))
+    }
+    if err == bolt.ErrBucketNotFound {
+        return nil
+    }
+    return err
+}
+
+func (tx boltTx) NextSequence(bucket string) (uint64, error) {
+    b, err := tx.getBucket(bucket, true)
+    if err != nil {
+        return 0, err
+    }
+    return b.NextSequence()
+}
+
+func (tx boltTx) Sequence(bucket string) (uint64, error) {
+    b, err := tx.getBucket(bucket, false)
+    if err != nil || b == nil {
+        return 0, err
+    }
+    return b.Sequence(), nil
+}
+
+func (tx boltTx) SetSequence(bucket string, sequence uint64) error {
+    b, err := tx.getBucket(bucket, true)
+    if err != nil {
+        return err
+    }
+    return b.SetSequence(sequence)
+}
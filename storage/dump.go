@@ -0,0 +1,307 @@
+package storage
+
+import (
+    "encoding/json"
+    "fmt"
+    "github.com/boltdb/bolt"
+    "io"
+    "os"
+    "time"
+)
+
+// content of a store, which can be written to and read from JSON. the keys and
+// values are encoded in base64, since some keys are binary.
+type Dump struct {
+    SchemaVersion uint64       `json:"schemaVersion"`
+    ExportedAt    time.Time    `json:"exportedAt"`
+    Buckets       []BucketDump `json:"buckets"`
+}
+
+// content of a bucket with its entries and sub buckets.
+type BucketDump struct {
+    Name string `json:"name"`
+    // sequence of the bucket, from which e.g. the keys of the
+    // audit log are generated.
+    Sequence uint64       `json:"sequence,omitempty"`
+    Entries  []EntryDump  `json:"entries"`
+    Buckets  []BucketDump `json:"buckets,omitempty"`
+}
+
+// key,value pair stored in a bucket.
+type EntryDump struct {
+    Key   []byte `json:"key"`
+    Value []byte `json:"value"`
+}
+
+// exports the content of all buckets in the given store except for the
+// meta information.
+func Export(store Store) (Dump, error) {
+    dump := Dump{ExportedAt: time.Now(), Buckets: []BucketDump{}}
+    err := store.View(func(tx Tx) error {
+        var err error
+        dump.SchemaVersion, err = getSchemaVersion(tx)
+        if err != nil {
+            return err
+        }
+        names, err := tx.Buckets("")
+        if err != nil {
+            return err
+        }
+        for _, name := range names {
+            if name == metaBucket {
+                continue
+            }
+            bucketDump, err := exportBucket(tx, name, name)
+            if err != nil {
+                return err
+            }
+            dump.Buckets = append(dump.Buckets, bucketDump)
+        }
+        return nil
+    })
+    return dump, err
+}
+
+func exportBucket(tx Tx, bucket string, name string) (BucketDump, error) {
+    bucketDump := BucketDump{Name: name, Entries: []EntryDump{}}
+    var err error
+    bucketDump.Sequence, err = tx.Sequence(bucket)
+    if err != nil {
+        return bucketDump, err
+    }
+    err = tx.ForEach(bucket, func(key string, value []byte) error {
+        bucketDump.Entries = append(bucketDump.Entries, EntryDump{
+            Key:   []byte(key),
+            Value: append([]byte{}, value...),
+        })
+        return nil
+    })
+    if err != nil {
+        return bucketDump, err
+    }
+    names, err := tx.Buckets(bucket)
+    if err != nil {
+        return bucketDump, err
+    }
+    for _, subName := range names {
+        subBucketDump, err := exportBucket(tx, SubBucket(bucket, subName), subName)
+        if err != nil {
+            return bucketDump, err
+        }
+        bucketDump.Buckets = append(bucketDump.Buckets, subBucketDump)
+    }
+    return bucketDump, nil
+}
+
+// writes the content of the given store as JSON to the given writer.
+func WriteExport(writer io.Writer, store Store) error {
+    dump, err := Export(store)
+    if err != nil {
+        return err
+    }
+    encoder := json.NewEncoder(writer)
+    encoder.SetIndent("", "  ")
+    return encoder.Encode(dump)
+}
+
+// checks whether the buckets of the given store contain no data.
+func IsEmpty(store Store) (bool, error) {
+    dump, err := Export(store)
+    if err != nil {
+        return false, err
+    }
+    for _, bucketDump := range dump.Buckets {
+        if !bucketDump.isEmpty() {
+            return false, nil
+        }
+    }
+    return true, nil
+}
+
+func (bucketDump BucketDump) isEmpty() bool {
+    if len(bucketDump.Entries) > 0 {
+        return false
+    }
+    for _, subBucketDump := range bucketDump.Buckets {
+        if !subBucketDump.isEmpty() {
+            return false
+        }
+    }
+    return true
+}
+
+// imports the given dump into the given store, which replaces all the existing
+// data. the store is migrated afterwards, if the dump has been exported with an
+// older schema version.
+func Import(store Store, dump Dump) error {
+    if dump.SchemaVersion > SchemaVersion {
+        return fmt.Errorf("the dump has been created by a newer version of thor (schema version %v, supported up to %v)",
+            dump.SchemaVersion, SchemaVersion)
+    }
+    err := store.Update(func(tx Tx) error {
+        names, err := tx.Buckets("")
+        if err != nil {
+            return err
+        }
+        for _, name := range names {
+            err := tx.DeleteBucket(name)
+            if err != nil {
+                return err
+            }
+        }
+        for _, bucketDump := range dump.Buckets {
+            err = importBucket(tx, bucketDump.Name, bucketDump)
+            if err != nil {
+                return err
+            }
+        }
+        if dump.SchemaVersion > 0 {
+            return setSchemaVersion(tx, dump.SchemaVersion)
+        }
+        return nil
+    })
+    if err != nil {
+        return err
+    }
+    return Migrate(store)
+}
+
+func importBucket(tx Tx, bucket string, bucketDump BucketDump) error {
+    err := tx.CreateBucket(bucket)
+    if err != nil {
+        return err
+    }
+    err = tx.SetSequence(bucket, bucketDump.Sequence)
+    if err != nil {
+        return err
+    }
+    for _, entry := range bucketDump.Entries {
+        err := tx.Put(bucket, string(entry.Key), entry.Value)
+        if err != nil {
+            return err
+        }
+    }
+    for _, subBucketDump := range bucketDump.Buckets {
+        err = importBucket(tx, SubBucket(bucket, subBucketDump.Name), subBucketDump)
+        if err != nil {
+            return err
+        }
+    }
+    return nil
+}
+
+// reads the JSON written by an export from the given reader, and imports it
+// into the given store.
+func ReadImport(reader io.Reader, store Store) error {
+    var dump Dump
+    err := json.NewDecoder(reader).Decode(&dump)
+    if err != nil {
+        return fmt.Errorf("could not parse the export. %v", err.Error())
+    }
+    return Import(store, dump)
+}
+
+// rewrites the file of the store specified by the given settings, which frees
+// the space of removed data. it returns the size of the file before and after
+// the compaction.
+func Compact(settings Settings) (int64, int64, error) {
+    switch settings.Backend {
+    case Bolt, "":
+        return compactBolt(settings.Path)
+    case SQLite:
+        return compactSQLite(settings.Path)
+    }
+    return 0, 0, fmt.Errorf("the storage backend '%v' cannot be compacted", settings.Backend)
+}
+
+// rewrites the bolt file at the given path into a new file.
+func compactBolt(dbPath string) (int64, int64, error) {
+    info, err := os.Stat(dbPath)
+    if err != nil {
+        return 0, 0, err
+    }
+    store, err := OpenBolt(dbPath)
+    if err != nil {
+        return 0, 0, err
+    }
+    src := store.(*boltStore).db
+    compactPath := dbPath + ".compact"
+    _ = os.Remove(compactPath)
+    dst, err := bolt.Open(compactPath, info.Mode(), nil)
+    if err != nil {
+        _ = src.Close()
+        return 0, 0, err
+    }
+    err = src.View(func(srcTx *bolt.Tx) error {
+        return dst.Update(func(dstTx *bolt.Tx) error {
+            return srcTx.ForEach(func(name []byte, b *bolt.Bucket) error {
+                dstBucket, err := dstTx.CreateBucket(name)
+                if err != nil {
+                    return err
+                }
+                return copyBucket(b, dstBucket)
+            })
+        })
+    })
+    closeErr := dst.Close()
+    if err == nil {
+        err = closeErr
+    }
+    _ = src.Close()
+    if err != nil {
+        _ = os.Remove(compactPath)
+        return 0, 0, err
+    }
+    err = os.Rename(compactPath, dbPath)
+    if err != nil {
+        return 0, 0, err
+    }
+    compactInfo, err := os.Stat(dbPath)
+    if err != nil {
+        return 0, 0, err
+    }
+    return info.Size(), compactInfo.Size(), nil
+}
+
+// copies the entries, sub buckets and sequence of the given bolt bucket.
+func copyBucket(src *bolt.Bucket, dst *bolt.Bucket) error {
+    err := dst.SetSequence(src.Sequence())
+    if err != nil {
+        return err
+    }
+    return src.ForEach(func(key []byte, value []byte) error {
+        if value == nil {
+            subBucket, err := dst.CreateBucket(key)
+            if err != nil {
+                return err
+            }
+            return copyBucket(src.Bucket(key), subBucket)
+        }
+        return dst.Put(key, value)
+    })
+}
+
+// rebuilds the SQLite file at the given path.
+func compactSQLite(dbPath string) (int64, int64, error) {
+    info, err := os.Stat(dbPath)
+    if err != nil {
+        return 0, 0, err
+    }
+    store, err := OpenSQLite(dbPath)
+    if err != nil {
+        return 0, 0, err
+    }
+    _, err = store.(*sqliteStore).db.Exec("VACUUM")
+    closeErr := store.Close()
+    if err == nil {
+        err = closeErr
+    }
+    if err != nil {
+        return 0, 0, err
+    }
+    compactInfo, err := os.Stat(dbPath)
+    if err != nil {
+        return 0, 0, err
+    }
+    return info.Size(), compactInfo.Size(), nil
+}
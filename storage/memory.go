@@ -0,0 +1,186 @@
+package storage
+
+import (
+    "errors"
+    "sort"
+    "strings"
+    "sync"
+)
+
+// the transaction is read-only, and cannot modify the store.
+var errReadOnly = errors.New("the transaction is read-only")
+
+// store, which keeps its state only in memory. it is meant for tests and
+// for running this tool without persisting any state.
+type memoryStore struct {
+    buckets map[string]*memoryBucket
+    mutex   *sync.RWMutex
+}
+
+type memoryBucket struct {
+    sequence uint64
+    entries  map[string][]byte
+}
+
+type memoryTx struct {
+    buckets  map[string]*memoryBucket
+    writable bool
+}
+
+// creates a new empty store in memory.
+func NewMemory() Store {
+    return &memoryStore{buckets: map[string]*memoryBucket{}, mutex: &sync.RWMutex{}}
+}
+
+func (store *memoryStore) View(fn func(tx Tx) error) error {
+    store.mutex.RLock()
+    defer store.mutex.RUnlock()
+    return fn(memoryTx{buckets: store.buckets, writable: false})
+}
+
+// runs the given function on a copy of the buckets, which replaces the
+// buckets of the store, if the function succeeds.
+func (store *memoryStore) Update(fn func(tx Tx) error) error {
+    store.mutex.Lock()
+    defer store.mutex.Unlock()
+    buckets := make(map[string]*memoryBucket, len(store.buckets))
+    for name, b := range store.buckets {
+        entries := make(map[string][]byte, len(b.entries))
+        for key, value := range b.entries {
+            entries[key] = value
+        }
+        buckets[name] = &memoryBucket{sequence: b.sequence, entries: entries}
+    }
+    err := fn(memoryTx{buckets: buckets, writable: true})
+    if err == nil {
+        store.buckets = buckets
+    }
+    return err
+}
+
+func (store *memoryStore) Close() error {
+    return nil
+}
+
+// gets the bucket with the given path, which is created with all its parents
+// if it does not exist yet.
+func (tx memoryTx) createBucket(bucket string) (*memoryBucket, error) {
+    if !tx.writable {
+        return nil, errReadOnly
+    }
+    names := splitBucketPath(bucket)
+    for i := range names {
+        parent := strings.Join(names[:i+1], "/")
+        if _, found := tx.buckets[parent]; !found {
+            tx.buckets[parent] = &memoryBucket{entries: map[string][]byte{}}
+        }
+    }
+    return tx.buckets[bucket], nil
+}
+
+func (tx memoryTx) Get(bucket string, key string) ([]byte, error) {
+    b, found := tx.buckets[bucket]
+    if !found {
+        return nil, nil
+    }
+    value, found := b.entries[key]
+    if !found {
+        return nil, nil
+    }
+    return append([]byte{}, value...), nil
+}
+
+func (tx memoryTx) Put(bucket string, key string, value []byte) error {
+    b, err := tx.createBucket(bucket)
+    if err != nil {
+        return err
+    }
+    b.entries[key] = append([]byte{}, value...)
+    return nil
+}
+
+func (tx memoryTx) Delete(bucket string, key string) error {
+    if !tx.writable {
+        return errReadOnly
+    }
+    if b, found := tx.buckets[bucket]; found {
+        delete(b.entries, key)
+    }
+    return nil
+}
+
+func (tx memoryTx) ForEach(bucket string, fn func(key string, value []byte) error) error {
+    b, found := tx.buckets[bucket]
+    if !found {
+        return nil
+    }
+    keys := make([]string, 0, len(b.entries))
+    for key := range b.entries {
+        keys = append(keys, key)
+    }
+    sort.Strings(keys)
+    for _, key := range keys {
+        err := fn(key, b.entries[key])
+        if err != nil {
+            return err
+        }
+    }
+    return nil
+}
+
+func (tx memoryTx) Buckets(bucket string) ([]string, error) {
+    prefix := ""
+    if bucket != "" {
+        prefix = bucket + "/"
+    }
+    names := make([]string, 0)
+    for name := range tx.buckets {
+        if strings.HasPrefix(name, prefix) && !strings.Contains(name[len(prefix):], "/") {
+            names = append(names, name[len(prefix):])
+        }
+    }
+    sort.Strings(names)
+    return names, nil
+}
+
+func (tx memoryTx) CreateBucket(bucket string) error {
+    _, err := tx.createBucket(bucket)
+    return err
+}
+
+func (tx memoryTx) DeleteBucket(bucket string) error {
+    if !tx.writable {
+        return errReadOnly
+    }
+    for name := range tx.buckets {
+        if name == bucket || strings.HasPrefix(name, bucket+"/") {
+            delete(tx.buckets, name)
+        }
+    }
+    return nil
+}
+
+func (tx memoryTx) NextSequence(bucket string) (uint64, error) {
+    b, err := tx.createBucket(bucket)
+    if err != nil {
+        return 0, err
+    }
+    b.sequence++
+    return b.sequence, nil
+}
+
+func (tx memoryTx) Sequence(bucket string) (uint64, error) {
+    if b, found := tx.buckets[bucket]; found {
+        return b.sequence, nil
+    }
+    return 0, nil
+}
+
+func (tx memoryTx) SetSequence(bucket string, sequence uint64) error {
+    b, err := tx.createBucket(bucket)
+    if err != nil {
+        return err
+    }
+    b.sequence = sequence
+    return nil
+}
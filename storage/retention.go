@@ -0,0 +1,158 @@
+package storage
+
+import (
+    "encoding/json"
+    log "github.com/sirupsen/logrus"
+    "github.com/sobitada/go-cardano"
+    "github.com/sobitada/thor/clock"
+    "strconv"
+    "time"
+)
+
+// buckets in which the data is keyed by epoch.
+var epochBuckets = []string{ScheduleBucket, ScheduleKeysBucket, PoolToolOutcomesBucket}
+
+// settings for the retention of data in the store.
+type RetentionSettings struct {
+    // number of past epochs for which the data is kept, the data
+    // of older epochs is removed. zero keeps all the data.
+    Epochs uint64
+    // interval in which old data is removed.
+    Interval time.Duration
+    // clock used for timing, the clock of the system is used if
+    // it is nil.
+    Clock clock.Clock
+}
+
+// number of removed entries per bucket.
+type Pruned map[string]int
+
+// gets the total number of removed entries.
+func (pruned Pruned) Total() int {
+    total := 0
+    for _, n := range pruned {
+        total += n
+    }
+    return total
+}
+
+// removes the data of epochs older than the retention from the store.
+type Retention struct {
+    store        Store
+    timeSettings *cardano.TimeSettings
+    settings     RetentionSettings
+}
+
+// creates a new retention for the given store, the time settings of the block
+// chain are required to determine the start of epochs.
+func NewRetention(store Store, timeSettings *cardano.TimeSettings, settings RetentionSettings) *Retention {
+    settings.Clock = clock.OrReal(settings.Clock)
+    return &Retention{store: store, timeSettings: timeSettings, settings: settings}
+}
+
+// removes the data of all epochs older than the retention now. nothing is
+// removed, if the retention is zero or not enough epochs have passed.
+func (retention *Retention) Prune() (Pruned, error) {
+    if retention.settings.Epochs == 0 {
+        return Pruned{}, nil
+    }
+    currentSlotDate, err := retention.timeSettings.GetSlotDateFor(retention.settings.Clock.Now())
+    if err != nil {
+        return Pruned{}, err
+    }
+    currentEpoch := currentSlotDate.GetEpoch().Uint64()
+    if currentEpoch <= retention.settings.Epochs {
+        return Pruned{}, nil
+    }
+    oldestEpoch := currentEpoch - retention.settings.Epochs
+    oldestStart := cardano.FullSlotDateFromInt(oldestEpoch, 0, *retention.timeSettings).GetStartDateTime()
+    return Prune(retention.store, oldestEpoch, oldestStart)
+}
+
+// a blocking call, which removes old data in the configured interval.
+func (retention *Retention) Run() {
+    log.Infof("[STORAGE] Keeping the data of the past %v epochs.", retention.settings.Epochs)
+    for ; ; {
+        pruned, err := retention.Prune()
+        if err != nil {
+            log.Errorf("[STORAGE] Could not remove old data. %v", err.Error())
+        } else if pruned.Total() > 0 {
+            log.Infof("[STORAGE] Removed %v old entries from the store.", pruned.Total())
+        }
+        retention.settings.Clock.Sleep(retention.settings.Interval)
+    }
+}
+
+// removes the data of all epochs before the given oldest epoch, as well as
+// audit log entries and uptime periods before the given time.
+func Prune(store Store, oldestEpoch uint64, oldestTime time.Time) (Pruned, error) {
+    pruned := Pruned{}
+    isOld := func(name string) bool {
+        epoch, err := strconv.ParseUint(name, 10, 64)
+        return err == nil && epoch < oldestEpoch
+    }
+    err := store.Update(func(tx Tx) error {
+        for _, bucket := range epochBuckets {
+            n, err := deleteWhere(tx, bucket, func(key string, value []byte) bool {
+                return isOld(key)
+            })
+            if err != nil {
+                return err
+            }
+            pruned[bucket] = n
+        }
+        epochs, err := tx.Buckets(BlocksBucket)
+        if err != nil {
+            return err
+        }
+        pruned[BlocksBucket] = 0
+        for _, epoch := range epochs {
+            if isOld(epoch) {
+                err := tx.DeleteBucket(SubBucket(BlocksBucket, epoch))
+                if err != nil {
+                    return err
+                }
+                pruned[BlocksBucket]++
+            }
+        }
+        pruned[AuditBucket], err = deleteWhere(tx, AuditBucket, func(key string, value []byte) bool {
+            var entry struct {
+                Time time.Time `json:"time"`
+            }
+            return json.Unmarshal(value, &entry) == nil && entry.Time.Before(oldestTime)
+        })
+        if err != nil {
+            return err
+        }
+        pruned[UptimeBucket], err = deleteWhere(tx, UptimeBucket, func(key string, value []byte) bool {
+            var period struct {
+                LastSeen time.Time `json:"lastSeen"`
+            }
+            return json.Unmarshal(value, &period) == nil && period.LastSeen.Before(oldestTime)
+        })
+        return err
+    })
+    return pruned, err
+}
+
+// deletes all the entries of the given bucket for which the given function
+// returns true.
+func deleteWhere(tx Tx, bucket string, shallDelete func(key string, value []byte) bool) (int, error) {
+    keys := make([]string, 0)
+    err := tx.ForEach(bucket, func(key string, value []byte) error {
+        if shallDelete(key, value) {
+            keys = append(keys, key)
+        }
+        return nil
+    })
+    if err != nil {
+        return 0, err
+    }
+    for i, key := range keys {
+        err = tx.Delete(bucket, key)
+        if err != nil {
+            return i, err
+        }
+    }
+    return len(keys), nil
+}
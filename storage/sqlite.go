@@ -0,0 +1,223 @@
+package storage
+
+import (
+    "database/sql"
+    _ "github.com/mattn/go-sqlite3"
+    "strings"
+)
+
+// schema of the SQLite file, in which each bucket is a row in the table
+// of buckets with its full path as name.
+var sqliteSchema = []string{
+    `CREATE TABLE IF NOT EXISTS buckets (
+        name     TEXT    NOT NULL PRIMARY KEY,
+        sequence INTEGER NOT NULL DEFAULT 0
+    )`,
+    `CREATE TABLE IF NOT EXISTS entries (
+        bucket TEXT NOT NULL,
+        key    BLOB NOT NULL,
+        value  BLOB NOT NULL,
+        PRIMARY KEY (bucket, key)
+    )`,
+}
+
+// store backed by a SQLite file.
+type sqliteStore struct {
+    db *sql.DB
+}
+
+type sqliteTx struct {
+    tx *sql.Tx
+}
+
+// opens the SQLite file at the given path, which is created if it does not
+// exist. this backend requires that this tool has been built with cgo.
+func OpenSQLite(dbPath string) (Store, error) {
+    db, err := sql.Open("sqlite3", dbPath+"?_busy_timeout=5000&_foreign_keys=off")
+    if err != nil {
+        return nil, err
+    }
+    // all transactions are serialized, such that concurrent writes
+    // do not fail because of a locked file.
+    db.SetMaxOpenConns(1)
+    for _, statement := range sqliteSchema {
+        _, err = db.Exec(statement)
+        if err != nil {
+            _ = db.Close()
+            return nil, err
+        }
+    }
+    return &sqliteStore{db: db}, nil
+}
+
+func (store *sqliteStore) run(fn func(tx Tx) error) error {
+    tx, err := store.db.Begin()
+    if err != nil {
+        return err
+    }
+    err = fn(sqliteTx{tx: tx})
+    if err != nil {
+        _ = tx.Rollback()
+        return err
+    }
+    return tx.Commit()
+}
+
+func (store *sqliteStore) View(fn func(tx Tx) error) error {
+    return store.run(fn)
+}
+
+func (store *sqliteStore) Update(fn func(tx Tx) error) error {
+    return store.run(fn)
+}
+
+func (store *sqliteStore) Close() error {
+    return store.db.Close()
+}
+
+// creates the bucket with the given path and all its parents, if they do
+// not exist yet.
+func (tx sqliteTx) createBucket(bucket string) error {
+    names := splitBucketPath(bucket)
+    for i := range names {
+        _, err := tx.tx.Exec("INSERT OR IGNORE INTO buckets (name) VALUES (?)", strings.Join(names[:i+1], "/"))
+        if err != nil {
+            return err
+        }
+    }
+    return nil
+}
+
+func (tx sqliteTx) Get(bucket string, key string) ([]byte, error) {
+    var value []byte
+    err := tx.tx.QueryRow("SELECT value FROM entries WHERE bucket = ? AND key = ?", bucket, []byte(key)).Scan(&value)
+    if err == sql.ErrNoRows {
+        return nil, nil
+    }
+    return value, err
+}
+
+func (tx sqliteTx) Put(bucket string, key string, value []byte) error {
+    err := tx.createBucket(bucket)
+    if err != nil {
+        return err
+    }
+    if value == nil {
+        value = []byte{}
+    }
+    _, err = tx.tx.Exec("INSERT OR REPLACE INTO entries (bucket, key, value) VALUES (?, ?, ?)", bucket,
+        []byte(key), value)
+    return err
+}
+
+func (tx sqliteTx) Delete(bucket string, key string) error {
+    _, err := tx.tx.Exec("DELETE FROM entries WHERE bucket = ? AND key = ?", bucket, []byte(key))
+    return err
+}
+
+func (tx sqliteTx) ForEach(bucket string, fn func(key string, value []byte) error) error {
+    rows, err := tx.tx.Query("SELECT key, value FROM entries WHERE bucket = ? ORDER BY key", bucket)
+    if err != nil {
+        return err
+    }
+    // the rows are read completely first, since only one statement can
+    // be active on the connection.
+    keys := make([]string, 0)
+    values := make([][]byte, 0)
+    for rows.Next() {
+        var key []byte
+        var value []byte
+        err = rows.Scan(&key, &value)
+        if err != nil {
+            _ = rows.Close()
+            return err
+        }
+        keys = append(keys, string(key))
+        values = append(values, value)
+    }
+    err = rows.Close()
+    if err == nil {
+        err = rows.Err()
+    }
+    if err != nil {
+        return err
+    }
+    for i := range keys {
+        err := fn(keys[i], values[i])
+        if err != nil {
+            return err
+        }
+    }
+    return nil
+}
+
+func (tx sqliteTx) Buckets(bucket string) ([]string, error) {
+    prefix := ""
+    if bucket != "" {
+        prefix = bucket + "/"
+    }
+    rows, err := tx.tx.Query("SELECT name FROM buckets WHERE substr(name, 1, ?) = ? ORDER BY name",
+        len(prefix), prefix)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+    names := make([]string, 0)
+    for rows.Next() {
+        var name string
+        err = rows.Scan(&name)
+        if err != nil {
+            return nil, err
+        }
+        if !strings.Contains(name[len(prefix):], "/") {
+            names = append(names, name[len(prefix):])
+        }
+    }
+    return names, rows.Err()
+}
+
+func (tx sqliteTx) CreateBucket(bucket string) error {
+    return tx.createBucket(bucket)
+}
+
+func (tx sqliteTx) DeleteBucket(bucket string) error {
+    prefix := bucket + "/"
+    _, err := tx.tx.Exec("DELETE FROM entries WHERE bucket = ? OR substr(bucket, 1, ?) = ?", bucket,
+        len(prefix), prefix)
+    if err != nil {
+        return err
+    }
+    _, err = tx.tx.Exec("DELETE FROM buckets WHERE name = ? OR substr(name, 1, ?) = ?", bucket,
+        len(prefix), prefix)
+    return err
+}
+
+func (tx sqliteTx) NextSequence(bucket string) (uint64, error) {
+    err := tx.createBucket(bucket)
+    if err != nil {
+        return 0, err
+    }
+    _, err = tx.tx.Exec("UPDATE buckets SET sequence = sequence + 1 WHERE name = ?", bucket)
+    if err != nil {
+        return 0, err
+    }
+    return tx.Sequence(bucket)
+}
+
+func (tx sqliteTx) Sequence(bucket string) (uint64, error) {
+    var sequence uint64
+    err := tx.tx.QueryRow("SELECT sequence FROM buckets WHERE name = ?", bucket).Scan(&sequence)
+    if err == sql.ErrNoRows {
+        return 0, nil
+    }
+    return sequence, err
+}
+
+func (tx sqliteTx) SetSequence(bucket string, sequence uint64) error {
+    err := tx.createBucket(bucket)
+    if err != nil {
+        return err
+    }
+    _, err = tx.tx.Exec("UPDATE buckets SET sequence = ? WHERE name = ?", int64(sequence), bucket)
+    return err
+}
@@ -0,0 +1,156 @@
+package storage
+
+import (
+    "fmt"
+    log "github.com/sirupsen/logrus"
+    "strconv"
+)
+
+// names of the buckets in the store.
+const (
+    // leader schedules keyed by epoch.
+    ScheduleBucket string = "schedule"
+    // keys with which the schedules sent to Pool Tool have been
+    // encrypted keyed by epoch.
+    ScheduleKeysBucket string = "schedule-epoch-keys"
+    // audit log entries keyed by sequence number.
+    AuditBucket string = "audit"
+    // block outcomes in a sub bucket per epoch keyed by slot.
+    BlocksBucket string = "blocks"
+    // periods in which this tool has been running keyed by start.
+    UptimeBucket string = "uptime"
+    // downsampled statistics of the nodes in a sub bucket per
+    // resolution and node keyed by time.
+    HistoryBucket string = "history"
+    // silences of the alert engine keyed by ID.
+    AlertSilencesBucket string = "alert-silences"
+    // schedules, which could not yet be sent to Pool Tool, keyed
+    // by epoch.
+    PoolToolQueueBucket string = "pooltool-queue"
+    // epochs, whose final outcomes have been sent to Pool Tool,
+    // keyed by epoch.
+    PoolToolOutcomesBucket string = "pooltool-outcomes"
+    // meta information about the store such as the schema version.
+    metaBucket string = "meta"
+)
+
+const schemaVersionKey string = "schemaVersion"
+
+// all the buckets with the data of this tool.
+var Buckets = []string{ScheduleBucket, ScheduleKeysBucket, AuditBucket, BlocksBucket, UptimeBucket, HistoryBucket,
+    AlertSilencesBucket, PoolToolQueueBucket, PoolToolOutcomesBucket}
+
+// migration of the store to the given schema version.
+type migration struct {
+    version     uint64
+    description string
+    apply       func(tx Tx) error
+}
+
+// migrations in ascending order of their version. a store without schema
+// version has been created before the versioning and is migrated from
+// version 0.
+var migrations = []migration{
+    {
+        version:     1,
+        description: "create the buckets of all components",
+        apply: func(tx Tx) error {
+            // the buckets at the introduction of the versioning, the ones
+            // added later are created by their own migration.
+            for _, name := range []string{ScheduleBucket, ScheduleKeysBucket, AuditBucket, BlocksBucket,
+                UptimeBucket} {
+                err := tx.CreateBucket(name)
+                if err != nil {
+                    return err
+                }
+            }
+            return nil
+        },
+    },
+    {
+        version:     2,
+        description: "create the bucket of the node history",
+        apply: func(tx Tx) error {
+            return tx.CreateBucket(HistoryBucket)
+        },
+    },
+    {
+        version:     3,
+        description: "create the bucket of the alert silences",
+        apply: func(tx Tx) error {
+            return tx.CreateBucket(AlertSilencesBucket)
+        },
+    },
+    {
+        version:     4,
+        description: "create the bucket of the pending Pool Tool schedule updates",
+        apply: func(tx Tx) error {
+            return tx.CreateBucket(PoolToolQueueBucket)
+        },
+    },
+    {
+        version:     5,
+        description: "create the bucket of the epochs, whose final outcomes have been sent to Pool Tool",
+        apply: func(tx Tx) error {
+            return tx.CreateBucket(PoolToolOutcomesBucket)
+        },
+    },
+}
+
+// the schema version of the store expected by this version of the tool.
+var SchemaVersion = migrations[len(migrations)-1].version
+
+// gets the schema version of the given store, which is 0 for a store created
+// before the versioning.
+func GetSchemaVersion(store Store) (uint64, error) {
+    var version uint64 = 0
+    err := store.View(func(tx Tx) error {
+        var err error
+        version, err = getSchemaVersion(tx)
+        return err
+    })
+    return version, err
+}
+
+func getSchemaVersion(tx Tx) (uint64, error) {
+    data, err := tx.Get(metaBucket, schemaVersionKey)
+    if err != nil || data == nil {
+        return 0, err
+    }
+    return strconv.ParseUint(string(data), 10, 64)
+}
+
+func setSchemaVersion(tx Tx, version uint64) error {
+    return tx.Put(metaBucket, schemaVersionKey, []byte(strconv.FormatUint(version, 10)))
+}
+
+// applies all the migrations to the given store, which have not been applied
+// yet. each migration is applied in its own transaction together with the
+// update of the schema version.
+func Migrate(store Store) error {
+    version, err := GetSchemaVersion(store)
+    if err != nil {
+        return err
+    }
+    if version > SchemaVersion {
+        return fmt.Errorf("the store has been created by a newer version of thor (schema version %v, supported up to %v)",
+            version, SchemaVersion)
+    }
+    for _, m := range migrations {
+        if m.version <= version {
+            continue
+        }
+        err := store.Update(func(tx Tx) error {
+            err := m.apply(tx)
+            if err != nil {
+                return err
+            }
+            return setSchemaVersion(tx, m.version)
+        })
+        if err != nil {
+            return fmt.Errorf("the migration to schema version %v failed. %v", m.version, err.Error())
+        }
+        log.Infof("[STORAGE] Migrated the store to schema version %v (%v).", m.version, m.description)
+    }
+    return nil
+}
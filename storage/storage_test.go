@@ -0,0 +1,358 @@
+package storage
+
+import (
+    "bytes"
+    "encoding/json"
+    "errors"
+    "github.com/boltdb/bolt"
+    "github.com/stretchr/testify/assert"
+    "io/ioutil"
+    "os"
+    "path"
+    "testing"
+    "time"
+)
+
+// opens a store of each backend, the files are located in a temporary
+// directory, which is removed by the returned function.
+func openTestStores(t *testing.T) (map[Backend]Store, func()) {
+    dir, err := ioutil.TempDir("", "thor-storage")
+    if err != nil {
+        t.Fatal(err)
+    }
+    stores := make(map[Backend]Store)
+    for _, backend := range []Backend{Bolt, SQLite, Memory} {
+        store, err := Open(Settings{Backend: backend, Path: path.Join(dir, "thor-"+string(backend))})
+        if err != nil {
+            t.Fatal(err)
+        }
+        stores[backend] = store
+    }
+    return stores, func() {
+        for _, store := range stores {
+            _ = store.Close()
+        }
+        _ = os.RemoveAll(dir)
+    }
+}
+
+func put(t *testing.T, store Store, bucket string, key string, value string) {
+    err := store.Update(func(tx Tx) error {
+        return tx.Put(bucket, key, []byte(value))
+    })
+    if err != nil {
+        t.Fatal(err)
+    }
+}
+
+func getKeys(t *testing.T, store Store, bucket string) []string {
+    keys := make([]string, 0)
+    err := store.View(func(tx Tx) error {
+        return tx.ForEach(bucket, func(key string, value []byte) error {
+            keys = append(keys, key)
+            return nil
+        })
+    })
+    if err != nil {
+        t.Fatal(err)
+    }
+    return keys
+}
+
+func getBuckets(t *testing.T, store Store, bucket string) []string {
+    var names []string
+    err := store.View(func(tx Tx) error {
+        var err error
+        names, err = tx.Buckets(bucket)
+        return err
+    })
+    if err != nil {
+        t.Fatal(err)
+    }
+    return names
+}
+
+func TestStore_putGetDelete(t *testing.T) {
+    stores, cleanUp := openTestStores(t)
+    defer cleanUp()
+    for backend, store := range stores {
+        put(t, store, "a", "2", "two")
+        put(t, store, "a", "1", "one")
+        put(t, store, "a", "\x00\x01", "binary")
+        err := store.View(func(tx Tx) error {
+            value, err := tx.Get("a", "1")
+            assert.Nil(t, err)
+            assert.Equal(t, []byte("one"), value, backend)
+            value, err = tx.Get("a", "3")
+            assert.Nil(t, err)
+            assert.Nil(t, value, backend)
+            value, err = tx.Get("missing", "1")
+            assert.Nil(t, err)
+            assert.Nil(t, value, backend)
+            return nil
+        })
+        assert.Nil(t, err)
+        assert.Equal(t, []string{"\x00\x01", "1", "2"}, getKeys(t, store, "a"), backend)
+        assert.Empty(t, getKeys(t, store, "missing"), backend)
+        err = store.Update(func(tx Tx) error {
+            return tx.Delete("a", "1")
+        })
+        assert.Nil(t, err)
+        assert.Equal(t, []string{"\x00\x01", "2"}, getKeys(t, store, "a"), backend)
+    }
+}
+
+func TestStore_subBuckets(t *testing.T) {
+    stores, cleanUp := openTestStores(t)
+    defer cleanUp()
+    for backend, store := range stores {
+        put(t, store, SubBucket("b", "10"), "1", "x")
+        put(t, store, SubBucket("b", "11"), "1", "y")
+        put(t, store, SubBucket(SubBucket("b", "11"), "c"), "1", "z")
+        put(t, store, "b", "entry", "v")
+        assert.Equal(t, []string{"10", "11"}, getBuckets(t, store, "b"), backend)
+        assert.Equal(t, []string{"c"}, getBuckets(t, store, SubBucket("b", "11")), backend)
+        assert.Contains(t, getBuckets(t, store, ""), "b", backend)
+        assert.Equal(t, []string{"entry"}, getKeys(t, store, "b"), backend)
+        err := store.Update(func(tx Tx) error {
+            return tx.DeleteBucket(SubBucket("b", "11"))
+        })
+        assert.Nil(t, err)
+        assert.Equal(t, []string{"10"}, getBuckets(t, store, "b"), backend)
+        assert.Empty(t, getKeys(t, store, SubBucket(SubBucket("b", "11"), "c")), backend)
+        err = store.Update(func(tx Tx) error {
+            return tx.DeleteBucket("missing")
+        })
+        assert.Nil(t, err, backend)
+    }
+}
+
+func TestStore_sequence(t *testing.T) {
+    stores, cleanUp := openTestStores(t)
+    defer cleanUp()
+    for backend, store := range stores {
+        err := store.Update(func(tx Tx) error {
+            first, err := tx.NextSequence("s")
+            assert.Nil(t, err)
+            second, err := tx.NextSequence("s")
+            assert.Nil(t, err)
+            assert.Equal(t, []uint64{1, 2}, []uint64{first, second}, backend)
+            err = tx.SetSequence("s", 10)
+            assert.Nil(t, err)
+            sequence, err := tx.Sequence("s")
+            assert.Nil(t, err)
+            assert.Equal(t, uint64(10), sequence, backend)
+            return nil
+        })
+        assert.Nil(t, err)
+    }
+}
+
+func TestStore_failedUpdate_mustBeRolledBack(t *testing.T) {
+    stores, cleanUp := openTestStores(t)
+    defer cleanUp()
+    for backend, store := range stores {
+        put(t, store, "r", "1", "one")
+        err := store.Update(func(tx Tx) error {
+            assert.Nil(t, tx.Put("r", "2", []byte("two")))
+            assert.Nil(t, tx.Delete("r", "1"))
+            return errors.New("failure")
+        })
+        assert.NotNil(t, err)
+        assert.Equal(t, []string{"1"}, getKeys(t, store, "r"), backend)
+    }
+}
+
+func TestOpen_legacyBoltFile_mustBeMigratedToCurrentVersionKeepingData(t *testing.T) {
+    dir, err := ioutil.TempDir("", "thor-storage")
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer os.RemoveAll(dir)
+    dbPath := path.Join(dir, "thor.db")
+    legacyDB, err := bolt.Open(dbPath, 0600, nil)
+    if err != nil {
+        t.Fatal(err)
+    }
+    err = legacyDB.Update(func(tx *bolt.Tx) error {
+        b, err := tx.CreateBucket([]byte(ScheduleBucket))
+        if err != nil {
+            return err
+        }
+        return b.Put([]byte("12"), []byte("[]"))
+    })
+    _ = legacyDB.Close()
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    store, err := Open(Settings{Backend: Bolt, Path: dbPath})
+    if assert.Nil(t, err) {
+        defer store.Close()
+        version, err := GetSchemaVersion(store)
+        assert.Nil(t, err)
+        assert.Equal(t, SchemaVersion, version)
+        assert.Equal(t, []string{"12"}, getKeys(t, store, ScheduleBucket))
+        assert.Subset(t, getBuckets(t, store, ""), Buckets)
+    }
+}
+
+func TestMigrate_firstVersion_mustOnlyCreateTheOriginalBuckets(t *testing.T) {
+    store := NewMemory()
+    defer store.Close()
+    err := store.Update(migrations[0].apply)
+    if assert.Nil(t, err) {
+        assert.ElementsMatch(t, []string{ScheduleBucket, ScheduleKeysBucket, AuditBucket, BlocksBucket, UptimeBucket},
+            getBuckets(t, store, ""))
+    }
+}
+
+func TestMigrate_newerSchemaVersion_mustFail(t *testing.T) {
+    store := NewMemory()
+    err := store.Update(func(tx Tx) error {
+        return setSchemaVersion(tx, SchemaVersion+1)
+    })
+    if err != nil {
+        t.Fatal(err)
+    }
+    assert.NotNil(t, Migrate(store))
+}
+
+func TestPrune_mustRemoveOnlyDataOlderThanTheOldestEpoch(t *testing.T) {
+    stores, cleanUp := openTestStores(t)
+    defer cleanUp()
+    oldestTime := time.Date(2020, 5, 1, 0, 0, 0, 0, time.UTC)
+    oldEntry, _ := json.Marshal(map[string]interface{}{"time": oldestTime.Add(-time.Minute), "node": "a"})
+    newEntry, _ := json.Marshal(map[string]interface{}{"time": oldestTime.Add(time.Minute), "node": "a"})
+    oldPeriod, _ := json.Marshal(map[string]interface{}{"start": oldestTime.Add(-time.Hour),
+        "lastSeen": oldestTime.Add(-time.Minute)})
+    newPeriod, _ := json.Marshal(map[string]interface{}{"start": oldestTime.Add(-time.Hour),
+        "lastSeen": oldestTime.Add(time.Minute)})
+    for backend, store := range stores {
+        for _, epoch := range []string{"8", "9", "10", "11"} {
+            put(t, store, ScheduleBucket, epoch, "[]")
+            put(t, store, ScheduleKeysBucket, epoch, "key")
+            put(t, store, PoolToolOutcomesBucket, epoch, "key")
+            put(t, store, SubBucket(BlocksBucket, epoch), "1", "{}")
+        }
+        put(t, store, AuditBucket, "1", string(oldEntry))
+        put(t, store, AuditBucket, "2", string(newEntry))
+        put(t, store, UptimeBucket, "a", string(oldPeriod))
+        put(t, store, UptimeBucket, "b", string(newPeriod))
+
+        pruned, err := Prune(store, 10, oldestTime)
+        if assert.Nil(t, err) {
+            assert.Equal(t, Pruned{ScheduleBucket: 2, ScheduleKeysBucket: 2, PoolToolOutcomesBucket: 2,
+                BlocksBucket: 2, AuditBucket: 1, UptimeBucket: 1}, pruned, backend)
+            assert.Equal(t, 10, pruned.Total())
+            assert.Equal(t, []string{"10", "11"}, getKeys(t, store, ScheduleBucket), backend)
+            assert.Equal(t, []string{"10", "11"}, getKeys(t, store, ScheduleKeysBucket), backend)
+            assert.Equal(t, []string{"10", "11"}, getBuckets(t, store, BlocksBucket), backend)
+            assert.Equal(t, []string{"2"}, getKeys(t, store, AuditBucket), backend)
+            assert.Equal(t, []string{"b"}, getKeys(t, store, UptimeBucket), backend)
+        }
+    }
+}
+
+func TestExportImport_acrossBackends_mustRestoreEntriesSubBucketsAndSequences(t *testing.T) {
+    stores, cleanUp := openTestStores(t)
+    defer cleanUp()
+    source := stores[Bolt]
+    put(t, source, ScheduleBucket, "10", "[]")
+    put(t, source, SubBucket(BlocksBucket, "10"), "42", "{}")
+    err := source.Update(func(tx Tx) error {
+        seq, err := tx.NextSequence(AuditBucket)
+        if err != nil {
+            return err
+        }
+        return tx.Put(AuditBucket, string([]byte{0, 0, 0, 0, 0, 0, 0, byte(seq)}), []byte("{}"))
+    })
+    if err != nil {
+        t.Fatal(err)
+    }
+    var buffer bytes.Buffer
+    if !assert.Nil(t, WriteExport(&buffer, source)) {
+        return
+    }
+    export := buffer.Bytes()
+
+    for _, backend := range []Backend{SQLite, Memory} {
+        target := stores[backend]
+        put(t, target, ScheduleBucket, "3", "[]")
+        err = ReadImport(bytes.NewReader(export), target)
+        if assert.Nil(t, err, backend) {
+            assert.Equal(t, []string{"10"}, getKeys(t, target, ScheduleBucket), backend)
+            assert.Equal(t, []string{"10"}, getBuckets(t, target, BlocksBucket), backend)
+            assert.Equal(t, []string{"42"}, getKeys(t, target, SubBucket(BlocksBucket, "10")), backend)
+            assert.Len(t, getKeys(t, target, AuditBucket), 1, backend)
+            _ = target.View(func(tx Tx) error {
+                sequence, err := tx.Sequence(AuditBucket)
+                assert.Nil(t, err)
+                assert.Equal(t, uint64(1), sequence, backend)
+                return nil
+            })
+            version, err := GetSchemaVersion(target)
+            assert.Nil(t, err)
+            assert.Equal(t, SchemaVersion, version, backend)
+        }
+    }
+}
+
+func TestImport_dumpOfNewerSchemaVersion_mustFail(t *testing.T) {
+    assert.NotNil(t, Import(NewMemory(), Dump{SchemaVersion: SchemaVersion + 1}))
+}
+
+func TestIsEmpty(t *testing.T) {
+    stores, cleanUp := openTestStores(t)
+    defer cleanUp()
+    for backend, store := range stores {
+        empty, err := IsEmpty(store)
+        assert.Nil(t, err)
+        assert.True(t, empty, backend)
+        put(t, store, SubBucket(BlocksBucket, "1"), "a", "{}")
+        empty, err = IsEmpty(store)
+        assert.Nil(t, err)
+        assert.False(t, empty, backend)
+    }
+}
+
+func TestCompact_mustKeepDataAndShrinkTheFile(t *testing.T) {
+    dir, err := ioutil.TempDir("", "thor-storage")
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer os.RemoveAll(dir)
+    for _, backend := range []Backend{Bolt, SQLite} {
+        settings := Settings{Backend: backend, Path: path.Join(dir, "thor-"+string(backend))}
+        store, err := Open(settings)
+        if err != nil {
+            t.Fatal(err)
+        }
+        value := string(make([]byte, 4096))
+        for i := 0; i < 200; i++ {
+            put(t, store, UptimeBucket, time.Unix(int64(i), 0).UTC().Format(time.RFC3339), value)
+        }
+        put(t, store, SubBucket(BlocksBucket, "10"), "42", "{}")
+        err = store.Update(func(tx Tx) error {
+            _, err := deleteWhere(tx, UptimeBucket, func(key string, value []byte) bool {
+                return true
+            })
+            return err
+        })
+        if err != nil {
+            t.Fatal(err)
+        }
+        _ = store.Close()
+
+        before, after, err := Compact(settings)
+        if assert.Nil(t, err, backend) {
+            assert.True(t, after < before, "%v: %v < %v", backend, after, before)
+            store, err = Open(settings)
+            if assert.Nil(t, err) {
+                assert.Equal(t, []string{"42"}, getKeys(t, store, SubBucket(BlocksBucket, "10")), backend)
+                assert.Empty(t, getKeys(t, store, UptimeBucket), backend)
+                _ = store.Close()
+            }
+        }
+    }
+}
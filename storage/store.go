@@ -0,0 +1,119 @@
+package storage
+
+import (
+    "fmt"
+    "os"
+    "path"
+    "strings"
+)
+
+// store of the state of this tool, which organizes key,value pairs in
+// buckets. a bucket can contain sub buckets, which are addressed with
+// a path such as 'blocks/10' (see SubBucket).
+type Store interface {
+    // runs the given function in a read-only transaction.
+    View(fn func(tx Tx) error) error
+    // runs the given function in a read-write transaction, which is
+    // rolled back, if the function returns an error.
+    Update(fn func(tx Tx) error) error
+    // closes the store.
+    Close() error
+}
+
+// transaction on a store. missing buckets are treated like empty buckets
+// when reading, and are created when writing.
+type Tx interface {
+    // gets the value stored under the given key in the given bucket, or
+    // nil, if there is no such value.
+    Get(bucket string, key string) ([]byte, error)
+    // stores the given value under the given key in the given bucket.
+    Put(bucket string, key string, value []byte) error
+    // deletes the given key in the given bucket.
+    Delete(bucket string, key string) error
+    // calls the given function for each key,value pair of the given bucket
+    // in the byte order of the keys. the bucket must not be modified in the
+    // function.
+    ForEach(bucket string, fn func(key string, value []byte) error) error
+    // gets the names of the sub buckets of the given bucket in byte order,
+    // or the names of the top level buckets, if the bucket is empty.
+    Buckets(bucket string) ([]string, error)
+    // creates the given bucket, if it does not exist yet.
+    CreateBucket(bucket string) error
+    // deletes the given bucket with all its entries and sub buckets.
+    DeleteBucket(bucket string) error
+    // increments the sequence of the given bucket and returns it.
+    NextSequence(bucket string) (uint64, error)
+    // gets the current sequence of the given bucket.
+    Sequence(bucket string) (uint64, error)
+    // sets the sequence of the given bucket.
+    SetSequence(bucket string, sequence uint64) error
+}
+
+// gets the path of the bucket with the given name in the given bucket.
+func SubBucket(bucket string, name string) string {
+    return bucket + "/" + name
+}
+
+// splits the given path of a bucket into the names of the buckets.
+func splitBucketPath(bucket string) []string {
+    return strings.Split(bucket, "/")
+}
+
+// backend in which the state of this tool is stored.
+type Backend string
+
+const (
+    // file-based key,value store, which is the default.
+    Bolt Backend = "bolt"
+    // file-based SQL database.
+    SQLite Backend = "sqlite"
+    // state is only kept in memory, and lost on a restart.
+    Memory Backend = "memory"
+)
+
+// settings specifying the backend of the store.
+type Settings struct {
+    Backend Backend
+    // path of the file, which is ignored for the memory backend.
+    Path string
+}
+
+// gets the default path of the file for the given backend, which is located in
+// the directory given by the environment variable THOR_DATA_DIR or by default
+// in 'data'.
+func GetDefaultPath(backend Backend) string {
+    dataDirPath := os.Getenv("THOR_DATA_DIR")
+    if len(dataDirPath) == 0 {
+        dataDirPath = "data"
+    }
+    if backend == SQLite {
+        return path.Join(dataDirPath, "thor.sqlite")
+    }
+    return path.Join(dataDirPath, "thor.db")
+}
+
+// opens the store specified by the given settings and migrates it to the
+// current schema version.
+func Open(settings Settings) (Store, error) {
+    var store Store
+    var err error
+    switch settings.Backend {
+    case Bolt, "":
+        store, err = OpenBolt(settings.Path)
+    case SQLite:
+        store, err = OpenSQLite(settings.Path)
+    case Memory:
+        store = NewMemory()
+    default:
+        return nil, fmt.Errorf("unknown storage backend '%v'", settings.Backend)
+    }
+    if err != nil {
+        return nil, err
+    }
+    err = Migrate(store)
+    if err != nil {
+        _ = store.Close()
+        return nil, err
+    }
+    return store, nil
+}